@@ -1,9 +1,11 @@
 package main
 
 import (
-	"log"
+	"context"
+	"net/http"
 	"os"
 	"os/signal"
+	"sync"
 	"syscall"
 	"time"
 
@@ -13,9 +15,25 @@ import (
 	"github.com/hlabs/banking-system/internal/chat"
 	"github.com/hlabs/banking-system/internal/config"
 	"github.com/hlabs/banking-system/internal/database"
+	"github.com/hlabs/banking-system/internal/dispute"
+	"github.com/hlabs/banking-system/internal/eventbus"
+	"github.com/hlabs/banking-system/internal/fee"
+	"github.com/hlabs/banking-system/internal/health"
+	"github.com/hlabs/banking-system/internal/interest"
+	"github.com/hlabs/banking-system/internal/ledger"
+	"github.com/hlabs/banking-system/internal/logging"
+	"github.com/hlabs/banking-system/internal/mailer"
+	"github.com/hlabs/banking-system/internal/middleware"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/notification"
+	"github.com/hlabs/banking-system/internal/password"
+	"github.com/hlabs/banking-system/internal/ratelimit"
+	"github.com/hlabs/banking-system/internal/realtime"
 	"github.com/hlabs/banking-system/internal/routes"
 	"github.com/hlabs/banking-system/internal/tigerbeetle"
+	"github.com/hlabs/banking-system/internal/tracing"
 	"github.com/hlabs/banking-system/internal/transaction"
+	"github.com/hlabs/banking-system/internal/webhook"
 )
 
 const (
@@ -24,15 +42,33 @@ const (
 	retryDelaySeconds    = 2
 )
 
-func main() {
-	log.Println("🚀 Starting HLABS Banking System Backend...")
+var logger = logging.For("main")
 
+func main() {
 	// Load configuration
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("❌ Failed to load configuration: %v", err)
+		fatal("failed to load configuration", err)
+	}
+
+	// Structured logging: every component logs through slog with redaction
+	logging.Setup(logging.Config{
+		Format: cfg.LogFormat,
+		Level:  cfg.LogLevel,
+		Levels: cfg.LogLevels,
+	})
+	logger.Info("starting HLABS Banking System backend")
+
+	// Distributed tracing: spans for HTTP requests, SQL queries, TigerBeetle and OpenRouter calls
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Exporter:    cfg.TracingExporter,
+		Endpoint:    cfg.TracingEndpoint,
+		ServiceName: "hlabs-banking-api",
+		SampleRatio: cfg.TracingSampleRatio,
+	})
+	if err != nil {
+		fatal("failed to initialize tracing", err)
 	}
-	log.Println("✅ Configuration loaded")
 
 	// Set Gin mode
 	gin.SetMode(cfg.GinMode)
@@ -40,84 +76,357 @@ func main() {
 	// Connect to PostgreSQL
 	db, err := database.Connect(cfg.PostgresDSN)
 	if err != nil {
-		log.Fatalf("❌ Failed to connect to PostgreSQL: %v", err)
+		fatal("failed to connect to PostgreSQL", err)
 	}
 
 	// Run migrations
 	if err := database.Migrate(db); err != nil {
-		log.Fatalf("❌ Failed to run migrations: %v", err)
+		fatal("failed to run migrations", err)
 	}
 
 	// Connect to TigerBeetle with retry logic
-	log.Println("🔗 Connecting to TigerBeetle...")
 	var tbClient *tigerbeetle.Client
 
 	for attempt := 1; attempt <= maxConnectionRetries; attempt++ {
-		log.Printf("   Attempt %d/%d to connect to TigerBeetle at %s", attempt, maxConnectionRetries, cfg.TigerBeetleAddress)
+		logger.Info("connecting to TigerBeetle", "address", cfg.TigerBeetleAddress, "attempt", attempt, "max_attempts", maxConnectionRetries)
 
 		tbClient, err = tigerbeetle.NewClient(cfg.TigerBeetleAddress)
 		if err == nil {
-			log.Println("✅ Successfully connected to TigerBeetle!")
 			break
 		}
 
-		log.Printf("⚠️  Connection attempt %d failed: %v", attempt, err)
-
 		if attempt < maxConnectionRetries {
-			log.Printf("   Retrying in %d seconds...", retryDelaySeconds)
+			logger.Warn("TigerBeetle connection attempt failed, retrying", "attempt", attempt, "retry_in", time.Duration(retryDelaySeconds)*time.Second, "error", err)
 			time.Sleep(time.Duration(retryDelaySeconds) * time.Second)
 		} else {
-			log.Fatalf("❌ Failed to connect to TigerBeetle after %d attempts: %v", maxConnectionRetries, err)
+			fatal("failed to connect to TigerBeetle", err)
 		}
 	}
-	defer tbClient.Close()
 
 	// Seed database with test users (if needed)
-	if err := database.Seed(db, tbClient); err != nil {
-		log.Printf("⚠️  Warning: Failed to seed database: %v", err)
+	seedStatus, err := database.Seed(db, tbClient)
+	if err != nil {
+		logger.Warn("failed to seed database", "error", err)
 		// Non-fatal: continue even if seeding fails
 	}
 
+	// Grant admin role to configured back-office users
+	if err := database.EnsureAdmins(db, cfg.AdminEmails); err != nil {
+		logger.Warn("failed to grant admin roles", "error", err)
+	}
+
+	// Load fee schedule
+	feeSchedule, err := fee.LoadSchedule(cfg.FeeSchedulePath)
+	if err != nil {
+		fatal("failed to load fee schedule", err)
+	}
+	logger.Info("fee schedule loaded", "rules", len(feeSchedule.Rules))
+
+	// Load interest products
+	interestProducts, err := interest.ParseProducts(cfg.InterestRates)
+	if err != nil {
+		fatal("failed to load interest rates", err)
+	}
+
+	// Outgoing email (verification links, password resets)
+	mail, err := mailer.New(mailer.Config{
+		Driver:       cfg.MailerDriver,
+		From:         cfg.MailFrom,
+		SMTPHost:     cfg.SMTPHost,
+		SMTPPort:     cfg.SMTPPort,
+		SMTPUsername: cfg.SMTPUsername,
+		SMTPPassword: cfg.SMTPPassword,
+		FilePath:     cfg.MailFilePath,
+	})
+	if err != nil {
+		fatal("failed to initialize mailer", err)
+	}
+
+	// Password policy (length, character classes, common-password blocklist)
+	passwordPolicy, err := password.NewPolicy(int(cfg.PasswordMinLength), int(cfg.PasswordMinClasses), cfg.PasswordBlocklistPath)
+	if err != nil {
+		fatal("failed to load password policy", err)
+	}
+
 	// Initialize services
+	signingKeys, err := auth.NewKeyManager(db, cfg.JWTKeyEncryption, auth.KeyPolicy{
+		Algorithm:   cfg.JWTAlgorithm,
+		RotateEvery: cfg.JWTKeyRotation,
+		Grace:       cfg.JWTKeyGrace,
+	})
+	if err != nil {
+		fatal("failed to initialize signing keys", err)
+	}
+	sessionService := auth.NewSessionService(db, signingKeys, cfg.AccessTokenTTL, cfg.RefreshTokenTTL)
+	apiKeyService := auth.NewAPIKeyService(db, signingKeys, cfg.AccessTokenTTL)
+	mfaService, err := auth.NewMFAService(db, cfg.JWTSecret, cfg.MFAEncryptionKey)
+	if err != nil {
+		fatal("failed to initialize MFA", err)
+	}
+	if _, err := mfaService.MigrateLegacySecrets(); err != nil {
+		fatal("failed to re-encrypt TOTP secrets", err)
+	}
+	loginThrottler := auth.NewLoginThrottler(db, auth.ThrottlePolicy{
+		AccountThreshold: int(cfg.LoginMaxFailures),
+		IPThreshold:      int(cfg.LoginIPMaxFailures),
+		BaseLockout:      cfg.LoginLockout,
+		MaxLockout:       cfg.LoginMaxLockout,
+		ResetAfter:       24 * time.Hour,
+	})
+	emailService := auth.NewEmailService(db, cfg.JWTSecret, mail, sessionService, loginThrottler, passwordPolicy, cfg.AppBaseURL)
+	stepUpService := auth.NewStepUpService(db, cfg.JWTSecret, mfaService, cfg.StepUpTokenTTL)
+	passkeyService, err := auth.NewPasskeyService(db, cfg.JWTSecret, mfaService, auth.PasskeyConfig{
+		RPID:    cfg.WebAuthnRPID,
+		RPName:  cfg.WebAuthnRPName,
+		Origins: cfg.WebAuthnOrigins,
+	})
+	if err != nil {
+		fatal("failed to initialize passkeys", err)
+	}
+	webhookService, err := webhook.NewService(db, cfg.WebhookEncryptionKey, cfg.WebhookAllowPrivate)
+	if err != nil {
+		fatal("failed to initialize webhooks", err)
+	}
+	// Realtime events: in memory per instance, or relayed through PostgreSQL to every instance
+	var eventBroker realtime.Broker
+	var pgBroker *realtime.PostgresBroker
+	if cfg.RealtimeBackend == "postgres" {
+		pgBroker = realtime.NewPostgresBroker(db, cfg.PostgresDSN)
+		eventBroker = pgBroker
+	} else {
+		eventBroker = realtime.NewHub()
+	}
+	// Domain events, forwarded to the configured sinks for consumers outside this service
+	eventBus := eventbus.NewBus()
+	var outboxSink *eventbus.OutboxSink
+	for _, name := range cfg.EventSinks {
+		var sink eventbus.Sink
+		switch name {
+		case "file":
+			sink, err = eventbus.NewFileSink(cfg.EventFilePath)
+		case "nats":
+			sink, err = eventbus.NewNATSSink(cfg.EventNATSURL, cfg.EventNATSSubjectPrefix)
+		case "kafka":
+			sink, err = eventbus.NewKafkaRESTSink(cfg.EventKafkaRESTURL, cfg.EventKafkaTopic)
+		case "outbox":
+			outboxSink = eventbus.NewOutboxSink(db, cfg.EventOutboxRetention)
+			sink = outboxSink
+		}
+		if err != nil {
+			fatal("failed to initialize "+name+" event sink", err)
+		}
+		eventBus.AddSink(sink)
+	}
+	// Notifications: in the app, by email through the mailer, and by SMS when a driver is configured
+	smsSender, err := notification.NewSMSSender(cfg.SMSDriver)
+	if err != nil {
+		fatal("failed to initialize SMS sender", err)
+	}
+	senders := map[string]notification.Sender{
+		models.NotificationChannelEmail: notification.NewEmailSender(mail, cfg.AppBaseURL),
+	}
+	if smsSender != nil {
+		senders[models.NotificationChannelSMS] = smsSender
+	}
+	notificationService := notification.NewService(db, senders, notification.Thresholds{
+		LargeWithdrawal: cfg.NotifyLargeWithdrawal,
+		LowBalance:      cfg.NotifyLowBalanceThreshold,
+	}, eventBroker)
 	accountService := account.NewService(db, tbClient)
-	transactionService := transaction.NewService(db, tbClient)
+	transactionService := transaction.NewService(db, tbClient, feeSchedule, stepUpService, transaction.StepUpPolicy{
+		Threshold:    cfg.StepUpThreshold,
+		NewRecipient: cfg.StepUpNewRecipient,
+	}, transaction.Limits{
+		UnverifiedDailyLimit: cfg.UnverifiedDailyLimit,
+	}, webhookService, eventBroker, notificationService, eventBus)
 	chatService := chat.NewService(accountService, transactionService)
+	interestService := interest.NewService(db, tbClient, interestProducts)
+	ledgerService := ledger.NewService(db, tbClient)
+	disputeService := dispute.NewService(db, tbClient, notificationService)
+	healthService := health.NewService(db, tbClient, chatService, seedStatus)
 
 	// Initialize handlers
-	authHandler := auth.NewHandler(db, tbClient, cfg.JWTSecret)
+	authHandler := auth.NewHandler(db, tbClient, sessionService, mfaService, stepUpService, loginThrottler, emailService, passwordPolicy, signingKeys, apiKeyService, passkeyService, notificationService, eventBus)
 	accountHandler := account.NewHandler(accountService)
 	transactionHandler := transaction.NewHandler(transactionService)
 	chatHandler := chat.NewHandler(chatService)
+	interestHandler := interest.NewHandler(interestService)
+	ledgerHandler := ledger.NewHandler(ledgerService)
+	disputeHandler := dispute.NewHandler(disputeService)
+	webhookHandler := webhook.NewHandler(webhookService)
+	eventsHandler := realtime.NewHandler(eventBroker, accountService, cfg.AccessTokenTTL)
+	notificationHandler := notification.NewHandler(notificationService)
+	healthHandler := health.NewHandler(healthService)
 
-	// Setup Gin router
-	router := gin.Default()
+	// Start background workers; shutdown cancels workerCtx and waits for them to return
+	workerCtx, stopWorkers := context.WithCancel(context.Background())
+	var workers sync.WaitGroup
+	startWorker := func(run func(context.Context)) {
+		workers.Add(1)
+		go func() {
+			defer workers.Done()
+			run(workerCtx)
+		}()
+	}
+	// The interest job posts transfers and writes their audit records, so it is waited for
+	// separately: shutdown never closes TigerBeetle or PostgreSQL while it is running
+	var interestJob sync.WaitGroup
+	interestJob.Add(1)
+	go func() {
+		defer interestJob.Done()
+		interest.NewJob(interestService).Run(workerCtx)
+	}()
+	startWorker(sessionService.RunCleanup)
+	startWorker(apiKeyService.RunUsageRecorder)
+	startWorker(signingKeys.RunRotation)
+	startWorker(webhook.NewDispatcher(webhookService, int(cfg.WebhookMaxAttempts)).Run)
+	if pgBroker != nil {
+		startWorker(pgBroker.Run)
+	}
+	if outboxSink != nil {
+		startWorker(outboxSink.RunCleanup)
+	}
+
+	// Rate limits: in memory per instance, or in PostgreSQL or Redis shared by every instance
+	limits := routes.RateLimits{ChatDailyQuota: int(cfg.ChatDailyQuota)}
+	switch cfg.RateLimitBackend {
+	case "postgres":
+		rateStore := ratelimit.NewPostgresStore(db)
+		startWorker(rateStore.RunCleanup)
+		limits.Store = rateStore
+	case "redis":
+		rateStore, err := ratelimit.NewRedisStore(cfg.RateLimitRedisURL)
+		if err != nil {
+			fatal("failed to initialize rate limit store", err)
+		}
+		defer rateStore.Close()
+		limits.Store = rateStore
+	default:
+		limits.Store = ratelimit.NewMemoryStore()
+	}
+	var resetLimit ratelimit.Policy
+	for _, policy := range []struct {
+		target *ratelimit.Policy
+		name   string
+		spec   string
+	}{
+		{&limits.Default, "default", cfg.RateLimitDefault},
+		{&limits.Auth, "auth", cfg.RateLimitAuth},
+		{&limits.Money, "money", cfg.RateLimitMoney},
+		{&limits.Chat, "chat", cfg.RateLimitChat},
+		{&resetLimit, "password-reset", cfg.RateLimitReset},
+	} {
+		if *policy.target, err = ratelimit.ParsePolicy(policy.name, policy.spec); err != nil {
+			fatal("invalid rate limit configuration", err)
+		}
+	}
+	emailService.LimitResets(limits.Store, resetLimit)
+	logger.Info("rate limits configured", "backend", cfg.RateLimitBackend, "default", limits.Default.String(), "auth", limits.Auth.String(),
+		"money", limits.Money.String(), "chat", limits.Chat.String(), "password_reset", resetLimit.String(), "chat_daily_quota", limits.ChatDailyQuota)
+
+	// Setup Gin router; requests are logged as JSON with their request ID instead of gin's text logger
+	router := gin.New()
+	router.Use(gin.Recovery(), middleware.Tracing(), middleware.RequestID(), middleware.RequestLogger(), middleware.Metrics())
 
 	// Setup all routes
-	routes.SetupRoutes(router, authHandler, accountHandler, transactionHandler, chatHandler, cfg.JWTSecret)
+	routes.SetupRoutes(router, authHandler, accountHandler, transactionHandler, chatHandler, interestHandler, ledgerHandler, disputeHandler, webhookHandler, eventsHandler, notificationHandler, healthHandler, signingKeys, sessionService, apiKeyService, limits, cfg.MetricsToken)
 
-	// Graceful shutdown
+	// Start server
+	server := &http.Server{
+		Addr:              ":" + cfg.ServerPort,
+		Handler:           router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	serverErr := make(chan error, 1)
 	go func() {
-		sigint := make(chan os.Signal, 1)
-		signal.Notify(sigint, os.Interrupt, syscall.SIGTERM)
-		<-sigint
+		serverErr <- server.ListenAndServe()
+	}()
+	logger.Info("server listening", "address", "http://localhost"+server.Addr)
 
-		log.Println("\n🛑 Shutting down server...")
+	stop, stopSignals := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stopSignals()
+	select {
+	case err := <-serverErr:
+		fatal("failed to start server", err)
+	case <-stop.Done():
+	}
 
-		// Close database connection
-		if sqlDB, err := db.DB(); err == nil {
-			sqlDB.Close()
-		}
+	// Graceful shutdown: HTTP, then workers, then TigerBeetle, then PostgreSQL, so nothing
+	// still running can post a transfer without being able to write its audit record
+	logger.Info("shutting down server", "timeout", cfg.ShutdownTimeout)
+	healthService.SetDraining()
+	if cfg.ShutdownDelay > 0 {
+		// Give load balancers time to see /readyz fail before the listener closes
+		time.Sleep(cfg.ShutdownDelay)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
 
-		os.Exit(0)
-	}()
+	// 1. Stop accepting connections and wait for in-flight requests; event streams are
+	// ended first since they would otherwise hold the drain open until the deadline
+	eventBroker.Close()
+	if err := server.Shutdown(ctx); err != nil {
+		logger.Error("requests still running at the shutdown deadline, closing their connections", "error", err)
+		server.Close()
+	}
+	// Closing a connection doesn't stop its handler: one may still be between posting a transfer
+	// and writing its record, so money movements are drained like the interest job below
+	if !waitFor(ctx, transactionService.Drain) {
+		logger.Warn("money movements still running at the shutdown deadline, waiting for them before closing TigerBeetle")
+		transactionService.Drain()
+	}
 
-	// Start server
-	serverAddr := ":" + cfg.ServerPort
-	log.Printf("🌐 Server listening on http://localhost%s", serverAddr)
-	log.Printf("📋 API Documentation: http://localhost%s/api", serverAddr)
-	log.Printf("💚 Health Check: http://localhost%s/health", serverAddr)
+	// 2. Stop background workers and deliver the domain events still queued
+	stopWorkers()
+	if !waitFor(ctx, workers.Wait) {
+		logger.Error("background workers still running at the shutdown deadline")
+	}
+	// The interest job can be partway through a day (up to a month-end capitalization) that
+	// it finishes even when cancelled - closing TigerBeetle under it would split a posted
+	// transfer from its audit record, so it gets all the time it needs
+	if !waitFor(ctx, interestJob.Wait) {
+		logger.Warn("interest job still running at the shutdown deadline, waiting for it before closing TigerBeetle")
+		interestJob.Wait()
+	}
+	eventBus.Close()
+
+	// 3. TigerBeetle
+	tbClient.Close()
+
+	// 4. PostgreSQL
+	if err := database.Close(db); err != nil {
+		logger.Warn("failed to close database", "error", err)
+	}
 
-	if err := router.Run(serverAddr); err != nil {
-		log.Fatalf("❌ Failed to start server: %v", err)
+	// Export the spans still buffered
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := shutdownTracing(flushCtx); err != nil {
+		logger.Warn("failed to flush traces", "error", err)
 	}
+
+	logger.Info("server stopped")
+}
+
+// waitFor runs wait until it returns or the context is done
+// Returns false if the context ended first; wait keeps running in the background
+func waitFor(ctx context.Context, wait func()) bool {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// fatal logs a startup error and exits
+func fatal(msg string, err error) {
+	logger.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -1,15 +1,18 @@
 package account
 
 import (
+	"context"
 	"fmt"
-	"log"
 
 	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/logging"
 	"github.com/hlabs/banking-system/internal/models"
 	"github.com/hlabs/banking-system/internal/tigerbeetle"
 	"gorm.io/gorm"
 )
 
+var logger = logging.For("account")
+
 // Service handles account-related business logic
 type Service struct {
 	db       *gorm.DB
@@ -26,6 +29,11 @@ func NewService(db *gorm.DB, tbClient *tigerbeetle.Client) *Service {
 
 // GetUserByID retrieves a user by their ID
 func (s *Service) GetUserByID(userID string) (*models.User, error) {
+	return s.getUserByID(context.Background(), userID)
+}
+
+// getUserByID retrieves a user by their ID with the caller's context
+func (s *Service) getUserByID(ctx context.Context, userID string) (*models.User, error) {
 	var user models.User
 
 	// Parse UUID
@@ -35,7 +43,7 @@ func (s *Service) GetUserByID(userID string) (*models.User, error) {
 	}
 
 	// Query database
-	if err := s.db.First(&user, "id = ?", uid).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(&user, "id = ?", uid).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("user not found")
 		}
@@ -46,26 +54,18 @@ func (s *Service) GetUserByID(userID string) (*models.User, error) {
 }
 
 // GetBalance retrieves the balance for a user's TigerBeetle account
-func (s *Service) GetBalance(userID string) (int64, error) {
-	log.Printf("🟡 [AccountService] GetBalance called for userID: %s", userID)
-
+func (s *Service) GetBalance(ctx context.Context, userID string) (int64, error) {
 	// Get user to retrieve their TigerBeetle account ID
-	log.Printf("🟡 [AccountService] Fetching user from database...")
-	user, err := s.GetUserByID(userID)
+	user, err := s.getUserByID(ctx, userID)
 	if err != nil {
-		log.Printf("❌ [AccountService] Failed to get user: %v", err)
 		return 0, err
 	}
-	log.Printf("🟡 [AccountService] User found: Email=%s, TigerBeetleAccountID=%d", user.Email, user.TigerBeetleAccountID)
 
 	// Query TigerBeetle for balance
-	log.Printf("🟡 [AccountService] Querying TigerBeetle for balance (AccountID: %d)...", user.TigerBeetleAccountID)
-	balance, err := s.tbClient.GetBalance(user.TigerBeetleAccountID)
+	balance, err := s.tbClient.GetBalance(ctx, user.TigerBeetleAccountID)
 	if err != nil {
-		log.Printf("❌ [AccountService] Failed to get balance from TigerBeetle: %v", err)
 		return 0, fmt.Errorf("failed to get balance from TigerBeetle: %w", err)
 	}
-	log.Printf("✅ [AccountService] TigerBeetle returned balance: %d cents for account %d", balance, user.TigerBeetleAccountID)
 
 	return balance, nil
 }
@@ -0,0 +1,406 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// Scopes that can be granted to API keys
+// Interactive user sessions are not restricted by scopes
+const (
+	ScopeReadBalance      = "read:balance"
+	ScopeReadTransactions = "read:transactions"
+	ScopeWriteTransfer    = "write:transfer"
+	ScopeWriteWebhooks    = "write:webhooks"
+)
+
+// APIScopes lists every scope an API key can be granted
+var APIScopes = []string{ScopeReadBalance, ScopeReadTransactions, ScopeWriteTransfer, ScopeWriteWebhooks}
+
+const (
+	// clientIDPrefix makes API keys recognizable (e.g. by secret scanners)
+	clientIDPrefix = "hlk_"
+
+	// apiKeySecretBytes is the amount of randomness in an API key secret
+	apiKeySecretBytes = 32
+
+	// maxAPIKeysPerUser bounds how many active keys a user can hold
+	maxAPIKeysPerUser = 20
+
+	// usageQueueSize bounds how many usage records wait to be written; more are dropped
+	usageQueueSize = 1024
+)
+
+// NewAPIKey is returned once when a key is created; the secret can't be retrieved later
+type NewAPIKey struct {
+	APIKey       models.APIKeyDTO `json:"api_key"`
+	Key          string           `json:"key"`           // "<client_id>.<client_secret>" for the X-API-Key header
+	ClientSecret string           `json:"client_secret"` // For the OAuth2 client-credentials grant
+}
+
+// ClientToken is the OAuth2 token response of the client-credentials grant (RFC 6749 section 5.1)
+type ClientToken struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+	Scope       string `json:"scope"`
+}
+
+// APIKeyService manages API keys, authenticates machine clients and records key usage
+type APIKeyService struct {
+	db       *gorm.DB
+	keys     *KeyManager
+	tokenTTL time.Duration
+	usage    chan models.APIKeyUsage
+}
+
+// NewAPIKeyService creates a new API key service
+// tokenTTL is the lifetime of access tokens issued with the client-credentials grant
+func NewAPIKeyService(db *gorm.DB, keys *KeyManager, tokenTTL time.Duration) *APIKeyService {
+	return &APIKeyService{
+		db:       db,
+		keys:     keys,
+		tokenTTL: tokenTTL,
+		usage:    make(chan models.APIKeyUsage, usageQueueSize),
+	}
+}
+
+// Create issues a new API key for a user with the given scopes
+// expiresAt is optional; keys without it are valid until revoked. transferLimit is the
+// amount the key may move when the step-up policy applies (see models.APIKey); the caller
+// is responsible for having the owner approve it
+func (s *APIKeyService) Create(userID, name string, scopes []string, expiresAt *time.Time, transferLimit int64) (*NewAPIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return nil, err
+	}
+	if len(scopes) == 0 {
+		return nil, fmt.Errorf("at least one scope is required")
+	}
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("expiry must be in the future")
+	}
+	if transferLimit < 0 {
+		return nil, fmt.Errorf("transfer limit must not be negative")
+	}
+	if transferLimit > 0 && !containsScope(scopes, ScopeWriteTransfer) {
+		return nil, fmt.Errorf("transfer limit requires the %s scope", ScopeWriteTransfer)
+	}
+
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	var active int64
+	if err := s.db.Model(&models.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", user.ID, time.Now()).
+		Count(&active).Error; err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if active >= maxAPIKeysPerUser {
+		return nil, fmt.Errorf("api key limit reached (%d active keys)", maxAPIKeysPerUser)
+	}
+
+	clientID, secret, err := newAPIKeyCredentials()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &models.APIKey{
+		UserID:     user.ID,
+		Name:       name,
+		ClientID:   clientID,
+		SecretHash: hashToken(secret),
+		Scopes:     strings.Join(scopes, " "),
+		ExpiresAt:  expiresAt,
+
+		TransferLimit: transferLimit,
+	}
+	if err := s.db.Create(key).Error; err != nil {
+		return nil, fmt.Errorf("failed to create api key: %w", err)
+	}
+
+	logger.Info("API key created", "client_id", key.ClientID, "name", key.Name, "user_id", user.ID, "scopes", key.Scopes, "transfer_limit", key.TransferLimit)
+	return &NewAPIKey{
+		APIKey:       key.ToDTO(),
+		Key:          clientID + "." + secret,
+		ClientSecret: secret,
+	}, nil
+}
+
+// List returns a user's API keys, newest first (revoked and expired keys included)
+func (s *APIKeyService) List(userID string) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	if err := s.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke disables one of a user's API keys and every token issued to it
+func (s *APIKeyService) Revoke(userID, keyID string) (*models.APIKey, error) {
+	key, err := s.owned(userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if key.RevokedAt != nil {
+		return key, nil
+	}
+
+	now := time.Now()
+	if err := s.db.Model(key).Update("revoked_at", now).Error; err != nil {
+		return nil, fmt.Errorf("failed to revoke api key: %w", err)
+	}
+	key.RevokedAt = &now
+
+	logger.Info("API key revoked", "client_id", key.ClientID)
+	return key, nil
+}
+
+// Usage returns the most recent requests made with one of a user's keys, newest first
+func (s *APIKeyService) Usage(userID, keyID string, limit int) ([]models.APIKeyUsage, error) {
+	key, err := s.owned(userID, keyID)
+	if err != nil {
+		return nil, err
+	}
+
+	var usage []models.APIKeyUsage
+	if err := s.db.
+		Where("api_key_id = ?", key.ID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&usage).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve api key usage: %w", err)
+	}
+	return usage, nil
+}
+
+// AuthenticateKey verifies an API key presented as "<client_id>.<secret>"
+// The owner is preloaded
+func (s *APIKeyService) AuthenticateKey(rawKey string) (*models.APIKey, error) {
+	clientID, secret, ok := strings.Cut(strings.TrimSpace(rawKey), ".")
+	if !ok {
+		return nil, fmt.Errorf("invalid api key")
+	}
+	return s.authenticate(clientID, secret)
+}
+
+// ActiveClient returns the key a client-credentials token was issued to, if it is still active
+// Revoking a key therefore also invalidates its outstanding tokens
+func (s *APIKeyService) ActiveClient(clientID string) (*models.APIKey, error) {
+	key, err := s.byClientID(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if !key.IsActive(time.Now()) {
+		return nil, fmt.Errorf("invalid api key")
+	}
+	return key, nil
+}
+
+// IssueClientToken implements the OAuth2 client-credentials grant
+// requestedScope is a space separated subset of the key's scopes; empty means all of them
+func (s *APIKeyService) IssueClientToken(clientID, clientSecret, requestedScope string) (*ClientToken, error) {
+	key, err := s.authenticate(clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	scopes := key.ScopeList()
+	if requested := strings.Fields(requestedScope); len(requested) > 0 {
+		granted := make(map[string]bool, len(scopes))
+		for _, scope := range scopes {
+			granted[scope] = true
+		}
+		for _, scope := range requested {
+			if !granted[scope] {
+				return nil, fmt.Errorf("invalid scope: %s", scope)
+			}
+		}
+		scopes = requested
+	}
+
+	token, _, err := GenerateClientToken(key, key.User, scopes, s.keys, s.tokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("client token issued", "client_id", key.ClientID, "scopes", strings.Join(scopes, " "))
+	return &ClientToken{
+		AccessToken: token,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.tokenTTL.Seconds()),
+		Scope:       strings.Join(scopes, " "),
+	}, nil
+}
+
+// RecordUsage queues a request for the key's usage log; RunUsageRecorder writes it
+// It never blocks the request: when the queue is full the record is dropped and logged
+func (s *APIKeyService) RecordUsage(keyID uuid.UUID, method, path string, status int, ip string) {
+	if len(path) > 255 {
+		path = path[:255]
+	}
+
+	select {
+	case s.usage <- models.APIKeyUsage{
+		APIKeyID:   keyID,
+		Method:     method,
+		Path:       path,
+		StatusCode: status,
+		IPAddress:  ip,
+		CreatedAt:  time.Now(),
+	}:
+	default:
+		logger.Warn("API key usage queue full, dropping record", "api_key_id", keyID)
+	}
+}
+
+// RunUsageRecorder writes queued usage records until the context is cancelled,
+// then writes whatever is still queued
+func (s *APIKeyService) RunUsageRecorder(ctx context.Context) {
+	for {
+		select {
+		case usage := <-s.usage:
+			s.writeUsage(usage)
+		case <-ctx.Done():
+			for {
+				select {
+				case usage := <-s.usage:
+					s.writeUsage(usage)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// writeUsage appends a record to the usage log and updates the key's last use
+// Failures are logged and dropped
+func (s *APIKeyService) writeUsage(usage models.APIKeyUsage) {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&usage).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.APIKey{}).Where("id = ?", usage.APIKeyID).Update("last_used_at", usage.CreatedAt).Error
+	})
+	if err != nil {
+		logger.Warn("failed to record API key usage", "api_key_id", usage.APIKeyID, "error", err)
+	}
+}
+
+// authenticate checks a client ID and secret against the stored hash
+func (s *APIKeyService) authenticate(clientID, secret string) (*models.APIKey, error) {
+	key, err := s.byClientID(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashToken(secret)), []byte(key.SecretHash)) != 1 {
+		return nil, fmt.Errorf("invalid api key")
+	}
+	if !key.IsActive(time.Now()) {
+		return nil, fmt.Errorf("invalid api key")
+	}
+	return key, nil
+}
+
+// byClientID loads a key and its owner by client ID
+func (s *APIKeyService) byClientID(clientID string) (*models.APIKey, error) {
+	if !strings.HasPrefix(clientID, clientIDPrefix) {
+		return nil, fmt.Errorf("invalid api key")
+	}
+
+	var key models.APIKey
+	if err := s.db.Preload("User").Where("client_id = ?", clientID).First(&key).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("invalid api key")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if key.User == nil {
+		return nil, fmt.Errorf("invalid api key")
+	}
+	return &key, nil
+}
+
+// owned loads one of a user's keys
+func (s *APIKeyService) owned(userID, keyID string) (*models.APIKey, error) {
+	id, err := uuid.Parse(keyID)
+	if err != nil {
+		return nil, fmt.Errorf("api key not found")
+	}
+
+	var key models.APIKey
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&key).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("api key not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &key, nil
+}
+
+// normalizeScopes validates and deduplicates requested scopes
+func normalizeScopes(scopes []string) ([]string, error) {
+	known := make(map[string]bool, len(APIScopes))
+	for _, scope := range APIScopes {
+		known[scope] = true
+	}
+
+	seen := make(map[string]bool, len(scopes))
+	var normalized []string
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if !known[scope] {
+			return nil, fmt.Errorf("invalid scope: %s", scope)
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			normalized = append(normalized, scope)
+		}
+	}
+	return normalized, nil
+}
+
+// containsScope reports whether scopes includes scope
+func containsScope(scopes []string, scope string) bool {
+	for _, granted := range scopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// newAPIKeyCredentials generates a public client ID and a random secret
+func newAPIKeyCredentials() (string, string, error) {
+	id := make([]byte, 10)
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(id); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", fmt.Errorf("failed to generate api key: %w", err)
+	}
+
+	clientID := clientIDPrefix + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(id))
+	return clientID, base64.RawURLEncoding.EncodeToString(secret), nil
+}
@@ -0,0 +1,220 @@
+package auth
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// CreateAPIKeyRequest represents a request for a new API key
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	ExpiresInDays int      `json:"expires_in_days"` // Optional; 0 keeps the key valid until revoked
+
+	// TransferLimit (cents) lets the key make withdrawals and transfers that need step-up,
+	// up to this amount each; it needs a step-up token for operation api_key and this amount
+	TransferLimit int64  `json:"transfer_limit"`
+	StepUpToken   string `json:"step_up_token"`
+}
+
+// ClientTokenRequest represents an OAuth2 token request (application/x-www-form-urlencoded)
+// The client may authenticate with HTTP Basic auth instead of client_id/client_secret
+type ClientTokenRequest struct {
+	GrantType    string `form:"grant_type"`
+	ClientID     string `form:"client_id"`
+	ClientSecret string `form:"client_secret"`
+	Scope        string `form:"scope"`
+}
+
+// CreateAPIKey issues an API key for the current user
+// The secret is only returned in this response
+// POST /api/auth/api-keys
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	h.createAPIKey(c, currentUserID(c), true)
+}
+
+// CreateUserAPIKey issues an API key for another user, e.g. a partner's service account (admin only)
+// The admin sets the transfer limit without step-up
+// POST /api/admin/users/:id/api-keys
+func (h *Handler) CreateUserAPIKey(c *gin.Context) {
+	h.createAPIKey(c, c.Param("id"), false)
+}
+
+// createAPIKey binds the request and creates a key owned by userID
+// With ownerStepUp a transfer limit must be approved by the owner with a step-up token
+func (h *Handler) createAPIKey(c *gin.Context, userID string, ownerStepUp bool) {
+	var req CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.ExpiresInDays < 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "expires_in_days must not be negative")
+		return
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInDays > 0 {
+		expiry := time.Now().AddDate(0, 0, req.ExpiresInDays)
+		expiresAt = &expiry
+	}
+
+	var stepUp *StepUpClaim
+	if ownerStepUp && req.TransferLimit > 0 {
+		var err error
+		stepUp, err = h.stepUp.Redeem(req.StepUpToken, userID, StepUpOperation{Operation: StepUpOperationAPIKey, Amount: req.TransferLimit})
+		if err != nil {
+			utils.RespondWithError(c, http.StatusForbidden, "A transfer limit needs a step-up token for operation api_key and the same amount")
+			return
+		}
+	}
+
+	created, err := h.apiKeys.Create(userID, req.Name, req.Scopes, expiresAt, req.TransferLimit)
+	if err != nil {
+		stepUp.Release()
+		errMsg := err.Error()
+		switch {
+		case errMsg == "user not found":
+			utils.RespondWithError(c, http.StatusNotFound, "User not found")
+		case errMsg == "name is required", errMsg == "at least one scope is required",
+			errMsg == "expiry must be in the future", strings.HasPrefix(errMsg, "invalid scope"),
+			strings.HasPrefix(errMsg, "transfer limit"):
+			utils.RespondWithError(c, http.StatusBadRequest, errMsg)
+		case strings.HasPrefix(errMsg, "api key limit reached"):
+			utils.RespondWithError(c, http.StatusConflict, errMsg)
+		default:
+			logger.ErrorContext(c.Request.Context(), "failed to create API key", "user_id", userID, "error", err)
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to create API key")
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, created, "API key created - store the key now, it won't be shown again")
+}
+
+// ListAPIKeys lists the current user's API keys
+// GET /api/auth/api-keys
+func (h *Handler) ListAPIKeys(c *gin.Context) {
+	keys, err := h.apiKeys.List(currentUserID(c))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to list API keys", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve API keys")
+		return
+	}
+
+	dtos := make([]models.APIKeyDTO, len(keys))
+	for i := range keys {
+		dtos[i] = keys[i].ToDTO()
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"api_keys": dtos,
+		"count":    len(dtos),
+		"scopes":   APIScopes,
+	}, "API keys retrieved successfully")
+}
+
+// RevokeAPIKey revokes one of the current user's API keys and the tokens issued to it
+// DELETE /api/auth/api-keys/:id
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	key, err := h.apiKeys.Revoke(currentUserID(c), c.Param("id"))
+	if err != nil {
+		if err.Error() == "api key not found" {
+			utils.RespondWithError(c, http.StatusNotFound, "API key not found")
+			return
+		}
+		logger.ErrorContext(c.Request.Context(), "failed to revoke API key", "api_key_id", c.Param("id"), "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to revoke API key")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"api_key": key.ToDTO()}, "API key revoked")
+}
+
+// GetAPIKeyUsage returns the recent requests made with one of the current user's API keys
+// GET /api/auth/api-keys/:id/usage?limit=50
+func (h *Handler) GetAPIKeyUsage(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+	if limit > 500 {
+		limit = 500
+	}
+
+	usage, err := h.apiKeys.Usage(currentUserID(c), c.Param("id"), limit)
+	if err != nil {
+		if err.Error() == "api key not found" {
+			utils.RespondWithError(c, http.StatusNotFound, "API key not found")
+			return
+		}
+		logger.ErrorContext(c.Request.Context(), "failed to retrieve API key usage", "api_key_id", c.Param("id"), "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve API key usage")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"usage": usage,
+		"count": len(usage),
+	}, "API key usage retrieved successfully")
+}
+
+// ClientToken implements the OAuth2 client-credentials grant (RFC 6749 section 4.4)
+// Responses use the OAuth2 format rather than the API envelope so standard clients work
+// POST /api/oauth/token
+func (h *Handler) ClientToken(c *gin.Context) {
+	var req ClientTokenRequest
+	if err := c.ShouldBind(&req); err != nil {
+		oauthError(c, http.StatusBadRequest, "invalid_request", "Malformed token request")
+		return
+	}
+	if req.GrantType != "client_credentials" {
+		oauthError(c, http.StatusBadRequest, "unsupported_grant_type", "Only client_credentials is supported")
+		return
+	}
+	if id, secret, ok := c.Request.BasicAuth(); ok {
+		req.ClientID, req.ClientSecret = id, secret
+	}
+	if req.ClientID == "" || req.ClientSecret == "" {
+		oauthError(c, http.StatusUnauthorized, "invalid_client", "Client authentication required")
+		return
+	}
+
+	token, err := h.apiKeys.IssueClientToken(req.ClientID, req.ClientSecret, req.Scope)
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case errMsg == "invalid api key":
+			logger.WarnContext(c.Request.Context(), "client authentication failed", "client_id", req.ClientID, "ip", c.ClientIP())
+			oauthError(c, http.StatusUnauthorized, "invalid_client", "Client authentication failed")
+		case strings.HasPrefix(errMsg, "invalid scope"):
+			oauthError(c, http.StatusBadRequest, "invalid_scope", errMsg)
+		default:
+			logger.ErrorContext(c.Request.Context(), "failed to issue client token", "client_id", req.ClientID, "error", err)
+			oauthError(c, http.StatusInternalServerError, "server_error", "Failed to issue token")
+		}
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.Header("Pragma", "no-cache")
+	c.JSON(http.StatusOK, token)
+}
+
+// oauthError writes an OAuth2 error response (RFC 6749 section 5.2)
+func oauthError(c *gin.Context, status int, code, description string) {
+	if status == http.StatusUnauthorized {
+		c.Header("WWW-Authenticate", `Basic realm="hlabs-banking-api"`)
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(status, gin.H{
+		"error":             code,
+		"error_description": description,
+	})
+}
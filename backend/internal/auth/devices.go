@@ -0,0 +1,71 @@
+package auth
+
+import "strings"
+
+// userAgentBrowsers maps user agent markers to browser or client names
+// Order matters: Edge and Opera also announce Chrome, and Chrome also announces Safari
+var userAgentBrowsers = []struct{ marker, name string }{
+	{"Edg/", "Edge"},
+	{"OPR/", "Opera"},
+	{"SamsungBrowser/", "Samsung Internet"},
+	{"Firefox/", "Firefox"},
+	{"FxiOS/", "Firefox"},
+	{"CriOS/", "Chrome"},
+	{"Chrome/", "Chrome"},
+	{"Safari/", "Safari"},
+	{"PostmanRuntime/", "Postman"},
+	{"curl/", "curl"},
+	{"okhttp/", "OkHttp"},
+	{"python-requests/", "Python"},
+	{"Go-http-client/", "Go"},
+}
+
+// userAgentSystems maps user agent markers to operating systems
+// iOS and Android are checked before macOS and Linux, which they also mention
+var userAgentSystems = []struct{ marker, name string }{
+	{"iPhone", "iOS"},
+	{"iPad", "iPadOS"},
+	{"Android", "Android"},
+	{"Windows", "Windows"},
+	{"CrOS", "ChromeOS"},
+	{"Mac OS X", "macOS"},
+	{"Macintosh", "macOS"},
+	{"Linux", "Linux"},
+}
+
+// deviceName derives a readable device label such as "Chrome on macOS" from a user agent
+func deviceName(userAgent string) string {
+	if strings.TrimSpace(userAgent) == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	for _, candidate := range userAgentBrowsers {
+		if strings.Contains(userAgent, candidate.marker) {
+			browser = candidate.name
+			break
+		}
+	}
+
+	for _, candidate := range userAgentSystems {
+		if strings.Contains(userAgent, candidate.marker) {
+			return browser + " on " + candidate.name
+		}
+	}
+	return browser
+}
+
+// deviceID validates a client-supplied device identifier: 16 to 64 URL-safe characters
+// (e.g. a UUID); anything else is ignored
+func deviceID(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if len(raw) < 16 || len(raw) > 64 {
+		return ""
+	}
+	for _, r := range raw {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return ""
+		}
+	}
+	return raw
+}
@@ -0,0 +1,282 @@
+package auth
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/mailer"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/password"
+	"github.com/hlabs/banking-system/internal/ratelimit"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Audiences keep verification and reset tokens from being used for each other
+	emailVerificationAudience = "email-verification"
+	passwordResetAudience     = "password-reset"
+
+	// emailVerificationTTL and passwordResetTTL bound how long emailed links stay valid
+	emailVerificationTTL = 24 * time.Hour
+	passwordResetTTL     = time.Hour
+)
+
+// emailTokenClaims are the claims of an emailed verification or reset token
+// Reset tokens carry a fingerprint of the current password hash, so they stop
+// working as soon as the password changes
+type emailTokenClaims struct {
+	UserID      string `json:"user_id"`
+	Email       string `json:"email"`
+	Fingerprint string `json:"pwf,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// EmailService runs the email verification and password reset flows
+type EmailService struct {
+	db        *gorm.DB
+	jwtSecret string
+	mailer    mailer.Mailer
+	sessions  *SessionService
+	throttle  *LoginThrottler
+	baseURL   string
+	policy    *password.Policy
+
+	// resetStore and resetPolicy limit reset emails per address (see LimitResets)
+	resetStore  ratelimit.Store
+	resetPolicy ratelimit.Policy
+}
+
+// NewEmailService creates a new email flow service
+// baseURL is the frontend origin the emailed links point to
+func NewEmailService(db *gorm.DB, jwtSecret string, m mailer.Mailer, sessions *SessionService, throttle *LoginThrottler, policy *password.Policy, baseURL string) *EmailService {
+	return &EmailService{
+		db:        db,
+		jwtSecret: jwtSecret,
+		mailer:    m,
+		sessions:  sessions,
+		throttle:  throttle,
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		policy:    policy,
+	}
+}
+
+// LimitResets caps how many password reset requests each email address can make
+// Requests over the limit are dropped silently, so the limit doesn't reveal which addresses exist
+func (s *EmailService) LimitResets(store ratelimit.Store, policy ratelimit.Policy) {
+	s.resetStore = store
+	s.resetPolicy = policy
+}
+
+// SendVerification emails the user a link to confirm their address
+func (s *EmailService) SendVerification(user *models.User) error {
+	if user.IsEmailVerified() {
+		return fmt.Errorf("email already verified")
+	}
+
+	token, err := s.issue(user, emailVerificationAudience, "", emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Confirm your email address",
+		Body: fmt.Sprintf("Hi %s,\n\nPlease confirm your email address by opening this link:\n\n%s\n\n"+
+			"The link expires in 24 hours. Until your address is confirmed, withdrawals and transfers are limited.\n",
+			user.FullName, s.link("/verify-email", token)),
+	})
+}
+
+// ConfirmEmail marks the user's email as verified
+// The token can only be used once and only for the address it was sent to
+func (s *EmailService) ConfirmEmail(token string) (*models.User, error) {
+	user, claims, err := s.parse(token, emailVerificationAudience)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.EqualFold(claims.Email, user.Email) {
+		return nil, fmt.Errorf("invalid or expired token")
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := burnEmailToken(tx, claims, user.ID, "email_verified"); err != nil {
+			return err
+		}
+		if user.IsEmailVerified() {
+			return nil
+		}
+		now := time.Now()
+		user.EmailVerifiedAt = &now
+		return tx.Model(user).Update("email_verified_at", now).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("email verified", "user_id", user.ID)
+	return user, nil
+}
+
+// RequestPasswordReset emails a reset link if the address belongs to a user
+// Unknown addresses are not reported, so the endpoint can't be used to enumerate accounts
+func (s *EmailService) RequestPasswordReset(email string) error {
+	email = strings.TrimSpace(email)
+	if s.resetStore != nil && s.resetPolicy.Enabled() {
+		result, err := s.resetStore.Take("rl:"+s.resetPolicy.Name+":email:"+strings.ToLower(email), s.resetPolicy, time.Now())
+		if err != nil {
+			return fmt.Errorf("rate limit check failed: %w", err)
+		}
+		if !result.Allowed {
+			return nil
+		}
+	}
+
+	var user models.User
+	if err := s.db.Where("email = ?", email).First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil
+		}
+		return fmt.Errorf("database error: %w", err)
+	}
+
+	token, err := s.issue(&user, passwordResetAudience, passwordFingerprint(&user), passwordResetTTL)
+	if err != nil {
+		return err
+	}
+
+	logger.Info("password reset requested", "user_id", user.ID)
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password of your account. If it was you, open this link:\n\n%s\n\n"+
+			"The link expires in 1 hour and can be used once. If you didn't ask for this, you can ignore this email.\n",
+			user.FullName, s.link("/reset-password", token)),
+	})
+}
+
+// ResetPassword sets a new password from a reset token
+// Every session of the user is revoked and any login lockout is lifted
+func (s *EmailService) ResetPassword(token, newPassword string) error {
+	user, claims, err := s.parse(token, passwordResetAudience)
+	if err != nil {
+		return err
+	}
+	if claims.Fingerprint != passwordFingerprint(user) {
+		return fmt.Errorf("invalid or expired token")
+	}
+	if err := s.policy.Validate(newPassword, user.Email, user.FullName); err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := burnEmailToken(tx, claims, user.ID, "password_reset"); err != nil {
+			return err
+		}
+		if err := setPassword(tx, s.policy, user, newPassword); err != nil {
+			return err
+		}
+		// Receiving the reset email proves ownership of the address
+		if user.IsEmailVerified() {
+			return nil
+		}
+		return tx.Model(user).Update("email_verified_at", time.Now()).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if _, err := s.sessions.RevokeAll(user.ID.String(), models.SessionRevokedPasswordChange); err != nil {
+		logger.Warn("failed to revoke sessions after password reset", "user_id", user.ID, "error", err)
+	}
+	if _, err := s.throttle.Unlock(user.ID.String()); err != nil {
+		logger.Warn("failed to lift login lockout after password reset", "user_id", user.ID, "error", err)
+	}
+
+	logger.Info("password reset completed", "user_id", user.ID)
+	return nil
+}
+
+// issue signs an emailed token for the given purpose
+func (s *EmailService) issue(user *models.User, audience, fingerprint string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	claims := emailTokenClaims{
+		UserID:      user.ID.String(),
+		Email:       user.Email,
+		Fingerprint: fingerprint,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "hlabs-banking-api",
+			Subject:   user.ID.String(),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return token, nil
+}
+
+// parse validates an emailed token and loads its user, rejecting tokens that were already used
+func (s *EmailService) parse(tokenString, audience string) (*models.User, *emailTokenClaims, error) {
+	claims := &emailTokenClaims{}
+	token, err := jwt.ParseWithClaims(strings.TrimSpace(tokenString), claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.jwtSecret), nil
+	}, jwt.WithAudience(audience))
+	if err != nil || !token.Valid {
+		return nil, nil, fmt.Errorf("invalid or expired token")
+	}
+
+	var user models.User
+	if err := s.db.First(&user, "id = ?", claims.UserID).Error; err != nil {
+		return nil, nil, fmt.Errorf("invalid or expired token")
+	}
+
+	var used int64
+	if err := s.db.Model(&models.RevokedToken{}).Where("jti = ?", claims.ID).Count(&used).Error; err != nil {
+		return nil, nil, fmt.Errorf("database error: %w", err)
+	}
+	if used > 0 {
+		return nil, nil, fmt.Errorf("invalid or expired token")
+	}
+
+	return &user, claims, nil
+}
+
+// link builds a frontend URL carrying the token
+func (s *EmailService) link(path, token string) string {
+	return s.baseURL + path + "?token=" + url.QueryEscape(token)
+}
+
+// burnEmailToken marks an emailed token as used; losing the race to a concurrent use is an invalid token
+func burnEmailToken(tx *gorm.DB, claims *emailTokenClaims, userID uuid.UUID, reason string) error {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    userID,
+		ExpiresAt: claims.ExpiresAt.Time,
+		Reason:    reason,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to redeem token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("invalid or expired token")
+	}
+	return nil
+}
+
+// passwordFingerprint identifies the current password hash without exposing it
+func passwordFingerprint(user *models.User) string {
+	return hashToken(user.Password)[:16]
+}
@@ -0,0 +1,112 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// TokenRequest represents a request carrying an emailed token
+type TokenRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// PasswordResetRequest represents a request for a password reset email
+type PasswordResetRequest struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// PasswordResetConfirmRequest represents the new password set from a reset link
+type PasswordResetConfirmRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// RequestEmailVerification (re)sends the verification email to the current user
+// POST /api/auth/verify-email/request
+func (h *Handler) RequestEmailVerification(c *gin.Context) {
+	user, err := h.mfa.getUser(currentUserID(c))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if err := h.emails.SendVerification(user); err != nil {
+		if err.Error() == "email already verified" {
+			utils.RespondWithError(c, http.StatusConflict, "Email is already verified")
+			return
+		}
+		logger.ErrorContext(c.Request.Context(), "failed to send verification email", "user_id", user.ID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to send verification email")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, nil, "Verification email sent")
+}
+
+// ConfirmEmailVerification verifies the email address from an emailed token
+// POST /api/auth/verify-email/confirm
+func (h *Handler) ConfirmEmailVerification(c *gin.Context) {
+	var req TokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	user, err := h.emails.ConfirmEmail(req.Token)
+	if err != nil {
+		respondWithEmailTokenError(c, err, "Failed to verify email")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"user": user.ToDTO()}, "Email verified successfully")
+}
+
+// RequestPasswordReset sends a password reset link
+// Always answers 202 so the endpoint doesn't reveal which emails have accounts
+// POST /api/auth/password-reset/request
+func (h *Handler) RequestPasswordReset(c *gin.Context) {
+	var req PasswordResetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := h.emails.RequestPasswordReset(req.Email); err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to request password reset", "error", err)
+	}
+
+	utils.RespondWithSuccess(c, http.StatusAccepted, nil, "If the email belongs to an account, a reset link has been sent")
+}
+
+// ConfirmPasswordReset sets a new password from a reset token
+// POST /api/auth/password-reset/confirm
+func (h *Handler) ConfirmPasswordReset(c *gin.Context) {
+	var req PasswordResetConfirmRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := h.emails.ResetPassword(req.Token, req.NewPassword); err != nil {
+		respondWithEmailTokenError(c, err, "Failed to reset password")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, nil, "Password reset successfully, please log in again")
+}
+
+// respondWithEmailTokenError maps email flow errors to HTTP responses
+func respondWithEmailTokenError(c *gin.Context, err error, fallback string) {
+	errMsg := err.Error()
+	switch {
+	case errMsg == "invalid or expired token":
+		utils.RespondWithError(c, http.StatusBadRequest, "The link is invalid, expired or was already used")
+	case len(errMsg) > 8 && errMsg[:8] == "password":
+		utils.RespondWithError(c, http.StatusBadRequest, errMsg)
+	default:
+		logger.ErrorContext(c.Request.Context(), "email flow failed", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, fallback)
+	}
+}
@@ -1,31 +1,57 @@
 package auth
 
 import (
-	"log"
 	"net/http"
 	"regexp"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/internal/eventbus"
+	"github.com/hlabs/banking-system/internal/logging"
 	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/notification"
+	"github.com/hlabs/banking-system/internal/password"
 	"github.com/hlabs/banking-system/internal/tigerbeetle"
 	"github.com/hlabs/banking-system/pkg/utils"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+var logger = logging.For("auth")
+
 // Handler handles authentication requests
 type Handler struct {
-	db        *gorm.DB
-	tbClient  *tigerbeetle.Client
-	jwtSecret string
+	db       *gorm.DB
+	tbClient *tigerbeetle.Client
+	sessions *SessionService
+	mfa      *MFAService
+	stepUp   *StepUpService
+	throttle *LoginThrottler
+	emails   *EmailService
+	policy   *password.Policy
+	keys     *KeyManager
+	apiKeys  *APIKeyService
+	passkeys *PasskeyService
+
+	notifications *notification.Service
+	bus           *eventbus.Bus
 }
 
 // NewHandler creates a new auth handler
-func NewHandler(db *gorm.DB, tbClient *tigerbeetle.Client, jwtSecret string) *Handler {
+func NewHandler(db *gorm.DB, tbClient *tigerbeetle.Client, sessions *SessionService, mfa *MFAService, stepUp *StepUpService, throttle *LoginThrottler, emails *EmailService, policy *password.Policy, keys *KeyManager, apiKeys *APIKeyService, passkeys *PasskeyService, notifications *notification.Service, bus *eventbus.Bus) *Handler {
 	return &Handler{
-		db:        db,
-		tbClient:  tbClient,
-		jwtSecret: jwtSecret,
+		db:       db,
+		tbClient: tbClient,
+		sessions: sessions,
+		mfa:      mfa,
+		stepUp:   stepUp,
+		throttle: throttle,
+		emails:   emails,
+		policy:   policy,
+		keys:     keys,
+		apiKeys:  apiKeys,
+		passkeys: passkeys,
+
+		notifications: notifications,
+		bus:           bus,
 	}
 }
 
@@ -42,10 +68,16 @@ type LoginRequest struct {
 	Password string `json:"password" binding:"required"`
 }
 
+// RefreshRequest represents the token refresh request payload
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
 // AuthResponse represents the authentication response
+// The access token is returned as "token" alongside the refresh token and expiry information
 type AuthResponse struct {
-	Token string         `json:"token"`
-	User  models.UserDTO `json:"user"`
+	*TokenPair
+	User models.UserDTO `json:"user"`
 }
 
 // Register handles user registration
@@ -62,9 +94,9 @@ func (h *Handler) Register(c *gin.Context) {
 		return
 	}
 
-	// Validate password strength
-	if len(req.Password) < 6 {
-		utils.RespondWithError(c, http.StatusBadRequest, "Password must be at least 6 characters")
+	// Validate password against the policy
+	if err := h.policy.Validate(req.Password, req.Email, req.FullName); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
@@ -76,9 +108,9 @@ func (h *Handler) Register(c *gin.Context) {
 	}
 
 	// Hash password
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	hashedPassword, err := password.Hash(req.Password)
 	if err != nil {
-		log.Printf("Error hashing password: %v", err)
+		logger.ErrorContext(c.Request.Context(), "failed to hash password", "error", err)
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to process password")
 		return
 	}
@@ -87,8 +119,8 @@ func (h *Handler) Register(c *gin.Context) {
 	tbAccountID := utils.GenerateAccountID()
 
 	// Create TigerBeetle account
-	if err := h.tbClient.CreateAccount(tbAccountID); err != nil {
-		log.Printf("Error creating TigerBeetle account: %v", err)
+	if err := h.tbClient.CreateAccount(c.Request.Context(), tbAccountID); err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to create TigerBeetle account", "error", err)
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to create bank account")
 		return
 	}
@@ -96,88 +128,191 @@ func (h *Handler) Register(c *gin.Context) {
 	// Create user in PostgreSQL
 	user := models.User{
 		Email:                req.Email,
-		Password:             string(hashedPassword),
+		Password:             hashedPassword,
 		FullName:             req.FullName,
 		TigerBeetleAccountID: tbAccountID,
+		Role:                 models.RoleCustomer,
 	}
 
 	if err := h.db.Create(&user).Error; err != nil {
-		log.Printf("Error creating user: %v", err)
+		logger.ErrorContext(c.Request.Context(), "failed to create user", "error", err)
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to create user")
 		return
 	}
 
-	// Generate JWT token
-	token, err := GenerateToken(user.ID, user.Email, h.jwtSecret)
+	// Ask the user to confirm their address (limits stay restricted until they do)
+	if err := h.emails.SendVerification(&user); err != nil {
+		logger.WarnContext(c.Request.Context(), "failed to send verification email", "user_id", user.ID, "error", err)
+	}
+
+	// Start a session and issue tokens
+	pair, err := h.sessions.Create(&user, clientInfo(c))
 	if err != nil {
-		log.Printf("Error generating token: %v", err)
+		logger.ErrorContext(c.Request.Context(), "failed to create session", "error", err)
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to generate authentication token")
 		return
 	}
 
 	// Return response
 	response := AuthResponse{
-		Token: token,
-		User:  user.ToDTO(),
+		TokenPair: pair,
+		User:      user.ToDTO(),
 	}
 
-	log.Printf("✅ User registered: %s (TB Account: %d)", user.Email, tbAccountID)
+	logger.InfoContext(c.Request.Context(), "user registered", "user_id", user.ID, "account_id", tbAccountID)
+	h.bus.Publish(eventbus.UserRegistered{
+		UserID:               user.ID,
+		TigerBeetleAccountID: user.TigerBeetleAccountID,
+		AccountType:          user.AccountType,
+		RegisteredAt:         user.CreatedAt,
+	})
 
 	utils.RespondWithSuccess(c, http.StatusCreated, response, "User registered successfully")
 }
 
 // Login handles user login
+// Failed attempts are counted per account and per IP; locked out clients get 429 with Retry-After
 func (h *Handler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
+	client := clientInfo(c)
+
+	// Refuse locked out accounts and IPs before touching the password
+	if !h.checkThrottle(c, req.Email, client) {
+		return
+	}
 
 	// Find user by email
 	var user models.User
 	if err := h.db.Where("email = ?", req.Email).First(&user).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
+			checkUnknownPassword(req.Password)
+			h.recordLoginFailure(req.Email, nil, client, models.LoginFailureInvalidCredentials)
 			utils.RespondWithError(c, http.StatusUnauthorized, "Invalid email or password")
 		} else {
-			log.Printf("Error finding user: %v", err)
+			logger.ErrorContext(c.Request.Context(), "failed to find user", "error", err)
 			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to authenticate")
 		}
 		return
 	}
 
-	// Verify password
-	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+	// Verify password (legacy hashes are upgraded to argon2id on success)
+	if err := checkPassword(h.db, &user, req.Password); err != nil {
+		h.recordLoginFailure(req.Email, &user.ID, client, models.LoginFailureInvalidCredentials)
 		utils.RespondWithError(c, http.StatusUnauthorized, "Invalid email or password")
 		return
 	}
 
-	// Generate JWT token
-	token, err := GenerateToken(user.ID, user.Email, h.jwtSecret)
+	// With MFA enabled the password only yields a challenge for the second step
+	if user.MFAEnabled {
+		challenge, err := h.mfa.IssueChallenge(&user)
+		if err != nil {
+			logger.ErrorContext(c.Request.Context(), "failed to issue MFA challenge", "error", err)
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to authenticate")
+			return
+		}
+
+		logger.InfoContext(c.Request.Context(), "MFA challenge issued", "user_id", user.ID)
+		utils.RespondWithSuccess(c, http.StatusOK, challenge, "MFA verification required")
+		return
+	}
+
+	// Start a session and issue tokens
+	pair, err := h.sessions.Create(&user, client)
 	if err != nil {
-		log.Printf("Error generating token: %v", err)
+		logger.ErrorContext(c.Request.Context(), "failed to create session", "error", err)
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to generate authentication token")
 		return
 	}
+	h.recordLoginSuccess(&user, client)
+	h.notifyNewDevice(&user, client, pair)
 
 	// Return response
 	response := AuthResponse{
-		Token: token,
-		User:  user.ToDTO(),
+		TokenPair: pair,
+		User:      user.ToDTO(),
 	}
 
-	log.Printf("✅ User logged in: %s", user.Email)
+	logger.InfoContext(c.Request.Context(), "user logged in", "user_id", user.ID)
 
 	utils.RespondWithSuccess(c, http.StatusOK, response, "Login successful")
 }
 
-// Logout handles user logout (client-side token removal)
+// Refresh exchanges a refresh token for a new token pair (the refresh token rotates)
+// POST /api/auth/refresh
+func (h *Handler) Refresh(c *gin.Context) {
+	var req RefreshRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	pair, user, err := h.sessions.Refresh(req.RefreshToken, clientInfo(c))
+	if err != nil {
+		switch err.Error() {
+		case "invalid refresh token", "refresh token expired", "user not found":
+			utils.RespondWithError(c, http.StatusUnauthorized, "Invalid or expired refresh token")
+		case "refresh token reuse detected":
+			utils.RespondWithError(c, http.StatusUnauthorized, "Refresh token reuse detected - session revoked, please log in again")
+		default:
+			logger.ErrorContext(c.Request.Context(), "failed to refresh session", "error", err)
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to refresh session")
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, AuthResponse{TokenPair: pair, User: user.ToDTO()}, "Token refreshed successfully")
+}
+
+// Logout ends the current session and revokes its tokens
+// POST /api/auth/logout
 func (h *Handler) Logout(c *gin.Context) {
+	sessionID := c.GetString("session_id")
+
+	if err := h.sessions.Revoke(currentUserID(c), sessionID, models.SessionRevokedLogout); err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to revoke session", "session_id", sessionID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to log out")
+		return
+	}
+
 	utils.RespondWithSuccess(c, http.StatusOK, nil, "Logout successful")
 }
 
+// LogoutAll ends every session of the current user ("log out everywhere")
+// POST /api/auth/logout-all
+func (h *Handler) LogoutAll(c *gin.Context) {
+	userID := currentUserID(c)
+
+	count, err := h.sessions.RevokeAll(userID, models.SessionRevokedLogoutAll)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to revoke sessions", "user_id", userID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to log out everywhere")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"sessions_revoked": count}, "Logged out of all sessions")
+}
+
 // Helper functions
 
+// currentUserID returns the authenticated user ID set by the auth middleware
+// (the middleware package imports auth, so its helpers can't be used here)
+func currentUserID(c *gin.Context) string {
+	return c.GetString("user_id")
+}
+
+// clientInfo captures the client IP and user agent for the session record
+func clientInfo(c *gin.Context) ClientInfo {
+	return ClientInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+		DeviceID:  deviceID(c.GetHeader("X-Device-ID")),
+	}
+}
+
 // isValidEmail validates that the provided email string matches a valid email format.
 // It uses a regex pattern to check for standard email structure (local@domain.tld).
 // Returns true if the email is valid, false otherwise.
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// Supported access token signing algorithms
+	AlgorithmEdDSA = "EdDSA"
+	AlgorithmRS256 = "RS256"
+)
+
+// PublicKey is a verification key identified by its kid
+type PublicKey struct {
+	ID        string
+	Algorithm string
+	Key       crypto.PublicKey
+}
+
+// KeyResolver finds the public key an access token was signed with
+// It is implemented by KeyManager
+type KeyResolver interface {
+	LookupKey(kid string) (*PublicKey, error)
+}
+
+// JWK is a public key in JSON Web Key format (RFC 7517)
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid"`
+	Use       string `json:"use"`
+	Algorithm string `json:"alg"`
+
+	// Ed25519 (kty OKP)
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+
+	// RSA (kty RSA)
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+}
+
+// JWKS is the key set served at /.well-known/jwks.json
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// toJWK encodes a verification key as a JWK
+func toJWK(key *PublicKey) (JWK, error) {
+	jwk := JWK{KeyID: key.ID, Use: "sig", Algorithm: key.Algorithm}
+	switch pub := key.Key.(type) {
+	case ed25519.PublicKey:
+		jwk.KeyType = "OKP"
+		jwk.Curve = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(pub)
+	case *rsa.PublicKey:
+		jwk.KeyType = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(pub.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes())
+	default:
+		return JWK{}, fmt.Errorf("unsupported key type %T", key.Key)
+	}
+	return jwk, nil
+}
+
+// thumbprint computes the RFC 7638 JWK thumbprint used as the kid of a new key
+func thumbprint(key crypto.PublicKey) (string, error) {
+	jwk, err := toJWK(&PublicKey{Key: key})
+	if err != nil {
+		return "", err
+	}
+
+	// Required members only, in lexicographic order
+	var canonical string
+	switch jwk.KeyType {
+	case "OKP":
+		canonical = fmt.Sprintf(`{"crv":%q,"kty":%q,"x":%q}`, jwk.Curve, jwk.KeyType, jwk.X)
+	default:
+		canonical = fmt.Sprintf(`{"e":%q,"kty":%q,"n":%q}`, jwk.E, jwk.KeyType, jwk.N)
+	}
+	sum := sha256.Sum256([]byte(canonical))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -2,56 +2,109 @@ package auth
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/models"
 )
 
 // Claims represents the JWT claims structure
+// The JWT ID (jti) identifies the token in the revocation list and sid ties it to a server-side session
+// Tokens issued with the client-credentials grant have no session; they carry the API key's
+// client_id and the granted scopes instead
 type Claims struct {
-	UserID string `json:"user_id"`
-	Email  string `json:"email"`
+	UserID    string `json:"user_id"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	SessionID string `json:"sid,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken creates a new JWT token for a user
-func GenerateToken(userID uuid.UUID, email, secret string) (string, error) {
+// tokenIssuer is the iss claim of access tokens
+const tokenIssuer = "hlabs-banking-api"
+
+// GenerateToken creates a new short-lived access token for a user session
+// The token is signed with the active key of keys and carries its kid
+func GenerateToken(userID uuid.UUID, email, role string, sessionID uuid.UUID, keys *KeyManager, ttl time.Duration) (string, *Claims, error) {
+	now := time.Now()
+
 	// Create claims with user information
-	claims := Claims{
-		UserID: userID.String(),
-		Email:  email,
+	claims := &Claims{
+		UserID:    userID.String(),
+		Email:     email,
+		Role:      role,
+		SessionID: sessionID.String(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(24 * time.Hour)), // 24 hours
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-			Issuer:    "hlabs-banking-api",
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    tokenIssuer,
 			Subject:   userID.String(),
 		},
 	}
 
-	// Create token with claims
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	// Sign token with the active key
+	tokenString, err := keys.Sign(claims)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return tokenString, claims, nil
+}
+
+// GenerateClientToken creates an access token for an API key (OAuth2 client-credentials grant)
+// The token acts for the key's owner, with the owner's role, restricted to scopes
+func GenerateClientToken(key *models.APIKey, owner *models.User, scopes []string, keys *KeyManager, ttl time.Duration) (string, *Claims, error) {
+	now := time.Now()
 
-	// Sign token with secret
-	tokenString, err := token.SignedString([]byte(secret))
+	claims := &Claims{
+		UserID:   owner.ID.String(),
+		Email:    owner.Email,
+		Role:     owner.Role,
+		ClientID: key.ClientID,
+		Scope:    strings.Join(scopes, " "),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    tokenIssuer,
+			Subject:   key.ClientID,
+		},
+	}
+
+	tokenString, err := keys.Sign(claims)
 	if err != nil {
-		return "", fmt.Errorf("failed to sign token: %w", err)
+		return "", nil, err
 	}
 
-	return tokenString, nil
+	return tokenString, claims, nil
 }
 
 // ValidateToken parses and validates a JWT token
-func ValidateToken(tokenString, secret string) (*Claims, error) {
+// The verification key is looked up by the token's kid; its algorithm must match the token's
+func ValidateToken(tokenString string, keys KeyResolver) (*Claims, error) {
 	// Parse token
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		// Validate signing method
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		kid, _ := token.Header["kid"].(string)
+		if kid == "" {
+			return nil, fmt.Errorf("token has no key ID")
+		}
+		key, err := keys.LookupKey(kid)
+		if err != nil {
+			return nil, err
+		}
+		// Validate signing method against the key, so a key can't be used with another algorithm
+		if token.Method.Alg() != key.Algorithm {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
-		return []byte(secret), nil
-	})
+		return key.Key, nil
+	}, jwt.WithValidMethods([]string{AlgorithmEdDSA, AlgorithmRS256}), jwt.WithIssuer(tokenIssuer))
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse token: %w", err)
@@ -63,12 +116,17 @@ func ValidateToken(tokenString, secret string) (*Claims, error) {
 		return nil, fmt.Errorf("invalid token claims")
 	}
 
+	// Tokens without an ID can't be revoked (issued before sessions existed)
+	if claims.ID == "" || (claims.SessionID == "" && claims.ClientID == "") {
+		return nil, fmt.Errorf("token has no session")
+	}
+
 	return claims, nil
 }
 
 // ExtractUserID extracts the user ID from a JWT token
-func ExtractUserID(tokenString, secret string) (uuid.UUID, error) {
-	claims, err := ValidateToken(tokenString, secret)
+func ExtractUserID(tokenString string, keys KeyResolver) (uuid.UUID, error) {
+	claims, err := ValidateToken(tokenString, keys)
 	if err != nil {
 		return uuid.Nil, err
 	}
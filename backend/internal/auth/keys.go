@@ -0,0 +1,427 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/secretbox"
+	"gorm.io/gorm"
+)
+
+const (
+	// rsaKeyBits is the modulus size of generated RS256 keys
+	rsaKeyBits = 2048
+
+	// keyCheckInterval is how often RunRotation reloads keys and rotates when due
+	keyCheckInterval = 10 * time.Minute
+
+	// keyReloadInterval bounds how often an unknown kid triggers a reload from the database
+	keyReloadInterval = 10 * time.Second
+
+	// signingKeyLock serializes rotations across instances (pg_advisory_xact_lock id)
+	signingKeyLock int64 = 0x6b657973
+)
+
+// KeyPolicy configures access token signing keys
+type KeyPolicy struct {
+	Algorithm   string        // AlgorithmEdDSA or AlgorithmRS256
+	RotateEvery time.Duration // Age at which the active key is replaced
+	Grace       time.Duration // How long a retired key keeps verifying tokens (at least the access token TTL)
+}
+
+// signingKey is a loaded key; only the active key has its private half decrypted
+type signingKey struct {
+	record  models.SigningKey
+	public  *PublicKey
+	private crypto.Signer
+}
+
+// KeyManager signs access tokens with the active key and verifies them with any key
+// that is active or still within its grace period
+// Keys live in PostgreSQL (private halves encrypted), so every instance shares them
+type KeyManager struct {
+	db     *gorm.DB
+	box    *secretbox.Box
+	policy KeyPolicy
+
+	mu       sync.RWMutex
+	active   *signingKey
+	keys     map[string]*signingKey
+	loadedAt time.Time
+}
+
+// NewKeyManager creates a key manager and makes sure an active key of the configured algorithm exists
+// Private keys are encrypted at rest with AES-256-GCM using a key derived from encryptionKey
+func NewKeyManager(db *gorm.DB, encryptionKey string, policy KeyPolicy) (*KeyManager, error) {
+	if _, err := signingMethod(policy.Algorithm); err != nil {
+		return nil, err
+	}
+	box, err := secretbox.New(encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create signing key cipher: %w", err)
+	}
+
+	m := &KeyManager{
+		db:     db,
+		box:    box,
+		policy: policy,
+		keys:   make(map[string]*signingKey),
+	}
+	if err := m.rotateIfDue(); err != nil {
+		return nil, err
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Sign signs claims with the active key; the kid header tells verifiers which key to use
+// The key is checked against the database first, so a key retired by another instance
+// is never used to sign
+func (m *KeyManager) Sign(claims jwt.Claims) (string, error) {
+	active, err := m.currentKey()
+	if err != nil {
+		return "", err
+	}
+
+	method, err := signingMethod(active.record.Algorithm)
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = active.record.KID
+
+	signed, err := token.SignedString(active.private)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return signed, nil
+}
+
+// currentKey returns the active key, reloading the keys when it has been retired since the last load
+func (m *KeyManager) currentKey() (*signingKey, error) {
+	m.mu.RLock()
+	active := m.active
+	m.mu.RUnlock()
+	if active == nil {
+		return nil, fmt.Errorf("no active signing key")
+	}
+
+	var stillActive int64
+	if err := m.db.Model(&models.SigningKey{}).
+		Where("kid = ? AND retired_at IS NULL", active.record.KID).
+		Count(&stillActive).Error; err != nil {
+		return nil, fmt.Errorf("failed to check signing key: %w", err)
+	}
+	if stillActive > 0 {
+		return active, nil
+	}
+
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.active, nil
+}
+
+// LookupKey returns the verification key with the given kid
+// Keys rotated by another instance are picked up by reloading on an unknown kid
+func (m *KeyManager) LookupKey(kid string) (*PublicKey, error) {
+	m.mu.RLock()
+	key, ok := m.keys[kid]
+	loadedAt := m.loadedAt
+	m.mu.RUnlock()
+
+	if !ok && time.Since(loadedAt) >= keyReloadInterval {
+		if err := m.load(); err != nil {
+			return nil, err
+		}
+		m.mu.RLock()
+		key, ok = m.keys[kid]
+		m.mu.RUnlock()
+	}
+	if !ok {
+		return nil, fmt.Errorf("unknown signing key %q", kid)
+	}
+	if exp := key.record.ExpiresAt; exp != nil && time.Now().After(*exp) {
+		return nil, fmt.Errorf("signing key %q expired", kid)
+	}
+	return key.public, nil
+}
+
+// JWKS returns the public keys that currently verify tokens
+func (m *KeyManager) JWKS() (*JWKS, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	set := &JWKS{Keys: []JWK{}}
+	for _, key := range m.keys {
+		if exp := key.record.ExpiresAt; exp != nil && now.After(*exp) {
+			continue
+		}
+		jwk, err := toJWK(key.public)
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// Keys lists the signing keys that are active or still in their grace period, newest first
+func (m *KeyManager) Keys() ([]models.SigningKey, error) {
+	var keys []models.SigningKey
+	if err := m.db.
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("created_at DESC").
+		Find(&keys).Error; err != nil {
+		return nil, fmt.Errorf("failed to list signing keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Rotate immediately replaces the active key (e.g. after a suspected compromise)
+// The previous key keeps verifying tokens for the grace period
+func (m *KeyManager) Rotate() (*models.SigningKey, error) {
+	var created *models.SigningKey
+	err := m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", signingKeyLock).Error; err != nil {
+			return fmt.Errorf("failed to lock signing keys: %w", err)
+		}
+		var err error
+		created, err = m.rotate(tx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
+
+// RunRotation rotates the active key when it reaches RotateEvery and drops keys past
+// their grace period, until the context is cancelled
+func (m *KeyManager) RunRotation(ctx context.Context) {
+	ticker := time.NewTicker(keyCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := m.rotateIfDue(); err != nil {
+				logger.Warn("signing key rotation failed", "error", err)
+			}
+			if err := m.purgeExpired(); err != nil {
+				logger.Warn("signing key cleanup failed", "error", err)
+			}
+			if err := m.load(); err != nil {
+				logger.Warn("signing key reload failed", "error", err)
+			}
+		}
+	}
+}
+
+// rotateIfDue creates a key when there is no active key, the active key is older than
+// RotateEvery or the configured algorithm changed
+func (m *KeyManager) rotateIfDue() error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", signingKeyLock).Error; err != nil {
+			return fmt.Errorf("failed to lock signing keys: %w", err)
+		}
+
+		var active models.SigningKey
+		err := tx.Where("retired_at IS NULL").Order("created_at DESC").First(&active).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return fmt.Errorf("failed to load active signing key: %w", err)
+		}
+		if err == nil && active.Algorithm == m.policy.Algorithm &&
+			(m.policy.RotateEvery <= 0 || time.Since(active.CreatedAt) < m.policy.RotateEvery) {
+			return nil
+		}
+
+		_, err = m.rotate(tx)
+		return err
+	})
+}
+
+// rotate retires the active keys and creates a new one; the caller holds the advisory lock
+func (m *KeyManager) rotate(tx *gorm.DB) (*models.SigningKey, error) {
+	now := time.Now()
+	if err := tx.Model(&models.SigningKey{}).
+		Where("retired_at IS NULL").
+		Updates(map[string]interface{}{
+			"retired_at": now,
+			"expires_at": now.Add(m.policy.Grace),
+		}).Error; err != nil {
+		return nil, fmt.Errorf("failed to retire signing key: %w", err)
+	}
+
+	record, err := m.generate()
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to store signing key: %w", err)
+	}
+
+	logger.Info("new signing key is active", "algorithm", record.Algorithm, "kid", record.KID)
+	return record, nil
+}
+
+// generate creates a key pair of the configured algorithm
+func (m *KeyManager) generate() (*models.SigningKey, error) {
+	var private crypto.Signer
+	switch m.policy.Algorithm {
+	case AlgorithmEdDSA:
+		_, key, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate Ed25519 key: %w", err)
+		}
+		private = key
+	case AlgorithmRS256:
+		key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate RSA key: %w", err)
+		}
+		private = key
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", m.policy.Algorithm)
+	}
+
+	kid, err := thumbprint(private.Public())
+	if err != nil {
+		return nil, err
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(private.Public())
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode public key: %w", err)
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(private)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode private key: %w", err)
+	}
+	sealed, err := m.box.Seal(privateDER)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt private key: %w", err)
+	}
+
+	return &models.SigningKey{
+		KID:        kid,
+		Algorithm:  m.policy.Algorithm,
+		PublicKey:  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER})),
+		PrivateKey: sealed,
+	}, nil
+}
+
+// load replaces the in-memory key set with the keys that still verify tokens
+func (m *KeyManager) load() error {
+	var records []models.SigningKey
+	if err := m.db.
+		Where("expires_at IS NULL OR expires_at > ?", time.Now()).
+		Order("created_at DESC").
+		Find(&records).Error; err != nil {
+		return fmt.Errorf("failed to load signing keys: %w", err)
+	}
+
+	keys := make(map[string]*signingKey, len(records))
+	var active *signingKey
+	for _, record := range records {
+		key, err := m.parse(record)
+		if err != nil {
+			logger.Warn("skipping signing key", "kid", record.KID, "error", err)
+			continue
+		}
+		keys[record.KID] = key
+		if active == nil && record.IsActive() {
+			if key.private, err = m.decrypt(record); err != nil {
+				return err
+			}
+			active = key
+		}
+	}
+	if active == nil {
+		return fmt.Errorf("no active signing key")
+	}
+
+	m.mu.Lock()
+	m.keys = keys
+	m.active = active
+	m.loadedAt = time.Now()
+	m.mu.Unlock()
+	return nil
+}
+
+// parse decodes the public half of a stored key
+func (m *KeyManager) parse(record models.SigningKey) (*signingKey, error) {
+	block, _ := pem.Decode([]byte(record.PublicKey))
+	if block == nil {
+		return nil, fmt.Errorf("invalid public key PEM")
+	}
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid public key: %w", err)
+	}
+	return &signingKey{
+		record: record,
+		public: &PublicKey{ID: record.KID, Algorithm: record.Algorithm, Key: pub},
+	}, nil
+}
+
+// decrypt recovers the private half of a stored key
+func (m *KeyManager) decrypt(record models.SigningKey) (crypto.Signer, error) {
+	der, err := m.box.Open(record.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt signing key %s: %w", record.KID, err)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signing key %s: %w", record.KID, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("invalid signing key %s", record.KID)
+	}
+	return signer, nil
+}
+
+// purgeExpired deletes keys whose grace period is over
+func (m *KeyManager) purgeExpired() error {
+	result := m.db.Where("expires_at IS NOT NULL AND expires_at <= ?", time.Now()).Delete(&models.SigningKey{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to purge signing keys: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		logger.Info("purged expired signing keys", "keys", result.RowsAffected)
+	}
+	return nil
+}
+
+// signingMethod maps a configured algorithm to its JWT signing method
+func signingMethod(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case AlgorithmEdDSA:
+		return jwt.SigningMethodEdDSA, nil
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q (use %s or %s)", algorithm, AlgorithmEdDSA, AlgorithmRS256)
+	}
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// JWKS publishes the public keys that verify access tokens (RFC 7517 key set)
+// GET /.well-known/jwks.json
+func (h *Handler) JWKS(c *gin.Context) {
+	set, err := h.keys.JWKS()
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to build JWKS", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to load signing keys")
+		return
+	}
+
+	// Verifiers refetch early when they see an unknown kid, so a short cache is enough
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, set)
+}
+
+// ListSigningKeys lists the active and retired signing keys (admin only)
+// GET /api/admin/signing-keys
+func (h *Handler) ListSigningKeys(c *gin.Context) {
+	keys, err := h.keys.Keys()
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to list signing keys", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to list signing keys")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"keys":  keys,
+		"count": len(keys),
+	}, "Signing keys retrieved successfully")
+}
+
+// RotateSigningKey replaces the active signing key right away (admin only)
+// Tokens signed with the previous key stay valid until its grace period ends
+// POST /api/admin/signing-keys/rotate
+func (h *Handler) RotateSigningKey(c *gin.Context) {
+	key, err := h.keys.Rotate()
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to rotate signing key", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to rotate signing key")
+		return
+	}
+
+	logger.InfoContext(c.Request.Context(), "signing key rotated by admin", "admin_id", currentUserID(c), "kid", key.KID)
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"key": key}, "Signing key rotated")
+}
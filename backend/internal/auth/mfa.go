@@ -0,0 +1,503 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/secretbox"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// totpIssuer is shown by authenticator apps next to the account name
+	totpIssuer = "HLABS Banking"
+
+	// totpPeriod and totpSkew: codes rotate every 30s, one step of clock drift is accepted
+	totpPeriod = 30
+	totpSkew   = 1
+
+	// recoveryCodeCount is how many recovery codes are issued at activation
+	recoveryCodeCount = 10
+
+	// mfaChallengeTTL is how long the user has to enter the TOTP code after the password
+	mfaChallengeTTL = 5 * time.Minute
+
+	// mfaChallengeAudience distinguishes challenge tokens from access tokens
+	mfaChallengeAudience = "mfa-challenge"
+)
+
+// totpOpts are the TOTP parameters every authenticator app supports
+var totpOpts = totp.ValidateOpts{
+	Period:    totpPeriod,
+	Skew:      0, // Skew is applied manually to learn which step matched
+	Digits:    otp.DigitsSix,
+	Algorithm: otp.AlgorithmSHA1,
+}
+
+// Enrollment is returned when a user starts TOTP enrolment
+type Enrollment struct {
+	Secret          string `json:"secret"`
+	ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// MFAChallenge is returned by the password step of a login when MFA is enabled
+type MFAChallenge struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// mfaChallengeClaims are the claims of an MFA challenge token
+type mfaChallengeClaims struct {
+	UserID string `json:"user_id"`
+	jwt.RegisteredClaims
+}
+
+// MFAService handles TOTP enrolment, verification, recovery codes and MFA login challenges
+type MFAService struct {
+	db        *gorm.DB
+	jwtSecret string
+	box       *secretbox.Box
+}
+
+// NewMFAService creates a new MFA service
+// TOTP secrets are encrypted at rest with AES-256-GCM using a key derived from encryptionKey
+func NewMFAService(db *gorm.DB, jwtSecret, encryptionKey string) (*MFAService, error) {
+	box, err := secretbox.New(encryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create MFA cipher: %w", err)
+	}
+
+	return &MFAService{
+		db:        db,
+		jwtSecret: jwtSecret,
+		box:       box,
+	}, nil
+}
+
+// MigrateLegacySecrets re-encrypts TOTP secrets sealed with the JWT secret, which was the
+// encryption key before MFA_ENCRYPTION_KEY became mandatory. Secrets already sealed with the
+// current key are left alone. Returns the number of secrets re-encrypted.
+func (s *MFAService) MigrateLegacySecrets() (int, error) {
+	legacy, err := secretbox.New(s.jwtSecret)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create legacy MFA cipher: %w", err)
+	}
+
+	var users []models.User
+	if err := s.db.Select("id", "totp_secret").Where("totp_secret <> ''").Find(&users).Error; err != nil {
+		return 0, fmt.Errorf("failed to load TOTP secrets: %w", err)
+	}
+
+	migrated := 0
+	for _, user := range users {
+		if _, err := s.box.Open(user.TOTPSecret); err == nil {
+			continue
+		}
+		secret, err := legacy.Open(user.TOTPSecret)
+		if err != nil {
+			logger.Warn("TOTP secret can't be decrypted with the current or legacy key", "user_id", user.ID)
+			continue
+		}
+		sealed, err := s.seal(string(secret))
+		if err != nil {
+			return migrated, err
+		}
+		if err := s.db.Model(&models.User{}).Where("id = ? AND totp_secret = ?", user.ID, user.TOTPSecret).
+			Update("totp_secret", sealed).Error; err != nil {
+			return migrated, fmt.Errorf("failed to re-encrypt TOTP secret: %w", err)
+		}
+		migrated++
+	}
+
+	if migrated > 0 {
+		logger.Info("TOTP secrets re-encrypted with MFA_ENCRYPTION_KEY", "users", migrated)
+	}
+	return migrated, nil
+}
+
+// Enroll generates a new TOTP secret for the user
+// The secret is stored but MFA stays disabled until Activate verifies a first code
+func (s *MFAService) Enroll(userID string) (*Enrollment, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, fmt.Errorf("mfa already enabled")
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      totpIssuer,
+		AccountName: user.Email,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+
+	sealed, err := s.seal(key.Secret())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(user).Updates(map[string]interface{}{
+		"totp_secret":         sealed,
+		"totp_last_used_step": 0,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to store TOTP secret: %w", err)
+	}
+
+	logger.Info("TOTP enrolment started", "user_id", user.ID)
+	return &Enrollment{
+		Secret:          key.Secret(),
+		ProvisioningURI: key.URL(),
+	}, nil
+}
+
+// Activate verifies the first code from the authenticator app, enables MFA and returns recovery codes
+func (s *MFAService) Activate(userID, code string) ([]string, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.MFAEnabled {
+		return nil, fmt.Errorf("mfa already enabled")
+	}
+	if user.TOTPSecret == "" {
+		return nil, fmt.Errorf("mfa enrolment not started")
+	}
+
+	if err := s.VerifyTOTP(user, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"mfa_enabled":    true,
+			"mfa_enabled_at": now,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to enable MFA: %w", err)
+		}
+
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("MFA enabled", "user_id", user.ID)
+	return codes, nil
+}
+
+// Disable turns MFA off after re-checking the password and a current code
+func (s *MFAService) Disable(userID, password, code string) error {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return err
+	}
+	if !user.MFAEnabled {
+		return fmt.Errorf("mfa not enabled")
+	}
+	if err := checkPassword(s.db, user, password); err != nil {
+		return err
+	}
+	if err := s.VerifySecondFactor(user, code, ""); err != nil {
+		return err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"mfa_enabled":         false,
+			"mfa_enabled_at":      nil,
+			"totp_secret":         "",
+			"totp_last_used_step": 0,
+		}).Error; err != nil {
+			return fmt.Errorf("failed to disable MFA: %w", err)
+		}
+		return tx.Where("user_id = ?", user.ID).Delete(&models.RecoveryCode{}).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Info("MFA disabled", "user_id", user.ID)
+	return nil
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after verifying a current TOTP code
+func (s *MFAService) RegenerateRecoveryCodes(userID, code string) ([]string, error) {
+	user, err := s.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	if !user.MFAEnabled {
+		return nil, fmt.Errorf("mfa not enabled")
+	}
+	if err := s.VerifyTOTP(user, code); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		codes, err = replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	return codes, err
+}
+
+// VerifyTOTP checks a TOTP code against the user's secret
+// Each time step can only be used once, so an intercepted code can't be replayed
+func (s *MFAService) VerifyTOTP(user *models.User, code string) error {
+	if user.TOTPSecret == "" {
+		return fmt.Errorf("mfa not enabled")
+	}
+	secret, err := s.open(user.TOTPSecret)
+	if err != nil {
+		return err
+	}
+
+	code = strings.TrimSpace(code)
+	now := time.Now()
+	for offset := -totpSkew; offset <= totpSkew; offset++ {
+		at := now.Add(time.Duration(offset*totpPeriod) * time.Second)
+		step := at.Unix() / totpPeriod
+
+		expected, err := totp.GenerateCodeCustom(secret, at, totpOpts)
+		if err != nil {
+			return fmt.Errorf("failed to generate TOTP code: %w", err)
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) != 1 {
+			continue
+		}
+
+		// Atomically move the last used step forward; zero rows means the step was already used
+		result := s.db.Model(&models.User{}).
+			Where("id = ? AND totp_last_used_step < ?", user.ID, step).
+			Update("totp_last_used_step", step)
+		if result.Error != nil {
+			return fmt.Errorf("failed to record TOTP use: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("invalid mfa code")
+		}
+		user.TOTPLastUsedStep = step
+		return nil
+	}
+
+	return fmt.Errorf("invalid mfa code")
+}
+
+// VerifySecondFactor accepts either a TOTP code or an unused recovery code
+func (s *MFAService) VerifySecondFactor(user *models.User, code, recoveryCode string) error {
+	if recoveryCode != "" {
+		return s.useRecoveryCode(user, recoveryCode)
+	}
+	return s.VerifyTOTP(user, code)
+}
+
+// useRecoveryCode burns a recovery code
+func (s *MFAService) useRecoveryCode(user *models.User, recoveryCode string) error {
+	result := s.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, hashToken(normalizeRecoveryCode(recoveryCode))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to use recovery code: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("invalid mfa code")
+	}
+
+	logger.Info("recovery code used", "user_id", user.ID)
+	return nil
+}
+
+// RemainingRecoveryCodes counts the user's unused recovery codes
+func (s *MFAService) RemainingRecoveryCodes(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := s.db.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// IssueChallenge creates the short-lived token exchanged for a session after the second factor
+func (s *MFAService) IssueChallenge(user *models.User) (*MFAChallenge, error) {
+	now := time.Now()
+	expiresAt := now.Add(mfaChallengeTTL)
+
+	claims := mfaChallengeClaims{
+		UserID: user.ID.String(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Audience:  jwt.ClaimStrings{mfaChallengeAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "hlabs-banking-api",
+			Subject:   user.ID.String(),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign MFA challenge: %w", err)
+	}
+
+	return &MFAChallenge{
+		MFARequired: true,
+		MFAToken:    token,
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// ChallengeUser returns the user an MFA challenge token was issued to, without redeeming it
+// Used to apply login throttling before the second factor is checked
+func (s *MFAService) ChallengeUser(challengeToken string) (*models.User, error) {
+	user, _, err := s.parseChallenge(challengeToken)
+	return user, err
+}
+
+// parseChallenge validates an MFA challenge token and loads its user
+func (s *MFAService) parseChallenge(challengeToken string) (*models.User, *mfaChallengeClaims, error) {
+	claims := &mfaChallengeClaims{}
+	token, err := jwt.ParseWithClaims(challengeToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.jwtSecret), nil
+	}, jwt.WithAudience(mfaChallengeAudience))
+	if err != nil || !token.Valid {
+		return nil, nil, fmt.Errorf("invalid mfa challenge")
+	}
+
+	user, err := s.getUser(claims.UserID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid mfa challenge")
+	}
+	if !user.MFAEnabled {
+		return nil, nil, fmt.Errorf("invalid mfa challenge")
+	}
+
+	return user, claims, nil
+}
+
+// CompleteChallenge verifies the second factor for an MFA challenge token
+// The challenge can only be redeemed once
+func (s *MFAService) CompleteChallenge(challengeToken, code, recoveryCode string) (*models.User, error) {
+	user, claims, err := s.parseChallenge(challengeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Claim the challenge in one conditional insert: of concurrent redemptions of the same
+	// token only one creates the row, every other one is rejected
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    user.ID,
+		ExpiresAt: claims.ExpiresAt.Time,
+		Reason:    "mfa_challenge_used",
+	})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to redeem MFA challenge: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("invalid mfa challenge")
+	}
+
+	if err := s.VerifySecondFactor(user, code, recoveryCode); err != nil {
+		// Wrong code: release the claim so the user can retry while the challenge is valid
+		if releaseErr := s.db.Where("jti = ?", claims.ID).Delete(&models.RevokedToken{}).Error; releaseErr != nil {
+			logger.Warn("failed to release MFA challenge", "user_id", user.ID, "error", releaseErr)
+		}
+		return nil, err
+	}
+
+	return user, nil
+}
+
+// getUser loads a user by ID
+func (s *MFAService) getUser(userID string) (*models.User, error) {
+	var user models.User
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+	if err := s.db.First(&user, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &user, nil
+}
+
+// seal encrypts a TOTP secret for storage
+func (s *MFAService) seal(secret string) (string, error) {
+	return s.box.Seal([]byte(secret))
+}
+
+// open decrypts a stored TOTP secret
+func (s *MFAService) open(stored string) (string, error) {
+	secret, err := s.box.Open(stored)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt TOTP secret: %w", err)
+	}
+	return string(secret), nil
+}
+
+// replaceRecoveryCodes deletes a user's recovery codes and issues a fresh set
+func replaceRecoveryCodes(tx *gorm.DB, userID uuid.UUID) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to delete recovery codes: %w", err)
+	}
+
+	codes := make([]string, 0, recoveryCodeCount)
+	records := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		code, err := newRecoveryCode()
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+		records = append(records, models.RecoveryCode{
+			UserID:   userID,
+			CodeHash: hashToken(normalizeRecoveryCode(code)),
+		})
+	}
+
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, fmt.Errorf("failed to store recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// newRecoveryCode generates a code like "ABCDE-FGHIJ" (50 bits of entropy)
+func newRecoveryCode() (string, error) {
+	bytes := make([]byte, 7)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate recovery code: %w", err)
+	}
+	encoded := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(bytes)[:10]
+	return encoded[:5] + "-" + encoded[5:], nil
+}
+
+// normalizeRecoveryCode makes recovery codes case- and dash-insensitive
+func normalizeRecoveryCode(code string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// MFALoginRequest represents the second step of an MFA login
+// Either a TOTP code or a recovery code must be provided
+type MFALoginRequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+// MFACodeRequest represents a request confirmed with a TOTP code
+type MFACodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// MFADisableRequest represents the request to turn MFA off
+type MFADisableRequest struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// LoginMFA exchanges an MFA challenge token and a second factor for a session
+// POST /api/auth/login/mfa
+func (h *Handler) LoginMFA(c *gin.Context) {
+	var req MFALoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	// Second factor guesses count against the same account lockout as passwords
+	client := clientInfo(c)
+	challenged, err := h.mfa.ChallengeUser(req.MFAToken)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, "MFA challenge is invalid or expired, please log in again")
+		return
+	}
+	if !h.checkThrottle(c, challenged.Email, client) {
+		return
+	}
+
+	user, err := h.mfa.CompleteChallenge(req.MFAToken, req.Code, req.RecoveryCode)
+	if err != nil {
+		switch err.Error() {
+		case "invalid mfa challenge":
+			utils.RespondWithError(c, http.StatusUnauthorized, "MFA challenge is invalid or expired, please log in again")
+		case "invalid mfa code":
+			h.recordLoginFailure(challenged.Email, &challenged.ID, client, models.LoginFailureInvalidMFACode)
+			utils.RespondWithError(c, http.StatusUnauthorized, "Invalid MFA code")
+		default:
+			logger.ErrorContext(c.Request.Context(), "failed to complete MFA challenge", "error", err)
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to authenticate")
+		}
+		return
+	}
+
+	pair, err := h.sessions.Create(user, client)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to create session", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to generate authentication token")
+		return
+	}
+	h.recordLoginSuccess(user, client)
+	h.notifyNewDevice(user, client, pair)
+
+	logger.InfoContext(c.Request.Context(), "user logged in with MFA", "user_id", user.ID)
+
+	utils.RespondWithSuccess(c, http.StatusOK, AuthResponse{TokenPair: pair, User: user.ToDTO()}, "Login successful")
+}
+
+// EnrollMFA starts TOTP enrolment and returns the secret and provisioning URI
+// POST /api/auth/mfa/enroll
+func (h *Handler) EnrollMFA(c *gin.Context) {
+	enrollment, err := h.mfa.Enroll(currentUserID(c))
+	if err != nil {
+		respondWithMFAError(c, err, "Failed to start MFA enrolment")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, enrollment, "Scan the provisioning URI with an authenticator app, then verify a code to activate MFA")
+}
+
+// ActivateMFA verifies the first TOTP code and enables MFA
+// POST /api/auth/mfa/activate
+func (h *Handler) ActivateMFA(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	codes, err := h.mfa.Activate(currentUserID(c), req.Code)
+	if err != nil {
+		respondWithMFAError(c, err, "Failed to activate MFA")
+		return
+	}
+	h.notifySecurity(currentUserID(c), "Two-factor authentication enabled",
+		"Two-factor authentication was turned on for your account. Sign-ins now require a code from your authenticator app.")
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"mfa_enabled":    true,
+		"recovery_codes": codes,
+	}, "MFA enabled - store the recovery codes somewhere safe, they will not be shown again")
+}
+
+// DisableMFA turns MFA off
+// POST /api/auth/mfa/disable
+func (h *Handler) DisableMFA(c *gin.Context) {
+	var req MFADisableRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if err := h.mfa.Disable(currentUserID(c), req.Password, req.Code); err != nil {
+		respondWithMFAError(c, err, "Failed to disable MFA")
+		return
+	}
+	h.notifySecurity(currentUserID(c), "Two-factor authentication disabled",
+		"Two-factor authentication was turned off for your account. If you didn't do this, change your password and turn it back on right away.")
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"mfa_enabled": false}, "MFA disabled")
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes
+// POST /api/auth/mfa/recovery-codes
+func (h *Handler) RegenerateRecoveryCodes(c *gin.Context) {
+	var req MFACodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	codes, err := h.mfa.RegenerateRecoveryCodes(currentUserID(c), req.Code)
+	if err != nil {
+		respondWithMFAError(c, err, "Failed to regenerate recovery codes")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"recovery_codes": codes}, "Recovery codes regenerated")
+}
+
+// respondWithMFAError maps MFA service errors to HTTP responses
+func respondWithMFAError(c *gin.Context, err error, fallback string) {
+	errMsg := err.Error()
+	switch {
+	case errMsg == "invalid mfa code", errMsg == "invalid password":
+		utils.RespondWithError(c, http.StatusUnauthorized, strings.ToUpper(errMsg[:1])+errMsg[1:])
+	case errMsg == "mfa already enabled", errMsg == "mfa not enabled", errMsg == "mfa enrolment not started":
+		utils.RespondWithError(c, http.StatusConflict, errMsg)
+	default:
+		logger.ErrorContext(c.Request.Context(), "MFA request failed", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, fallback)
+	}
+}
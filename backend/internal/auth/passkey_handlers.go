@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// PasskeyRegistrationRequest starts registering a passkey
+// Either the account password or a TOTP code must be provided
+type PasskeyRegistrationRequest struct {
+	Password string `json:"password"`
+	Code     string `json:"code"`
+}
+
+// PasskeyRegistrationFinishRequest completes registering a passkey
+// Credential is the PublicKeyCredential returned by navigator.credentials.create()
+type PasskeyRegistrationFinishRequest struct {
+	CeremonyToken string          `json:"ceremony_token" binding:"required"`
+	Name          string          `json:"name"`
+	Credential    json.RawMessage `json:"credential" binding:"required"`
+}
+
+// PasskeyLoginRequest starts a passkey login; without an email any discoverable passkey is accepted
+type PasskeyLoginRequest struct {
+	Email string `json:"email"`
+}
+
+// PasskeyLoginFinishRequest completes a passkey login
+// Credential is the PublicKeyCredential returned by navigator.credentials.get()
+type PasskeyLoginFinishRequest struct {
+	CeremonyToken string          `json:"ceremony_token" binding:"required"`
+	Credential    json.RawMessage `json:"credential" binding:"required"`
+}
+
+// RenamePasskeyRequest represents a new label for a passkey
+type RenamePasskeyRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// BeginPasskeyRegistration re-authenticates the user and returns the options for navigator.credentials.create()
+// POST /api/auth/passkeys/register/begin
+func (h *Handler) BeginPasskeyRegistration(c *gin.Context) {
+	var req PasskeyRegistrationRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Password == "" && req.Code == "") {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	ceremony, err := h.passkeys.BeginRegistration(currentUserID(c), req.Password, req.Code)
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case errMsg == "invalid password", errMsg == "invalid mfa code":
+			utils.RespondWithError(c, http.StatusUnauthorized, "Verification failed")
+		case errMsg == "mfa not enabled":
+			utils.RespondWithError(c, http.StatusBadRequest, "MFA is not enabled, confirm with your password")
+		case errMsg == "user not found":
+			utils.RespondWithError(c, http.StatusNotFound, "User not found")
+		case strings.HasPrefix(errMsg, "passkey limit reached"):
+			utils.RespondWithError(c, http.StatusConflict, errMsg)
+		default:
+			logger.ErrorContext(c.Request.Context(), "failed to start passkey registration", "error", err)
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to start passkey registration")
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, ceremony, "Passkey registration started")
+}
+
+// FinishPasskeyRegistration verifies the authenticator's response and stores the passkey
+// POST /api/auth/passkeys/register/finish
+func (h *Handler) FinishPasskeyRegistration(c *gin.Context) {
+	var req PasskeyRegistrationFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	credential, err := h.passkeys.FinishRegistration(currentUserID(c), req.CeremonyToken, req.Name, req.Credential)
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case errMsg == "invalid or expired ceremony":
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid or expired registration, please start again")
+		case strings.HasPrefix(errMsg, "invalid passkey response"), strings.HasPrefix(errMsg, "passkey verification failed"):
+			utils.RespondWithError(c, http.StatusBadRequest, errMsg)
+		case errMsg == "passkey already registered":
+			utils.RespondWithError(c, http.StatusConflict, "This passkey is already registered")
+		case errMsg == "user not found":
+			utils.RespondWithError(c, http.StatusNotFound, "User not found")
+		default:
+			logger.ErrorContext(c.Request.Context(), "failed to finish passkey registration", "error", err)
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to register passkey")
+		}
+		return
+	}
+
+	h.notifySecurity(currentUserID(c), "New passkey added",
+		fmt.Sprintf("A passkey named %q was added to your account. If you didn't do this, remove it from your passkeys and change your password.", credential.Name))
+	utils.RespondWithSuccess(c, http.StatusCreated, credential, "Passkey registered")
+}
+
+// BeginPasskeyLogin returns the options for navigator.credentials.get()
+// POST /api/auth/passkeys/login/begin
+func (h *Handler) BeginPasskeyLogin(c *gin.Context) {
+	var req PasskeyLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	ceremony, err := h.passkeys.BeginLogin(req.Email)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to start passkey login", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to start passkey login")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, ceremony, "Passkey login started")
+}
+
+// FinishPasskeyLogin verifies the authenticator's assertion and starts a session
+// Passkeys verify the user (PIN or biometrics), so no TOTP step follows
+// POST /api/auth/passkeys/login/finish
+func (h *Handler) FinishPasskeyLogin(c *gin.Context) {
+	var req PasskeyLoginFinishRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	client := clientInfo(c)
+
+	// Only the IP lockout applies; an account locked by password guessing can still use its passkeys
+	if !h.checkThrottle(c, "", client) {
+		return
+	}
+
+	user, err := h.passkeys.FinishLogin(req.CeremonyToken, req.Credential)
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case errMsg == "invalid or expired ceremony", strings.HasPrefix(errMsg, "invalid passkey response"),
+			strings.HasPrefix(errMsg, "passkey verification failed"):
+			var email string
+			var userID *uuid.UUID
+			if user != nil {
+				email, userID = user.Email, &user.ID
+			}
+			if err := h.throttle.RecordPasskeyFailure(email, userID, client); err != nil {
+				logger.WarnContext(c.Request.Context(), "failed to record passkey failure", "error", err)
+			}
+			logger.WarnContext(c.Request.Context(), "passkey login failed", "ip", client.IPAddress, "error", err)
+			utils.RespondWithError(c, http.StatusUnauthorized, "Passkey verification failed")
+		default:
+			logger.ErrorContext(c.Request.Context(), "failed to finish passkey login", "error", err)
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to authenticate")
+		}
+		return
+	}
+
+	pair, err := h.sessions.Create(user, client)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to create session", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to generate authentication token")
+		return
+	}
+	h.recordLoginSuccess(user, client)
+	h.notifyNewDevice(user, client, pair)
+
+	logger.InfoContext(c.Request.Context(), "user logged in with a passkey", "user_id", user.ID)
+	utils.RespondWithSuccess(c, http.StatusOK, AuthResponse{TokenPair: pair, User: user.ToDTO()}, "Login successful")
+}
+
+// ListPasskeys returns the current user's passkeys
+// GET /api/auth/passkeys
+func (h *Handler) ListPasskeys(c *gin.Context) {
+	credentials, err := h.passkeys.List(currentUserID(c))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to list passkeys", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve passkeys")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"passkeys": credentials,
+		"count":    len(credentials),
+	}, "Passkeys retrieved successfully")
+}
+
+// RenamePasskey changes the label of one of the current user's passkeys
+// PATCH /api/auth/passkeys/:id
+func (h *Handler) RenamePasskey(c *gin.Context) {
+	var req RenamePasskeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	credential, err := h.passkeys.Rename(currentUserID(c), c.Param("id"), req.Name)
+	if err != nil {
+		switch err.Error() {
+		case "passkey not found":
+			utils.RespondWithError(c, http.StatusNotFound, "Passkey not found")
+		case "name must be 1-100 characters":
+			utils.RespondWithError(c, http.StatusBadRequest, err.Error())
+		default:
+			logger.ErrorContext(c.Request.Context(), "failed to rename passkey", "passkey_id", c.Param("id"), "error", err)
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to rename passkey")
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, credential, "Passkey renamed")
+}
+
+// DeletePasskey removes one of the current user's passkeys
+// DELETE /api/auth/passkeys/:id
+func (h *Handler) DeletePasskey(c *gin.Context) {
+	if err := h.passkeys.Delete(currentUserID(c), c.Param("id")); err != nil {
+		if err.Error() == "passkey not found" {
+			utils.RespondWithError(c, http.StatusNotFound, "Passkey not found")
+			return
+		}
+		logger.ErrorContext(c.Request.Context(), "failed to delete passkey", "passkey_id", c.Param("id"), "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to delete passkey")
+		return
+	}
+
+	h.notifySecurity(currentUserID(c), "Passkey removed",
+		"A passkey was removed from your account. If you didn't do this, change your password right away.")
+	utils.RespondWithSuccess(c, http.StatusOK, nil, "Passkey deleted")
+}
@@ -0,0 +1,534 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// Audiences keep registration and login ceremony tokens apart from every other token
+	passkeyRegistrationAudience = "webauthn-registration"
+	passkeyLoginAudience        = "webauthn-login"
+
+	// passkeyCeremonyTTL bounds how long the browser has to answer a ceremony
+	passkeyCeremonyTTL = 5 * time.Minute
+
+	// maxPasskeysPerUser bounds how many authenticators a user can register
+	maxPasskeysPerUser = 10
+)
+
+// PasskeyConfig identifies the relying party to authenticators
+type PasskeyConfig struct {
+	RPID    string   // Domain the credentials are scoped to (e.g. "bank.example.com")
+	RPName  string   // Name shown by the authenticator
+	Origins []string // Frontend origins allowed to run the ceremonies
+}
+
+// PasskeyCeremony is returned when a registration or login ceremony starts
+// Options are passed to navigator.credentials.create()/get(); the ceremony token
+// carries the challenge and must be sent back with the authenticator's response
+type PasskeyCeremony struct {
+	Options       interface{} `json:"options"`
+	CeremonyToken string      `json:"ceremony_token"`
+	ExpiresAt     time.Time   `json:"expires_at"`
+}
+
+// passkeyCeremonyClaims are the claims of a ceremony token
+// UserID is empty for a usernameless (discoverable credential) login
+type passkeyCeremonyClaims struct {
+	UserID  string               `json:"user_id,omitempty"`
+	Session webauthn.SessionData `json:"webauthn"`
+	jwt.RegisteredClaims
+}
+
+// PasskeyService runs the WebAuthn registration and login ceremonies and manages
+// the authenticators registered by each user
+type PasskeyService struct {
+	db        *gorm.DB
+	jwtSecret string
+	mfa       *MFAService
+	webAuthn  *webauthn.WebAuthn
+}
+
+// NewPasskeyService creates a new passkey service
+// Passkeys must verify the user (PIN or biometrics), so a passkey login counts as two factors
+func NewPasskeyService(db *gorm.DB, jwtSecret string, mfa *MFAService, cfg PasskeyConfig) (*PasskeyService, error) {
+	web, err := webauthn.New(&webauthn.Config{
+		RPID:                  cfg.RPID,
+		RPDisplayName:         cfg.RPName,
+		RPOrigins:             cfg.Origins,
+		AttestationPreference: protocol.PreferNoAttestation,
+		AuthenticatorSelection: protocol.AuthenticatorSelection{
+			ResidentKey:        protocol.ResidentKeyRequirementPreferred,
+			RequireResidentKey: protocol.ResidentKeyNotRequired(),
+			UserVerification:   protocol.VerificationRequired,
+		},
+		Timeouts: webauthn.TimeoutsConfig{
+			Login:        webauthn.TimeoutConfig{Enforce: true, Timeout: passkeyCeremonyTTL, TimeoutUVD: passkeyCeremonyTTL},
+			Registration: webauthn.TimeoutConfig{Enforce: true, Timeout: passkeyCeremonyTTL, TimeoutUVD: passkeyCeremonyTTL},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid WebAuthn configuration: %w", err)
+	}
+
+	return &PasskeyService{
+		db:        db,
+		jwtSecret: jwtSecret,
+		mfa:       mfa,
+		webAuthn:  web,
+	}, nil
+}
+
+// BeginRegistration starts registering a new authenticator for the user
+// Adding a sign-in method is sensitive, so the user re-authenticates with their password or a TOTP code
+func (s *PasskeyService) BeginRegistration(userID, password, code string) (*PasskeyCeremony, error) {
+	user, err := s.mfa.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case code != "":
+		if !user.MFAEnabled {
+			return nil, fmt.Errorf("mfa not enabled")
+		}
+		if err := s.mfa.VerifyTOTP(user, code); err != nil {
+			return nil, err
+		}
+	case password != "":
+		if err := checkPassword(s.db, user, password); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("password or code is required")
+	}
+
+	account, err := s.loadUser(user)
+	if err != nil {
+		return nil, err
+	}
+	if len(account.credentials) >= maxPasskeysPerUser {
+		return nil, fmt.Errorf("passkey limit reached (%d registered)", maxPasskeysPerUser)
+	}
+
+	// Excluding known credentials stops the same authenticator from being registered twice
+	creation, session, err := s.webAuthn.BeginRegistration(account,
+		webauthn.WithExclusions(webauthn.Credentials(account.WebAuthnCredentials()).CredentialDescriptors()))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start passkey registration: %w", err)
+	}
+
+	return s.ceremony(creation, session, user.ID.String(), passkeyRegistrationAudience)
+}
+
+// FinishRegistration verifies the authenticator's attestation and stores the new credential
+func (s *PasskeyService) FinishRegistration(userID, ceremonyToken, name string, response []byte) (*models.WebAuthnCredential, error) {
+	claims, err := s.parseCeremony(ceremonyToken, passkeyRegistrationAudience)
+	if err != nil {
+		return nil, err
+	}
+	if claims.UserID != userID {
+		return nil, fmt.Errorf("invalid or expired ceremony")
+	}
+
+	user, err := s.mfa.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+	account, err := s.loadUser(user)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, fmt.Errorf("invalid passkey response: %s", protocolErrorDetails(err))
+	}
+	credential, err := s.webAuthn.CreateCredential(account, claims.Session, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("passkey verification failed: %s", protocolErrorDetails(err))
+	}
+
+	record := credentialRecord(user.ID, name, credential)
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := burnCeremony(tx, claims, user.ID, "passkey_registered"); err != nil {
+			return err
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(record)
+		if result.Error != nil {
+			return fmt.Errorf("failed to store passkey: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("passkey already registered")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.Info("passkey registered", "passkey_id", record.ID, "user_id", user.ID)
+	return record, nil
+}
+
+// BeginLogin starts a passkey login
+// With an email that has passkeys the browser is told which credentials to use; otherwise
+// (no email, unknown email or no passkeys) any discoverable credential for this site is accepted,
+// so the response never reveals whether an account exists
+func (s *PasskeyService) BeginLogin(email string) (*PasskeyCeremony, error) {
+	if email = strings.TrimSpace(email); email != "" {
+		var user models.User
+		err := s.db.Where("email = ?", email).First(&user).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("database error: %w", err)
+		}
+		if err == nil {
+			account, err := s.loadUser(&user)
+			if err != nil {
+				return nil, err
+			}
+			if len(account.credentials) > 0 {
+				assertion, session, err := s.webAuthn.BeginLogin(account)
+				if err != nil {
+					return nil, fmt.Errorf("failed to start passkey login: %w", err)
+				}
+				return s.ceremony(assertion, session, user.ID.String(), passkeyLoginAudience)
+			}
+		}
+	}
+
+	assertion, session, err := s.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		return nil, fmt.Errorf("failed to start passkey login: %w", err)
+	}
+	return s.ceremony(assertion, session, "", passkeyLoginAudience)
+}
+
+// FinishLogin verifies the authenticator's assertion and returns the user it belongs to
+// The stored sign count is advanced; a count that goes backwards flags the credential as
+// possibly cloned and the login is refused
+// When verification fails after the user was identified, the user is returned with the error
+// so the failure can be recorded in their login history
+func (s *PasskeyService) FinishLogin(ceremonyToken string, response []byte) (*models.User, error) {
+	claims, err := s.parseCeremony(ceremonyToken, passkeyLoginAudience)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, fmt.Errorf("invalid passkey response: %s", protocolErrorDetails(err))
+	}
+
+	var account *passkeyUser
+	var credential *webauthn.Credential
+	if claims.UserID != "" {
+		user, err := s.mfa.getUser(claims.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid or expired ceremony")
+		}
+		if account, err = s.loadUser(user); err != nil {
+			return nil, err
+		}
+		credential, err = s.webAuthn.ValidateLogin(account, claims.Session, parsed)
+		if err != nil {
+			return user, fmt.Errorf("passkey verification failed: %s", protocolErrorDetails(err))
+		}
+	} else {
+		// Usernameless login: the authenticator's user handle identifies the account
+		var found webauthn.User
+		found, credential, err = s.webAuthn.ValidatePasskeyLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+			id, err := uuid.FromBytes(userHandle)
+			if err != nil {
+				return nil, fmt.Errorf("unknown user handle")
+			}
+			user, err := s.mfa.getUser(id.String())
+			if err != nil {
+				return nil, err
+			}
+			return s.loadUser(user)
+		}, claims.Session, parsed)
+		if err != nil {
+			return nil, fmt.Errorf("passkey verification failed: %s", protocolErrorDetails(err))
+		}
+		account = found.(*passkeyUser)
+	}
+
+	record := account.credential(credential.ID)
+	if record == nil {
+		return account.user, fmt.Errorf("passkey verification failed: unknown credential")
+	}
+
+	if credential.Authenticator.CloneWarning {
+		if err := s.db.Model(record).Update("clone_warning", true).Error; err != nil {
+			logger.Warn("failed to flag passkey", "passkey_id", record.ID, "error", err)
+		}
+		logger.Error("passkey presented a stale sign count, possible cloned authenticator", "passkey_id", record.ID, "user_id", account.user.ID)
+		return account.user, fmt.Errorf("passkey verification failed: sign count mismatch")
+	}
+
+	now := time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := burnCeremony(tx, claims, account.user.ID, "passkey_login"); err != nil {
+			return err
+		}
+		return tx.Model(record).Updates(map[string]interface{}{
+			"sign_count":   int64(credential.Authenticator.SignCount),
+			"flags":        uint8(credential.Flags.ProtocolValue()),
+			"last_used_at": now,
+		}).Error
+	})
+	if err != nil {
+		return account.user, err
+	}
+
+	return account.user, nil
+}
+
+// List returns the passkeys registered by a user, oldest first
+func (s *PasskeyService) List(userID string) ([]models.WebAuthnCredential, error) {
+	var credentials []models.WebAuthnCredential
+	if err := s.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&credentials).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve passkeys: %w", err)
+	}
+	return credentials, nil
+}
+
+// Rename changes the label of one of a user's passkeys
+func (s *PasskeyService) Rename(userID, credentialID, name string) (*models.WebAuthnCredential, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 100 {
+		return nil, fmt.Errorf("name must be 1-100 characters")
+	}
+
+	credential, err := s.owned(userID, credentialID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(credential).Update("name", name).Error; err != nil {
+		return nil, fmt.Errorf("failed to rename passkey: %w", err)
+	}
+	credential.Name = name
+	return credential, nil
+}
+
+// Delete removes one of a user's passkeys
+func (s *PasskeyService) Delete(userID, credentialID string) error {
+	credential, err := s.owned(userID, credentialID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(credential).Error; err != nil {
+		return fmt.Errorf("failed to delete passkey: %w", err)
+	}
+
+	logger.Info("passkey removed", "passkey_id", credential.ID, "user_id", userID)
+	return nil
+}
+
+// ceremony signs the WebAuthn session data into a single-use ceremony token
+func (s *PasskeyService) ceremony(options interface{}, session *webauthn.SessionData, userID, audience string) (*PasskeyCeremony, error) {
+	now := time.Now()
+	expiresAt := now.Add(passkeyCeremonyTTL)
+	claims := passkeyCeremonyClaims{
+		UserID:  userID,
+		Session: *session,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Audience:  jwt.ClaimStrings{audience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "hlabs-banking-api",
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign ceremony token: %w", err)
+	}
+
+	return &PasskeyCeremony{
+		Options:       options,
+		CeremonyToken: token,
+		ExpiresAt:     expiresAt,
+	}, nil
+}
+
+// parseCeremony validates a ceremony token, rejecting tokens that were already used
+func (s *PasskeyService) parseCeremony(ceremonyToken, audience string) (*passkeyCeremonyClaims, error) {
+	claims, err := s.decodeCeremony(ceremonyToken, audience)
+	if err != nil {
+		return nil, err
+	}
+
+	var used int64
+	if err := s.db.Model(&models.RevokedToken{}).Where("jti = ?", claims.ID).Count(&used).Error; err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if used > 0 {
+		return nil, fmt.Errorf("invalid or expired ceremony")
+	}
+
+	return claims, nil
+}
+
+// decodeCeremony verifies a ceremony token's signature, expiry and audience
+func (s *PasskeyService) decodeCeremony(ceremonyToken, audience string) (*passkeyCeremonyClaims, error) {
+	claims := &passkeyCeremonyClaims{}
+	token, err := jwt.ParseWithClaims(strings.TrimSpace(ceremonyToken), claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.jwtSecret), nil
+	}, jwt.WithAudience(audience))
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid or expired ceremony")
+	}
+	return claims, nil
+}
+
+// loadUser wraps a user and their registered credentials for the WebAuthn library
+func (s *PasskeyService) loadUser(user *models.User) (*passkeyUser, error) {
+	var credentials []models.WebAuthnCredential
+	if err := s.db.Where("user_id = ?", user.ID).Find(&credentials).Error; err != nil {
+		return nil, fmt.Errorf("failed to load passkeys: %w", err)
+	}
+	return &passkeyUser{user: user, credentials: credentials}, nil
+}
+
+// owned loads one of a user's passkeys
+func (s *PasskeyService) owned(userID, credentialID string) (*models.WebAuthnCredential, error) {
+	id, err := uuid.Parse(credentialID)
+	if err != nil {
+		return nil, fmt.Errorf("passkey not found")
+	}
+
+	var credential models.WebAuthnCredential
+	if err := s.db.Where("id = ? AND user_id = ?", id, userID).First(&credential).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("passkey not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &credential, nil
+}
+
+// credentialRecord converts a verified credential into the record stored for the user
+func credentialRecord(userID uuid.UUID, name string, credential *webauthn.Credential) *models.WebAuthnCredential {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		name = "Passkey"
+	}
+	if len(name) > 100 {
+		name = name[:100]
+	}
+
+	transports := make([]string, len(credential.Transport))
+	for i, transport := range credential.Transport {
+		transports[i] = string(transport)
+	}
+	return &models.WebAuthnCredential{
+		UserID:          userID,
+		Name:            name,
+		CredentialID:    credential.ID,
+		PublicKey:       credential.PublicKey,
+		AttestationType: credential.AttestationType,
+		Transports:      strings.Join(transports, ","),
+		AAGUID:          credential.Authenticator.AAGUID,
+		Flags:           uint8(credential.Flags.ProtocolValue()),
+		SignCount:       int64(credential.Authenticator.SignCount),
+	}
+}
+
+// burnCeremony marks a ceremony token as used; losing the race to a concurrent use is an invalid ceremony
+func burnCeremony(tx *gorm.DB, claims *passkeyCeremonyClaims, userID uuid.UUID, reason string) error {
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    userID,
+		ExpiresAt: claims.ExpiresAt.Time,
+		Reason:    reason,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to redeem ceremony: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("invalid or expired ceremony")
+	}
+	return nil
+}
+
+// protocolErrorDetails extracts the readable part of a WebAuthn protocol error
+func protocolErrorDetails(err error) string {
+	if perr, ok := err.(*protocol.Error); ok && perr.Details != "" {
+		return perr.Details
+	}
+	return err.Error()
+}
+
+// passkeyUser adapts a user and their credentials to the webauthn.User interface
+// The user handle is the user's UUID (16 random bytes)
+type passkeyUser struct {
+	user        *models.User
+	credentials []models.WebAuthnCredential
+}
+
+// WebAuthnID implements webauthn.User
+func (u *passkeyUser) WebAuthnID() []byte {
+	id := u.user.ID
+	return id[:]
+}
+
+// WebAuthnName implements webauthn.User
+func (u *passkeyUser) WebAuthnName() string {
+	return u.user.Email
+}
+
+// WebAuthnDisplayName implements webauthn.User
+func (u *passkeyUser) WebAuthnDisplayName() string {
+	return u.user.FullName
+}
+
+// WebAuthnCredentials implements webauthn.User
+func (u *passkeyUser) WebAuthnCredentials() []webauthn.Credential {
+	credentials := make([]webauthn.Credential, len(u.credentials))
+	for i, stored := range u.credentials {
+		var transports []protocol.AuthenticatorTransport
+		for _, transport := range strings.Split(stored.Transports, ",") {
+			if transport != "" {
+				transports = append(transports, protocol.AuthenticatorTransport(transport))
+			}
+		}
+		credentials[i] = webauthn.Credential{
+			ID:              stored.CredentialID,
+			PublicKey:       stored.PublicKey,
+			AttestationType: stored.AttestationType,
+			Transport:       transports,
+			Flags:           webauthn.NewCredentialFlags(protocol.AuthenticatorFlags(stored.Flags)),
+			Authenticator: webauthn.Authenticator{
+				AAGUID:    stored.AAGUID,
+				SignCount: uint32(stored.SignCount),
+			},
+		}
+	}
+	return credentials
+}
+
+// credential returns the stored record of a credential ID
+func (u *passkeyUser) credential(credentialID []byte) *models.WebAuthnCredential {
+	for i := range u.credentials {
+		if string(u.credentials[i].CredentialID) == string(credentialID) {
+			return &u.credentials[i]
+		}
+	}
+	return nil
+}
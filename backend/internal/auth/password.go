@@ -0,0 +1,78 @@
+package auth
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/password"
+	"gorm.io/gorm"
+)
+
+// checkPassword verifies a user's password
+// Hashes made with an older algorithm (bcrypt) or older argon2id parameters are
+// transparently replaced with a fresh argon2id hash once the password is known to be right
+func checkPassword(db *gorm.DB, user *models.User, plain string) error {
+	ok, needsRehash, err := password.Verify(user.Password, plain)
+	if err != nil {
+		logger.Warn("unreadable password hash", "user_id", user.ID, "error", err)
+		return fmt.Errorf("invalid password")
+	}
+	if !ok {
+		return fmt.Errorf("invalid password")
+	}
+
+	if needsRehash {
+		hashed, err := password.Hash(plain)
+		if err != nil {
+			logger.Warn("failed to rehash password", "user_id", user.ID, "error", err)
+			return nil
+		}
+		// Only replace the hash that was verified, in case the password changed meanwhile
+		result := db.Model(&models.User{}).
+			Where("id = ? AND password = ?", user.ID, user.Password).
+			Update("password", hashed)
+		if result.Error != nil {
+			logger.Warn("failed to store rehashed password", "user_id", user.ID, "error", result.Error)
+			return nil
+		}
+		user.Password = hashed
+		logger.Info("password hash upgraded to argon2id", "user_id", user.ID)
+	}
+
+	return nil
+}
+
+// dummyHash is an argon2id hash of a fixed password with the current parameters
+// Logins for unknown emails are checked against it so they cost as much as a wrong password
+var dummyHash = sync.OnceValue(func() string {
+	hashed, err := password.Hash("unknown-account")
+	if err != nil {
+		logger.Warn("failed to build dummy password hash", "error", err)
+	}
+	return hashed
+})
+
+// checkUnknownPassword spends the time of a password check when no account matched, so the
+// response time doesn't tell whether an email is registered
+func checkUnknownPassword(plain string) {
+	_, _, _ = password.Verify(dummyHash(), plain)
+}
+
+// setPassword validates a new password against the policy and stores its argon2id hash
+func setPassword(tx *gorm.DB, policy *password.Policy, user *models.User, plain string) error {
+	if err := policy.Validate(plain, user.Email, user.FullName); err != nil {
+		return err
+	}
+
+	hashed, err := password.Hash(plain)
+	if err != nil {
+		return fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	if err := tx.Model(user).Update("password", hashed).Error; err != nil {
+		return fmt.Errorf("failed to update password: %w", err)
+	}
+	user.Password = hashed
+	return nil
+}
@@ -0,0 +1,69 @@
+package auth
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/password"
+	"github.com/hlabs/banking-system/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// ChangePasswordRequest represents a password change by the signed-in user
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
+
+// ChangePassword replaces the current user's password
+// The current session stays signed in; every other session is revoked
+// POST /api/auth/password
+func (h *Handler) ChangePassword(c *gin.Context) {
+	var req ChangePasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	userID := currentUserID(c)
+	user, err := h.mfa.getUser(userID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if err := checkPassword(h.db, user, req.CurrentPassword); err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, "Current password is incorrect")
+		return
+	}
+	if req.NewPassword == req.CurrentPassword {
+		utils.RespondWithError(c, http.StatusBadRequest, "New password must be different from the current password")
+		return
+	}
+
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		return setPassword(tx, h.policy, user, req.NewPassword)
+	})
+	if err != nil {
+		var policyErr *password.PolicyError
+		if errors.As(err, &policyErr) {
+			utils.RespondWithError(c, http.StatusBadRequest, policyErr.Error())
+			return
+		}
+		logger.ErrorContext(c.Request.Context(), "failed to change password", "user_id", user.ID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to change password")
+		return
+	}
+
+	count, err := h.sessions.RevokeAllExcept(userID, c.GetString("session_id"), models.SessionRevokedPasswordChange)
+	if err != nil {
+		logger.WarnContext(c.Request.Context(), "failed to revoke other sessions after password change", "user_id", user.ID, "error", err)
+	}
+
+	logger.InfoContext(c.Request.Context(), "password changed", "user_id", user.ID)
+	h.notifySecurity(userID, "Your password was changed",
+		"The password of your account was just changed and your other devices were signed out. If you didn't do this, reset your password right away.")
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"sessions_revoked": count}, "Password changed successfully")
+}
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/hlabs/banking-system/internal/password"
+)
+
+func TestDummyHashCostsAsMuchAsARealOne(t *testing.T) {
+	dummy := dummyHash()
+	actual, err := password.Hash("correct horse battery staple")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Same algorithm and parameters, so verifying either takes the same time
+	params := func(hash string) string {
+		parts := strings.Split(hash, "$")
+		if len(parts) != 6 {
+			t.Fatalf("hash %q is not in PHC format", hash)
+		}
+		return strings.Join(parts[:4], "$")
+	}
+	if params(dummy) != params(actual) {
+		t.Errorf("dummy hash parameters %q differ from %q", params(dummy), params(actual))
+	}
+
+	if ok, _, err := password.Verify(dummy, "correct horse battery staple"); err != nil || ok {
+		t.Errorf("Verify(dummy) = %v, %v; want a mismatch", ok, err)
+	}
+	if dummyHash() != dummy {
+		t.Error("dummy hash changed between calls")
+	}
+}
@@ -0,0 +1,421 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// refreshTokenBytes is the amount of randomness in a refresh token
+	refreshTokenBytes = 32
+
+	// cleanupInterval is how often expired revocations and refresh tokens are purged
+	cleanupInterval = time.Hour
+
+	// touchInterval bounds how often a session's last seen time is written
+	touchInterval = time.Minute
+)
+
+// TokenPair is what a successful login or refresh returns to the client
+type TokenPair struct {
+	AccessToken      string    `json:"token"`
+	RefreshToken     string    `json:"refresh_token"`
+	ExpiresIn        int64     `json:"expires_in"` // Access token lifetime in seconds
+	ExpiresAt        time.Time `json:"expires_at"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	SessionID        uuid.UUID `json:"session_id"`
+	NewDevice        bool      `json:"new_device"` // First login of the user from this device
+}
+
+// ClientInfo identifies the client a session was created from
+// DeviceID is a random identifier the client keeps for the device (X-Device-ID header)
+type ClientInfo struct {
+	IPAddress string
+	UserAgent string
+	DeviceID  string
+}
+
+// SessionService manages server-side sessions, rotating refresh tokens and the
+// access token revocation list
+type SessionService struct {
+	db         *gorm.DB
+	keys       *KeyManager
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+}
+
+// NewSessionService creates a new session service
+func NewSessionService(db *gorm.DB, keys *KeyManager, accessTTL, refreshTTL time.Duration) *SessionService {
+	return &SessionService{
+		db:         db,
+		keys:       keys,
+		accessTTL:  accessTTL,
+		refreshTTL: refreshTTL,
+	}
+}
+
+// Create starts a new session for a user and issues its first token pair
+// The pair reports a new device when the user has logged in before, but never from this device.
+// A device is recognized by its device identifier; clients that send none are only recognized
+// when both the user agent and the device name match an earlier session
+func (s *SessionService) Create(user *models.User, client ClientInfo) (*TokenPair, error) {
+	now := time.Now()
+	session := &models.Session{
+		UserID:     user.ID,
+		DeviceName: deviceName(client.UserAgent),
+		IPAddress:  client.IPAddress,
+		UserAgent:  client.UserAgent,
+		ExpiresAt:  now.Add(s.refreshTTL),
+		LastUsedAt: now,
+	}
+	if client.DeviceID != "" {
+		session.DeviceIDHash = hashToken(client.DeviceID)
+	}
+
+	var pair *TokenPair
+	newDevice := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var previous, sameDevice int64
+		if err := tx.Model(&models.Session{}).Where("user_id = ?", user.ID).Count(&previous).Error; err != nil {
+			return fmt.Errorf("failed to load sessions: %w", err)
+		}
+		if previous > 0 {
+			query := tx.Model(&models.Session{}).Where("user_id = ?", user.ID)
+			if session.DeviceIDHash != "" {
+				query = query.Where("device_id_hash = ?", session.DeviceIDHash)
+			} else {
+				query = query.Where("user_agent = ? AND device_name = ?", session.UserAgent, session.DeviceName)
+			}
+			if err := query.Count(&sameDevice).Error; err != nil {
+				return fmt.Errorf("failed to load sessions: %w", err)
+			}
+			newDevice = sameDevice == 0
+		}
+
+		if err := tx.Create(session).Error; err != nil {
+			return fmt.Errorf("failed to create session: %w", err)
+		}
+
+		var err error
+		pair, err = s.issue(tx, user, session)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	pair.NewDevice = newDevice
+
+	logger.Info("session created", "session_id", session.ID, "user_id", user.ID, "device", session.DeviceName, "ip", client.IPAddress)
+	return pair, nil
+}
+
+// Refresh rotates a refresh token: the presented token is marked used and a new pair is issued
+// Presenting a token that was already used revokes the whole session (reuse detection)
+func (s *SessionService) Refresh(refreshToken string, client ClientInfo) (*TokenPair, *models.User, error) {
+	now := time.Now()
+	hash := hashToken(refreshToken)
+
+	var pair *TokenPair
+	var user models.User
+	reused := false
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var token models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", hash).
+			First(&token).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("invalid refresh token")
+			}
+			return fmt.Errorf("database error: %w", err)
+		}
+
+		var session models.Session
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&session, "id = ?", token.SessionID).Error; err != nil {
+			return fmt.Errorf("invalid refresh token")
+		}
+
+		if token.UsedAt != nil {
+			// A rotated token came back: someone else holds the chain
+			reused = true
+			if session.RevokedAt == nil {
+				if err := s.revokeSession(tx, &session, models.SessionRevokedReuse); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+
+		if !session.IsActive(now) || now.After(token.ExpiresAt) {
+			return fmt.Errorf("refresh token expired")
+		}
+
+		if err := tx.First(&user, "id = ?", session.UserID).Error; err != nil {
+			return fmt.Errorf("user not found")
+		}
+
+		if err := tx.Model(&token).Update("used_at", now).Error; err != nil {
+			return fmt.Errorf("failed to rotate refresh token: %w", err)
+		}
+
+		// The access token issued with the previous refresh token is superseded
+		if session.CurrentJTI != "" {
+			if err := revokeJTI(tx, session.CurrentJTI, session.UserID, now.Add(s.accessTTL), "rotated"); err != nil {
+				return err
+			}
+		}
+
+		session.DeviceName = deviceName(client.UserAgent)
+		session.IPAddress = client.IPAddress
+		session.UserAgent = client.UserAgent
+		session.LastUsedAt = now
+
+		var err error
+		pair, err = s.issue(tx, &user, &session)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if reused {
+		logger.Error("refresh token reuse detected, session revoked", "ip", client.IPAddress)
+		return nil, nil, fmt.Errorf("refresh token reuse detected")
+	}
+
+	return pair, &user, nil
+}
+
+// issue creates the next refresh token and access token of a session
+func (s *SessionService) issue(tx *gorm.DB, user *models.User, session *models.Session) (*TokenPair, error) {
+	refreshToken, err := newRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.RefreshToken{
+		SessionID: session.ID,
+		TokenHash: hashToken(refreshToken),
+		ExpiresAt: session.ExpiresAt,
+	}
+	if err := tx.Create(record).Error; err != nil {
+		return nil, fmt.Errorf("failed to store refresh token: %w", err)
+	}
+
+	accessToken, claims, err := GenerateToken(user.ID, user.Email, user.Role, session.ID, s.keys, s.accessTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	session.CurrentJTI = claims.ID
+	if err := tx.Model(session).Updates(map[string]interface{}{
+		"current_jti":  session.CurrentJTI,
+		"device_name":  session.DeviceName,
+		"ip_address":   session.IPAddress,
+		"user_agent":   session.UserAgent,
+		"last_used_at": session.LastUsedAt,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to update session: %w", err)
+	}
+
+	return &TokenPair{
+		AccessToken:      accessToken,
+		RefreshToken:     refreshToken,
+		ExpiresIn:        int64(s.accessTTL.Seconds()),
+		ExpiresAt:        claims.ExpiresAt.Time,
+		RefreshExpiresAt: session.ExpiresAt,
+		SessionID:        session.ID,
+	}, nil
+}
+
+// List returns a user's active sessions, most recently seen first
+// currentSessionID (if set) is flagged so clients can tell which device they are on
+func (s *SessionService) List(userID, currentSessionID string) ([]models.Session, error) {
+	var sessions []models.Session
+	if err := s.db.
+		Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve sessions: %w", err)
+	}
+
+	for i := range sessions {
+		sessions[i].Current = sessions[i].ID.String() == currentSessionID
+	}
+	return sessions, nil
+}
+
+// Touch records that a session was just used from ip
+// Writes are skipped while the last seen time is less than touchInterval old
+func (s *SessionService) Touch(sessionID, ip string) error {
+	now := time.Now()
+	if err := s.db.Model(&models.Session{}).
+		Where("id = ? AND last_used_at < ?", sessionID, now.Add(-touchInterval)).
+		Updates(map[string]interface{}{
+			"last_used_at": now,
+			"ip_address":   ip,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+	return nil
+}
+
+// Revoke ends one of a user's sessions
+func (s *SessionService) Revoke(userID, sessionID, reason string) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return fmt.Errorf("session not found")
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var session models.Session
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&session, "id = ? AND user_id = ?", sessionID, userID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("session not found")
+			}
+			return fmt.Errorf("database error: %w", err)
+		}
+		if session.RevokedAt != nil {
+			return nil
+		}
+		return s.revokeSession(tx, &session, reason)
+	})
+}
+
+// RevokeAll ends every active session of a user ("log out everywhere")
+func (s *SessionService) RevokeAll(userID, reason string) (int, error) {
+	return s.RevokeAllExcept(userID, "", reason)
+}
+
+// RevokeAllExcept ends every active session of a user except keepSessionID (if set)
+func (s *SessionService) RevokeAllExcept(userID, keepSessionID, reason string) (int, error) {
+	count := 0
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		query := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND revoked_at IS NULL", userID)
+		if keepSessionID != "" {
+			query = query.Where("id <> ?", keepSessionID)
+		}
+
+		var sessions []models.Session
+		if err := query.Find(&sessions).Error; err != nil {
+			return fmt.Errorf("failed to load sessions: %w", err)
+		}
+
+		for i := range sessions {
+			if err := s.revokeSession(tx, &sessions[i], reason); err != nil {
+				return err
+			}
+		}
+		count = len(sessions)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	logger.Info("sessions revoked", "sessions", count, "user_id", userID, "reason", reason)
+	return count, nil
+}
+
+// revokeSession marks a session revoked, burns its refresh tokens and revokes its access token
+func (s *SessionService) revokeSession(tx *gorm.DB, session *models.Session, reason string) error {
+	now := time.Now()
+
+	session.RevokedAt = &now
+	session.RevokedReason = reason
+	if err := tx.Model(session).Updates(map[string]interface{}{
+		"revoked_at":     session.RevokedAt,
+		"revoked_reason": session.RevokedReason,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to revoke session: %w", err)
+	}
+
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("session_id = ? AND used_at IS NULL", session.ID).
+		Update("used_at", now).Error; err != nil {
+		return fmt.Errorf("failed to revoke refresh tokens: %w", err)
+	}
+
+	if session.CurrentJTI != "" {
+		return revokeJTI(tx, session.CurrentJTI, session.UserID, now.Add(s.accessTTL), reason)
+	}
+	return nil
+}
+
+// revokeJTI adds an access token to the revocation list
+func revokeJTI(tx *gorm.DB, jti string, userID uuid.UUID, expiresAt time.Time, reason string) error {
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       jti,
+		UserID:    userID,
+		ExpiresAt: expiresAt,
+		Reason:    reason,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to revoke access token: %w", err)
+	}
+	return nil
+}
+
+// IsRevoked reports whether an access token is on the revocation list
+func (s *SessionService) IsRevoked(jti string) (bool, error) {
+	var count int64
+	if err := s.db.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check revocation list: %w", err)
+	}
+	return count > 0, nil
+}
+
+// PurgeExpired deletes revocation entries and refresh tokens that can no longer be used
+func (s *SessionService) PurgeExpired() error {
+	now := time.Now()
+	if err := s.db.Where("expires_at < ?", now).Delete(&models.RevokedToken{}).Error; err != nil {
+		return fmt.Errorf("failed to purge revoked tokens: %w", err)
+	}
+	if err := s.db.Where("expires_at < ?", now).Delete(&models.RefreshToken{}).Error; err != nil {
+		return fmt.Errorf("failed to purge refresh tokens: %w", err)
+	}
+	return nil
+}
+
+// RunCleanup purges expired entries periodically until the context is cancelled
+func (s *SessionService) RunCleanup(ctx context.Context) {
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.PurgeExpired(); err != nil {
+				logger.Warn("session cleanup failed", "error", err)
+			}
+		}
+	}
+}
+
+// newRefreshToken generates an opaque random refresh token
+func newRefreshToken() (string, error) {
+	bytes := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
+// hashToken returns the SHA-256 hex digest stored in place of a token
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
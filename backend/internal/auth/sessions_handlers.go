@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// ListSessions returns the current user's active sessions (one per logged in device)
+// GET /api/auth/sessions
+func (h *Handler) ListSessions(c *gin.Context) {
+	sessions, err := h.sessions.List(currentUserID(c), c.GetString("session_id"))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to list sessions", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve sessions")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"sessions": sessions,
+		"count":    len(sessions),
+	}, "Sessions retrieved successfully")
+}
+
+// RevokeSession signs out one of the current user's devices
+// Revoking the current session works like logout
+// DELETE /api/auth/sessions/:id
+func (h *Handler) RevokeSession(c *gin.Context) {
+	userID := currentUserID(c)
+	sessionID := c.Param("id")
+
+	if err := h.sessions.Revoke(userID, sessionID, models.SessionRevokedByUser); err != nil {
+		if err.Error() == "session not found" {
+			utils.RespondWithError(c, http.StatusNotFound, "Session not found")
+			return
+		}
+		logger.ErrorContext(c.Request.Context(), "failed to revoke session", "session_id", sessionID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to revoke session")
+		return
+	}
+
+	logger.InfoContext(c.Request.Context(), "session revoked by user", "user_id", userID, "session_id", sessionID)
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"session_id": sessionID}, "Session revoked")
+}
+
+// RevokeOtherSessions signs out every device except the current one
+// DELETE /api/auth/sessions
+func (h *Handler) RevokeOtherSessions(c *gin.Context) {
+	userID := currentUserID(c)
+
+	count, err := h.sessions.RevokeAllExcept(userID, c.GetString("session_id"), models.SessionRevokedByUser)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to revoke sessions", "user_id", userID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to revoke sessions")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"sessions_revoked": count}, "Other sessions revoked")
+}
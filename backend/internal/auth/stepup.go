@@ -0,0 +1,192 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// stepUpAudience distinguishes step-up tokens from access and MFA challenge tokens
+	stepUpAudience = "step-up"
+
+	// Step-up methods a user can present to authorize a sensitive operation
+	StepUpMethodPassword = "password"
+	StepUpMethodTOTP     = "totp"
+
+	// StepUpOperationAPIKey authorizes an API key to move up to Amount per operation
+	StepUpOperationAPIKey = "api_key"
+)
+
+// StepUpOperation is the money movement a step-up token authorizes (or, for
+// StepUpOperationAPIKey, the transfer limit of a new API key)
+// The token is only valid for exactly this operation, amount and destination
+type StepUpOperation struct {
+	Operation   string `json:"operation"`
+	Amount      int64  `json:"amount"`
+	ToAccountID uint64 `json:"to_account_id,omitempty"`
+}
+
+// StepUpGrant is returned once the user re-authenticates for an operation
+type StepUpGrant struct {
+	StepUpToken string          `json:"step_up_token"`
+	ExpiresAt   time.Time       `json:"expires_at"`
+	Operation   StepUpOperation `json:"operation"`
+}
+
+// stepUpClaims are the claims of a step-up token
+type stepUpClaims struct {
+	UserID string          `json:"user_id"`
+	Op     StepUpOperation `json:"op"`
+	jwt.RegisteredClaims
+}
+
+// StepUpService issues and redeems operation-bound step-up tokens
+type StepUpService struct {
+	db        *gorm.DB
+	jwtSecret string
+	mfa       *MFAService
+	ttl       time.Duration
+}
+
+// NewStepUpService creates a new step-up service
+func NewStepUpService(db *gorm.DB, jwtSecret string, mfa *MFAService, ttl time.Duration) *StepUpService {
+	return &StepUpService{
+		db:        db,
+		jwtSecret: jwtSecret,
+		mfa:       mfa,
+		ttl:       ttl,
+	}
+}
+
+// StepUpMethods returns the re-authentication methods available to a user
+func StepUpMethods(user *models.User) []string {
+	methods := []string{StepUpMethodPassword}
+	if user.MFAEnabled {
+		methods = append(methods, StepUpMethodTOTP)
+	}
+	return methods
+}
+
+// Authorize re-authenticates the user with their password or a TOTP code
+// and returns a short-lived token bound to the given operation
+func (s *StepUpService) Authorize(userID string, op StepUpOperation, password, code string) (*StepUpGrant, error) {
+	if op.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	user, err := s.mfa.getUser(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case code != "":
+		if !user.MFAEnabled {
+			return nil, fmt.Errorf("mfa not enabled")
+		}
+		if err := s.mfa.VerifyTOTP(user, code); err != nil {
+			return nil, err
+		}
+	case password != "":
+		if err := checkPassword(s.db, user, password); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("password or code is required")
+	}
+
+	now := time.Now()
+	expiresAt := now.Add(s.ttl)
+	claims := stepUpClaims{
+		UserID: user.ID.String(),
+		Op:     op,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.New().String(),
+			Audience:  jwt.ClaimStrings{stepUpAudience},
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			Issuer:    "hlabs-banking-api",
+			Subject:   user.ID.String(),
+		},
+	}
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(s.jwtSecret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign step-up token: %w", err)
+	}
+
+	logger.Info("step-up granted", "user_id", user.ID, "operation", op.Operation, "amount", op.Amount)
+	return &StepUpGrant{
+		StepUpToken: token,
+		ExpiresAt:   expiresAt,
+		Operation:   op,
+	}, nil
+}
+
+// StepUpClaim is a step-up token reserved for the operation being executed
+// The token is burned when claimed, so concurrent requests can't both use it; if the
+// operation then fails, Release hands the token back so the user can retry without
+// re-authenticating
+type StepUpClaim struct {
+	service *StepUpService
+	jti     string
+}
+
+// Release makes a claimed token usable again, for operations that failed after the claim
+// It is a no-op on a nil claim (operations that didn't need step-up)
+func (c *StepUpClaim) Release() {
+	if c == nil {
+		return
+	}
+	if err := c.service.db.Where("jti = ? AND reason = ?", c.jti, "step_up_used").
+		Delete(&models.RevokedToken{}).Error; err != nil {
+		logger.Warn("failed to release step-up token", "error", err)
+	}
+}
+
+// Redeem verifies a step-up token for the operation being executed and claims it
+// Tokens issued for another user, operation, amount or destination are rejected
+func (s *StepUpService) Redeem(stepUpToken, userID string, op StepUpOperation) (*StepUpClaim, error) {
+	claims := &stepUpClaims{}
+	token, err := jwt.ParseWithClaims(strings.TrimSpace(stepUpToken), claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return []byte(s.jwtSecret), nil
+	}, jwt.WithAudience(stepUpAudience))
+	if err != nil || !token.Valid {
+		return nil, fmt.Errorf("invalid step-up token")
+	}
+
+	if claims.UserID != userID || claims.Op != op {
+		return nil, fmt.Errorf("invalid step-up token")
+	}
+
+	uid, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid step-up token")
+	}
+
+	// Burn the token; a conflict means it was already used
+	result := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RevokedToken{
+		JTI:       claims.ID,
+		UserID:    uid,
+		ExpiresAt: claims.ExpiresAt.Time,
+		Reason:    "step_up_used",
+	})
+	if result.Error != nil {
+		return nil, fmt.Errorf("failed to redeem step-up token: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("invalid step-up token")
+	}
+
+	return &StepUpClaim{service: s, jti: claims.ID}, nil
+}
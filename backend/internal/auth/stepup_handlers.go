@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// StepUpRequest represents a re-authentication for one withdrawal or transfer, or for
+// the transfer limit of a new API key (operation api_key, amount = limit)
+// Either the account password or a TOTP code must be provided
+type StepUpRequest struct {
+	Operation   string `json:"operation" binding:"required,oneof=withdraw transfer api_key"`
+	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	ToAccountID uint64 `json:"to_account_id"`
+	Password    string `json:"password"`
+	Code        string `json:"code"`
+}
+
+// StepUp re-authenticates the user and returns a token bound to a single operation
+// POST /api/auth/step-up
+func (h *Handler) StepUp(c *gin.Context) {
+	var req StepUpRequest
+	if err := c.ShouldBindJSON(&req); err != nil || (req.Password == "" && req.Code == "") {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+	if req.Operation == "transfer" && req.ToAccountID == 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "to_account_id is required for transfers")
+		return
+	}
+
+	// Password and code guesses count against the same lockout as logins
+	user, err := h.mfa.getUser(currentUserID(c))
+	if err != nil {
+		utils.RespondWithError(c, http.StatusNotFound, "User not found")
+		return
+	}
+	client := clientInfo(c)
+	if !h.checkThrottle(c, user.Email, client) {
+		return
+	}
+
+	op := StepUpOperation{
+		Operation:   req.Operation,
+		Amount:      req.Amount,
+		ToAccountID: req.ToAccountID,
+	}
+	grant, err := h.stepUp.Authorize(user.ID.String(), op, req.Password, req.Code)
+	if err != nil {
+		switch err.Error() {
+		case "invalid password":
+			h.recordLoginFailure(user.Email, &user.ID, client, models.LoginFailureInvalidCredentials)
+			utils.RespondWithError(c, http.StatusUnauthorized, "Verification failed")
+		case "invalid mfa code":
+			h.recordLoginFailure(user.Email, &user.ID, client, models.LoginFailureInvalidMFACode)
+			utils.RespondWithError(c, http.StatusUnauthorized, "Verification failed")
+		case "mfa not enabled":
+			utils.RespondWithError(c, http.StatusBadRequest, "MFA is not enabled, confirm with your password")
+		default:
+			logger.ErrorContext(c.Request.Context(), "failed to authorize step-up", "error", err)
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to authorize operation")
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, grant, "Operation authorized")
+}
@@ -0,0 +1,232 @@
+package auth
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ThrottlePolicy configures brute-force protection for the login endpoint
+type ThrottlePolicy struct {
+	AccountThreshold int           // Consecutive failures per account before lockouts start
+	IPThreshold      int           // Consecutive failures per client IP before lockouts start
+	BaseLockout      time.Duration // First lockout; doubles with every further failure
+	MaxLockout       time.Duration // Upper bound for a single lockout
+	ResetAfter       time.Duration // Failure counts older than this are forgotten
+}
+
+// ThrottledError is returned when an account or IP is temporarily locked out
+type ThrottledError struct {
+	Scope       string
+	LockedUntil time.Time
+}
+
+// Error implements the error interface
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("too many failed login attempts (%s locked until %s)", e.Scope, e.LockedUntil.Format(time.RFC3339))
+}
+
+// RetryAfter returns how long the client has to wait before trying again
+func (e *ThrottledError) RetryAfter() time.Duration {
+	return time.Until(e.LockedUntil)
+}
+
+// LoginThrottler counts failed logins per account and per IP, applies exponential
+// lockouts and records the login history
+type LoginThrottler struct {
+	db     *gorm.DB
+	policy ThrottlePolicy
+}
+
+// NewLoginThrottler creates a new login throttler
+func NewLoginThrottler(db *gorm.DB, policy ThrottlePolicy) *LoginThrottler {
+	return &LoginThrottler{
+		db:     db,
+		policy: policy,
+	}
+}
+
+// Check returns a ThrottledError if the account or the client IP is currently locked out
+// It runs before the password is verified, so a locked account can't be probed
+func (t *LoginThrottler) Check(email, ip string) error {
+	var throttles []models.LoginThrottle
+	if err := t.db.
+		Where("(scope = ? AND throttle_key = ?) OR (scope = ? AND throttle_key = ?)",
+			models.ThrottleScopeAccount, accountKey(email), models.ThrottleScopeIP, ip).
+		Find(&throttles).Error; err != nil {
+		return fmt.Errorf("failed to check login throttle: %w", err)
+	}
+
+	now := time.Now()
+	for _, throttle := range throttles {
+		if throttle.LockedUntil != nil && now.Before(*throttle.LockedUntil) {
+			return &ThrottledError{Scope: throttle.Scope, LockedUntil: *throttle.LockedUntil}
+		}
+	}
+	return nil
+}
+
+// RecordFailure records a failed login and bumps the account and IP counters
+// userID is nil when the email doesn't belong to any user
+func (t *LoginThrottler) RecordFailure(email string, userID *uuid.UUID, client ClientInfo, reason string) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		if err := t.recordAttempt(tx, email, userID, client, false, reason); err != nil {
+			return err
+		}
+		if err := t.bump(tx, models.ThrottleScopeAccount, accountKey(email), t.policy.AccountThreshold); err != nil {
+			return err
+		}
+		if client.IPAddress == "" {
+			return nil
+		}
+		return t.bump(tx, models.ThrottleScopeIP, client.IPAddress, t.policy.IPThreshold)
+	})
+}
+
+// RecordPasskeyFailure records a failed passkey login and bumps only the IP counter
+// A passkey can't be guessed, so its failures don't count towards the account lockout
+// userID is nil when the credential couldn't be tied to a user
+func (t *LoginThrottler) RecordPasskeyFailure(email string, userID *uuid.UUID, client ClientInfo) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		if err := t.recordAttempt(tx, email, userID, client, false, models.LoginFailureInvalidPasskey); err != nil {
+			return err
+		}
+		if client.IPAddress == "" {
+			return nil
+		}
+		return t.bump(tx, models.ThrottleScopeIP, client.IPAddress, t.policy.IPThreshold)
+	})
+}
+
+// RecordThrottled records a login rejected because of a lockout (counters are not bumped)
+func (t *LoginThrottler) RecordThrottled(email string, client ClientInfo) error {
+	return t.recordAttempt(t.db, email, nil, client, false, models.LoginFailureThrottled)
+}
+
+// RecordSuccess records a successful login and clears the account's failure count
+// The IP counter is left alone so a stuffing run can't reset it with one valid account
+func (t *LoginThrottler) RecordSuccess(user *models.User, client ClientInfo) error {
+	return t.db.Transaction(func(tx *gorm.DB) error {
+		if err := t.recordAttempt(tx, user.Email, &user.ID, client, true, ""); err != nil {
+			return err
+		}
+		return tx.Where("scope = ? AND throttle_key = ?", models.ThrottleScopeAccount, accountKey(user.Email)).
+			Delete(&models.LoginThrottle{}).Error
+	})
+}
+
+// Unlock lifts the lockout of a user's account and resets its failure count (admin action)
+func (t *LoginThrottler) Unlock(userID string) (*models.User, error) {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+
+	var user models.User
+	if err := t.db.First(&user, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	if err := t.db.Where("scope = ? AND throttle_key = ?", models.ThrottleScopeAccount, accountKey(user.Email)).
+		Delete(&models.LoginThrottle{}).Error; err != nil {
+		return nil, fmt.Errorf("failed to unlock account: %w", err)
+	}
+
+	logger.Info("login lockout lifted", "user_id", user.ID)
+	return &user, nil
+}
+
+// History returns the most recent login attempts of a user, newest first
+func (t *LoginThrottler) History(userID string, limit int) ([]models.LoginAttempt, error) {
+	var attempts []models.LoginAttempt
+	if err := t.db.
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&attempts).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve login history: %w", err)
+	}
+	return attempts, nil
+}
+
+// recordAttempt appends an entry to the login history
+func (t *LoginThrottler) recordAttempt(tx *gorm.DB, email string, userID *uuid.UUID, client ClientInfo, success bool, reason string) error {
+	attempt := &models.LoginAttempt{
+		UserID:        userID,
+		Email:         accountKey(email),
+		IPAddress:     client.IPAddress,
+		UserAgent:     client.UserAgent,
+		Success:       success,
+		FailureReason: reason,
+	}
+	if err := tx.Create(attempt).Error; err != nil {
+		return fmt.Errorf("failed to record login attempt: %w", err)
+	}
+	return nil
+}
+
+// bump increments a failure counter under a row lock and applies the lockout once the threshold is reached
+func (t *LoginThrottler) bump(tx *gorm.DB, scope, key string, threshold int) error {
+	now := time.Now()
+
+	// Make sure the row exists, then lock it so concurrent failures are all counted
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.LoginThrottle{
+		Scope:         scope,
+		Key:           key,
+		LastFailureAt: now,
+	}).Error; err != nil {
+		return fmt.Errorf("failed to update login throttle: %w", err)
+	}
+
+	var throttle models.LoginThrottle
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("scope = ? AND throttle_key = ?", scope, key).
+		First(&throttle).Error; err != nil {
+		return fmt.Errorf("failed to update login throttle: %w", err)
+	}
+
+	// Old failures are forgotten unless the key is still locked
+	locked := throttle.LockedUntil != nil && now.Before(*throttle.LockedUntil)
+	if !locked && now.Sub(throttle.LastFailureAt) > t.policy.ResetAfter {
+		throttle.Failures = 0
+	}
+
+	throttle.Failures++
+	throttle.LastFailureAt = now
+	if threshold > 0 && throttle.Failures >= threshold {
+		lockedUntil := now.Add(t.lockoutFor(throttle.Failures - threshold))
+		throttle.LockedUntil = &lockedUntil
+		logger.Warn("login locked", "scope", scope, "key", key, "locked_until", lockedUntil, "failures", throttle.Failures)
+	}
+
+	if err := tx.Save(&throttle).Error; err != nil {
+		return fmt.Errorf("failed to update login throttle: %w", err)
+	}
+	return nil
+}
+
+// lockoutFor returns the lockout applied after the given number of failures past the threshold
+// BaseLockout, 2x, 4x, ... capped at MaxLockout
+func (t *LoginThrottler) lockoutFor(excess int) time.Duration {
+	lockout := t.policy.BaseLockout
+	for i := 0; i < excess && lockout < t.policy.MaxLockout; i++ {
+		lockout *= 2
+	}
+	if lockout > t.policy.MaxLockout {
+		lockout = t.policy.MaxLockout
+	}
+	return lockout
+}
+
+// accountKey normalizes an email into the account throttle key
+func accountKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/eventbus"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// GetLoginHistory returns the authenticated user's recent login attempts
+// GET /api/auth/login-history?limit=20
+func (h *Handler) GetLoginHistory(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 {
+		limit = 20
+	}
+	if limit > 100 {
+		limit = 100
+	}
+
+	attempts, err := h.throttle.History(currentUserID(c), limit)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to retrieve login history", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve login history")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"attempts": attempts,
+		"count":    len(attempts),
+	}, "Login history retrieved successfully")
+}
+
+// UnlockUser lifts a user's login lockout (admin only)
+// POST /api/admin/users/:id/unlock
+func (h *Handler) UnlockUser(c *gin.Context) {
+	user, err := h.throttle.Unlock(c.Param("id"))
+	if err != nil {
+		if err.Error() == "user not found" {
+			utils.RespondWithError(c, http.StatusNotFound, "User not found")
+			return
+		}
+		logger.ErrorContext(c.Request.Context(), "failed to unlock user", "user_id", c.Param("id"), "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to unlock user")
+		return
+	}
+
+	logger.InfoContext(c.Request.Context(), "login unlocked by admin", "admin_id", currentUserID(c), "user_id", user.ID)
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"user_id": user.ID}, "Login lockout lifted")
+}
+
+// checkThrottle rejects the request with 429 if the account or IP is locked out
+// Returns false if a response has been written
+func (h *Handler) checkThrottle(c *gin.Context, email string, client ClientInfo) bool {
+	err := h.throttle.Check(email, client.IPAddress)
+	if err == nil {
+		return true
+	}
+
+	var throttled *ThrottledError
+	if !errors.As(err, &throttled) {
+		logger.ErrorContext(c.Request.Context(), "failed to check login throttle", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to authenticate")
+		return false
+	}
+
+	if err := h.throttle.RecordThrottled(email, client); err != nil {
+		logger.WarnContext(c.Request.Context(), "failed to record throttled login", "email", email, "error", err)
+	}
+
+	logger.WarnContext(c.Request.Context(), "login throttled", "email", email, "ip", client.IPAddress, "error", err)
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(throttled.RetryAfter().Seconds()))))
+	utils.RespondWithError(c, http.StatusTooManyRequests, "Too many failed login attempts, please try again later")
+	return false
+}
+
+// recordLoginFailure logs a failed attempt; bookkeeping errors never change the response
+func (h *Handler) recordLoginFailure(email string, userID *uuid.UUID, client ClientInfo, reason string) {
+	if err := h.throttle.RecordFailure(email, userID, client, reason); err != nil {
+		logger.Warn("failed to record login failure", "email", email, "error", err)
+	}
+	h.bus.Publish(eventbus.LoginFailed{
+		UserID:    userID,
+		IPAddress: client.IPAddress,
+		Reason:    reason,
+		FailedAt:  time.Now().UTC(),
+	})
+}
+
+// recordLoginSuccess logs a successful login and resets the account's failure count
+func (h *Handler) recordLoginSuccess(user *models.User, client ClientInfo) {
+	if err := h.throttle.RecordSuccess(user, client); err != nil {
+		logger.Warn("failed to record login", "user_id", user.ID, "error", err)
+	}
+}
+
+// notifyNewDevice alerts the user when a login came from a device they haven't used before
+// Notification failures are logged and never fail the login
+func (h *Handler) notifyNewDevice(user *models.User, client ClientInfo, pair *TokenPair) {
+	if !pair.NewDevice {
+		return
+	}
+	h.notifications.Security(user.ID, "New sign-in to your account",
+		fmt.Sprintf("Your account was just signed in to from a new device: %s, IP address %s, at %s. "+
+			"If this was you, there's nothing to do. If not, sign out that device from your active sessions and change your password right away.",
+			deviceName(client.UserAgent), client.IPAddress, time.Now().UTC().Format("2 Jan 2006 15:04 MST")))
+	logger.Info("new device alert raised", "user_id", user.ID, "device", deviceName(client.UserAgent), "ip", client.IPAddress)
+}
+
+// notifySecurity raises a security notification for a change the user made to their account
+func (h *Handler) notifySecurity(userID, title, body string) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return
+	}
+	h.notifications.Security(uid, title, body)
+}
@@ -0,0 +1,298 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/protocol/webauthncbor"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/models"
+)
+
+const (
+	testRPID   = "bank.example.com"
+	testOrigin = "https://bank.example.com"
+)
+
+// softAuthenticator is a software passkey: an ES256 key pair with a sign counter
+type softAuthenticator struct {
+	t            *testing.T
+	key          *ecdsa.PrivateKey
+	credentialID []byte
+	signCount    uint32
+}
+
+func newSoftAuthenticator(t *testing.T) *softAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	credentialID := make([]byte, 32)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatal(err)
+	}
+	return &softAuthenticator{t: t, key: key, credentialID: credentialID}
+}
+
+// authData builds authenticator data with user presence and user verification set
+func (a *softAuthenticator) authData(flags protocol.AuthenticatorFlags, attested []byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(testRPID))
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, byte(flags|protocol.FlagUserPresent|protocol.FlagUserVerified))
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	return append(data, attested...)
+}
+
+// clientData builds the clientDataJSON the browser would send for a ceremony
+func (a *softAuthenticator) clientData(ceremony protocol.CeremonyType, challenge string) []byte {
+	data, err := json.Marshal(map[string]string{
+		"type":      string(ceremony),
+		"challenge": challenge,
+		"origin":    testOrigin,
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return data
+}
+
+// create answers navigator.credentials.create() with a "none" attestation
+func (a *softAuthenticator) create(challenge string) []byte {
+	a.t.Helper()
+	publicKey, err := webauthncbor.Marshal(map[int]interface{}{
+		1:  2,  // kty: EC2
+		3:  -7, // alg: ES256
+		-1: 1,  // crv: P-256
+		-2: a.key.PublicKey.X.FillBytes(make([]byte, 32)),
+		-3: a.key.PublicKey.Y.FillBytes(make([]byte, 32)),
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	attested := make([]byte, 16) // AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credentialID)))
+	attested = append(attested, a.credentialID...)
+	attested = append(attested, publicKey...)
+
+	attestation, err := webauthncbor.Marshal(map[string]interface{}{
+		"fmt":      "none",
+		"attStmt":  map[string]interface{}{},
+		"authData": a.authData(protocol.FlagAttestedCredentialData, attested),
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	return a.response(map[string]string{
+		"clientDataJSON":    b64(a.clientData(protocol.CreateCeremony, challenge)),
+		"attestationObject": b64(attestation),
+	})
+}
+
+// get answers navigator.credentials.get(), advancing the sign counter
+func (a *softAuthenticator) get(challenge string, userHandle []byte) []byte {
+	a.t.Helper()
+	a.signCount++
+	authData := a.authData(0, nil)
+	clientData := a.clientData(protocol.AssertCeremony, challenge)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		a.t.Fatal(err)
+	}
+
+	return a.response(map[string]string{
+		"clientDataJSON":    b64(clientData),
+		"authenticatorData": b64(authData),
+		"signature":         b64(signature),
+		"userHandle":        b64(userHandle),
+	})
+}
+
+// response wraps an authenticator response in a PublicKeyCredential
+func (a *softAuthenticator) response(fields map[string]string) []byte {
+	data, err := json.Marshal(map[string]interface{}{
+		"id":       b64(a.credentialID),
+		"rawId":    b64(a.credentialID),
+		"type":     "public-key",
+		"response": fields,
+	})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return data
+}
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func newTestPasskeyService(t *testing.T) *PasskeyService {
+	t.Helper()
+	service, err := NewPasskeyService(nil, "test-secret", nil, PasskeyConfig{
+		RPID:    testRPID,
+		RPName:  "Test Bank",
+		Origins: []string{testOrigin},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return service
+}
+
+// register runs a registration ceremony and returns the stored record
+func register(t *testing.T, service *PasskeyService, account *passkeyUser, authenticator *softAuthenticator) *models.WebAuthnCredential {
+	t.Helper()
+	creation, session, err := service.webAuthn.BeginRegistration(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ceremony, err := service.ceremony(creation, session, account.user.ID.String(), passkeyRegistrationAudience)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := service.decodeCeremony(ceremony.CeremonyToken, passkeyRegistrationAudience)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(authenticator.create(claims.Session.Challenge))
+	if err != nil {
+		t.Fatalf("parse attestation: %s", protocolErrorDetails(err))
+	}
+	credential, err := service.webAuthn.CreateCredential(account, claims.Session, parsed)
+	if err != nil {
+		t.Fatalf("verify attestation: %s", protocolErrorDetails(err))
+	}
+	return credentialRecord(account.user.ID, "  Laptop  ", credential)
+}
+
+func TestPasskeyRegistrationAndLogin(t *testing.T) {
+	service := newTestPasskeyService(t)
+	user := &models.User{ID: uuid.New(), Email: "ada@example.com", FullName: "Ada Lovelace"}
+	account := &passkeyUser{user: user}
+	authenticator := newSoftAuthenticator(t)
+
+	record := register(t, service, account, authenticator)
+	if record.Name != "Laptop" {
+		t.Errorf("record name = %q, want %q", record.Name, "Laptop")
+	}
+	if string(record.CredentialID) != string(authenticator.credentialID) {
+		t.Error("stored credential ID differs from the authenticator's")
+	}
+	account.credentials = []models.WebAuthnCredential{*record}
+
+	// The login ceremony token doesn't verify for registration and vice versa
+	assertion, session, err := service.webAuthn.BeginLogin(account)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ceremony, err := service.ceremony(assertion, session, user.ID.String(), passkeyLoginAudience)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := service.decodeCeremony(ceremony.CeremonyToken, passkeyRegistrationAudience); err == nil {
+		t.Error("login ceremony token accepted as a registration ceremony")
+	}
+	claims, err := service.decodeCeremony(ceremony.CeremonyToken, passkeyLoginAudience)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(authenticator.get(claims.Session.Challenge, user.ID[:]))
+	if err != nil {
+		t.Fatalf("parse assertion: %s", protocolErrorDetails(err))
+	}
+	credential, err := service.webAuthn.ValidateLogin(account, claims.Session, parsed)
+	if err != nil {
+		t.Fatalf("verify assertion: %s", protocolErrorDetails(err))
+	}
+	if credential.Authenticator.SignCount != 1 {
+		t.Errorf("sign count = %d, want 1", credential.Authenticator.SignCount)
+	}
+	if credential.Authenticator.CloneWarning {
+		t.Error("clone warning on the first login")
+	}
+}
+
+func TestPasskeyDiscoverableLogin(t *testing.T) {
+	service := newTestPasskeyService(t)
+	user := &models.User{ID: uuid.New(), Email: "grace@example.com", FullName: "Grace Hopper"}
+	account := &passkeyUser{user: user}
+	authenticator := newSoftAuthenticator(t)
+	account.credentials = []models.WebAuthnCredential{*register(t, service, account, authenticator)}
+
+	_, session, err := service.webAuthn.BeginDiscoverableLogin(webauthn.WithUserVerification(protocol.VerificationRequired))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(authenticator.get(session.Challenge, user.ID[:]))
+	if err != nil {
+		t.Fatalf("parse assertion: %s", protocolErrorDetails(err))
+	}
+	found, _, err := service.webAuthn.ValidatePasskeyLogin(func(rawID, userHandle []byte) (webauthn.User, error) {
+		id, err := uuid.FromBytes(userHandle)
+		if err != nil || id != user.ID {
+			t.Fatalf("user handle %x doesn't identify the user", userHandle)
+		}
+		return account, nil
+	}, *session, parsed)
+	if err != nil {
+		t.Fatalf("verify assertion: %s", protocolErrorDetails(err))
+	}
+	if found.(*passkeyUser).user.ID != user.ID {
+		t.Error("discoverable login resolved the wrong user")
+	}
+}
+
+func TestPasskeyLoginRejectsWrongKeyAndStaleCounter(t *testing.T) {
+	service := newTestPasskeyService(t)
+	user := &models.User{ID: uuid.New(), Email: "alan@example.com", FullName: "Alan Turing"}
+	account := &passkeyUser{user: user}
+	authenticator := newSoftAuthenticator(t)
+	record := register(t, service, account, authenticator)
+	record.SignCount = 5
+	account.credentials = []models.WebAuthnCredential{*record}
+
+	login := func(a *softAuthenticator) (*webauthn.Credential, error) {
+		_, session, err := service.webAuthn.BeginLogin(account)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := protocol.ParseCredentialRequestResponseBytes(a.get(session.Challenge, user.ID[:]))
+		if err != nil {
+			t.Fatalf("parse assertion: %s", protocolErrorDetails(err))
+		}
+		return service.webAuthn.ValidateLogin(account, *session, parsed)
+	}
+
+	// A different key presenting the same credential ID fails the signature check
+	impostor := newSoftAuthenticator(t)
+	impostor.credentialID = authenticator.credentialID
+	impostor.signCount = 10
+	if _, err := login(impostor); err == nil {
+		t.Error("assertion signed by another key was accepted")
+	}
+
+	// A counter that doesn't move past the stored one flags a possible clone
+	credential, err := login(authenticator)
+	if err != nil {
+		t.Fatalf("verify assertion: %s", protocolErrorDetails(err))
+	}
+	if !credential.Authenticator.CloneWarning {
+		t.Error("stale sign count not flagged")
+	}
+}
@@ -1,7 +1,6 @@
 package chat
 
 import (
-	"log"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -51,24 +50,13 @@ func (h *Handler) ProcessMessage(c *gin.Context) {
 	}
 
 	// Process the message
-	response, err := h.service.ProcessMessage(userID, req.Message)
+	response, err := h.service.ProcessMessage(c.Request.Context(), userID, req.Message)
 	if err != nil {
-		log.Printf("❌ Error processing chat message for user %s: %v", userID, err)
+		logger.ErrorContext(c.Request.Context(), "failed to process chat message", "user_id", userID, "error", err)
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to process your request")
 		return
 	}
 
-	// Log the complete response being sent to frontend
-	log.Printf("📤 HANDLER: Sending response to frontend:")
-	log.Printf("   Reply: %s", response.Reply)
-	log.Printf("   Intent: %s", response.Intent)
-	log.Printf("   RequiresConfirmation: %v", response.RequiresConfirmation)
-	log.Printf("   Data: %+v", response.Data)
-	if response.ConfirmationData != nil {
-		log.Printf("   ConfirmationData.ToolName: %s", response.ConfirmationData.ToolName)
-		log.Printf("   ConfirmationData.Arguments: %+v", response.ConfirmationData.Arguments)
-	}
-
 	// Return chat response
 	utils.RespondWithSuccess(c, http.StatusOK, response, "Message processed successfully")
 }
@@ -97,9 +85,9 @@ func (h *Handler) ProcessConfirmation(c *gin.Context) {
 	}
 
 	// Process the confirmation
-	response, err := h.service.ProcessConfirmation(userID, req.ToolName, req.Arguments, req.Confirmed)
+	response, err := h.service.ProcessConfirmation(c.Request.Context(), userID, req.ToolName, req.Arguments, req.Confirmed, req.StepUpToken)
 	if err != nil {
-		log.Printf("Error processing confirmation for user %s: %v", userID, err)
+		logger.ErrorContext(c.Request.Context(), "failed to process confirmation", "user_id", userID, "error", err)
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to process confirmation")
 		return
 	}
@@ -6,11 +6,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/hlabs/banking-system/internal/logging"
+	"github.com/hlabs/banking-system/internal/metrics"
+	"github.com/hlabs/banking-system/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // AIClient handles AI integration with OpenRouter for natural language banking operations
@@ -113,6 +121,15 @@ func (c *AIClient) ProcessMessage(ctx context.Context, userID, message string) (
 		return nil, fmt.Errorf("message too long (maximum 2000 characters)")
 	}
 
+	// Trace the whole completion/tool loop as one span
+	ctx, span := tracer.Start(ctx, "chat.process_message")
+	result, err := c.processMessage(ctx, userID, message)
+	tracing.End(span, err)
+	return result, err
+}
+
+// processMessage runs the completion/tool loop for a validated message
+func (c *AIClient) processMessage(ctx context.Context, userID, message string) (*ProcessResult, error) {
 	// Step 1: Build OpenRouter request
 	request := c.buildOpenRouterRequest(message)
 
@@ -178,17 +195,6 @@ When users ask about operations in natural language, extract the relevant parame
 	// Get tool definitions from MCP server
 	tools := c.mcpServer.GetToolDefinitions()
 
-	// Log tool definitions being sent to OpenRouter
-	log.Printf("🔧 TOOLS: Sending %d tools to OpenRouter:", len(tools))
-	for i, tool := range tools {
-		toolJSON, err := json.MarshalIndent(tool, "", "  ")
-		if err != nil {
-			log.Printf("   Tool %d: [Error marshaling: %v]", i+1, err)
-			continue
-		}
-		log.Printf("   Tool %d:\n%s", i+1, string(toolJSON))
-	}
-
 	// Create OpenRouter request
 	request := OpenRouterRequest{
 		Model:    c.model,
@@ -197,13 +203,6 @@ When users ask about operations in natural language, extract the relevant parame
 		Stream:   false,
 	}
 
-	// Log the complete request being sent (without tool details since we logged them above)
-	log.Printf("📤 OPENROUTER REQUEST:")
-	log.Printf("   Model: %s", request.Model)
-	log.Printf("   Messages: %d", len(request.Messages))
-	log.Printf("   Tools: %d", len(request.Tools))
-	log.Printf("   Stream: %v", request.Stream)
-
 	return request
 }
 
@@ -224,7 +223,16 @@ When users ask about operations in natural language, extract the relevant parame
 // Returns:
 //   - *OpenRouterResponse: API response with AI message and optional tool calls
 //   - error: HTTP or API error if any
-func (c *AIClient) callOpenRouter(ctx context.Context, request OpenRouterRequest) (*OpenRouterResponse, error) {
+func (c *AIClient) callOpenRouter(ctx context.Context, request OpenRouterRequest) (_ *OpenRouterResponse, err error) {
+	// Ask for the cost of the call along with the token counts
+	request.Usage = &OpenRouterUsageOptions{Include: true}
+
+	ctx, span := tracer.Start(ctx, "openrouter.chat_completions",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("gen_ai.system", "openrouter"), attribute.String("gen_ai.request.model", request.Model)),
+	)
+	defer func() { tracing.End(span, err) }()
+
 	// Marshal request to JSON
 	jsonData, err := json.Marshal(request)
 	if err != nil {
@@ -242,23 +250,35 @@ func (c *AIClient) callOpenRouter(ctx context.Context, request OpenRouterRequest
 	httpReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("HTTP-Referer", "https://github.com/hlabs/banking-system")
+	if requestID := logging.RequestID(ctx); requestID != "" {
+		httpReq.Header.Set("X-Request-ID", requestID)
+	}
+	tracing.Inject(ctx, propagation.HeaderCarrier(httpReq.Header))
 
 	// Execute request
+	start := time.Now()
 	httpResp, err := c.httpClient.Do(httpReq)
+	duration := time.Since(start)
 	if err != nil {
+		metrics.ObserveOpenRouter(request.Model, "error", duration)
+		logger.ErrorContext(ctx, "OpenRouter request failed", "model", request.Model, "duration", duration, "error", err)
 		return nil, fmt.Errorf("failed to execute HTTP request: %w", err)
 	}
 	defer httpResp.Body.Close()
+	span.SetAttributes(semconv.HTTPResponseStatusCode(httpResp.StatusCode))
 
 	// Read response body
 	body, err := io.ReadAll(httpResp.Body)
 	if err != nil {
+		metrics.ObserveOpenRouter(request.Model, "error", duration)
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	// Handle non-200 status codes with sanitized error messages
 	if httpResp.StatusCode != http.StatusOK {
+		metrics.ObserveOpenRouter(request.Model, strconv.Itoa(httpResp.StatusCode), duration)
 		// Don't expose internal API errors to users - return sanitized messages
+		logger.WarnContext(ctx, "OpenRouter returned an error", "model", request.Model, "status", httpResp.StatusCode, "duration", duration)
 		switch httpResp.StatusCode {
 		case http.StatusTooManyRequests:
 			return nil, fmt.Errorf("AI service temporarily busy, please try again in a moment")
@@ -276,27 +296,24 @@ func (c *AIClient) callOpenRouter(ctx context.Context, request OpenRouterRequest
 	// Unmarshal response
 	var response OpenRouterResponse
 	if err := json.Unmarshal(body, &response); err != nil {
+		metrics.ObserveOpenRouter(request.Model, "error", duration)
 		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
 	}
+	metrics.ObserveOpenRouter(request.Model, "ok", duration)
+	metrics.AddOpenRouterUsage(request.Model, response.Usage.PromptTokens, response.Usage.CompletionTokens, response.Usage.Cost)
+	span.SetAttributes(
+		attribute.Int("gen_ai.usage.input_tokens", response.Usage.PromptTokens),
+		attribute.Int("gen_ai.usage.output_tokens", response.Usage.CompletionTokens),
+		attribute.Float64("openrouter.cost_usd", response.Usage.Cost),
+	)
 
-	// Log the response from OpenRouter
-	log.Printf("📥 OPENROUTER RESPONSE:")
-	log.Printf("   Choices: %d", len(response.Choices))
+	toolCalls := 0
 	if len(response.Choices) > 0 {
-		choice := response.Choices[0]
-		log.Printf("   Message Role: %s", choice.Message.Role)
-		log.Printf("   Message Content: %s", choice.Message.Content)
-		log.Printf("   Tool Calls: %d", len(choice.Message.ToolCalls))
-		if len(choice.Message.ToolCalls) > 0 {
-			log.Printf("   ✅ AI IS CALLING TOOLS!")
-			for i, tc := range choice.Message.ToolCalls {
-				log.Printf("      Tool Call %d: ID=%s, Type=%s, Function=%s", i+1, tc.ID, tc.Type, tc.Function.Name)
-				log.Printf("      Arguments: %s", tc.Function.Arguments)
-			}
-		} else {
-			log.Printf("   ❌ AI IS NOT CALLING ANY TOOLS - Just returning text")
-		}
+		toolCalls = len(response.Choices[0].Message.ToolCalls)
 	}
+	logger.DebugContext(ctx, "OpenRouter response received", "model", request.Model, "duration", duration,
+		"choices", len(response.Choices), "tool_calls", toolCalls,
+		"prompt_tokens", response.Usage.PromptTokens, "completion_tokens", response.Usage.CompletionTokens, "cost", response.Usage.Cost)
 
 	return &response, nil
 }
@@ -346,36 +363,33 @@ func (c *AIClient) handleAIResponse(ctx context.Context, userID, userMessage str
 	}
 
 	// Case 2: AI wants to call a tool
-	log.Printf("🔧 MCP_CLIENT: AI wants to call tool '%s' with args: %+v", toolName, args)
+	logger.DebugContext(ctx, "AI requested a tool call", "tool", toolName)
 
 	// Execute tool via MCP server (with confirmed=false initially)
 	result, err := c.mcpServer.ExecuteTool(ctx, toolName, userID, args, false)
 	if err != nil {
 		// Handle tool execution error
-		log.Printf("❌ MCP_CLIENT: Tool execution error: %v", err)
+		logger.ErrorContext(ctx, "tool execution failed", "tool", toolName, "error", err)
 		return &ProcessResult{
 			Reply:                fmt.Sprintf("I encountered an error while processing your request: %v", err),
 			RequiresConfirmation: false,
 		}, nil
 	}
 
-	log.Printf("✅ MCP_CLIENT: Tool executed. Success=%v, RequiresConfirmation=%v", result.Success, result.RequiresConfirmation)
-	log.Printf("   Message: %s", result.Message)
-	log.Printf("   Data: %+v", result.Data)
+	logger.DebugContext(ctx, "tool executed", "tool", toolName, "success", result.Success, "requires_confirmation", result.RequiresConfirmation)
 
 	// Case 2a: Tool requires confirmation
 	if result.RequiresConfirmation {
-		log.Printf("⏸️  MCP_CLIENT: Tool requires confirmation, returning to user")
 		return &ProcessResult{
 			Reply:                result.ConfirmationMessage,
 			RequiresConfirmation: true,
 			ToolName:             toolName,
 			Arguments:            args,
+			Data:                 result.Data, // Fee quote for the confirmation step
 		}, nil
 	}
 
 	// Case 2b: Tool executed successfully - send result back to AI for natural language response
-	log.Printf("🔄 MCP_CLIENT: Sending tool result back to AI for formatting")
 	return c.handleToolResultWithAI(ctx, userMessage, response, toolName, result)
 }
 
@@ -462,13 +476,11 @@ Format monetary amounts as currency (e.g., $1,234.56).`
 	}
 	
 	// Call OpenRouter again to get natural language response
-	log.Printf("📞 MCP_CLIENT: Calling OpenRouter for AI formatting (2nd call)")
 	response, err := c.callOpenRouter(ctx, request)
 	if err != nil {
 		// If AI formatting fails, fall back to basic formatted response
-		log.Printf("⚠️  MCP_CLIENT: AI formatting failed, using fallback: %v", err)
+		logger.WarnContext(ctx, "AI formatting failed, using fallback reply", "tool", toolName, "error", err)
 		fallbackReply := c.formatToolResponse(toolResult)
-		log.Printf("   Fallback reply: %s", fallbackReply)
 		return &ProcessResult{
 			Reply:                fallbackReply,
 			RequiresConfirmation: false,
@@ -478,7 +490,7 @@ Format monetary amounts as currency (e.g., $1,234.56).`
 
 	// Extract the AI's natural language response
 	if len(response.Choices) == 0 {
-		log.Printf("⚠️  MCP_CLIENT: No choices in AI response, using fallback")
+		logger.WarnContext(ctx, "AI formatting returned no choices, using fallback reply", "tool", toolName)
 		fallbackReply := c.formatToolResponse(toolResult)
 		return &ProcessResult{
 			Reply:                fallbackReply,
@@ -490,14 +502,10 @@ Format monetary amounts as currency (e.g., $1,234.56).`
 	aiReply := response.Choices[0].Message.Content
 	if aiReply == "" {
 		// Fallback if AI doesn't provide content
-		log.Printf("⚠️  MCP_CLIENT: AI returned empty content, using fallback")
+		logger.WarnContext(ctx, "AI formatting returned empty content, using fallback reply", "tool", toolName)
 		aiReply = c.formatToolResponse(toolResult)
 	}
 
-	log.Printf("✅ MCP_CLIENT: AI formatted response received")
-	log.Printf("   AI Reply: %s", aiReply)
-	log.Printf("   Data included: %+v", toolResult.Data)
-
 	return &ProcessResult{
 		Reply:                aiReply,
 		RequiresConfirmation: false,
@@ -520,20 +528,15 @@ Format monetary amounts as currency (e.g., $1,234.56).`
 //   - args: Parsed tool arguments as map
 //   - hasToolCall: True if tool call exists, false otherwise
 func (c *AIClient) parseToolCall(response *OpenRouterResponse) (string, map[string]interface{}, bool) {
-	log.Printf("🔍 PARSE_TOOL_CALL: Starting to parse tool call")
-
 	// Validate response structure
 	if len(response.Choices) == 0 {
-		log.Printf("   ❌ No choices in response")
 		return "", nil, false
 	}
 
 	message := response.Choices[0].Message
-	log.Printf("   Message.ToolCalls length: %d", len(message.ToolCalls))
 
 	// Check if tool_calls exist
 	if len(message.ToolCalls) == 0 {
-		log.Printf("   ❌ No tool calls in message")
 		return "", nil, false
 	}
 
@@ -542,16 +545,12 @@ func (c *AIClient) parseToolCall(response *OpenRouterResponse) (string, map[stri
 	toolName := toolCall.Function.Name
 	argsString := toolCall.Function.Arguments
 
-	log.Printf("   Tool Name: %s", toolName)
-	log.Printf("   Arguments String: '%s' (length: %d)", argsString, len(argsString))
-
 	var args map[string]interface{}
 
 	// Handle empty arguments (valid for tools with no required parameters)
 	// OpenRouter may return empty string "" or "{}" for parameterless tools
 	trimmed := strings.TrimSpace(argsString)
 	if trimmed == "" || trimmed == "{}" {
-		log.Printf("   ⚠️  Arguments empty or '{}', using empty map (valid for parameterless tools)")
 		return toolName, map[string]interface{}{}, true
 	}
 
@@ -559,11 +558,10 @@ func (c *AIClient) parseToolCall(response *OpenRouterResponse) (string, map[stri
 	// OpenRouter returns arguments as JSON string: '{"amount": 100.50}'
 	if err := json.Unmarshal([]byte(argsString), &args); err != nil {
 		// Log error and return false to indicate parsing failure
-		log.Printf("   ❌ JSON Unmarshal failed: %v", err)
+		logger.Warn("failed to parse tool call arguments", "tool", toolName, "error", err)
 		return toolName, nil, false
 	}
 
-	log.Printf("   ✅ Successfully parsed %d arguments", len(args))
 	return toolName, args, true
 }
 
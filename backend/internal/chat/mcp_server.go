@@ -3,9 +3,13 @@ package chat
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/hlabs/banking-system/internal/account"
+	"github.com/hlabs/banking-system/internal/fee"
+	"github.com/hlabs/banking-system/internal/tracing"
 	"github.com/hlabs/banking-system/internal/transaction"
+	"go.opentelemetry.io/otel/attribute"
 )
 
 // MCPServer is an embedded MCP server that exposes banking operations as tools
@@ -179,19 +183,48 @@ func (s *MCPServer) ExecuteTool(
 
 	// Check if confirmation is required
 	if tool.RequiresConfirmation && !confirmed {
+		// Price the operation so the user confirms knowing the fee
+		message := tool.GetConfirmationMessage(args)
+		var data map[string]interface{}
+		if quote, ok := s.quoteFee(ctx, toolName, userID, args); ok {
+			message += " " + describeFee(quote)
+			data = map[string]interface{}{
+				"fee_cents":   quote.Fee,
+				"fee_usd":     float64(quote.Fee) / 100.0,
+				"total_cents": quote.Total,
+				"total_usd":   float64(quote.Total) / 100.0,
+			}
+		}
+
+		// Warn up front when the operation will need step-up authentication
+		if challenge := s.stepUpChallenge(ctx, toolName, userID, args); challenge != nil {
+			message += " This operation requires additional verification (password or authenticator code)."
+			if data == nil {
+				data = map[string]interface{}{}
+			}
+			data["step_up"] = challenge
+		}
+
 		// Return confirmation request
 		return ToolResult{
 			Success:              false,
 			RequiresConfirmation: true,
-			ConfirmationMessage:  tool.GetConfirmationMessage(args),
+			ConfirmationMessage:  message,
 			ToolName:             toolName,
 			Arguments:            args,
+			Data:                 data,
 			Message:              "Confirmation required for this operation",
 		}, nil
 	}
 
 	// Execute the tool handler
+	ctx, span := tracer.Start(ctx, "chat.tool "+toolName)
+	span.SetAttributes(attribute.String("chat.tool.name", toolName), attribute.Bool("chat.tool.confirmed", confirmed))
 	result, err := tool.Handler(ctx, userID, args)
+	if err == nil {
+		span.SetAttributes(attribute.Bool("chat.tool.success", result.Success))
+	}
+	tracing.End(span, err)
 	if err != nil {
 		return ToolResult{
 			Success: false,
@@ -202,6 +235,81 @@ func (s *MCPServer) ExecuteTool(
 	return result, nil
 }
 
+// quoteFee prices a money-movement tool call for the confirmation message
+// Returns false if the tool has no fee or the arguments can't be priced
+func (s *MCPServer) quoteFee(ctx context.Context, toolName, userID string, args map[string]interface{}) (fee.Quote, bool) {
+	var op fee.Operation
+	switch toolName {
+	case "deposit":
+		op = fee.OperationDeposit
+	case "withdraw":
+		op = fee.OperationWithdraw
+	case "transfer":
+		op = fee.OperationTransfer
+	default:
+		return fee.Quote{}, false
+	}
+
+	amountUSD, ok := args["amount"].(float64)
+	if !ok || amountUSD <= 0 {
+		return fee.Quote{}, false
+	}
+
+	quote, err := s.transactionService.QuoteFee(ctx, userID, op, int64(amountUSD*100))
+	if err != nil {
+		logger.WarnContext(ctx, "failed to quote fee for tool call", "tool", toolName, "error", err)
+		return fee.Quote{}, false
+	}
+
+	return quote, true
+}
+
+// stepUpChallenge evaluates the step-up policy for a money-movement tool call
+// Returns nil if the operation needs no re-authentication or can't be evaluated
+func (s *MCPServer) stepUpChallenge(ctx context.Context, toolName, userID string, args map[string]interface{}) *transaction.StepUpChallenge {
+	var op fee.Operation
+	var toAccountID uint64
+	switch toolName {
+	case "withdraw":
+		op = fee.OperationWithdraw
+	case "transfer":
+		op = fee.OperationTransfer
+		toAccountIDStr, _ := args["to_account_id"].(string)
+		parsed, err := strconv.ParseUint(toAccountIDStr, 10, 64)
+		if err != nil {
+			return nil
+		}
+		toAccountID = parsed
+	default:
+		return nil
+	}
+
+	amountUSD, ok := args["amount"].(float64)
+	if !ok || amountUSD <= 0 {
+		return nil
+	}
+
+	challenge, err := s.transactionService.StepUpChallengeFor(ctx, userID, op, int64(amountUSD*100), toAccountID)
+	if err != nil {
+		logger.Warn("failed to evaluate step-up for tool call", "tool", toolName, "error", err)
+		return nil
+	}
+	return challenge
+}
+
+// describeFee renders a fee quote as a sentence appended to confirmation messages
+func describeFee(quote fee.Quote) string {
+	if quote.Fee == 0 {
+		return "No fee applies."
+	}
+
+	feeUSD := float64(quote.Fee) / 100.0
+	if quote.Operation == fee.OperationDeposit {
+		return fmt.Sprintf("A fee of $%.2f applies, so $%.2f will be credited.", feeUSD, float64(quote.Total)/100.0)
+	}
+	return fmt.Sprintf("A fee of $%.2f applies (total $%.2f).", feeUSD, float64(quote.Total)/100.0)
+}
+
 // GetToolDefinitions returns all registered tools in OpenRouter format
 // This is used by the AI client to inform the LLM about available banking operations
 //
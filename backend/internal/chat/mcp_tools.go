@@ -2,8 +2,11 @@ package chat
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strconv"
+
+	"github.com/hlabs/banking-system/internal/transaction"
 )
 
 // handleGetBalance retrieves the current account balance for the authenticated user
@@ -13,7 +16,7 @@ import (
 // Returns: ToolResult with balance_cents and balance_usd in data
 func (s *MCPServer) handleGetBalance(ctx context.Context, userID string, args map[string]interface{}) (ToolResult, error) {
 	// Call account service to get balance in cents
-	balanceCents, err := s.accountService.GetBalance(userID)
+	balanceCents, err := s.accountService.GetBalance(ctx, userID)
 	if err != nil {
 		return ToolResult{
 			Success: false,
@@ -65,7 +68,7 @@ func (s *MCPServer) handleGetHistory(ctx context.Context, userID string, args ma
 	}
 
 	// Call transaction service to get history (page 1)
-	transactions, err := s.transactionService.GetHistory(userID, 1, limit)
+	transactions, err := s.transactionService.GetHistory(ctx, userID, 1, limit)
 	if err != nil {
 		return ToolResult{
 			Success: false,
@@ -111,7 +114,7 @@ func (s *MCPServer) handleDeposit(ctx context.Context, userID string, args map[s
 	amountCents := int64(amountUSD * 100)
 
 	// Call transaction service to perform deposit
-	err := s.transactionService.Deposit(userID, amountCents)
+	tx, err := s.transactionService.Deposit(ctx, userID, amountCents)
 	if err != nil {
 		return ToolResult{
 			Success: false,
@@ -121,7 +124,8 @@ func (s *MCPServer) handleDeposit(ctx context.Context, userID string, args map[s
 
 	return ToolResult{
 		Success: true,
-		Message: fmt.Sprintf("Successfully deposited $%.2f", amountUSD),
+		Data:    feeData(tx.Fee),
+		Message: fmt.Sprintf("Successfully deposited $%.2f%s", amountUSD, feeSuffix(tx.Fee)),
 	}, nil
 }
 
@@ -155,8 +159,11 @@ func (s *MCPServer) handleWithdraw(ctx context.Context, userID string, args map[
 
 	// Call transaction service to perform withdrawal
 	// Service will validate sufficient balance via TigerBeetle
-	err := s.transactionService.Withdraw(userID, amountCents)
+	tx, err := s.transactionService.Withdraw(ctx, userID, amountCents, stepUpTokenFromContext(ctx))
 	if err != nil {
+		if result, ok := stepUpResult("withdraw", args, err); ok {
+			return result, nil
+		}
 		return ToolResult{
 			Success: false,
 			Message: fmt.Sprintf("Failed to withdraw funds: %v", err),
@@ -165,7 +172,8 @@ func (s *MCPServer) handleWithdraw(ctx context.Context, userID string, args map[
 
 	return ToolResult{
 		Success: true,
-		Message: fmt.Sprintf("Successfully withdrew $%.2f", amountUSD),
+		Data:    feeData(tx.Fee),
+		Message: fmt.Sprintf("Successfully withdrew $%.2f%s", amountUSD, feeSuffix(tx.Fee)),
 	}, nil
 }
 
@@ -218,8 +226,11 @@ func (s *MCPServer) handleTransfer(ctx context.Context, userID string, args map[
 
 	// Call transaction service to perform transfer
 	// Service will validate sufficient balance and destination account existence
-	err = s.transactionService.Transfer(userID, toAccountID, amountCents)
+	tx, err := s.transactionService.Transfer(ctx, userID, toAccountID, amountCents, stepUpTokenFromContext(ctx))
 	if err != nil {
+		if result, ok := stepUpResult("transfer", args, err); ok {
+			return result, nil
+		}
 		return ToolResult{
 			Success: false,
 			Message: fmt.Sprintf("Failed to transfer funds: %v", err),
@@ -228,10 +239,70 @@ func (s *MCPServer) handleTransfer(ctx context.Context, userID string, args map[
 
 	return ToolResult{
 		Success: true,
-		Message: fmt.Sprintf("Successfully transferred $%.2f to account %s", amountUSD, toAccountIDStr),
+		Data:    feeData(tx.Fee),
+		Message: fmt.Sprintf("Successfully transferred $%.2f to account %s%s", amountUSD, toAccountIDStr, feeSuffix(tx.Fee)),
 	}, nil
 }
 
+// stepUpContextKey carries the step-up token presented with a chat confirmation
+type stepUpContextKey struct{}
+
+// withStepUpToken attaches a step-up token to the tool execution context
+func withStepUpToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, stepUpContextKey{}, token)
+}
+
+// stepUpTokenFromContext returns the step-up token presented with the confirmation, if any
+func stepUpTokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(stepUpContextKey{}).(string)
+	return token
+}
+
+// stepUpResult turns a step-up failure into a tool result asking the user to re-authenticate
+// The same tool call can be confirmed again with the step-up token once obtained
+func stepUpResult(toolName string, args map[string]interface{}, err error) (ToolResult, bool) {
+	var required *transaction.StepUpRequiredError
+	switch {
+	case errors.As(err, &required):
+		return ToolResult{
+			Success:              false,
+			RequiresConfirmation: true,
+			ToolName:             toolName,
+			Arguments:            args,
+			Data:                 map[string]interface{}{"step_up": required.Challenge},
+			Message:              "This operation needs additional verification. Please confirm with your password or authenticator code, then confirm again.",
+		}, true
+	case err.Error() == "invalid step-up token":
+		return ToolResult{
+			Success:              false,
+			RequiresConfirmation: true,
+			ToolName:             toolName,
+			Arguments:            args,
+			Message:              "The verification for this operation is invalid or has expired. Please verify again.",
+		}, true
+	}
+	return ToolResult{}, false
+}
+
+// feeData returns the fee charged by a completed operation as tool result data
+func feeData(feeCents int64) map[string]interface{} {
+	return map[string]interface{}{
+		"fee_cents": feeCents,
+		"fee_usd":   float64(feeCents) / 100.0,
+	}
+}
+
+// feeSuffix describes the fee charged by a completed operation, if any
+func feeSuffix(feeCents int64) string {
+	if feeCents == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (fee: $%.2f)", float64(feeCents)/100.0)
+}
+
 // initializeToolHandlers injects all handler functions into the MCPServer tools
 // This function should be called during MCPServer initialization (in NewMCPServer)
 //
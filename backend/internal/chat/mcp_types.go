@@ -60,6 +60,12 @@ type OpenRouterRequest struct {
 	Messages []OpenRouterMessage      `json:"messages"`
 	Tools    []OpenRouterToolDef      `json:"tools,omitempty"`
 	Stream   bool                     `json:"stream,omitempty"`
+	Usage    *OpenRouterUsageOptions  `json:"usage,omitempty"`
+}
+
+// OpenRouterUsageOptions asks OpenRouter to report usage details such as the cost
+type OpenRouterUsageOptions struct {
+	Include bool `json:"include"`
 }
 
 // OpenRouterMessage represents a message in the conversation
@@ -120,6 +126,7 @@ type OpenRouterUsage struct {
 	PromptTokens     int `json:"prompt_tokens"`
 	CompletionTokens int `json:"completion_tokens"`
 	TotalTokens      int `json:"total_tokens"`
+	Cost             float64 `json:"cost"` // USD (credits), reported when usage is requested
 }
 
 // ProcessResult is the result of processing a user message through the AI client
@@ -45,4 +45,7 @@ type ConfirmationRequest struct {
 	ToolName  string                 `json:"tool_name" binding:"required"`
 	Arguments map[string]interface{} `json:"arguments" binding:"required"`
 	Confirmed bool                   `json:"confirmed" binding:"required"`
+
+	// StepUpToken authorizes withdrawals and transfers that require step-up authentication
+	StepUpToken string `json:"step_up_token"`
 }
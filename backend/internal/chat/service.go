@@ -3,12 +3,18 @@ package chat
 import (
 	"context"
 	"fmt"
-	"log"
 
 	"github.com/hlabs/banking-system/internal/account"
+	"github.com/hlabs/banking-system/internal/logging"
+	"github.com/hlabs/banking-system/internal/tracing"
 	"github.com/hlabs/banking-system/internal/transaction"
 )
 
+var (
+	logger = logging.For("chat")
+	tracer = tracing.Tracer("chat")
+)
+
 // Service handles chat-related business logic
 type Service struct {
 	accountService     *account.Service
@@ -21,17 +27,16 @@ type Service struct {
 func NewService(accountService *account.Service, transactionService *transaction.Service) *Service {
 	// Initialize MCP Server with banking tools
 	mcpServer := NewMCPServer(accountService, transactionService)
-	log.Println("✅ MCP Server initialized with 5 banking tools")
+	logger.Info("MCP server initialized", "tools", len(mcpServer.GetToolDefinitions()))
 
 	// Initialize AI Client (loads from environment variables)
 	aiClient, err := NewAIClient(mcpServer)
 	if err != nil {
-		log.Printf("⚠️  Warning: Failed to initialize AI Client: %v", err)
-		log.Println("   Chat feature will be degraded. Check OPENROUTER_API_KEY environment variable.")
+		logger.Warn("failed to initialize AI client, chat will be degraded (check OPENROUTER_API_KEY)", "error", err)
 		// Continue with nil aiClient - service will handle gracefully
 	} else {
 		model, baseURL := aiClient.GetModelInfo()
-		log.Printf("✅ AI Client initialized (Model: %s, URL: %s)", model, baseURL)
+		logger.Info("AI client initialized", "model", model, "url", baseURL)
 	}
 
 	return &Service{
@@ -42,14 +47,24 @@ func NewService(accountService *account.Service, transactionService *transaction
 	}
 }
 
+// OpenRouterStatus reports the configured model and endpoint, or why the AI client is unusable
+// It only checks the configuration; no request is sent to OpenRouter
+func (s *Service) OpenRouterStatus() (model, baseURL string, err error) {
+	if s.aiClient == nil {
+		return "", "", fmt.Errorf("AI client not initialized (check OPENROUTER_API_KEY)")
+	}
+	model, baseURL = s.aiClient.GetModelInfo()
+	return model, baseURL, s.aiClient.ValidateConfiguration()
+}
+
 // ProcessMessage processes a user's chat message using AI and MCP tools
-func (s *Service) ProcessMessage(userID, message string) (ChatResponse, error) {
-	// Log chat interaction for audit
-	log.Printf("💬 Chat: User %s: %s", userID, message)
+func (s *Service) ProcessMessage(ctx context.Context, userID, message string) (ChatResponse, error) {
+	// Log chat interaction for audit (without the message, which may hold personal data)
+	logger.InfoContext(ctx, "chat message received", "user_id", userID, "length", len(message))
 
 	// Check if AI client is available
 	if s.aiClient == nil {
-		log.Printf("⚠️  AI Client not available, falling back to error response")
+		logger.WarnContext(ctx, "AI client not available, falling back to error response")
 		return ChatResponse{
 			Reply:                "AI chat service is currently unavailable. Please contact support.",
 			Intent:               IntentUnknown,
@@ -58,20 +73,12 @@ func (s *Service) ProcessMessage(userID, message string) (ChatResponse, error) {
 	}
 
 	// Process message through AI client
-	ctx := context.Background()
 	result, err := s.aiClient.ProcessMessage(ctx, userID, message)
 	if err != nil {
-		log.Printf("❌ Error processing message with AI: %v", err)
+		logger.ErrorContext(ctx, "failed to process message with AI", "user_id", userID, "error", err)
 		return ChatResponse{}, fmt.Errorf("failed to process message: %w", err)
 	}
 
-	// Log what we received from AI client
-	log.Printf("📥 SERVICE: Received ProcessResult from AI Client:")
-	log.Printf("   Reply: %s", result.Reply)
-	log.Printf("   RequiresConfirmation: %v", result.RequiresConfirmation)
-	log.Printf("   ToolName: %s", result.ToolName)
-	log.Printf("   Data: %+v", result.Data)
-
 	// Build response with the AI's reply and confirmation metadata
 	response := ChatResponse{
 		Reply:                result.Reply,
@@ -86,22 +93,23 @@ func (s *Service) ProcessMessage(userID, message string) (ChatResponse, error) {
 			ToolName:  result.ToolName,
 			Arguments: result.Arguments,
 		}
-		log.Printf("💬 Chat: Confirmation required for %s", result.ToolName)
+		logger.InfoContext(ctx, "chat confirmation required", "user_id", userID, "tool", result.ToolName)
 	} else {
-		log.Printf("💬 Chat: AI Response delivered successfully")
+		logger.DebugContext(ctx, "chat reply delivered", "user_id", userID)
 	}
 
 	return response, nil
 }
 
 // ProcessConfirmation processes a user's confirmation for a critical operation
-func (s *Service) ProcessConfirmation(userID, toolName string, args map[string]interface{}, confirmed bool) (ChatResponse, error) {
+// stepUpToken is forwarded to withdrawals and transfers that require step-up authentication
+func (s *Service) ProcessConfirmation(ctx context.Context, userID, toolName string, args map[string]interface{}, confirmed bool, stepUpToken string) (ChatResponse, error) {
 	// Log confirmation interaction for audit
-	log.Printf("💬 Confirmation: User %s - Tool: %s, Confirmed: %v", userID, toolName, confirmed)
+	logger.InfoContext(ctx, "chat confirmation received", "user_id", userID, "tool", toolName, "confirmed", confirmed)
 
 	// Check if MCP server is available
 	if s.mcpServer == nil {
-		log.Printf("❌ MCP Server not available")
+		logger.ErrorContext(ctx, "MCP server not available")
 		return ChatResponse{}, fmt.Errorf("MCP server not initialized")
 	}
 
@@ -115,13 +123,28 @@ func (s *Service) ProcessConfirmation(userID, toolName string, args map[string]i
 	}
 
 	// Execute the tool with confirmation
-	ctx := context.Background()
+	ctx = withStepUpToken(ctx, stepUpToken)
 	result, err := s.mcpServer.ExecuteTool(ctx, toolName, userID, args, true)
 	if err != nil {
-		log.Printf("❌ Error executing confirmed tool %s: %v", toolName, err)
+		logger.ErrorContext(ctx, "failed to execute confirmed tool", "tool", toolName, "error", err)
 		return ChatResponse{}, fmt.Errorf("failed to execute operation: %w", err)
 	}
 
+	// Step-up required: ask for re-authentication and let the user confirm the same call again
+	if result.RequiresConfirmation {
+		logger.InfoContext(ctx, "chat step-up required", "user_id", userID, "tool", toolName)
+		return ChatResponse{
+			Reply:                result.Message,
+			Intent:               IntentUnknown,
+			Data:                 result.Data,
+			RequiresConfirmation: true,
+			ConfirmationData: &ConfirmationData{
+				ToolName:  result.ToolName,
+				Arguments: result.Arguments,
+			},
+		}, nil
+	}
+
 	// Check if tool execution was successful
 	if !result.Success {
 		return ChatResponse{
@@ -139,7 +162,7 @@ func (s *Service) ProcessConfirmation(userID, toolName string, args map[string]i
 		RequiresConfirmation: false,
 	}
 
-	log.Printf("💬 Confirmation: Operation %s completed successfully", toolName)
+	logger.InfoContext(ctx, "chat operation completed", "user_id", userID, "tool", toolName)
 	return response, nil
 }
 
@@ -151,7 +174,7 @@ func (s *Service) ProcessConfirmation(userID, toolName string, args map[string]i
 
 // handleBalanceIntent handles balance queries
 func (s *Service) handleBalanceIntent(userID string) (ChatResponse, error) {
-	balance, err := s.accountService.GetBalance(userID)
+	balance, err := s.accountService.GetBalance(context.Background(), userID)
 	if err != nil {
 		return ChatResponse{}, fmt.Errorf("failed to retrieve balance: %w", err)
 	}
@@ -223,7 +246,7 @@ func (s *Service) handleTransferIntent(amount int64, toAccountID uint64) ChatRes
 // handleHistoryIntent handles transaction history queries
 func (s *Service) handleHistoryIntent(userID string, limit int) (ChatResponse, error) {
 	// Default to page 1, use the limit from the parsed intent
-	history, err := s.transactionService.GetHistory(userID, 1, limit)
+	history, err := s.transactionService.GetHistory(context.Background(), userID, 1, limit)
 	if err != nil {
 		return ChatResponse{}, fmt.Errorf("failed to retrieve transaction history: %w", err)
 	}
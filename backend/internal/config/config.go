@@ -2,16 +2,38 @@ package config
 
 import (
 	"fmt"
+	"log/slog"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/hlabs/banking-system/internal/logging"
 	"github.com/joho/godotenv"
 )
 
+var logger = logging.For("config")
+
 // Config holds all application configuration
 type Config struct {
 	// Server configuration
-	ServerPort string
-	GinMode    string
+	ServerPort      string
+	GinMode         string
+	ShutdownTimeout time.Duration // Deadline for draining requests and stopping workers on SIGTERM
+	ShutdownDelay   time.Duration // Time readiness reports draining before the listener closes, so load balancers stop routing first
+
+	// Logging
+	LogFormat string                // "json" or "text"
+	LogLevel  slog.Level            // Level of every component without its own
+	LogLevels map[string]slog.Level // Per-component levels, e.g. "tigerbeetle=debug,chat=warn"
+
+	// Metrics
+	MetricsToken string // Bearer token required to scrape /metrics (open when empty)
+
+	// Tracing
+	TracingExporter    string  // "none", "otlp" or "stdout"
+	TracingEndpoint    string  // OTLP/HTTP collector URL (defaults to the OTEL_EXPORTER_OTLP_* variables)
+	TracingSampleRatio float64 // Share of new traces recorded, 0-1
 
 	// PostgreSQL configuration
 	PostgresHost     string
@@ -27,10 +49,96 @@ type Config struct {
 	TigerBeetleAddress string // Full address (host:port)
 
 	// JWT configuration
-	JWTSecret string
+	JWTSecret       string        // Signs internal single-use tokens (MFA challenge, step-up, email links)
+	AccessTokenTTL  time.Duration // Lifetime of access tokens (JWT)
+	RefreshTokenTTL time.Duration // Lifetime of a session / refresh token chain
+
+	// Access token signing keys
+	JWTAlgorithm     string        // "EdDSA" or "RS256"
+	JWTKeyRotation   time.Duration // Age at which the signing key is replaced
+	JWTKeyGrace      time.Duration // How long a replaced key keeps verifying tokens
+	JWTKeyEncryption string        // Key for encrypting private signing keys at rest (defaults to JWT_SECRET)
+
+	// MFA configuration
+	MFAEncryptionKey string // Key for encrypting TOTP secrets at rest (required, distinct from JWT_SECRET)
+
+	// Step-up authentication for sensitive money movements
+	StepUpThreshold    int64         // Withdrawals/transfers above this amount (cents) require step-up
+	StepUpNewRecipient bool          // Transfers to an account never paid before require step-up
+	StepUpTokenTTL     time.Duration // Lifetime of an operation-bound step-up token
+
+	// Unverified users can only send this much (cents) per 24h
+	UnverifiedDailyLimit int64
+
+	// Outgoing email
+	MailerDriver string // "smtp", "file" or "log"
+	MailFrom     string
+	MailFilePath string // File sink for the "file" driver
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	AppBaseURL   string // Frontend origin used in emailed links
+
+	// Password policy
+	PasswordMinLength     int64  // Minimum number of characters
+	PasswordMinClasses    int64  // Minimum number of character classes (lower, upper, digit, symbol)
+	PasswordBlocklistPath string // Optional extra list of forbidden passwords, one per line
+
+	// Passkeys (WebAuthn relying party)
+	WebAuthnRPID    string   // Domain passkeys are bound to; must match the frontend's host or a parent domain
+	WebAuthnRPName  string   // Name shown by authenticators
+	WebAuthnOrigins []string // Frontend origins allowed to use passkeys (defaults to APP_BASE_URL)
+
+	// Login brute-force protection
+	LoginMaxFailures   int64         // Consecutive failures per account before lockouts start
+	LoginIPMaxFailures int64         // Consecutive failures per IP before lockouts start
+	LoginLockout       time.Duration // First lockout, doubled by every further failure
+	LoginMaxLockout    time.Duration // Longest single lockout
+
+	// Rate limits, written as "<requests>/<period>" (e.g. "20/1m"; "off" disables one)
+	RateLimitBackend  string // "memory" (per instance), "postgres" or "redis" (shared by all instances)
+	RateLimitRedisURL string // Redis (or compatible) server for the "redis" backend
+	RateLimitDefault  string // Every API request, per IP
+	RateLimitAuth     string // Login, registration, password reset and token endpoints, per IP
+	RateLimitMoney    string // Deposits, withdrawals, transfers and reversals, per user
+	RateLimitChat     string // AI chat, per user
+	RateLimitReset    string // Password reset emails, per email address
+	ChatDailyQuota    int64  // Chat messages per user and day (0 disables the quota)
+
+	// Realtime event streams
+	RealtimeBackend string // "memory" (events only reach streams on the same instance) or "postgres" (LISTEN/NOTIFY)
+
+	// Outbound webhooks
+	WebhookEncryptionKey string // Key for encrypting webhook signing secrets at rest (defaults to JWT_SECRET)
+	WebhookMaxAttempts   int64  // Failed attempts before a delivery is moved to the dead letters
+	WebhookAllowPrivate  bool   // Allow http:// and private-network endpoints (local development only)
+
+	// Notifications
+	SMSDriver                 string // "log" (development stub) or "none" (SMS channel disabled)
+	NotifyLargeWithdrawal     int64  // Default large withdrawal alert threshold (cents)
+	NotifyLowBalanceThreshold int64  // Default low balance alert threshold (cents)
+
+	// Domain event sinks
+	EventSinks             []string      // Any of "file", "nats", "kafka", "outbox"
+	EventFilePath          string        // JSON-lines file of the file sink
+	EventNATSURL           string        // nats://[user:password@]host:port or tls://, comma-separated for a cluster
+	EventNATSSubjectPrefix string        // Events are published to "<prefix>.<type>"
+	EventKafkaRESTURL      string        // Kafka REST proxy (v2 API) base URL
+	EventKafkaTopic        string        // Topic the kafka sink produces to
+	EventOutboxRetention   time.Duration // How long outbox events are kept
 
 	// OpenRouter/AI configuration
 	OpenRouterAPIKey string
+
+	// Fee configuration
+	FeeSchedulePath string // Optional JSON fee schedule (defaults to the built-in schedule)
+
+	// Interest configuration
+	InterestRates string // Optional per account type rates, e.g. "savings=250,investment=400" (basis points)
+
+	// Back-office configuration
+	AdminEmails []string // Users granted the admin role at startup
 }
 
 // Load loads configuration from environment variables
@@ -39,8 +147,17 @@ func Load() (*Config, error) {
 	_ = godotenv.Load()
 
 	cfg := &Config{
-		ServerPort: getEnv("SERVER_PORT", "8080"),
-		GinMode:    getEnv("GIN_MODE", "release"),
+		ServerPort:      getEnv("SERVER_PORT", "8080"),
+		GinMode:         getEnv("GIN_MODE", "release"),
+		ShutdownTimeout: getDuration("SHUTDOWN_TIMEOUT", 25*time.Second),
+		ShutdownDelay:   getDuration("SHUTDOWN_DELAY", 0),
+
+		LogFormat: getEnv("LOG_FORMAT", "json"),
+
+		MetricsToken: getEnv("METRICS_TOKEN", ""),
+
+		TracingExporter: getEnv("TRACING_EXPORTER", "none"),
+		TracingEndpoint: getEnv("TRACING_OTLP_ENDPOINT", ""),
 
 		PostgresHost:     getEnv("POSTGRES_HOST", "localhost"),
 		PostgresPort:     getEnv("POSTGRES_PORT", "5432"),
@@ -52,9 +169,89 @@ func Load() (*Config, error) {
 		TigerBeetlePort: getEnv("TIGERBEETLE_PORT", "3000"),
 
 		JWTSecret:        getEnv("JWT_SECRET", ""),
+		AccessTokenTTL:   getDuration("ACCESS_TOKEN_TTL", 15*time.Minute),
+		RefreshTokenTTL:  getDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
 		OpenRouterAPIKey: getEnv("OPENROUTER_API_KEY", ""),
+
+		JWTAlgorithm:   getEnv("JWT_ALGORITHM", "EdDSA"),
+		JWTKeyRotation: getDuration("JWT_KEY_ROTATION", 30*24*time.Hour),
+		JWTKeyGrace:    getDuration("JWT_KEY_GRACE", time.Hour),
+
+		StepUpThreshold:    getInt64("STEPUP_THRESHOLD_CENTS", 100000),
+		StepUpNewRecipient: getEnv("STEPUP_NEW_RECIPIENT", "true") == "true",
+		StepUpTokenTTL:     getDuration("STEPUP_TOKEN_TTL", 5*time.Minute),
+
+		UnverifiedDailyLimit: getInt64("UNVERIFIED_DAILY_LIMIT_CENTS", 50000),
+
+		MailerDriver: getEnv("MAILER_DRIVER", "log"),
+		MailFrom:     getEnv("MAIL_FROM", "HLABS Banking <no-reply@hlabs.local>"),
+		MailFilePath: getEnv("MAIL_FILE_PATH", ""),
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnv("SMTP_PORT", "587"),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		AppBaseURL:   getEnv("APP_BASE_URL", "http://localhost:5173"),
+
+		PasswordMinLength:     getInt64("PASSWORD_MIN_LENGTH", 10),
+		PasswordMinClasses:    getInt64("PASSWORD_MIN_CLASSES", 3),
+		PasswordBlocklistPath: getEnv("PASSWORD_BLOCKLIST_PATH", ""),
+
+		WebAuthnRPID:   getEnv("WEBAUTHN_RP_ID", "localhost"),
+		WebAuthnRPName: getEnv("WEBAUTHN_RP_NAME", "HLABS Banking"),
+
+		LoginMaxFailures:   getInt64("LOGIN_MAX_FAILURES", 5),
+		LoginIPMaxFailures: getInt64("LOGIN_IP_MAX_FAILURES", 50),
+		LoginLockout:       getDuration("LOGIN_LOCKOUT", time.Minute),
+		LoginMaxLockout:    getDuration("LOGIN_MAX_LOCKOUT", time.Hour),
+
+		RateLimitBackend:  getEnv("RATE_LIMIT_BACKEND", "memory"),
+		RateLimitRedisURL: getEnv("RATE_LIMIT_REDIS_URL", "redis://localhost:6379/0"),
+		RateLimitDefault:  getEnv("RATE_LIMIT_DEFAULT", "300/1m"),
+		RateLimitAuth:     getEnv("RATE_LIMIT_AUTH", "20/1m"),
+		RateLimitMoney:    getEnv("RATE_LIMIT_MONEY", "30/1m"),
+		RateLimitChat:     getEnv("RATE_LIMIT_CHAT", "10/1m"),
+		RateLimitReset:    getEnv("RATE_LIMIT_PASSWORD_RESET", "3/1h"),
+		ChatDailyQuota:    getInt64("CHAT_DAILY_QUOTA", 200),
+
+		RealtimeBackend: getEnv("REALTIME_BACKEND", "memory"),
+
+		WebhookMaxAttempts:  getInt64("WEBHOOK_MAX_ATTEMPTS", 10),
+		WebhookAllowPrivate: getEnv("WEBHOOK_ALLOW_PRIVATE", "false") == "true",
+
+		SMSDriver:                 getEnv("SMS_DRIVER", "none"),
+		NotifyLargeWithdrawal:     getInt64("NOTIFY_LARGE_WITHDRAWAL_CENTS", 100000),
+		NotifyLowBalanceThreshold: getInt64("NOTIFY_LOW_BALANCE_CENTS", 10000),
+
+		EventSinks:             splitList(getEnv("EVENT_SINKS", "")),
+		EventFilePath:          getEnv("EVENT_FILE_PATH", "events.jsonl"),
+		EventNATSURL:           getEnv("EVENT_NATS_URL", ""),
+		EventNATSSubjectPrefix: getEnv("EVENT_NATS_SUBJECT_PREFIX", "banking.events"),
+		EventKafkaRESTURL:      getEnv("EVENT_KAFKA_REST_URL", ""),
+		EventKafkaTopic:        getEnv("EVENT_KAFKA_TOPIC", "banking-events"),
+		EventOutboxRetention:   getDuration("EVENT_OUTBOX_RETENTION", 7*24*time.Hour),
+
+		FeeSchedulePath: getEnv("FEE_SCHEDULE_PATH", ""),
+		InterestRates:   getEnv("INTEREST_RATES", ""),
+
+		AdminEmails: splitList(getEnv("ADMIN_EMAILS", "")),
+	}
+
+	var err error
+	if cfg.LogLevel, err = logging.ParseLevel(getEnv("LOG_LEVEL", "info")); err != nil {
+		return nil, fmt.Errorf("LOG_LEVEL: %w", err)
+	}
+	if cfg.LogLevels, err = logging.ParseLevels(getEnv("LOG_LEVELS", "")); err != nil {
+		return nil, fmt.Errorf("LOG_LEVELS: %w", err)
+	}
+	if cfg.TracingSampleRatio, err = strconv.ParseFloat(getEnv("TRACING_SAMPLE_RATIO", "1"), 64); err != nil {
+		return nil, fmt.Errorf("TRACING_SAMPLE_RATIO must be a number between 0 and 1: %w", err)
 	}
 
+	cfg.MFAEncryptionKey = getEnv("MFA_ENCRYPTION_KEY", "")
+	cfg.JWTKeyEncryption = getEnv("JWT_KEY_ENCRYPTION_KEY", cfg.JWTSecret)
+	cfg.WebhookEncryptionKey = getEnv("WEBHOOK_ENCRYPTION_KEY", cfg.JWTSecret)
+	cfg.WebAuthnOrigins = splitList(getEnv("WEBAUTHN_ORIGINS", cfg.AppBaseURL))
+
 	// Build PostgreSQL DSN if not provided
 	cfg.PostgresDSN = getEnv("POSTGRES_DSN", "")
 	if cfg.PostgresDSN == "" {
@@ -92,6 +289,67 @@ func (c *Config) validate() error {
 		return fmt.Errorf("JWT_SECRET must be at least 32 characters long")
 	}
 
+	// TOTP seeds get their own key, so a leaked JWT_SECRET doesn't also decrypt them
+	if len(c.MFAEncryptionKey) < 32 {
+		return fmt.Errorf("MFA_ENCRYPTION_KEY is required and must be at least 32 characters long")
+	}
+	if c.MFAEncryptionKey == c.JWTSecret {
+		return fmt.Errorf("MFA_ENCRYPTION_KEY must be different from JWT_SECRET")
+	}
+
+	if c.LogFormat != "json" && c.LogFormat != "text" {
+		return fmt.Errorf("LOG_FORMAT must be \"json\" or \"text\", got %q", c.LogFormat)
+	}
+
+	switch c.TracingExporter {
+	case "none", "otlp", "stdout":
+	default:
+		return fmt.Errorf("TRACING_EXPORTER must be \"none\", \"otlp\" or \"stdout\", got %q", c.TracingExporter)
+	}
+
+	if c.TracingSampleRatio < 0 || c.TracingSampleRatio > 1 {
+		return fmt.Errorf("TRACING_SAMPLE_RATIO must be between 0 and 1, got %g", c.TracingSampleRatio)
+	}
+
+	// A retired key must outlive every token it signed
+	if c.JWTKeyGrace < c.AccessTokenTTL {
+		return fmt.Errorf("JWT_KEY_GRACE (%s) must be at least ACCESS_TOKEN_TTL (%s)", c.JWTKeyGrace, c.AccessTokenTTL)
+	}
+
+	switch c.RateLimitBackend {
+	case "memory", "postgres", "redis":
+	default:
+		return fmt.Errorf("RATE_LIMIT_BACKEND must be \"memory\", \"postgres\" or \"redis\", got %q", c.RateLimitBackend)
+	}
+
+	if c.RealtimeBackend != "memory" && c.RealtimeBackend != "postgres" {
+		return fmt.Errorf("REALTIME_BACKEND must be \"memory\" or \"postgres\", got %q", c.RealtimeBackend)
+	}
+
+	if c.WebhookMaxAttempts < 1 {
+		return fmt.Errorf("WEBHOOK_MAX_ATTEMPTS must be at least 1")
+	}
+
+	if c.NotifyLargeWithdrawal < 0 || c.NotifyLowBalanceThreshold < 0 {
+		return fmt.Errorf("NOTIFY_LARGE_WITHDRAWAL_CENTS and NOTIFY_LOW_BALANCE_CENTS must not be negative")
+	}
+
+	for _, sink := range c.EventSinks {
+		switch sink {
+		case "file", "outbox":
+		case "nats":
+			if c.EventNATSURL == "" {
+				return fmt.Errorf("EVENT_NATS_URL is required for the nats event sink")
+			}
+		case "kafka":
+			if c.EventKafkaRESTURL == "" {
+				return fmt.Errorf("EVENT_KAFKA_REST_URL is required for the kafka event sink")
+			}
+		default:
+			return fmt.Errorf("unknown event sink %q in EVENT_SINKS (expected file, nats, kafka or outbox)", sink)
+		}
+	}
+
 	return nil
 }
 
@@ -102,3 +360,46 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// splitList splits a comma-separated value, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// getDuration parses a duration environment variable (e.g. "15m", "720h")
+// Invalid values fall back to the default with a warning
+func getDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		logger.Warn("invalid setting, using default", "key", key, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+// getInt64 parses an integer environment variable
+// Invalid values fall back to the default with a warning
+func getInt64(key string, defaultValue int64) int64 {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || parsed < 0 {
+		logger.Warn("invalid setting, using default", "key", key, "value", value, "default", defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
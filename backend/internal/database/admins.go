@@ -0,0 +1,35 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hlabs/banking-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// EnsureAdmins grants the admin role to the users with the given emails
+// Emails that don't belong to a registered user are skipped with a warning
+func EnsureAdmins(db *gorm.DB, emails []string) error {
+	for _, email := range emails {
+		email = strings.TrimSpace(email)
+		if email == "" {
+			continue
+		}
+
+		result := db.Model(&models.User{}).
+			Where("email = ?", email).
+			Update("role", models.RoleAdmin)
+		if result.Error != nil {
+			return fmt.Errorf("failed to grant admin role to %s: %w", email, result.Error)
+		}
+
+		if result.RowsAffected == 0 {
+			logger.Warn("admin email does not match any user", "email", email)
+			continue
+		}
+		logger.Info("admin role granted", "email", email)
+	}
+
+	return nil
+}
@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/hlabs/banking-system/internal/logging"
+	"gorm.io/gorm"
+	gormlogger "gorm.io/gorm/logger"
+)
+
+// slowQueryThreshold is the duration above which a query is logged as a warning
+const slowQueryThreshold = 200 * time.Millisecond
+
+var logger = logging.For("database")
+
+// queryLogger routes GORM's logs to slog
+// Statements are logged at debug level, slow ones as warnings and failed ones as errors; bound
+// parameters are never logged since they hold emails, password hashes and tokens
+type queryLogger struct{}
+
+// LogMode implements gormlogger.Interface; levels are configured through the "database" component
+func (l queryLogger) LogMode(gormlogger.LogLevel) gormlogger.Interface {
+	return l
+}
+
+// Info implements gormlogger.Interface
+func (queryLogger) Info(ctx context.Context, msg string, args ...interface{}) {
+	logger.InfoContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Warn implements gormlogger.Interface
+func (queryLogger) Warn(ctx context.Context, msg string, args ...interface{}) {
+	logger.WarnContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Error implements gormlogger.Interface
+func (queryLogger) Error(ctx context.Context, msg string, args ...interface{}) {
+	logger.ErrorContext(ctx, fmt.Sprintf(msg, args...))
+}
+
+// Trace implements gormlogger.Interface
+func (queryLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
+	elapsed := time.Since(begin)
+	switch {
+	case err != nil && !errors.Is(err, gorm.ErrRecordNotFound):
+		sql, rows := fc()
+		logger.ErrorContext(ctx, "query failed", "sql", sql, "rows", rows, "duration", elapsed, "error", err)
+	case elapsed > slowQueryThreshold:
+		sql, rows := fc()
+		logger.WarnContext(ctx, "slow query", "sql", sql, "rows", rows, "duration", elapsed)
+	default:
+		if logger.Enabled(ctx, slog.LevelDebug) {
+			sql, rows := fc()
+			logger.DebugContext(ctx, "query", "sql", sql, "rows", rows, "duration", elapsed)
+		}
+	}
+}
+
+// ParamsFilter implements gorm.ParamsFilter: statements are logged with placeholders only
+func (queryLogger) ParamsFilter(_ context.Context, sql string, _ ...interface{}) (string, []interface{}) {
+	return sql, nil
+}
@@ -1,24 +1,22 @@
 package database
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"time"
 
+	"github.com/hlabs/banking-system/internal/metrics"
 	"github.com/hlabs/banking-system/internal/models"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 // Connect establishes a connection to PostgreSQL database
 func Connect(dsn string) (*gorm.DB, error) {
-	// Configure GORM logger
-	gormLogger := logger.Default.LogMode(logger.Info)
-
 	// Connect to database with UTF-8 support
 	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
-		Logger: gormLogger,
+		Logger: queryLogger{},
 		NowFunc: func() time.Time {
 			return time.Now().UTC()
 		},
@@ -29,6 +27,11 @@ func Connect(dsn string) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to database: %w", err)
 	}
 
+	// Trace every statement as a child of the caller's context (see db.WithContext)
+	if err := db.Use(tracingPlugin{}); err != nil {
+		return nil, fmt.Errorf("failed to register tracing plugin: %w", err)
+	}
+
 	// Get underlying SQL DB to configure connection pool
 	sqlDB, err := db.DB()
 	if err != nil {
@@ -40,6 +43,11 @@ func Connect(dsn string) (*gorm.DB, error) {
 	sqlDB.SetMaxOpenConns(100)
 	sqlDB.SetConnMaxLifetime(time.Hour)
 
+	// Export pool statistics (open, in use, idle, waits) on /metrics
+	if err := metrics.RegisterDB(sqlDB, "postgres"); err != nil {
+		logger.Warn("failed to register connection pool metrics", "error", err)
+	}
+
 	// Test connection
 	if err := sqlDB.Ping(); err != nil {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
@@ -48,30 +56,116 @@ func Connect(dsn string) (*gorm.DB, error) {
 	// Verify UTF-8 encoding is set correctly
 	var clientEncoding string
 	if err := db.Raw("SHOW client_encoding").Scan(&clientEncoding).Error; err != nil {
-		log.Printf("⚠️  Warning: Could not verify client encoding: %v", err)
+		logger.Warn("could not verify client encoding", "error", err)
 	} else {
-		log.Printf("✅ PostgreSQL connection established (client_encoding=%s)", clientEncoding)
+		logger.Info("PostgreSQL connection established", "client_encoding", clientEncoding)
 		if clientEncoding != "UTF8" {
-			log.Printf("⚠️  WARNING: Client encoding is %s, expected UTF8 - special characters may not display correctly", clientEncoding)
+			logger.Warn("client encoding is not UTF8, special characters may not display correctly", "client_encoding", clientEncoding)
 		}
 	}
 
 	return db, nil
 }
 
+// SchemaVersion is the schema version the migrations of this build bring the database to
+// Bump it whenever Migrate changes the schema, so /health/details shows which build migrated the database
+const SchemaVersion = 2
+
 // Migrate runs database migrations
 func Migrate(db *gorm.DB) error {
-	log.Println("Running database migrations...")
+	logger.Info("running database migrations")
+
+	// Drop check constraints whose allowed values have changed so AutoMigrate
+	// recreates them from the current model definitions
+	if err := refreshCheckConstraints(db); err != nil {
+		return fmt.Errorf("failed to refresh check constraints: %w", err)
+	}
+
+	// Email verification was introduced after accounts existed: those accounts are grandfathered in
+	backfillVerified := db.Migrator().HasTable(&models.User{}) && !db.Migrator().HasColumn(&models.User{}, "EmailVerifiedAt")
 
 	// Auto-migrate models
 	if err := db.AutoMigrate(
 		&models.User{},
 		&models.Transaction{},
+		&models.InterestAccrual{},
+		&models.InterestCapitalization{},
+		&models.Dispute{},
+		&models.Session{},
+		&models.RefreshToken{},
+		&models.RevokedToken{},
+		&models.RecoveryCode{},
+		&models.LoginAttempt{},
+		&models.LoginThrottle{},
+		&models.SigningKey{},
+		&models.APIKey{},
+		&models.APIKeyUsage{},
+		&models.WebAuthnCredential{},
+		&models.RateLimitBucket{},
+		&models.UsageQuota{},
+		&models.WebhookSubscription{},
+		&models.WebhookEvent{},
+		&models.WebhookDelivery{},
+		&models.Notification{},
+		&models.NotificationPreference{},
+		&models.OutboxEvent{},
+		&models.SchemaVersion{},
 	); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
-	log.Println("✅ Database migrations completed")
+	if backfillVerified {
+		result := db.Model(&models.User{}).Where("email_verified_at IS NULL").Update("email_verified_at", gorm.Expr("created_at"))
+		if result.Error != nil {
+			return fmt.Errorf("failed to backfill email verification: %w", result.Error)
+		}
+		logger.Info("marked existing users as email verified", "users", result.RowsAffected)
+	}
+
+	if err := db.Where(models.SchemaVersion{Version: SchemaVersion}).
+		Attrs(models.SchemaVersion{AppliedAt: time.Now().UTC()}).
+		FirstOrCreate(&models.SchemaVersion{}).Error; err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+
+	logger.Info("database migrations completed", "schema_version", SchemaVersion)
+
+	return nil
+}
+
+// AppliedSchemaVersion returns the highest schema version recorded in the database
+// Returns 0 when no version has been recorded yet
+func AppliedSchemaVersion(ctx context.Context, db *gorm.DB) (int, error) {
+	var version models.SchemaVersion
+	err := db.WithContext(ctx).Order("version DESC").First(&version).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read schema version: %w", err)
+	}
+	return version.Version, nil
+}
+
+// refreshCheckConstraints drops enum-like check constraints on existing tables
+// AutoMigrate only creates missing constraints, it never updates their definition
+func refreshCheckConstraints(db *gorm.DB) error {
+	constraints := []struct {
+		model interface{}
+		name  string
+	}{
+		{&models.Transaction{}, "chk_transactions_type"},
+		{&models.Dispute{}, "chk_disputes_status"},
+	}
+
+	for _, c := range constraints {
+		if !db.Migrator().HasTable(c.model) || !db.Migrator().HasConstraint(c.model, c.name) {
+			continue
+		}
+		if err := db.Migrator().DropConstraint(c.model, c.name); err != nil {
+			return fmt.Errorf("failed to drop constraint %s: %w", c.name, err)
+		}
+	}
 
 	return nil
 }
@@ -1,23 +1,26 @@
 package database
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"sort"
 	"time"
 	"unicode/utf8"
 
 	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/logging"
 	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/password"
 	"github.com/hlabs/banking-system/internal/tigerbeetle"
 	"github.com/hlabs/banking-system/pkg/utils"
 	tb_types "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 )
 
+var seedLogger = logging.For("seed")
+
 // TestUser represents a user from the test data JSON file
 type TestUser struct {
 	ID        string    `json:"id"`
@@ -54,23 +57,30 @@ type TestDataFile struct {
 	Transactions []TestTransaction `json:"transactions"`
 }
 
+// SeedStatus is the outcome of Seed, reported by /health/details
+type SeedStatus string
+
+const (
+	SeedStatusCompleted  SeedStatus = "completed"    // Test data was loaded on this start
+	SeedStatusSkipped    SeedStatus = "skipped"      // The database already had users
+	SeedStatusNoTestData SeedStatus = "no_test_data" // The test data file is not mounted
+	SeedStatusFailed     SeedStatus = "failed"       // Seeding stopped with an error
+)
+
 // Seed populates the database with test users, accounts, and transactions from datos-prueba-HNL.json
 // This function is idempotent - it only seeds if the users table is empty
-func Seed(db *gorm.DB, tbClient *tigerbeetle.Client) error {
-	log.Println("================================================================")
-	log.Println("🌱 DATABASE SEEDING - Starting initialization...")
-	log.Println("================================================================")
+func Seed(db *gorm.DB, tbClient *tigerbeetle.Client) (SeedStatus, error) {
+	seedLogger.Info("database seeding started")
 
 	// Check if users already exist
 	var count int64
 	if err := db.Model(&models.User{}).Count(&count).Error; err != nil {
-		return fmt.Errorf("failed to count users: %w", err)
+		return SeedStatusFailed, fmt.Errorf("failed to count users: %w", err)
 	}
 
 	if count > 0 {
-		log.Printf("ℹ️  Database already contains %d users, skipping seed", count)
-		log.Println("================================================================")
-		return nil
+		seedLogger.Info("database already contains users, skipping seed", "users", count)
+		return SeedStatusSkipped, nil
 	}
 
 	// Path to test data file (mounted by docker-compose)
@@ -79,72 +89,63 @@ func Seed(db *gorm.DB, tbClient *tigerbeetle.Client) error {
 	// Check if file exists
 	if _, err := os.Stat(testDataPath); os.IsNotExist(err) {
 		// Not a fatal error - just log and continue
-		log.Printf("⚠️  Test data file not found at %s, skipping seed", testDataPath)
-		log.Println("================================================================")
-		return nil
+		seedLogger.Warn("test data file not found, skipping seed", "path", testDataPath)
+		return SeedStatusNoTestData, nil
 	}
 
 	// Read test data file
-	log.Printf("📖 Reading test data from: %s", testDataPath)
+	seedLogger.Info("reading test data", "path", testDataPath)
 	fileData, err := os.ReadFile(testDataPath)
 	if err != nil {
-		return fmt.Errorf("failed to read test data file: %w", err)
+		return SeedStatusFailed, fmt.Errorf("failed to read test data file: %w", err)
 	}
 
 	// Validate UTF-8 encoding
 	if !utf8.Valid(fileData) {
-		log.Printf("⚠️  WARNING: Test data file contains invalid UTF-8 sequences - attempting to parse anyway")
+		seedLogger.Warn("test data file contains invalid UTF-8 sequences, attempting to parse anyway")
 		// Note: We continue because json.Unmarshal can often handle this,
 		// but we want to alert the user to potential encoding issues
 	} else {
-		log.Printf("✅ UTF-8 validation passed - file encoding is correct")
+		seedLogger.Debug("UTF-8 validation passed")
 	}
 
 	// Parse JSON
-	log.Println("📋 Parsing JSON data...")
 	var testData TestDataFile
 	if err := json.Unmarshal(fileData, &testData); err != nil {
-		return fmt.Errorf("failed to parse test data JSON: %w", err)
+		return SeedStatusFailed, fmt.Errorf("failed to parse test data JSON: %w", err)
 	}
 
-	log.Printf("📊 Data summary:")
-	log.Printf("   Users: %d", len(testData.Users))
-	log.Printf("   Accounts: %d", len(testData.Accounts))
-	log.Printf("   Transactions: %d", len(testData.Transactions))
-	log.Println("================================================================")
+	seedLogger.Info("test data loaded", "users", len(testData.Users), "accounts", len(testData.Accounts), "transactions", len(testData.Transactions))
 
 	// PHASE 1: Create users
 	if err := seedUsers(db, tbClient, testData.Users); err != nil {
-		return fmt.Errorf("failed to seed users: %w", err)
+		return SeedStatusFailed, fmt.Errorf("failed to seed users: %w", err)
 	}
 
 	// PHASE 2: Link accounts and set initial balances
 	if err := seedAccounts(db, tbClient, testData.Accounts); err != nil {
-		return fmt.Errorf("failed to seed accounts: %w", err)
+		return SeedStatusFailed, fmt.Errorf("failed to seed accounts: %w", err)
 	}
 
 	// PHASE 3: Load historical transactions
 	if err := seedTransactions(db, tbClient, testData.Transactions); err != nil {
-		return fmt.Errorf("failed to seed transactions: %w", err)
+		return SeedStatusFailed, fmt.Errorf("failed to seed transactions: %w", err)
 	}
 
-	log.Println("================================================================")
-	log.Println("✅ DATABASE SEEDING COMPLETED SUCCESSFULLY")
-	log.Println("================================================================")
+	seedLogger.Info("database seeding completed")
 
 	// DIAGNOSTIC: Show statistics
 	if err := showSeedingStatistics(db, tbClient); err != nil {
-		log.Printf("⚠️  Failed to show statistics: %v", err)
+		seedLogger.Warn("failed to collect seeding statistics", "error", err)
 	}
 
-	return nil
+	return SeedStatusCompleted, nil
 }
 
 // seedUsers creates users in PostgreSQL and TigerBeetle
 func seedUsers(db *gorm.DB, tbClient *tigerbeetle.Client, users []TestUser) error {
-	log.Println("================================================================")
-	log.Println("👥 PHASE 1: Creating Users")
-	log.Println("================================================================")
+	seedLogger.Info("phase 1: creating users", "users", len(users))
+	ctx := context.Background()
 
 	totalUsers := len(users)
 	successCount := 0
@@ -157,10 +158,10 @@ func seedUsers(db *gorm.DB, tbClient *tigerbeetle.Client, users []TestUser) erro
 		showProgress := (i+1)%500 == 0 || i == 0 || i == totalUsers-1
 
 		// Hash password
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(testUser.Password), bcrypt.DefaultCost)
+		hashedPassword, err := password.Hash(testUser.Password)
 		if err != nil {
 			if showProgress {
-				log.Printf("❌ [%d/%d] %.1f%% - Failed: %s (password hash error)", i+1, totalUsers, progress, testUser.Email)
+				seedLogger.Error("failed to create user: password hash error", "index", i+1, "total", totalUsers, "email", testUser.Email, "error", err)
 			}
 			failCount++
 			continue
@@ -170,9 +171,9 @@ func seedUsers(db *gorm.DB, tbClient *tigerbeetle.Client, users []TestUser) erro
 		tbAccountID := utils.GenerateAccountID()
 
 		// Create TigerBeetle account (empty initially, balance set in Phase 2)
-		if err := tbClient.CreateAccount(tbAccountID); err != nil {
+		if err := tbClient.CreateAccount(ctx, tbAccountID); err != nil {
 			if showProgress {
-				log.Printf("❌ [%d/%d] %.1f%% - Failed: %s (TigerBeetle error)", i+1, totalUsers, progress, testUser.Email)
+				seedLogger.Error("failed to create user: TigerBeetle error", "index", i+1, "total", totalUsers, "email", testUser.Email, "error", err)
 			}
 			failCount++
 			continue
@@ -186,18 +187,21 @@ func seedUsers(db *gorm.DB, tbClient *tigerbeetle.Client, users []TestUser) erro
 		}
 
 		// Create user in PostgreSQL
+		// Test users can't receive mail, so they start out verified
+		verifiedAt := testUser.CreatedAt
 		user := models.User{
 			ID:                   userUUID,
 			Email:                testUser.Email,
-			Password:             string(hashedPassword),
+			Password:             hashedPassword,
 			FullName:             testUser.FullName,
 			TigerBeetleAccountID: tbAccountID,
+			EmailVerifiedAt:      &verifiedAt,
 			CreatedAt:            testUser.CreatedAt,
 		}
 
 		if err := db.Create(&user).Error; err != nil {
 			if showProgress {
-				log.Printf("❌ [%d/%d] %.1f%% - Failed: %s (database error)", i+1, totalUsers, progress, testUser.Email)
+				seedLogger.Error("failed to create user: database error", "index", i+1, "total", totalUsers, "email", testUser.Email, "error", err)
 			}
 			failCount++
 			continue
@@ -207,18 +211,16 @@ func seedUsers(db *gorm.DB, tbClient *tigerbeetle.Client, users []TestUser) erro
 
 		// Show progress only at milestones
 		if showProgress {
-			log.Printf("✅ [%d/%d] %.1f%% - Created users...", i+1, totalUsers, progress)
+			seedLogger.Info("creating users", "done", i+1, "total", totalUsers, "progress", fmt.Sprintf("%.1f%%", progress))
 		}
 	}
 
 	duration := time.Since(startTime)
 
-	log.Println("----------------------------------------------------------------")
-	log.Printf("✅ Phase 1 Complete - Users Created: %d/%d (⏱️  %v)", successCount, totalUsers, duration)
+	seedLogger.Info("phase 1 complete", "created", successCount, "total", totalUsers, "failed", failCount, "duration", duration)
 	if failCount > 0 {
-		log.Printf("⚠️  Failed: %d users", failCount)
+		seedLogger.Warn("some users could not be created", "failed", failCount)
 	}
-	log.Println("================================================================")
 
 	if successCount == 0 {
 		return fmt.Errorf("all user creations failed")
@@ -229,9 +231,8 @@ func seedUsers(db *gorm.DB, tbClient *tigerbeetle.Client, users []TestUser) erro
 
 // seedAccounts links account numbers to users and sets initial balances
 func seedAccounts(db *gorm.DB, tbClient *tigerbeetle.Client, accounts []TestAccount) error {
-	log.Println("================================================================")
-	log.Println("💳 PHASE 2: Linking Accounts & Setting Initial Balances")
-	log.Println("================================================================")
+	seedLogger.Info("phase 2: linking accounts and setting initial balances", "accounts", len(accounts))
+	ctx := context.Background()
 
 	totalAccounts := len(accounts)
 	successCount := 0
@@ -249,7 +250,7 @@ func seedAccounts(db *gorm.DB, tbClient *tigerbeetle.Client, accounts []TestAcco
 		userMap[user.ID.String()] = user.TigerBeetleAccountID
 	}
 
-	log.Printf("📋 Loaded %d users into lookup map", len(userMap))
+	seedLogger.Debug("loaded users into lookup map", "users", len(userMap))
 
 	for i, account := range accounts {
 		progress := float64(i+1) / float64(totalAccounts) * 100
@@ -259,48 +260,55 @@ func seedAccounts(db *gorm.DB, tbClient *tigerbeetle.Client, accounts []TestAcco
 		tbAccountID, exists := userMap[account.UserID]
 		if !exists {
 			if showProgress {
-				log.Printf("⚠️  [%d/%d] %.1f%% - Skipping: %s (user not found)", i+1, totalAccounts, progress, account.AccountNumber)
+				seedLogger.Warn("skipping account: user not found", "index", i+1, "total", totalAccounts, "account_number", account.AccountNumber)
 			}
 			failCount++
 			continue
 		}
 
-		// Update user with account number
+		// Update user with account number and product type
+		accountType := account.AccountType
+		if accountType == "" {
+			accountType = models.AccountTypeChecking
+		}
 		if err := db.Model(&models.User{}).
 			Where("id = ?", account.UserID).
-			Update("account_number", account.AccountNumber).Error; err != nil {
+			Updates(map[string]interface{}{
+				"account_number": account.AccountNumber,
+				"account_type":   accountType,
+			}).Error; err != nil {
 			if showProgress {
-				log.Printf("❌ [%d/%d] %.1f%% - Failed to update account number: %s", i+1, totalAccounts, progress, account.AccountNumber)
+				seedLogger.Error("failed to update account number", "index", i+1, "total", totalAccounts, "account_number", account.AccountNumber, "error", err)
 			}
 			failCount++
 			continue
 		}
 
-		// Set initial balance via deposit from system account
+		// Set initial balance via transfer from the opening balance equity account
 		// Convert dollars to cents
 		amountCents := int64(account.InitialBalance * 100)
 
 		if amountCents > 0 {
 			// Generate transfer ID
-			transferID := tb_types.ToUint128(uint64(uuid.New().ID()))
+			transferID := tb_types.ID()
 
-			// Create transfer from system account to user account
+			// Create transfer from opening balance equity to user account
 			transfers := []tb_types.Transfer{
 				{
 					ID:              transferID,
-					DebitAccountID:  tbClient.SystemAccountID,                 // System account (source)
+					DebitAccountID:  tbClient.OpeningBalanceEquityAccountID,   // Opening balance equity (source)
 					CreditAccountID: tb_types.ToUint128(tbAccountID),          // User account (destination)
 					Amount:          tb_types.ToUint128(uint64(amountCents)),
-					Ledger:          1,
-					Code:            100, // Initial balance deposit code
+					Ledger:          tigerbeetle.LedgerUSD,
+					Code:            tigerbeetle.TransferCodeOpeningBalance,
 				},
 			}
 
 			// Execute transfer in TigerBeetle
-			results, err := tbClient.CreateTransfers(transfers)
+			results, err := tbClient.CreateTransfers(ctx, transfers)
 			if err != nil || len(results) > 0 {
 				if showProgress {
-					log.Printf("❌ [%d/%d] %.1f%% - Failed to set balance: %s", i+1, totalAccounts, progress, account.AccountNumber)
+					seedLogger.Error("failed to set initial balance", "index", i+1, "total", totalAccounts, "account_number", account.AccountNumber)
 				}
 				failCount++
 				continue
@@ -308,13 +316,13 @@ func seedAccounts(db *gorm.DB, tbClient *tigerbeetle.Client, accounts []TestAcco
 
 			// Record transaction in PostgreSQL
 			userUUID, _ := uuid.Parse(account.UserID)
-			systemAcctBI := tbClient.SystemAccountID.BigInt()
+			equityAcctBI := tbClient.OpeningBalanceEquityAccountID.BigInt()
 
 			txRecord := &models.Transaction{
 				UserID:          userUUID,
 				Type:            models.TransactionTypeDeposit,
 				Amount:          amountCents,
-				DebitAccountID:  systemAcctBI.Uint64(),
+				DebitAccountID:  equityAcctBI.Uint64(),
 				CreditAccountID: tbAccountID,
 				Status:          models.TransactionStatusCompleted,
 				Description:     fmt.Sprintf("Initial balance: $%.2f", account.InitialBalance),
@@ -323,37 +331,33 @@ func seedAccounts(db *gorm.DB, tbClient *tigerbeetle.Client, accounts []TestAcco
 
 			// Save to PostgreSQL (non-critical if it fails)
 			if err := db.Create(txRecord).Error; err != nil {
-				log.Printf("⚠️  Failed to log initial balance transaction for %s", account.AccountNumber)
+				seedLogger.Warn("failed to log initial balance transaction", "account_number", account.AccountNumber, "error", err)
 			}
 		}
 
 		successCount++
 
 		if showProgress {
-			log.Printf("✅ [%d/%d] %.1f%% - Linked accounts and set balances...", i+1, totalAccounts, progress)
+			seedLogger.Info("linking accounts", "done", i+1, "total", totalAccounts, "progress", fmt.Sprintf("%.1f%%", progress))
 		}
 	}
 
 	duration := time.Since(startTime)
 
-	log.Println("----------------------------------------------------------------")
-	log.Printf("✅ Phase 2 Complete - Accounts Linked: %d/%d (⏱️  %v)", successCount, totalAccounts, duration)
+	seedLogger.Info("phase 2 complete", "linked", successCount, "total", totalAccounts, "failed", failCount, "duration", duration)
 	if failCount > 0 {
-		log.Printf("⚠️  Failed: %d accounts", failCount)
+		seedLogger.Warn("some accounts could not be linked", "failed", failCount)
 	}
-	log.Println("================================================================")
 
 	return nil
 }
 
 // seedTransactions loads historical transactions from the test data
 func seedTransactions(db *gorm.DB, tbClient *tigerbeetle.Client, transactions []TestTransaction) error {
-	log.Println("================================================================")
-	log.Println("💸 PHASE 3: Loading Historical Transactions")
-	log.Println("================================================================")
+	seedLogger.Info("phase 3: loading historical transactions", "transactions", len(transactions))
+	ctx := context.Background()
 
 	// Sort transactions by timestamp (chronological order)
-	log.Println("📋 Sorting transactions chronologically...")
 	sort.Slice(transactions, func(i, j int) bool {
 		return transactions[i].Timestamp.Before(transactions[j].Timestamp)
 	})
@@ -377,8 +381,7 @@ func seedTransactions(db *gorm.DB, tbClient *tigerbeetle.Client, transactions []
 		}
 	}
 
-	log.Printf("📋 Loaded %d accounts into lookup map", len(accountMap))
-	log.Println("🚀 Processing transactions...")
+	seedLogger.Debug("loaded accounts into lookup map", "accounts", len(accountMap))
 
 	for i, tx := range transactions {
 		progress := float64(i+1) / float64(totalTransactions) * 100
@@ -430,7 +433,7 @@ func seedTransactions(db *gorm.DB, tbClient *tigerbeetle.Client, transactions []
 		}
 
 		// Generate transfer ID
-		transferID := tb_types.ToUint128(uint64(uuid.New().ID()))
+		transferID := tb_types.ID()
 
 		// Create transfer in TigerBeetle
 		transfers := []tb_types.Transfer{
@@ -439,12 +442,12 @@ func seedTransactions(db *gorm.DB, tbClient *tigerbeetle.Client, transactions []
 				DebitAccountID:  tb_types.ToUint128(debitTBAccountID),
 				CreditAccountID: tb_types.ToUint128(creditTBAccountID),
 				Amount:          tb_types.ToUint128(uint64(amountCents)),
-				Ledger:          1,
-				Code:            4, // Historical transaction code
+				Ledger:          tigerbeetle.LedgerUSD,
+				Code:            tigerbeetle.TransferCodeHistorical,
 			},
 		}
 
-		results, err := tbClient.CreateTransfers(transfers)
+		results, err := tbClient.CreateTransfers(ctx, transfers)
 		if err != nil || len(results) > 0 {
 			// Transfer failed (likely insufficient funds or other constraint)
 			failCount++
@@ -483,65 +486,55 @@ func seedTransactions(db *gorm.DB, tbClient *tigerbeetle.Client, transactions []
 
 			if err := db.Create(txRecord).Error; err != nil {
 				// Log but don't fail - TigerBeetle is source of truth
-				log.Printf("⚠️  Failed to log transaction in PostgreSQL: %v", err)
+				seedLogger.Warn("failed to log transaction in PostgreSQL", "error", err)
 			}
 		}
 
 		successCount++
 
 		if showProgress {
-			log.Printf("✅ [%d/%d] %.1f%% - Processed transactions...", i+1, totalTransactions, progress)
+			seedLogger.Info("processing transactions", "done", i+1, "total", totalTransactions, "progress", fmt.Sprintf("%.1f%%", progress))
 		}
 	}
 
 	duration := time.Since(startTime)
 
-	log.Println("----------------------------------------------------------------")
-	log.Printf("✅ Phase 3 Complete - Transactions Loaded: %d/%d (⏱️  %v)", successCount, totalTransactions, duration)
+	seedLogger.Info("phase 3 complete", "loaded", successCount, "total", totalTransactions, "skipped", skippedCount, "failed", failCount, "duration", duration)
 	if skippedCount > 0 {
-		log.Printf("⚠️  Skipped: %d transactions (incomplete or invalid)", skippedCount)
+		seedLogger.Warn("some transactions were skipped (incomplete or invalid)", "skipped", skippedCount)
 	}
 	if failCount > 0 {
-		log.Printf("❌ Failed: %d transactions", failCount)
+		seedLogger.Error("some transactions failed", "failed", failCount)
 	}
-	log.Println("================================================================")
 
 	return nil
 }
 
-// showSeedingStatistics displays diagnostic information after seeding
+// showSeedingStatistics logs diagnostic information after seeding
+// Totals are logged at info level; sample balances and the reference test user at debug level
 func showSeedingStatistics(db *gorm.DB, tbClient *tigerbeetle.Client) error {
-	log.Println("")
-	log.Println("================================================================")
-	log.Println("📊 SEEDING STATISTICS & DIAGNOSTICS")
-	log.Println("================================================================")
+	ctx := context.Background()
 
 	// Count users
 	var userCount int64
 	if err := db.Model(&models.User{}).Count(&userCount).Error; err != nil {
 		return fmt.Errorf("failed to count users: %w", err)
 	}
-	log.Printf("👥 Total Users: %d", userCount)
 
 	// Count users with account numbers
 	var usersWithAccounts int64
 	if err := db.Model(&models.User{}).Where("account_number IS NOT NULL AND account_number != ''").Count(&usersWithAccounts).Error; err != nil {
 		return fmt.Errorf("failed to count users with accounts: %w", err)
 	}
-	log.Printf("💳 Users with Account Numbers: %d", usersWithAccounts)
 
 	// Count transactions
 	var txCount int64
 	if err := db.Model(&models.Transaction{}).Count(&txCount).Error; err != nil {
 		return fmt.Errorf("failed to count transactions: %w", err)
 	}
-	log.Printf("💸 Total Transactions: %d", txCount)
+	seedLogger.Info("seeding statistics", "users", userCount, "users_with_accounts", usersWithAccounts, "transactions", txCount)
 
 	// Get sample users with balances
-	log.Println("----------------------------------------------------------------")
-	log.Println("📋 Sample Account Balances (from TigerBeetle):")
-	log.Println("----------------------------------------------------------------")
-
 	var sampleUsers []models.User
 	if err := db.Where("account_number IS NOT NULL AND account_number != ''").
 		Limit(5).
@@ -549,63 +542,47 @@ func showSeedingStatistics(db *gorm.DB, tbClient *tigerbeetle.Client) error {
 		return fmt.Errorf("failed to get sample users: %w", err)
 	}
 
-	for i, user := range sampleUsers {
+	for _, user := range sampleUsers {
 		// Get balance from TigerBeetle
-		balance, err := tbClient.GetBalance(user.TigerBeetleAccountID)
+		balance, err := tbClient.GetBalance(ctx, user.TigerBeetleAccountID)
 		if err != nil {
-			log.Printf("  %d. ⚠️  %s - Failed to get balance: %v", i+1, user.Email, err)
+			seedLogger.Warn("failed to get sample balance", "user_id", user.ID, "error", err)
 			continue
 		}
 
-		// Convert cents to dollars
-		balanceUSD := float64(balance) / 100.0
-
 		// Get transaction count for this user
 		var userTxCount int64
 		db.Model(&models.Transaction{}).
 			Where("user_id = ? OR recipient_user_id = ?", user.ID, user.ID).
 			Count(&userTxCount)
 
-		log.Printf("  %d. ✅ %s", i+1, user.Email)
-		log.Printf("     Account: %s", user.AccountNumber)
-		log.Printf("     Balance: $%.2f USD (%d cents)", balanceUSD, balance)
-		log.Printf("     TigerBeetle ID: %d", user.TigerBeetleAccountID)
-		log.Printf("     Transactions: %d", userTxCount)
+		seedLogger.Debug("sample account", "user_id", user.ID, "account_number", user.AccountNumber,
+			"account_id", user.TigerBeetleAccountID, "balance", balance, "transactions", userTxCount)
 	}
 
 	// Check Isabel Hernández specifically
-	log.Println("----------------------------------------------------------------")
-	log.Println("🔍 Checking Test User (Isabel Hernández):")
-	log.Println("----------------------------------------------------------------")
-
 	var isabel models.User
 	if err := db.Where("email = ?", "ihernandez@email.com").First(&isabel).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			log.Println("  ⚠️  Isabel Hernández not found in database")
+			seedLogger.Warn("reference test user not found")
 		} else {
 			return fmt.Errorf("failed to find Isabel: %w", err)
 		}
 	} else {
-		balance, err := tbClient.GetBalance(isabel.TigerBeetleAccountID)
+		balance, err := tbClient.GetBalance(ctx, isabel.TigerBeetleAccountID)
 		if err != nil {
-			log.Printf("  ⚠️  Failed to get Isabel's balance from TigerBeetle: %v", err)
+			seedLogger.Warn("failed to get the reference test user's balance", "user_id", isabel.ID, "error", err)
 		} else {
-			balanceUSD := float64(balance) / 100.0
-			log.Printf("  ✅ Email: %s", isabel.Email)
-			log.Printf("     Account: %s", isabel.AccountNumber)
-			log.Printf("     Balance: $%.2f USD (%d cents)", balanceUSD, balance)
-			log.Printf("     TigerBeetle ID: %d", isabel.TigerBeetleAccountID)
-
 			// Get her transactions
 			var isabelTxCount int64
 			db.Model(&models.Transaction{}).
 				Where("user_id = ? OR recipient_user_id = ?", isabel.ID, isabel.ID).
 				Count(&isabelTxCount)
-			log.Printf("     Transactions: %d", isabelTxCount)
+			seedLogger.Debug("reference test user", "user_id", isabel.ID, "account_number", isabel.AccountNumber,
+				"account_id", isabel.TigerBeetleAccountID, "balance", balance, "transactions", isabelTxCount)
 
 			// Show recent transactions
 			if isabelTxCount > 0 {
-				log.Println("     Recent Transactions:")
 				var recentTx []models.Transaction
 				db.Where("user_id = ? OR recipient_user_id = ?", isabel.ID, isabel.ID).
 					Order("created_at DESC").
@@ -613,17 +590,11 @@ func showSeedingStatistics(db *gorm.DB, tbClient *tigerbeetle.Client) error {
 					Find(&recentTx)
 
 				for _, tx := range recentTx {
-					amountUSD := float64(tx.Amount) / 100.0
-					log.Printf("       - %s: $%.2f - %s", tx.Type, amountUSD, tx.Description)
+					seedLogger.Debug("reference test user transaction", "transaction_id", tx.ID, "type", tx.Type, "amount", tx.Amount)
 				}
 			}
 		}
 	}
 
-	log.Println("================================================================")
-	log.Println("✅ DIAGNOSTICS COMPLETE")
-	log.Println("================================================================")
-	log.Println("")
-
 	return nil
 }
@@ -0,0 +1,209 @@
+package dispute
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/internal/middleware"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// Handler handles HTTP requests for disputes
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new dispute handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// OpenDisputeRequest represents the payload to open a dispute
+type OpenDisputeRequest struct {
+	TransactionID string               `json:"transaction_id" binding:"required"`
+	ReasonCode    models.DisputeReason `json:"reason_code" binding:"required"`
+	Description   string               `json:"description" binding:"max=2000"`
+	Amount        int64                `json:"amount" binding:"omitempty,gt=0"`
+}
+
+// ResolveRequest represents the payload to resolve a dispute
+type ResolveRequest struct {
+	Outcome models.DisputeStatus `json:"outcome" binding:"required,oneof=won lost"`
+	Note    string               `json:"note" binding:"required,max=2000"`
+}
+
+// GetReasons lists the dispute reason codes
+// GET /api/disputes/reasons
+func (h *Handler) GetReasons(c *gin.Context) {
+	utils.RespondWithSuccess(c, http.StatusOK, models.DisputeReasons, "Dispute reasons retrieved successfully")
+}
+
+// Open creates a dispute for one of the user's transactions
+// POST /api/disputes
+func (h *Handler) Open(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	var req OpenDisputeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	dispute, err := h.service.Open(c.Request.Context(), OpenRequest{
+		TransactionID: req.TransactionID,
+		UserID:        userID,
+		ReasonCode:    req.ReasonCode,
+		Description:   req.Description,
+		Amount:        req.Amount,
+	})
+	if err != nil {
+		logger.WarnContext(c.Request.Context(), "failed to open dispute", "transaction_id", req.TransactionID, "user_id", userID, "error", err)
+		respondWithDisputeError(c, err, "Failed to open dispute")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, dispute, "Dispute opened successfully")
+}
+
+// List returns the user's disputes
+// GET /api/disputes
+func (h *Handler) List(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	disputes, err := h.service.ListForUser(userID)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to list disputes", "user_id", userID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve disputes")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, disputes, "Disputes retrieved successfully")
+}
+
+// Get returns one of the user's disputes
+// GET /api/disputes/:id
+func (h *Handler) Get(c *gin.Context) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	dispute, err := h.service.GetForUser(c.Param("id"), userID)
+	if err != nil {
+		respondWithDisputeError(c, err, "Failed to retrieve dispute")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, dispute, "Dispute retrieved successfully")
+}
+
+// Queue returns the admin dispute queue
+// GET /api/admin/disputes?status=opened&page=1&limit=20
+func (h *Handler) Queue(c *gin.Context) {
+	status := models.DisputeStatus(c.Query("status"))
+	if status != "" && !status.IsValid() {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid status filter")
+		return
+	}
+
+	page := 1
+	limit := 20
+	if p := c.Query("page"); p != "" {
+		if _, err := fmt.Sscanf(p, "%d", &page); err != nil || page < 1 {
+			page = 1
+		}
+	}
+	if l := c.Query("limit"); l != "" {
+		if _, err := fmt.Sscanf(l, "%d", &limit); err != nil || limit < 1 || limit > 100 {
+			limit = 20
+		}
+	}
+
+	disputes, total, err := h.service.Queue(status, page, limit)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to load dispute queue", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve dispute queue")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"disputes": disputes,
+		"pagination": gin.H{
+			"page":  page,
+			"limit": limit,
+			"total": total,
+		},
+	}, "Dispute queue retrieved successfully")
+}
+
+// StartReview assigns a dispute to the current admin
+// POST /api/admin/disputes/:id/review
+func (h *Handler) StartReview(c *gin.Context) {
+	adminID, _ := middleware.GetUserID(c)
+
+	dispute, err := h.service.StartReview(c.Request.Context(), c.Param("id"), adminID)
+	if err != nil {
+		logger.WarnContext(c.Request.Context(), "failed to start dispute review", "dispute_id", c.Param("id"), "error", err)
+		respondWithDisputeError(c, err, "Failed to update dispute")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, dispute, "Dispute under review")
+}
+
+// Resolve settles a dispute as won or lost
+// POST /api/admin/disputes/:id/resolve
+func (h *Handler) Resolve(c *gin.Context) {
+	adminID, _ := middleware.GetUserID(c)
+
+	var req ResolveRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	dispute, err := h.service.Resolve(c.Request.Context(), c.Param("id"), adminID, req.Outcome, req.Note)
+	if err != nil {
+		logger.WarnContext(c.Request.Context(), "failed to resolve dispute", "dispute_id", c.Param("id"), "error", err)
+		respondWithDisputeError(c, err, "Failed to resolve dispute")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, dispute, "Dispute resolved successfully")
+}
+
+// respondWithDisputeError maps service errors to HTTP responses
+func respondWithDisputeError(c *gin.Context, err error, fallback string) {
+	errMsg := err.Error()
+	switch {
+	case errMsg == "transaction not found", errMsg == "dispute not found":
+		utils.RespondWithError(c, http.StatusNotFound, errMsg)
+	case strings.HasPrefix(errMsg, "invalid dispute state"),
+		strings.HasPrefix(errMsg, "transaction cannot be disputed"),
+		strings.HasPrefix(errMsg, "dispute amount exceeds"),
+		strings.HasPrefix(errMsg, "insufficient funds"):
+		utils.RespondWithError(c, http.StatusConflict, errMsg)
+	case strings.HasPrefix(errMsg, "invalid reason code"),
+		strings.HasPrefix(errMsg, "invalid outcome"),
+		strings.HasPrefix(errMsg, "description is required"),
+		strings.HasPrefix(errMsg, "resolution note is required"),
+		strings.HasPrefix(errMsg, "dispute amount must be"):
+		utils.RespondWithError(c, http.StatusBadRequest, errMsg)
+	default:
+		utils.RespondWithError(c, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,848 @@
+package dispute
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/logging"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/notification"
+	"github.com/hlabs/banking-system/internal/tigerbeetle"
+	tb_types "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var logger = logging.For("dispute")
+
+// Service handles the dispute workflow
+type Service struct {
+	db            *gorm.DB
+	tbClient      *tigerbeetle.Client
+	notifications *notification.Service
+}
+
+// NewService creates a new dispute service
+// Customers are notified when their case is opened and when it is resolved
+func NewService(db *gorm.DB, tbClient *tigerbeetle.Client, notifications *notification.Service) *Service {
+	return &Service{
+		db:            db,
+		tbClient:      tbClient,
+		notifications: notifications,
+	}
+}
+
+// OpenRequest describes a new dispute
+type OpenRequest struct {
+	TransactionID string
+	UserID        string
+	ReasonCode    models.DisputeReason
+	Description   string
+	Amount        int64 // Zero disputes everything still refundable
+}
+
+// Open creates a dispute case and reserves the disputed amount: a pending hold on the
+// counterparty, or a provisional credit from suspense when the counterparty can't cover it
+//
+// Like reversals, opening runs in three steps so no row lock is held while TigerBeetle is called:
+//  1. reserve: with the transaction locked, check the disputable amount and save the case as
+//     opening with the ID of its reservation transfer, then commit
+//  2. post the reservation in TigerBeetle
+//  3. mark the case opened, or failed if TigerBeetle rejected the reservation
+//
+// A case whose reservation may have been posted but can't be confirmed stays opening, still
+// counting against the transaction, and is the reconciliation entry for the transfer.
+func (s *Service) Open(ctx context.Context, req OpenRequest) (*models.Dispute, error) {
+	if _, ok := models.DisputeReasons[req.ReasonCode]; !ok {
+		return nil, fmt.Errorf("invalid reason code: %s", req.ReasonCode)
+	}
+	req.Description = strings.TrimSpace(req.Description)
+	if req.ReasonCode == models.DisputeReasonOther && req.Description == "" {
+		return nil, fmt.Errorf("description is required for reason code other")
+	}
+
+	txID, err := uuid.Parse(req.TransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("transaction not found")
+	}
+	userID, err := uuid.Parse(req.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	dispute, original, err := s.reserveDispute(ctx, txID, userID, req)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.postReservation(ctx, dispute, original); err != nil {
+		if releasable(err) {
+			s.failDispute(ctx, dispute)
+		} else {
+			logger.ErrorContext(ctx, "dispute reservation outcome unknown, case left opening for reconciliation",
+				"dispute_id", dispute.ID, "transaction_id", txID, "pending_transfer_id", dispute.PendingTransferID, "error", err)
+		}
+		return nil, err
+	}
+
+	// Posted in TigerBeetle: opening the case must not be cancelled with the request
+	if err := s.db.WithContext(context.WithoutCancel(ctx)).Model(&models.Dispute{}).
+		Where("id = ? AND status = ?", dispute.ID, models.DisputeStatusOpening).
+		Update("status", models.DisputeStatusOpened).Error; err != nil {
+		// The amount is reserved in TigerBeetle and still counted against the transaction -
+		// the opening case flags it for reconciliation
+		logger.ErrorContext(ctx, "dispute reservation posted in TigerBeetle but the case failed to open in PostgreSQL",
+			"dispute_id", dispute.ID, "transaction_id", txID, "pending_transfer_id", dispute.PendingTransferID, "error", err)
+		return dispute, nil
+	}
+	dispute.Status = models.DisputeStatusOpened
+
+	logger.InfoContext(ctx, "dispute opened", "dispute_id", dispute.ID, "user_id", req.UserID, "transaction_id", txID,
+		"amount", dispute.Amount, "reason_code", dispute.ReasonCode, "provisional_credit", dispute.ProvisionalCredit)
+	s.notifications.Dispute(dispute)
+	return dispute, nil
+}
+
+// reserveDispute validates the dispute against the locked transaction and saves the case as
+// opening, so the amount counts against the transaction before any money moves
+func (s *Service) reserveDispute(ctx context.Context, txID, userID uuid.UUID, req OpenRequest) (*models.Dispute, *models.Transaction, error) {
+	var original models.Transaction
+	var dispute *models.Dispute
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the transaction so disputes and reversals can't over-commit its amount
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&original, "id = ?", txID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("transaction not found")
+			}
+			return fmt.Errorf("database error: %w", err)
+		}
+
+		// Only the customer whose money left can dispute: senders of transfers and withdrawals
+		if original.UserID != userID {
+			return fmt.Errorf("transaction not found")
+		}
+		if original.Type != models.TransactionTypeTransfer && original.Type != models.TransactionTypeWithdraw {
+			return fmt.Errorf("transaction cannot be disputed: only transfers and withdrawals can be disputed")
+		}
+		if original.Status != models.TransactionStatusCompleted {
+			return fmt.Errorf("transaction cannot be disputed: status is %s", original.Status)
+		}
+
+		held, err := heldAmount(tx, original.ID)
+		if err != nil {
+			return err
+		}
+		remaining := original.RefundableAmount() - held
+		if remaining <= 0 {
+			return fmt.Errorf("transaction cannot be disputed: nothing left to dispute")
+		}
+
+		amount := req.Amount
+		if amount == 0 {
+			amount = remaining
+		}
+		if amount < 0 {
+			return fmt.Errorf("dispute amount must be positive")
+		}
+		if amount > remaining {
+			return fmt.Errorf("dispute amount exceeds disputable amount: requested %d, disputable %d", amount, remaining)
+		}
+
+		// Hold at the counterparty when it's a customer, otherwise provisionally credit the
+		// customer from suspense (postReservation also falls back to suspense)
+		holdAccountID := original.CreditAccountID
+		provisional := tigerbeetle.IsSystemAccount(holdAccountID)
+		if provisional {
+			holdAccountID = s.suspenseAccountID()
+		}
+
+		dispute = &models.Dispute{
+			TransactionID:     original.ID,
+			UserID:            userID,
+			ReasonCode:        req.ReasonCode,
+			Description:       req.Description,
+			Amount:            amount,
+			Status:            models.DisputeStatusOpening,
+			HoldAccountID:     holdAccountID,
+			CreditAccountID:   original.DebitAccountID,
+			PendingTransferID: models.Uint128ToHex(tb_types.ID()),
+			ProvisionalCredit: provisional,
+		}
+		if err := tx.Create(dispute).Error; err != nil {
+			return fmt.Errorf("failed to create dispute: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return dispute, &original, nil
+}
+
+// disputeRejectedError is returned when a reservation or settlement was certainly not posted,
+// so the case can go back to its previous state
+type disputeRejectedError struct {
+	err error
+}
+
+// Error implements the error interface
+func (e *disputeRejectedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *disputeRejectedError) Unwrap() error {
+	return e.err
+}
+
+// rejected marks err as a transfer TigerBeetle did not post
+func rejected(err error) error {
+	return &disputeRejectedError{err: err}
+}
+
+// releasable reports whether an error proves nothing was posted in TigerBeetle
+func releasable(err error) bool {
+	var rejectedErr *disputeRejectedError
+	return errors.As(err, &rejectedErr)
+}
+
+// postReservation posts the case's reservation in TigerBeetle
+// A hold the counterparty can't cover falls back to a provisional credit from suspense: the case
+// is switched over first, since a transfer ID TigerBeetle rejected can't be used again
+func (s *Service) postReservation(ctx context.Context, dispute *models.Dispute, original *models.Transaction) error {
+	result, err := s.createReservation(ctx, dispute, original)
+	if err != nil {
+		return err
+	}
+
+	if result == tb_types.TransferExceedsCredits && !dispute.ProvisionalCredit {
+		if err := s.switchToProvisional(ctx, dispute); err != nil {
+			return rejected(err)
+		}
+		if result, err = s.createReservation(ctx, dispute, original); err != nil {
+			return err
+		}
+	}
+
+	if result != tb_types.TransferOK {
+		return rejected(fmt.Errorf("hold failed with result code: %d", result))
+	}
+	return nil
+}
+
+// createReservation reserves the disputed amount for the customer: a pending hold on the
+// counterparty, or a posted provisional credit from suspense
+// Returns the TigerBeetle result so the caller can fall back to suspense on ExceedsCredits
+func (s *Service) createReservation(ctx context.Context, dispute *models.Dispute, original *models.Transaction) (tb_types.CreateTransferResult, error) {
+	originalTransferID, err := original.GetTigerBeetleTransferID()
+	if err != nil {
+		return 0, rejected(fmt.Errorf("invalid original transfer ID: %w", err))
+	}
+	reserveID, err := models.HexToUint128(dispute.PendingTransferID)
+	if err != nil {
+		return 0, rejected(fmt.Errorf("invalid pending transfer ID: %w", err))
+	}
+
+	code := tigerbeetle.TransferCodeDisputeHold
+	flags := tb_types.TransferFlags{Pending: true}
+	if dispute.ProvisionalCredit {
+		code = tigerbeetle.TransferCodeDisputeCredit
+		flags = tb_types.TransferFlags{}
+	}
+
+	results, err := s.tbClient.CreateTransfers(ctx, []tb_types.Transfer{
+		{
+			ID:              reserveID,
+			DebitAccountID:  tb_types.ToUint128(dispute.HoldAccountID),
+			CreditAccountID: tb_types.ToUint128(dispute.CreditAccountID),
+			Amount:          tb_types.ToUint128(uint64(dispute.Amount)),
+			UserData128:     originalTransferID,
+			Ledger:          tigerbeetle.LedgerUSD,
+			Code:            code,
+			Flags:           flags.ToUint16(),
+		},
+	})
+	if err != nil {
+		// The request may have reached TigerBeetle - only a lookup can tell
+		if s.transferExists(ctx, reserveID) {
+			return tb_types.TransferOK, nil
+		}
+		return 0, fmt.Errorf("failed to create transfer: %w", err)
+	}
+	if len(results) > 0 {
+		return results[0].Result, nil
+	}
+
+	return tb_types.TransferOK, nil
+}
+
+// switchToProvisional moves an opening case from a hold on the counterparty to a provisional
+// credit from suspense, with a new reservation transfer ID
+func (s *Service) switchToProvisional(ctx context.Context, dispute *models.Dispute) error {
+	holdAccountID := s.suspenseAccountID()
+	reserveID := models.Uint128ToHex(tb_types.ID())
+
+	if err := s.db.WithContext(ctx).Model(&models.Dispute{}).
+		Where("id = ? AND status = ?", dispute.ID, models.DisputeStatusOpening).
+		Updates(map[string]interface{}{
+			"hold_account_id":     holdAccountID,
+			"pending_transfer_id": reserveID,
+			"provisional_credit":  true,
+		}).Error; err != nil {
+		return fmt.Errorf("failed to switch dispute to provisional credit: %w", err)
+	}
+
+	dispute.HoldAccountID = holdAccountID
+	dispute.PendingTransferID = reserveID
+	dispute.ProvisionalCredit = true
+	return nil
+}
+
+// failDispute marks an opening case whose reservation TigerBeetle rejected as failed
+// If that fails the case stays opening: the transaction can be disputed or refunded less,
+// never more, and the case is left for reconciliation
+func (s *Service) failDispute(ctx context.Context, dispute *models.Dispute) {
+	if err := s.db.WithContext(ctx).Model(&models.Dispute{}).
+		Where("id = ? AND status = ?", dispute.ID, models.DisputeStatusOpening).
+		Update("status", models.DisputeStatusFailed).Error; err != nil {
+		logger.ErrorContext(ctx, "failed to mark rejected dispute as failed",
+			"dispute_id", dispute.ID, "transaction_id", dispute.TransactionID, "error", err)
+		return
+	}
+	dispute.Status = models.DisputeStatusFailed
+}
+
+// transferExists reports whether TigerBeetle holds a transfer with the given ID
+// A lookup that fails counts as not found, so the caller keeps treating the outcome as unknown
+func (s *Service) transferExists(ctx context.Context, transferID tb_types.Uint128) bool {
+	transfers, err := s.tbClient.LookupTransfers(ctx, []tb_types.Uint128{transferID})
+	return err == nil && len(transfers) > 0
+}
+
+// settlement is what settling a case did in TigerBeetle
+type settlement struct {
+	posted     bool  // This attempt posted a new transfer
+	writtenOff int64 // Part of a provisional credit written off to dispute losses
+}
+
+// settle releases the disputed amount according to the case's outcome
+//
+// A hold is posted (won) or voided (lost). A provisional credit is recovered into suspense from
+// the counterparty (won) or clawed back from the customer (lost). The settlement transfer IDs are
+// saved on the case before this is called, so a retry posts the same transfers and TigerBeetle
+// answers that they exist.
+func (s *Service) settle(ctx context.Context, dispute *models.Dispute) (settlement, error) {
+	reserveID, err := models.HexToUint128(dispute.PendingTransferID)
+	if err != nil {
+		return settlement{}, rejected(fmt.Errorf("invalid pending transfer ID: %w", err))
+	}
+	settlementID, err := models.HexToUint128(dispute.SettlementTransferID)
+	if err != nil {
+		return settlement{}, rejected(fmt.Errorf("invalid settlement transfer ID: %w", err))
+	}
+
+	if dispute.ProvisionalCredit {
+		return s.settleCredit(ctx, dispute, reserveID, settlementID)
+	}
+
+	// Post or void the pending transfer; both inherit accounts, ledger and code from it
+	flags := tb_types.TransferFlags{VoidPendingTransfer: true}
+	if dispute.Outcome == models.DisputeStatusWon {
+		flags = tb_types.TransferFlags{PostPendingTransfer: true}
+	}
+	results, err := s.tbClient.CreateTransfers(ctx, []tb_types.Transfer{
+		{
+			ID:        settlementID,
+			PendingID: reserveID,
+			Amount:    tb_types.ToUint128(uint64(dispute.Amount)),
+			Ledger:    tigerbeetle.LedgerUSD,
+			Code:      tigerbeetle.TransferCodeDisputeHold,
+			Flags:     flags.ToUint16(),
+		},
+	})
+	if err != nil {
+		// The case stays resolving and a retry posts the same transfer
+		return settlement{}, fmt.Errorf("failed to create transfer: %w", err)
+	}
+	if len(results) > 0 {
+		if results[0].Result == tb_types.TransferExists {
+			return settlement{}, nil // Posted by an earlier attempt
+		}
+		return settlement{}, rejected(fmt.Errorf("settlement failed with result code: %d", results[0].Result))
+	}
+
+	return settlement{posted: true}, nil
+}
+
+// settleCredit moves a provisional credit back into suspense
+// A win recovers it from the counterparty, a loss claws it back from the customer. A customer
+// account is debited with a balancing transfer, so it gives up at most its balance and a customer
+// who already spent the credit doesn't block the case; the shortfall is written off from dispute
+// losses. The bank's own accounts (the cash vault for withdrawals) cover the full amount.
+func (s *Service) settleCredit(ctx context.Context, dispute *models.Dispute, reserveID, settlementID tb_types.Uint128) (settlement, error) {
+	sourceAccountID := dispute.CreditAccountID
+	if dispute.Outcome == models.DisputeStatusWon {
+		var original models.Transaction
+		if err := s.db.WithContext(ctx).First(&original, "id = ?", dispute.TransactionID).Error; err != nil {
+			return settlement{}, rejected(fmt.Errorf("database error: %w", err))
+		}
+		sourceAccountID = original.CreditAccountID
+	}
+	balancing := !tigerbeetle.IsSystemAccount(sourceAccountID)
+
+	recovered, posted, err := s.recoverCredit(ctx, tb_types.Transfer{
+		ID:              settlementID,
+		DebitAccountID:  tb_types.ToUint128(sourceAccountID),
+		CreditAccountID: tb_types.ToUint128(dispute.HoldAccountID),
+		Amount:          tb_types.ToUint128(uint64(dispute.Amount)),
+		UserData128:     reserveID,
+		Ledger:          tigerbeetle.LedgerUSD,
+		Code:            tigerbeetle.TransferCodeDisputeRecover,
+		Flags:           tb_types.TransferFlags{BalancingDebit: balancing}.ToUint16(),
+	}, balancing)
+	if err != nil {
+		return settlement{}, err
+	}
+
+	shortfall := dispute.Amount - recovered
+	if shortfall <= 0 {
+		return settlement{posted: posted}, nil
+	}
+
+	// From here on the recovery is posted, so no error can put the case back under review
+	writeOffID, err := models.HexToUint128(dispute.WriteOffTransferID)
+	if err != nil {
+		return settlement{posted: posted}, fmt.Errorf("invalid write-off transfer ID: %w", err)
+	}
+	results, err := s.tbClient.CreateTransfers(ctx, []tb_types.Transfer{
+		{
+			ID:              writeOffID,
+			DebitAccountID:  s.tbClient.DisputeLossAccountID,
+			CreditAccountID: tb_types.ToUint128(dispute.HoldAccountID),
+			Amount:          tb_types.ToUint128(uint64(shortfall)),
+			UserData128:     reserveID,
+			Ledger:          tigerbeetle.LedgerUSD,
+			Code:            tigerbeetle.TransferCodeDisputeLoss,
+		},
+	})
+	if err != nil {
+		if !s.transferExists(ctx, writeOffID) {
+			return settlement{posted: posted}, fmt.Errorf("failed to create transfer: %w", err)
+		}
+	} else if len(results) > 0 {
+		if results[0].Result != tb_types.TransferExists {
+			return settlement{posted: posted}, fmt.Errorf("write-off failed with result code: %d", results[0].Result)
+		}
+	} else {
+		posted = true
+	}
+
+	return settlement{posted: posted, writtenOff: shortfall}, nil
+}
+
+// recoverCredit posts the recovery of a provisional credit and returns the amount it moved
+// A balancing recovery may move less than the credit, or nothing when the account is empty
+// (TigerBeetle answers ExceedsCredits, and IDAlreadyFailed when the same transfer is retried)
+func (s *Service) recoverCredit(ctx context.Context, transfer tb_types.Transfer, balancing bool) (int64, bool, error) {
+	posted := true
+	results, err := s.tbClient.CreateTransfers(ctx, []tb_types.Transfer{transfer})
+	if err != nil {
+		// The request may have reached TigerBeetle - only a lookup can tell
+		recovered, lookupErr := s.transferAmount(ctx, transfer.ID)
+		if lookupErr != nil {
+			return 0, false, fmt.Errorf("failed to create transfer: %w", err)
+		}
+		return recovered, false, nil
+	}
+	if len(results) > 0 {
+		switch result := results[0].Result; {
+		case result == tb_types.TransferExists:
+			posted = false // Posted by an earlier attempt
+		case balancing && (result == tb_types.TransferExceedsCredits || result == tb_types.TransferIDAlreadyFailed):
+			return 0, false, nil
+		default:
+			return 0, false, rejected(fmt.Errorf("recovery failed with result code: %d", result))
+		}
+	}
+
+	// The amount a balancing transfer moved is only known from the stored transfer
+	recovered, err := s.transferAmount(ctx, transfer.ID)
+	if err != nil {
+		return 0, posted, err
+	}
+	return recovered, posted, nil
+}
+
+// transferAmount looks up the amount of a posted transfer
+func (s *Service) transferAmount(ctx context.Context, transferID tb_types.Uint128) (int64, error) {
+	transfers, err := s.tbClient.LookupTransfers(ctx, []tb_types.Uint128{transferID})
+	if err != nil {
+		return 0, fmt.Errorf("failed to look up transfer: %w", err)
+	}
+	if len(transfers) == 0 {
+		return 0, fmt.Errorf("transfer not found")
+	}
+	amount := transfers[0].Amount.BigInt()
+	return amount.Int64(), nil
+}
+
+// suspenseAccountID returns the suspense account as a PostgreSQL account ID
+func (s *Service) suspenseAccountID() uint64 {
+	suspense := s.tbClient.SuspenseAccountID.BigInt()
+	return suspense.Uint64()
+}
+
+// heldAmount sums the amounts reserved by disputes on a transaction
+func heldAmount(tx *gorm.DB, transactionID uuid.UUID) (int64, error) {
+	var held int64
+	if err := tx.Model(&models.Dispute{}).
+		Where("transaction_id = ? AND status IN ?", transactionID, models.DisputeHoldingStatuses).
+		Select("COALESCE(SUM(amount), 0)").
+		Scan(&held).Error; err != nil {
+		return 0, fmt.Errorf("failed to sum active disputes: %w", err)
+	}
+	return held, nil
+}
+
+// HeldAmount returns the amount of a transaction reserved by disputes
+func (s *Service) HeldAmount(transactionID uuid.UUID) (int64, error) {
+	return heldAmount(s.db, transactionID)
+}
+
+// StartReview moves an opened case to under_review and assigns it to an admin
+func (s *Service) StartReview(ctx context.Context, disputeID, adminID string) (*models.Dispute, error) {
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	var dispute models.Dispute
+	err = s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockDispute(tx, disputeID, &dispute); err != nil {
+			return err
+		}
+		if dispute.Status != models.DisputeStatusOpened {
+			return fmt.Errorf("invalid dispute state: case is %s", dispute.Status)
+		}
+
+		dispute.Status = models.DisputeStatusUnderReview
+		dispute.AssignedTo = &adminUUID
+		return tx.Model(&dispute).Updates(map[string]interface{}{
+			"status":      dispute.Status,
+			"assigned_to": dispute.AssignedTo,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "dispute under review", "dispute_id", dispute.ID, "admin_id", adminID)
+	return &dispute, nil
+}
+
+// Resolve closes a case and settles the ledger: a win posts the hold (or recovers the provisional
+// credit from the counterparty) and records a reversal on the original transaction, a loss voids
+// the hold (or claws the provisional credit back from the customer). What a customer's balance
+// can't cover of a provisional credit is written off to dispute losses and recorded on the case.
+//
+// Resolution follows the same steps as Open:
+//  1. with the case locked, save the outcome and the settlement transfer ID and move the case
+//     to resolving, then commit
+//  2. post the settlement in TigerBeetle
+//  3. close the case as won or lost, recording the reversal of a win
+//
+// If TigerBeetle rejects the settlement the case goes back to review. If the outcome is unknown
+// or the case can't be closed it stays resolving: resolving it again with the same outcome
+// retries the same transfer and closes the case.
+func (s *Service) Resolve(ctx context.Context, disputeID, adminID string, outcome models.DisputeStatus, note string) (*models.Dispute, error) {
+	if outcome != models.DisputeStatusWon && outcome != models.DisputeStatusLost {
+		return nil, fmt.Errorf("invalid outcome: %s", outcome)
+	}
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, fmt.Errorf("resolution note is required")
+	}
+	adminUUID, err := uuid.Parse(adminID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	dispute, err := s.beginResolution(ctx, disputeID, adminUUID, outcome, note)
+	if err != nil {
+		return nil, err
+	}
+
+	settled, err := s.settle(ctx, dispute)
+	if err != nil {
+		if releasable(err) {
+			s.cancelResolution(ctx, dispute)
+		} else {
+			logger.ErrorContext(ctx, "dispute settlement outcome unknown, case left resolving",
+				"dispute_id", dispute.ID, "outcome", outcome, "settlement_transfer_id", dispute.SettlementTransferID,
+				"write_off_transfer_id", dispute.WriteOffTransferID, "error", err)
+		}
+		return nil, err
+	}
+
+	if err := s.completeResolution(ctx, dispute, settled.writtenOff); err != nil {
+		if settled.posted {
+			logger.ErrorContext(ctx, "dispute settled in TigerBeetle but failed to update PostgreSQL, case left resolving",
+				"dispute_id", dispute.ID, "outcome", outcome, "settlement_transfer_id", dispute.SettlementTransferID, "error", err)
+		}
+		return nil, err
+	}
+
+	logger.InfoContext(ctx, "dispute resolved", "dispute_id", dispute.ID, "outcome", outcome, "admin_id", adminID,
+		"amount", dispute.Amount, "written_off", dispute.WrittenOff)
+	s.notifications.Dispute(dispute)
+	return dispute, nil
+}
+
+// beginResolution moves an active case to resolving with its outcome and settlement transfer ID
+// A case already resolving with the same outcome is returned as is, so its settlement is retried
+func (s *Service) beginResolution(ctx context.Context, disputeID string, adminID uuid.UUID, outcome models.DisputeStatus, note string) (*models.Dispute, error) {
+	var dispute models.Dispute
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockDispute(tx, disputeID, &dispute); err != nil {
+			return err
+		}
+		if dispute.Status == models.DisputeStatusResolving {
+			if dispute.Outcome != outcome {
+				return fmt.Errorf("invalid dispute state: case is being resolved as %s", dispute.Outcome)
+			}
+			return nil
+		}
+		if !dispute.Status.IsActive() {
+			return fmt.Errorf("invalid dispute state: case is already %s", dispute.Status)
+		}
+
+		dispute.Status = models.DisputeStatusResolving
+		dispute.Outcome = outcome
+		dispute.ResolvedBy = &adminID
+		dispute.ResolutionNote = note
+		dispute.SettlementTransferID = models.Uint128ToHex(tb_types.ID())
+		if dispute.ProvisionalCredit {
+			dispute.WriteOffTransferID = models.Uint128ToHex(tb_types.ID())
+		}
+
+		return tx.Model(&dispute).Updates(map[string]interface{}{
+			"status":                 dispute.Status,
+			"outcome":                dispute.Outcome,
+			"resolved_by":            dispute.ResolvedBy,
+			"resolution_note":        dispute.ResolutionNote,
+			"settlement_transfer_id": dispute.SettlementTransferID,
+			"write_off_transfer_id":  dispute.WriteOffTransferID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &dispute, nil
+}
+
+// refundTransferID returns the transfer that credited the customer on a win: the posted hold,
+// or the provisional credit itself
+func refundTransferID(dispute *models.Dispute) string {
+	if dispute.ProvisionalCredit {
+		return dispute.PendingTransferID
+	}
+	return dispute.SettlementTransferID
+}
+
+// cancelResolution puts a case whose settlement TigerBeetle rejected back under review
+// The settlement IDs are dropped with the outcome: TigerBeetle doesn't accept a rejected ID again
+func (s *Service) cancelResolution(ctx context.Context, dispute *models.Dispute) {
+	previous := models.DisputeStatusOpened
+	if dispute.AssignedTo != nil {
+		previous = models.DisputeStatusUnderReview
+	}
+
+	if err := s.db.WithContext(ctx).Model(&models.Dispute{}).
+		Where("id = ? AND status = ?", dispute.ID, models.DisputeStatusResolving).
+		Updates(map[string]interface{}{
+			"status":                 previous,
+			"outcome":                "",
+			"resolved_by":            nil,
+			"resolution_note":        "",
+			"settlement_transfer_id": "",
+			"write_off_transfer_id":  "",
+		}).Error; err != nil {
+		logger.ErrorContext(ctx, "failed to reopen dispute after rejected settlement",
+			"dispute_id", dispute.ID, "settlement_transfer_id", dispute.SettlementTransferID, "error", err)
+	}
+}
+
+// completeResolution closes a resolving case as won or lost with the amount written off
+// A win records the reversal on the original transaction in the same database transaction. The
+// settlement is posted by then, so the write ignores cancellation of ctx
+func (s *Service) completeResolution(ctx context.Context, dispute *models.Dispute, writtenOff int64) error {
+	return s.db.WithContext(context.WithoutCancel(ctx)).Transaction(func(tx *gorm.DB) error {
+		if err := lockDispute(tx, dispute.ID.String(), dispute); err != nil {
+			return err
+		}
+		if dispute.Status != models.DisputeStatusResolving {
+			return fmt.Errorf("invalid dispute state: case is already %s", dispute.Status)
+		}
+
+		var original models.Transaction
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&original, "id = ?", dispute.TransactionID).Error; err != nil {
+			return fmt.Errorf("database error: %w", err)
+		}
+
+		now := time.Now()
+		dispute.Status = dispute.Outcome
+		dispute.ResolvedAt = &now
+		dispute.WrittenOff = writtenOff
+
+		if dispute.Outcome == models.DisputeStatusWon {
+			refundID, err := models.HexToUint128(refundTransferID(dispute))
+			if err != nil {
+				return fmt.Errorf("invalid refund transfer ID: %w", err)
+			}
+			reversal, err := recordReversal(tx, &original, dispute, refundID, dispute.ResolvedBy.String())
+			if err != nil {
+				return err
+			}
+			dispute.ReversalTransactionID = &reversal.ID
+		}
+
+		return tx.Model(dispute).Updates(map[string]interface{}{
+			"status":                  dispute.Status,
+			"resolved_at":             dispute.ResolvedAt,
+			"written_off":             dispute.WrittenOff,
+			"reversal_transaction_id": dispute.ReversalTransactionID,
+		}).Error
+	})
+}
+
+// recordReversal links a won dispute to the original transaction as a reversal
+func recordReversal(tx *gorm.DB, original *models.Transaction, dispute *models.Dispute, refundID tb_types.Uint128, adminID string) (*models.Transaction, error) {
+	metadata, err := json.Marshal(map[string]interface{}{
+		"reversal_of":                      original.ID,
+		"original_tigerbeetle_transfer_id": original.TigerBeetleTransferID,
+		"original_type":                    original.Type,
+		"partial":                          dispute.Amount < original.Amount,
+		"reason":                           fmt.Sprintf("Dispute %s won (%s)", dispute.ID, dispute.ReasonCode),
+		"dispute_id":                       dispute.ID,
+		"initiated_by":                     adminID,
+		"initiated_by_role":                models.RoleAdmin,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode reversal metadata: %w", err)
+	}
+
+	reversal := &models.Transaction{
+		UserID:          original.UserID,
+		Type:            models.TransactionTypeReversal,
+		Amount:          dispute.Amount,
+		DebitAccountID:  dispute.HoldAccountID,
+		CreditAccountID: dispute.CreditAccountID,
+		Status:          models.TransactionStatusCompleted,
+		Description:     fmt.Sprintf("Dispute refund of %d cents for %s %s", dispute.Amount, original.Type, original.ID),
+		Metadata:        datatypes.JSON(metadata),
+		ReversalOfID:    &original.ID,
+	}
+	reversal.SetTigerBeetleTransferID(refundID)
+
+	// When the counterparty customer was debited, show the refund in both histories
+	if !dispute.ProvisionalCredit && original.RecipientUserID != nil {
+		sender := original.UserID
+		reversal.UserID = *original.RecipientUserID
+		reversal.RecipientUserID = &sender
+	}
+
+	if err := tx.Create(reversal).Error; err != nil {
+		return nil, fmt.Errorf("failed to create reversal record: %w", err)
+	}
+
+	if err := tx.Model(original).
+		Update("reversed_amount", gorm.Expr("reversed_amount + ?", dispute.Amount)).Error; err != nil {
+		return nil, fmt.Errorf("failed to update original transaction: %w", err)
+	}
+
+	return reversal, nil
+}
+
+// lockDispute loads a dispute row FOR UPDATE
+func lockDispute(tx *gorm.DB, disputeID string, dispute *models.Dispute) error {
+	id, err := uuid.Parse(disputeID)
+	if err != nil {
+		return fmt.Errorf("dispute not found")
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(dispute, "id = ?", id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("dispute not found")
+		}
+		return fmt.Errorf("database error: %w", err)
+	}
+	return nil
+}
+
+// GetForUser returns one of the customer's disputes
+func (s *Service) GetForUser(disputeID, userID string) (*models.Dispute, error) {
+	var dispute models.Dispute
+	id, err := uuid.Parse(disputeID)
+	if err != nil {
+		return nil, fmt.Errorf("dispute not found")
+	}
+	if err := s.db.Preload("Transaction").
+		First(&dispute, "id = ? AND user_id = ?", id, userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("dispute not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &dispute, nil
+}
+
+// ListForUser returns the customer's disputes, newest first
+func (s *Service) ListForUser(userID string) ([]models.Dispute, error) {
+	var disputes []models.Dispute
+	if err := s.db.Preload("Transaction").
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&disputes).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve disputes: %w", err)
+	}
+	return disputes, nil
+}
+
+// Queue returns disputes for the admin queue, oldest first
+// An empty status returns every case still holding funds, including cases left opening or resolving
+func (s *Service) Queue(status models.DisputeStatus, page, limit int) ([]models.Dispute, int64, error) {
+	query := s.db.Model(&models.Dispute{})
+	if status == "" {
+		query = query.Where("status IN ?", models.DisputeHoldingStatuses)
+	} else {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count disputes: %w", err)
+	}
+
+	var disputes []models.Dispute
+	if err := query.Preload("Transaction").
+		Order("created_at ASC").
+		Limit(limit).
+		Offset((page - 1) * limit).
+		Find(&disputes).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve disputes: %w", err)
+	}
+
+	return disputes, total, nil
+}
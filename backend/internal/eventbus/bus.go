@@ -0,0 +1,194 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/logging"
+)
+
+var logger = logging.For("eventbus")
+
+const (
+	// queueSize is how many events a consumer can lag behind before publishers wait for it
+	queueSize = 1024
+
+	// writeAttempts is how often a sink is tried before an event is given up for that sink
+	writeAttempts = 3
+
+	// retryDelay is the pause before the second attempt, doubled for the next
+	retryDelay = 500 * time.Millisecond
+)
+
+// Envelope is an event with the metadata every sink records
+// ID is unique per event, so consumers can deduplicate redelivered events
+type Envelope struct {
+	ID         uuid.UUID       `json:"id"`
+	Type       string          `json:"type"`
+	Key        string          `json:"key"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+// Sink forwards events out of the process (a file, a message broker, an outbox table)
+type Sink interface {
+	// Name identifies the sink in logs
+	Name() string
+
+	// Write stores or sends one event; it is retried on error
+	Write(envelope Envelope) error
+
+	// Close flushes and releases the sink
+	Close() error
+}
+
+// Bus fans domain events out to in-process subscribers and sinks
+// Every consumer has its own queue and goroutine, so events reach each one in publish order
+// and a slow consumer doesn't delay the others. When a queue is full, Publish waits rather
+// than dropping the event.
+type Bus struct {
+	mu        sync.RWMutex
+	consumers []*consumer
+	closed    bool
+	wg        sync.WaitGroup
+}
+
+// consumer is a subscriber or sink with its queue
+type consumer struct {
+	name    string
+	queue   chan delivery
+	deliver func(delivery) error
+	close   func() error
+}
+
+// delivery is one event on its way to a consumer
+type delivery struct {
+	event    Event
+	envelope Envelope
+}
+
+// NewBus creates a bus without consumers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// AddSink forwards every event published from now on to sink
+func (b *Bus) AddSink(sink Sink) {
+	b.add(&consumer{
+		name:    sink.Name(),
+		deliver: func(d delivery) error { return sink.Write(d.envelope) },
+		close:   sink.Close,
+	})
+	logger.Info("domain event sink enabled", "sink", sink.Name())
+}
+
+// Subscribe calls handler with every event published from now on, on the subscriber's own goroutine
+// Handlers switch on the concrete event type, e.g. case eventbus.TransferPosted
+func (b *Bus) Subscribe(name string, handler func(Event)) {
+	b.add(&consumer{
+		name: name,
+		deliver: func(d delivery) error {
+			handler(d.event)
+			return nil
+		},
+	})
+}
+
+// Publish hands an event to every consumer
+// Call it once the change the event describes is committed. A nil bus publishes nothing.
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		logger.Warn("failed to encode domain event", "type", event.EventType(), "error", err)
+		return
+	}
+	d := delivery{
+		event: event,
+		envelope: Envelope{
+			ID:         uuid.New(),
+			Type:       event.EventType(),
+			Key:        event.Key(),
+			OccurredAt: time.Now().UTC(),
+			Data:       data,
+		},
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		logger.Warn("domain event published after shutdown, dropped", "type", d.envelope.Type)
+		return
+	}
+	for _, c := range b.consumers {
+		c.queue <- d
+	}
+}
+
+// Close stops accepting events, waits until every queued event is delivered and closes the sinks
+func (b *Bus) Close() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		b.mu.Unlock()
+		return
+	}
+	b.closed = true
+	for _, c := range b.consumers {
+		close(c.queue)
+	}
+	b.mu.Unlock()
+
+	b.wg.Wait()
+	for _, c := range b.consumers {
+		if c.close == nil {
+			continue
+		}
+		if err := c.close(); err != nil {
+			logger.Warn("failed to close event sink", "sink", c.name, "error", err)
+		}
+	}
+}
+
+// add registers a consumer and starts its goroutine
+func (b *Bus) add(c *consumer) {
+	c.queue = make(chan delivery, queueSize)
+
+	b.mu.Lock()
+	b.consumers = append(b.consumers, c)
+	b.mu.Unlock()
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		for d := range c.queue {
+			c.run(d)
+		}
+	}()
+}
+
+// run delivers one event, retrying with backoff; an event that still fails is logged and skipped
+func (c *consumer) run(d delivery) {
+	delay := retryDelay
+	for attempt := 1; ; attempt++ {
+		err := c.deliver(d)
+		if err == nil {
+			return
+		}
+		if attempt == writeAttempts {
+			logger.Error("domain event lost", "event_id", d.envelope.ID, "type", d.envelope.Type, "sink", c.name,
+				"attempts", attempt, "error", err)
+			return
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
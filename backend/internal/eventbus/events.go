@@ -0,0 +1,96 @@
+package eventbus
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Event types
+const (
+	TypeUserRegistered = "user.registered"
+	TypeDepositPosted  = "deposit.posted"
+	TypeTransferPosted = "transfer.posted"
+	TypeLoginFailed    = "login.failed"
+)
+
+// Event is a domain event: something that has happened and been committed
+// Events are published after the change they describe is durable (in TigerBeetle and/or
+// PostgreSQL), so consumers never see an event for something that was rolled back
+type Event interface {
+	// EventType names the event (e.g. "transfer.posted")
+	EventType() string
+
+	// Key identifies what the event is about; partitioned sinks keep events with the same key in order
+	Key() string
+}
+
+// UserRegistered is published when a new customer account has been opened
+type UserRegistered struct {
+	UserID               uuid.UUID `json:"user_id"`
+	TigerBeetleAccountID uint64    `json:"tigerbeetle_account_id"`
+	AccountType          string    `json:"account_type"`
+	RegisteredAt         time.Time `json:"registered_at"`
+}
+
+// EventType implements Event
+func (UserRegistered) EventType() string { return TypeUserRegistered }
+
+// Key implements Event
+func (e UserRegistered) Key() string { return e.UserID.String() }
+
+// DepositPosted is published when a deposit has been posted to the ledger
+type DepositPosted struct {
+	TransactionID uuid.UUID `json:"transaction_id"` // Nil if the audit record could not be written
+	TransferID    string    `json:"transfer_id"`    // TigerBeetle transfer ID (hex)
+	UserID        uuid.UUID `json:"user_id"`
+	AccountID     uint64    `json:"account_id"`
+	Amount        int64     `json:"amount"` // Cents
+	Fee           int64     `json:"fee"`    // Cents
+	PostedAt      time.Time `json:"posted_at"`
+}
+
+// EventType implements Event
+func (DepositPosted) EventType() string { return TypeDepositPosted }
+
+// Key implements Event
+func (e DepositPosted) Key() string { return e.UserID.String() }
+
+// TransferPosted is published when a transfer between two accounts has been posted to the ledger
+type TransferPosted struct {
+	TransactionID uuid.UUID  `json:"transaction_id"` // Nil if the audit record could not be written
+	TransferID    string     `json:"transfer_id"`    // TigerBeetle transfer ID (hex)
+	FromUserID    uuid.UUID  `json:"from_user_id"`
+	ToUserID      *uuid.UUID `json:"to_user_id,omitempty"` // Nil for accounts without a user
+	FromAccountID uint64     `json:"from_account_id"`
+	ToAccountID   uint64     `json:"to_account_id"`
+	Amount        int64      `json:"amount"` // Cents
+	Fee           int64      `json:"fee"`    // Cents, paid by the sender
+	PostedAt      time.Time  `json:"posted_at"`
+}
+
+// EventType implements Event
+func (TransferPosted) EventType() string { return TypeTransferPosted }
+
+// Key implements Event
+func (e TransferPosted) Key() string { return e.FromUserID.String() }
+
+// LoginFailed is published for every rejected login attempt
+// The attempted email is left out; UserID is set when it belongs to an account
+type LoginFailed struct {
+	UserID    *uuid.UUID `json:"user_id,omitempty"`
+	IPAddress string     `json:"ip_address"`
+	Reason    string     `json:"reason"`
+	FailedAt  time.Time  `json:"failed_at"`
+}
+
+// EventType implements Event
+func (LoginFailed) EventType() string { return TypeLoginFailed }
+
+// Key implements Event
+func (e LoginFailed) Key() string {
+	if e.UserID != nil {
+		return e.UserID.String()
+	}
+	return e.IPAddress
+}
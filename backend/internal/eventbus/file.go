@@ -0,0 +1,45 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// FileSink appends events to a file as JSON lines, one envelope per line
+// Suited to log shippers (Vector, Fluent Bit) or replaying activity locally
+type FileSink struct {
+	file *os.File
+}
+
+// NewFileSink opens (or creates) the file at path for appending
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+	return &FileSink{file: file}, nil
+}
+
+// Name implements Sink
+func (s *FileSink) Name() string {
+	return "file"
+}
+
+// Write implements Sink
+// Each line is written with a single call, so lines are never interleaved
+func (s *FileSink) Write(envelope Envelope) error {
+	line, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+	if _, err := s.file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to write event file: %w", err)
+	}
+	return nil
+}
+
+// Close implements Sink
+func (s *FileSink) Close() error {
+	return s.file.Close()
+}
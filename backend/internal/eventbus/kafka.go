@@ -0,0 +1,107 @@
+package eventbus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// kafkaRequestTimeout bounds a single produce request
+const kafkaRequestTimeout = 10 * time.Second
+
+// KafkaRESTSink produces events to a Kafka topic through an HTTP gateway speaking the Kafka REST
+// Proxy v2 API (Confluent REST Proxy, Redpanda's HTTP proxy)
+// Records are keyed with the event key, so events about the same user land on the same
+// partition and keep their order.
+type KafkaRESTSink struct {
+	endpoint string
+	client   *http.Client
+}
+
+// kafkaRecords is the body of a produce request
+type kafkaRecords struct {
+	Records []kafkaRecord `json:"records"`
+}
+
+// kafkaRecord is one record of a produce request
+type kafkaRecord struct {
+	Key   string   `json:"key"`
+	Value Envelope `json:"value"`
+}
+
+// kafkaProduceResponse reports the outcome of each record
+type kafkaProduceResponse struct {
+	Offsets []struct {
+		ErrorCode *int   `json:"error_code"`
+		Error     string `json:"error"`
+	} `json:"offsets"`
+}
+
+// NewKafkaRESTSink creates a sink producing to topic through the REST proxy at baseURL
+func NewKafkaRESTSink(baseURL, topic string) (*KafkaRESTSink, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Kafka REST proxy url %q", baseURL)
+	}
+	if topic == "" {
+		return nil, fmt.Errorf("a Kafka topic is required")
+	}
+
+	return &KafkaRESTSink{
+		endpoint: strings.TrimSuffix(baseURL, "/") + "/topics/" + url.PathEscape(topic),
+		client:   &http.Client{Timeout: kafkaRequestTimeout},
+	}, nil
+}
+
+// Name implements Sink
+func (s *KafkaRESTSink) Name() string {
+	return "kafka"
+}
+
+// Write implements Sink
+func (s *KafkaRESTSink) Write(envelope Envelope) error {
+	body, err := json.Marshal(kafkaRecords{Records: []kafkaRecord{{Key: envelope.Key, Value: envelope}}})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/vnd.kafka.json.v2+json")
+	req.Header.Set("Accept", "application/vnd.kafka.v2+json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to produce to Kafka: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("kafka REST proxy responded with HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	// The proxy answers 200 even when a record was rejected by the broker
+	var produced kafkaProduceResponse
+	if err := json.Unmarshal(respBody, &produced); err == nil {
+		for _, offset := range produced.Offsets {
+			if offset.ErrorCode != nil {
+				return fmt.Errorf("kafka rejected the event: %s (code %d)", offset.Error, *offset.ErrorCode)
+			}
+		}
+	}
+	return nil
+}
+
+// Close implements Sink
+func (s *KafkaRESTSink) Close() error {
+	s.client.CloseIdleConnections()
+	return nil
+}
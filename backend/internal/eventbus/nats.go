@@ -0,0 +1,90 @@
+package eventbus
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+const (
+	// natsDialTimeout bounds connecting to a NATS server
+	natsDialTimeout = 5 * time.Second
+
+	// natsFlushTimeout bounds the wait for the server to acknowledge a published event
+	natsFlushTimeout = 5 * time.Second
+)
+
+// NATSSink publishes events to NATS (core NATS or a JetStream stream bound to the subjects)
+// Events go to "<prefix>.<type>", e.g. "banking.events.transfer.posted". Every publish is
+// followed by a flush, so a returned nil means the server has received the event.
+type NATSSink struct {
+	conn   *nats.Conn
+	prefix string
+}
+
+// NewNATSSink creates a sink for the servers at url: nats://[user:password@]host:port, tls://
+// for TLS, several servers separated by commas
+// The client connects in the background and reconnects after errors; while it is disconnected
+// writes fail instead of being buffered, so they are retried from the outbox
+func NewNATSSink(url, subjectPrefix string) (*NATSSink, error) {
+	if subjectPrefix == "" || strings.ContainsAny(subjectPrefix, " \t\r\n*>") {
+		return nil, fmt.Errorf("invalid NATS subject prefix %q", subjectPrefix)
+	}
+
+	conn, err := nats.Connect(url,
+		nats.Name("hlabs-banking-events"),
+		nats.Timeout(natsDialTimeout),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ReconnectBufSize(-1),
+		nats.ConnectHandler(func(conn *nats.Conn) {
+			logger.Info("connected to NATS", "server", conn.ConnectedUrlRedacted())
+		}),
+		nats.ReconnectHandler(func(conn *nats.Conn) {
+			logger.Info("reconnected to NATS", "server", conn.ConnectedUrlRedacted())
+		}),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				logger.Warn("disconnected from NATS", "error", err)
+			}
+		}),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid NATS url: %w", err)
+	}
+
+	return &NATSSink{
+		conn:   conn,
+		prefix: subjectPrefix,
+	}, nil
+}
+
+// Name implements Sink
+func (s *NATSSink) Name() string {
+	return "nats"
+}
+
+// Write implements Sink
+func (s *NATSSink) Write(envelope Envelope) error {
+	payload, err := json.Marshal(envelope)
+	if err != nil {
+		return err
+	}
+
+	if err := s.conn.Publish(s.prefix+"."+envelope.Type, payload); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	if err := s.conn.FlushTimeout(natsFlushTimeout); err != nil {
+		return fmt.Errorf("failed to publish to NATS: %w", err)
+	}
+	return nil
+}
+
+// Close implements Sink
+func (s *NATSSink) Close() error {
+	s.conn.Close()
+	return nil
+}
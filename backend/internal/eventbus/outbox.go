@@ -0,0 +1,87 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hlabs/banking-system/internal/models"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// outboxPurgeInterval is how often events past the retention are deleted
+const outboxPurgeInterval = time.Hour
+
+// OutboxSink records events in the event_outbox table
+// Consumers with database access (or a CDC connector such as Debezium) read the table in
+// position order. Rows are written in publish order per API instance; with several instances a
+// consumer should re-read the last few seconds of positions, as rows can commit out of order.
+type OutboxSink struct {
+	db        *gorm.DB
+	retention time.Duration
+}
+
+// NewOutboxSink creates an outbox sink; events older than retention are purged by RunCleanup
+func NewOutboxSink(db *gorm.DB, retention time.Duration) *OutboxSink {
+	return &OutboxSink{
+		db:        db,
+		retention: retention,
+	}
+}
+
+// Name implements Sink
+func (s *OutboxSink) Name() string {
+	return "outbox"
+}
+
+// Write implements Sink
+// Retried writes of the same event are ignored thanks to the unique event ID
+func (s *OutboxSink) Write(envelope Envelope) error {
+	row := models.OutboxEvent{
+		ID:         envelope.ID,
+		Type:       envelope.Type,
+		Key:        envelope.Key,
+		Payload:    datatypes.JSON(envelope.Data),
+		OccurredAt: envelope.OccurredAt,
+	}
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error; err != nil {
+		return fmt.Errorf("failed to write outbox event: %w", err)
+	}
+	return nil
+}
+
+// Close implements Sink
+func (s *OutboxSink) Close() error {
+	return nil
+}
+
+// RunCleanup purges events past the retention periodically until the context is cancelled
+func (s *OutboxSink) RunCleanup(ctx context.Context) {
+	ticker := time.NewTicker(outboxPurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.Purge(); err != nil {
+				logger.Warn("event outbox cleanup failed", "error", err)
+			}
+		}
+	}
+}
+
+// Purge deletes the events older than the retention
+func (s *OutboxSink) Purge() error {
+	result := s.db.Where("occurred_at < ?", time.Now().Add(-s.retention)).Delete(&models.OutboxEvent{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to purge outbox events: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		logger.Info("purged outbox events", "events", result.RowsAffected)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package fee
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Operation represents the kind of money movement a fee applies to
+type Operation string
+
+const (
+	OperationDeposit  Operation = "deposit"
+	OperationWithdraw Operation = "withdraw"
+	OperationTransfer Operation = "transfer"
+)
+
+// AnyAccountType matches every account type in a rule
+const AnyAccountType = "*"
+
+// Rule describes how the fee is computed for one operation, account type and amount band
+//
+// All amounts are in cents. The band is [MinAmount, MaxAmount); a MaxAmount of 0
+// means the band has no upper bound. The computed fee is Flat + Amount*PercentBps/10000,
+// then clamped to [MinFee, MaxFee] (a MaxFee of 0 means no cap).
+type Rule struct {
+	Operation   Operation `json:"operation"`
+	AccountType string    `json:"account_type"` // "savings", "checking", "investment" or "*"
+	MinAmount   int64     `json:"min_amount"`
+	MaxAmount   int64     `json:"max_amount"`
+	Flat        int64     `json:"flat"`
+	PercentBps  int64     `json:"percent_bps"` // Basis points (1% = 100)
+	MinFee      int64     `json:"min_fee"`
+	MaxFee      int64     `json:"max_fee"`
+}
+
+// Schedule is an ordered list of fee rules - the first matching rule wins
+type Schedule struct {
+	Rules []Rule `json:"rules"`
+}
+
+// Quote is the result of pricing an operation against the schedule
+type Quote struct {
+	Operation   Operation `json:"operation"`
+	AccountType string    `json:"account_type"`
+	Amount      int64     `json:"amount"` // Principal in cents
+	Fee         int64     `json:"fee"`    // Fee in cents
+	Total       int64     `json:"total"`  // Net effect on the customer's account (see Calculate)
+}
+
+// DefaultSchedule returns the fee schedule used when no schedule file is configured
+func DefaultSchedule() *Schedule {
+	return &Schedule{
+		Rules: []Rule{
+			// Withdrawals: flat fee per account type, percentage for investment accounts
+			{Operation: OperationWithdraw, AccountType: "checking", Flat: 100},
+			{Operation: OperationWithdraw, AccountType: "savings", Flat: 250},
+			{Operation: OperationWithdraw, AccountType: "investment", PercentBps: 100, MinFee: 500, MaxFee: 2500},
+
+			// Transfers: small transfers are free, larger ones pay a capped percentage
+			{Operation: OperationTransfer, AccountType: AnyAccountType, MinAmount: 0, MaxAmount: 1000},
+			{Operation: OperationTransfer, AccountType: "savings", MinAmount: 1000, PercentBps: 50, MinFee: 50, MaxFee: 1000},
+			{Operation: OperationTransfer, AccountType: AnyAccountType, MinAmount: 1000, PercentBps: 25, MinFee: 25, MaxFee: 500},
+		},
+	}
+}
+
+// LoadSchedule reads a fee schedule from a JSON file
+// An empty path returns the default schedule
+func LoadSchedule(path string) (*Schedule, error) {
+	if path == "" {
+		return DefaultSchedule(), nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read fee schedule: %w", err)
+	}
+
+	var schedule Schedule
+	if err := json.Unmarshal(data, &schedule); err != nil {
+		return nil, fmt.Errorf("failed to parse fee schedule: %w", err)
+	}
+
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &schedule, nil
+}
+
+// Validate checks that every rule in the schedule is well-formed
+func (s *Schedule) Validate() error {
+	for i, rule := range s.Rules {
+		switch rule.Operation {
+		case OperationDeposit, OperationWithdraw, OperationTransfer:
+		default:
+			return fmt.Errorf("fee rule %d: unknown operation %q", i, rule.Operation)
+		}
+		if rule.Flat < 0 || rule.PercentBps < 0 || rule.MinFee < 0 || rule.MaxFee < 0 {
+			return fmt.Errorf("fee rule %d: fee values must not be negative", i)
+		}
+		if rule.MaxAmount != 0 && rule.MaxAmount <= rule.MinAmount {
+			return fmt.Errorf("fee rule %d: max_amount must be greater than min_amount", i)
+		}
+		if rule.MaxFee != 0 && rule.MaxFee < rule.MinFee {
+			return fmt.Errorf("fee rule %d: max_fee must not be lower than min_fee", i)
+		}
+	}
+	return nil
+}
+
+// Calculate prices an operation for the given account type and amount (in cents)
+// Operations without a matching rule are free
+//
+// Withdrawal and transfer fees are charged on top of the amount, so Total is what
+// leaves the customer's account (Amount + Fee). Deposit fees are taken out of the
+// deposited funds, so Total is what is credited (Amount - Fee) and the fee is
+// capped at the deposited amount.
+func (s *Schedule) Calculate(op Operation, accountType string, amount int64) Quote {
+	quote := Quote{
+		Operation:   op,
+		AccountType: accountType,
+		Amount:      amount,
+		Total:       amount,
+	}
+
+	rule := s.match(op, accountType, amount)
+	if rule == nil {
+		return quote
+	}
+
+	fee := rule.Flat + amount*rule.PercentBps/10000
+	if fee < rule.MinFee {
+		fee = rule.MinFee
+	}
+	if rule.MaxFee > 0 && fee > rule.MaxFee {
+		fee = rule.MaxFee
+	}
+
+	// A rule that computes nothing (e.g. a "free band") charges nothing,
+	// even if it has a minimum configured
+	if rule.Flat == 0 && rule.PercentBps == 0 {
+		fee = 0
+	}
+
+	if op == OperationDeposit {
+		fee = min(fee, amount)
+		quote.Fee = fee
+		quote.Total = amount - fee
+		return quote
+	}
+
+	quote.Fee = fee
+	quote.Total = amount + fee
+	return quote
+}
+
+// match returns the first rule that applies to the operation, or nil
+func (s *Schedule) match(op Operation, accountType string, amount int64) *Rule {
+	for i := range s.Rules {
+		rule := &s.Rules[i]
+		if rule.Operation != op {
+			continue
+		}
+		if rule.AccountType != AnyAccountType && rule.AccountType != "" && rule.AccountType != accountType {
+			continue
+		}
+		if amount < rule.MinAmount {
+			continue
+		}
+		if rule.MaxAmount > 0 && amount >= rule.MaxAmount {
+			continue
+		}
+		return rule
+	}
+	return nil
+}
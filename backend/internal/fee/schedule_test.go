@@ -0,0 +1,146 @@
+package fee
+
+import "testing"
+
+func TestCalculateDefaultSchedule(t *testing.T) {
+	schedule := DefaultSchedule()
+
+	tests := []struct {
+		name        string
+		op          Operation
+		accountType string
+		amount      int64
+		fee         int64
+		total       int64
+	}{
+		{"checking withdrawal pays flat fee", OperationWithdraw, "checking", 10000, 100, 10100},
+		{"savings withdrawal pays flat fee", OperationWithdraw, "savings", 10000, 250, 10250},
+		{"investment withdrawal below minimum fee", OperationWithdraw, "investment", 10000, 500, 10500},
+		{"investment withdrawal percentage", OperationWithdraw, "investment", 100000, 1000, 101000},
+		{"investment withdrawal capped", OperationWithdraw, "investment", 1000000, 2500, 1002500},
+		{"withdrawal from unknown account type is free", OperationWithdraw, "business", 10000, 0, 10000},
+		{"small transfer is free despite savings rule", OperationTransfer, "savings", 999, 0, 999},
+		{"savings transfer at band boundary", OperationTransfer, "savings", 1000, 50, 1050},
+		{"savings transfer percentage", OperationTransfer, "savings", 50000, 250, 50250},
+		{"savings transfer capped", OperationTransfer, "savings", 1000000, 1000, 1001000},
+		{"checking transfer falls through to wildcard", OperationTransfer, "checking", 50000, 125, 50125},
+		{"checking transfer minimum fee", OperationTransfer, "checking", 1000, 25, 1025},
+		{"checking transfer capped", OperationTransfer, "checking", 1000000, 500, 1000500},
+		{"deposit without rule is free", OperationDeposit, "checking", 10000, 0, 10000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := schedule.Calculate(tt.op, tt.accountType, tt.amount)
+			if quote.Fee != tt.fee || quote.Total != tt.total {
+				t.Errorf("Calculate(%s, %s, %d) = fee %d, total %d; want fee %d, total %d",
+					tt.op, tt.accountType, tt.amount, quote.Fee, quote.Total, tt.fee, tt.total)
+			}
+			if quote.Operation != tt.op || quote.AccountType != tt.accountType || quote.Amount != tt.amount {
+				t.Errorf("Calculate(%s, %s, %d) echoed %+v", tt.op, tt.accountType, tt.amount, quote)
+			}
+		})
+	}
+}
+
+func TestCalculateFirstMatchWins(t *testing.T) {
+	schedule := &Schedule{Rules: []Rule{
+		{Operation: OperationTransfer, AccountType: AnyAccountType, Flat: 10},
+		{Operation: OperationTransfer, AccountType: "checking", Flat: 99},
+	}}
+
+	if quote := schedule.Calculate(OperationTransfer, "checking", 5000); quote.Fee != 10 {
+		t.Errorf("fee = %d, want 10 from the first rule", quote.Fee)
+	}
+
+	// An empty account type matches like the wildcard
+	schedule.Rules[0].AccountType = ""
+	if quote := schedule.Calculate(OperationTransfer, "checking", 5000); quote.Fee != 10 {
+		t.Errorf("fee with empty account type = %d, want 10", quote.Fee)
+	}
+
+	// Rules for other operations and bands are skipped
+	schedule.Rules[0].Operation = OperationWithdraw
+	if quote := schedule.Calculate(OperationTransfer, "checking", 5000); quote.Fee != 99 {
+		t.Errorf("fee after first rule stops matching = %d, want 99", quote.Fee)
+	}
+}
+
+func TestCalculateBands(t *testing.T) {
+	schedule := &Schedule{Rules: []Rule{
+		{Operation: OperationWithdraw, MinAmount: 100, MaxAmount: 200, Flat: 1},
+		{Operation: OperationWithdraw, MinAmount: 200, Flat: 2},
+	}}
+
+	tests := []struct {
+		amount int64
+		fee    int64
+	}{
+		{99, 0},  // Below every band
+		{100, 1}, // MinAmount is inclusive
+		{199, 1},
+		{200, 2}, // MaxAmount is exclusive
+		{1 << 40, 2},
+	}
+	for _, tt := range tests {
+		if quote := schedule.Calculate(OperationWithdraw, "checking", tt.amount); quote.Fee != tt.fee {
+			t.Errorf("Calculate(withdraw, %d) fee = %d, want %d", tt.amount, quote.Fee, tt.fee)
+		}
+	}
+}
+
+func TestCalculateFreeBandIgnoresMinFee(t *testing.T) {
+	schedule := &Schedule{Rules: []Rule{
+		{Operation: OperationTransfer, MinFee: 50},
+	}}
+
+	quote := schedule.Calculate(OperationTransfer, "checking", 5000)
+	if quote.Fee != 0 || quote.Total != 5000 {
+		t.Errorf("free band quote = fee %d, total %d; want fee 0, total 5000", quote.Fee, quote.Total)
+	}
+}
+
+func TestCalculateDeposit(t *testing.T) {
+	schedule := &Schedule{Rules: []Rule{
+		{Operation: OperationDeposit, Flat: 300},
+	}}
+
+	tests := []struct {
+		name   string
+		amount int64
+		fee    int64
+		total  int64
+	}{
+		{"fee is taken out of the deposit", 10000, 300, 9700},
+		{"fee equal to the deposit", 300, 300, 0},
+		{"fee capped at the deposit", 200, 200, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			quote := schedule.Calculate(OperationDeposit, "checking", tt.amount)
+			if quote.Fee != tt.fee || quote.Total != tt.total {
+				t.Errorf("Calculate(deposit, %d) = fee %d, total %d; want fee %d, total %d",
+					tt.amount, quote.Fee, quote.Total, tt.fee, tt.total)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := DefaultSchedule().Validate(); err != nil {
+		t.Fatalf("default schedule is invalid: %v", err)
+	}
+
+	invalid := []Rule{
+		{Operation: "refund"},
+		{Operation: OperationWithdraw, Flat: -1},
+		{Operation: OperationWithdraw, MinAmount: 100, MaxAmount: 100},
+		{Operation: OperationWithdraw, MinFee: 10, MaxFee: 5},
+	}
+	for _, rule := range invalid {
+		schedule := &Schedule{Rules: []Rule{rule}}
+		if err := schedule.Validate(); err == nil {
+			t.Errorf("Validate(%+v) succeeded, want error", rule)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// Handler handles liveness, readiness and status requests
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new health handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// Live reports that the process is up and serving HTTP, without probing dependencies
+// Orchestrators restart the container when it fails, so a database outage must not fail it
+// GET /livez
+func (h *Handler) Live(c *gin.Context) {
+	utils.RespondWithData(c, http.StatusOK, gin.H{
+		"status":  "alive",
+		"service": "hlabs-banking-api",
+	})
+}
+
+// Ready reports whether PostgreSQL and TigerBeetle answer, with 503 when either is down or the server is draining
+// Orchestrators stop routing traffic to the instance until it is ready again
+// GET /readyz
+func (h *Handler) Ready(c *gin.Context) {
+	readiness := h.service.Readiness(c.Request.Context())
+
+	status := "ready"
+	code := http.StatusOK
+	switch {
+	case readiness.Draining:
+		status = "draining"
+		code = http.StatusServiceUnavailable
+	case !readiness.Ready:
+		status = "not_ready"
+		code = http.StatusServiceUnavailable
+	}
+
+	response := gin.H{
+		"status":  status,
+		"service": "hlabs-banking-api",
+	}
+	if readiness.Checks != nil {
+		response["checks"] = readiness.Checks
+	}
+	utils.RespondWithData(c, code, response)
+}
+
+// Details returns dependency latencies, the OpenRouter configuration, the schema version and the seed status
+// GET /health/details
+func (h *Handler) Details(c *gin.Context) {
+	details := h.service.Details(c.Request.Context())
+	utils.RespondWithSuccess(c, http.StatusOK, details, "Health details retrieved successfully")
+}
@@ -0,0 +1,219 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hlabs/banking-system/internal/database"
+	"github.com/hlabs/banking-system/internal/logging"
+	"github.com/hlabs/banking-system/internal/tigerbeetle"
+	tb_types "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
+	"gorm.io/gorm"
+)
+
+var logger = logging.For("health")
+
+// probeTimeout bounds each dependency probe, well under the usual orchestrator probe timeout
+const probeTimeout = 2 * time.Second
+
+// Dependency states
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check is the result of probing one dependency
+type Check struct {
+	Status    string  `json:"status"` // "up" or "down"
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Readiness is the result of the readiness probes
+type Readiness struct {
+	Ready    bool             `json:"ready"`
+	Draining bool             `json:"draining,omitempty"` // Shutdown has started; dependencies are not probed
+	Checks   map[string]Check `json:"checks,omitempty"`
+}
+
+// OpenRouterStatus describes the AI chat configuration
+type OpenRouterStatus struct {
+	Configured bool   `json:"configured"`
+	Model      string `json:"model,omitempty"`
+	BaseURL    string `json:"base_url,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// SchemaStatus compares the schema version of this build with the one recorded in the database
+type SchemaStatus struct {
+	Expected int    `json:"expected"`
+	Applied  int    `json:"applied"`
+	UpToDate bool   `json:"up_to_date"`
+	Error    string `json:"error,omitempty"`
+}
+
+// Details is the full status report for operators
+type Details struct {
+	Readiness
+	OpenRouter OpenRouterStatus    `json:"openrouter"`
+	Schema     SchemaStatus        `json:"schema"`
+	Seed       database.SeedStatus `json:"seed"`
+	StartedAt  time.Time           `json:"started_at"`
+	Uptime     string              `json:"uptime"`
+}
+
+// OpenRouterChecker reports the AI client configuration (implemented by chat.Service)
+type OpenRouterChecker interface {
+	OpenRouterStatus() (model, baseURL string, err error)
+}
+
+// Service probes the service dependencies
+type Service struct {
+	db        *gorm.DB
+	tbClient  *tigerbeetle.Client
+	ai        OpenRouterChecker
+	seed      database.SeedStatus
+	startedAt time.Time
+	draining  atomic.Bool
+
+	// tbProbe holds a token while a TigerBeetle probe is running: the client has no timeout, so a probe
+	// against an unreachable cluster can block until it comes back and must not be stacked with others
+	tbProbe chan struct{}
+}
+
+// NewService creates a new health service
+// seed is the outcome of database seeding at startup
+func NewService(db *gorm.DB, tbClient *tigerbeetle.Client, ai OpenRouterChecker, seed database.SeedStatus) *Service {
+	return &Service{
+		db:        db,
+		tbClient:  tbClient,
+		ai:        ai,
+		seed:      seed,
+		startedAt: time.Now().UTC(),
+		tbProbe:   make(chan struct{}, 1),
+	}
+}
+
+// SetDraining marks the instance as shutting down: readiness fails from then on, so
+// load balancers stop sending new requests while the in-flight ones complete
+func (s *Service) SetDraining() {
+	s.draining.Store(true)
+}
+
+// Readiness probes PostgreSQL and TigerBeetle concurrently
+// The service is ready when both answer within the probe timeout and it isn't draining
+func (s *Service) Readiness(ctx context.Context) Readiness {
+	if s.draining.Load() {
+		return Readiness{Ready: false, Draining: true}
+	}
+
+	var postgres, tb Check
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		postgres = s.checkPostgres(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		tb = s.checkTigerBeetle(ctx)
+	}()
+	wg.Wait()
+
+	return Readiness{
+		Ready: postgres.Status == StatusUp && tb.Status == StatusUp,
+		Checks: map[string]Check{
+			"postgres":    postgres,
+			"tigerbeetle": tb,
+		},
+	}
+}
+
+// Details runs the readiness probes and adds configuration, schema and seed status
+func (s *Service) Details(ctx context.Context) Details {
+	details := Details{
+		Readiness: s.Readiness(ctx),
+		Seed:      s.seed,
+		StartedAt: s.startedAt,
+		Uptime:    time.Since(s.startedAt).Round(time.Second).String(),
+	}
+
+	if s.ai != nil {
+		model, baseURL, err := s.ai.OpenRouterStatus()
+		details.OpenRouter = OpenRouterStatus{Configured: err == nil, Model: model, BaseURL: baseURL}
+		if err != nil {
+			details.OpenRouter.Error = err.Error()
+		}
+	} else {
+		details.OpenRouter.Error = "chat service not configured"
+	}
+
+	details.Schema.Expected = database.SchemaVersion
+	probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	applied, err := database.AppliedSchemaVersion(probeCtx, s.db)
+	if err != nil {
+		details.Schema.Error = err.Error()
+	}
+	details.Schema.Applied = applied
+	details.Schema.UpToDate = err == nil && applied >= database.SchemaVersion
+
+	return details
+}
+
+// checkPostgres pings the database through the connection pool
+func (s *Service) checkPostgres(ctx context.Context) Check {
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	start := time.Now()
+	sqlDB, err := s.db.DB()
+	if err == nil {
+		err = sqlDB.PingContext(ctx)
+	}
+	return newCheck(ctx, "postgres", start, err)
+}
+
+// checkTigerBeetle looks up the cash vault system account, which exists in every initialized cluster
+func (s *Service) checkTigerBeetle(ctx context.Context) Check {
+	start := time.Now()
+	select {
+	case s.tbProbe <- struct{}{}:
+	default:
+		return newCheck(ctx, "tigerbeetle", start, fmt.Errorf("previous probe still pending"))
+	}
+
+	result := make(chan error, 1)
+	go func() {
+		defer func() { <-s.tbProbe }()
+		accounts, err := s.tbClient.LookupAccounts(ctx, []tb_types.Uint128{s.tbClient.SystemAccountID})
+		if err == nil && len(accounts) == 0 {
+			err = fmt.Errorf("system account not found")
+		}
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		return newCheck(ctx, "tigerbeetle", start, err)
+	case <-time.After(probeTimeout):
+		return newCheck(ctx, "tigerbeetle", start, fmt.Errorf("no response within %s", probeTimeout))
+	}
+}
+
+// newCheck builds the result of a probe started at start
+func newCheck(ctx context.Context, dependency string, start time.Time, err error) Check {
+	check := Check{
+		Status:    StatusUp,
+		LatencyMS: float64(time.Since(start).Microseconds()) / 1000,
+	}
+	if err != nil {
+		check.Status = StatusDown
+		check.Error = err.Error()
+		logger.WarnContext(ctx, "dependency probe failed", "dependency", dependency, "latency_ms", check.LatencyMS, "error", err)
+	}
+	return check
+}
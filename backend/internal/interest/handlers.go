@@ -0,0 +1,41 @@
+package interest
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/internal/middleware"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// Handler handles HTTP requests for interest information
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new interest handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetAccrued returns the interest accrued to date for the current user
+// GET /api/accounts/interest
+func (h *Handler) GetAccrued(c *gin.Context) {
+	// Get user ID from context (set by auth middleware)
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	accrued, err := h.service.GetAccrued(userID)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to get accrued interest", "user_id", userID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve accrued interest")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, accrued, "Accrued interest retrieved successfully")
+}
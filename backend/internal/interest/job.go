@@ -0,0 +1,118 @@
+package interest
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// initialCatchUpDays is how many past days are accrued on a first start, before any accrual exists
+	initialCatchUpDays = 7
+
+	// runOffset delays the daily run past midnight UTC so the previous day is closed
+	runOffset = 5 * time.Minute
+)
+
+// Job runs daily accrual and month-end capitalization in the background
+type Job struct {
+	service *Service
+	now     func() time.Time
+}
+
+// NewJob creates a new interest job
+func NewJob(service *Service) *Job {
+	return &Job{
+		service: service,
+		now:     time.Now,
+	}
+}
+
+// Run accrues missed days on startup, then catches up once per day until the context is cancelled
+func (j *Job) Run(ctx context.Context) {
+	logger.Info("accrual job started")
+
+	j.catchUp(ctx)
+
+	for {
+		next := dayStart(j.now()).AddDate(0, 0, 1).Add(runOffset)
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			logger.Info("accrual job stopped")
+			return
+		case <-timer.C:
+			j.catchUp(ctx)
+		}
+	}
+}
+
+// catchUp accrues every closed day from the last accrued day (inclusive, so an interrupted run or a
+// failed month-end capitalization is finished) and retries outstanding capitalizations
+// Runs on startup and on every daily tick, so a failed day is retried the next day rather than on
+// the next restart - every step is idempotent, so rerunning processed days is safe
+func (j *Job) catchUp(ctx context.Context) {
+	today := dayStart(j.now())
+	last, err := j.service.LastAccrualDate(ctx)
+	if err != nil {
+		logger.Error("failed to find last accrual date", "error", err)
+	}
+	for _, day := range catchUpDays(today, last) {
+		if ctx.Err() != nil {
+			return
+		}
+		j.runFor(context.WithoutCancel(ctx), day)
+	}
+	j.capitalizeOutstanding(context.WithoutCancel(ctx), today)
+}
+
+// catchUpDays returns the closed days to accrue, oldest first
+// Starts at the last accrued day (inclusive) or, before any accrual exists, initialCatchUpDays
+// before today; today itself is still open and is never included
+func catchUpDays(today time.Time, last *time.Time) []time.Time {
+	today = dayStart(today)
+	from := today.AddDate(0, 0, -initialCatchUpDays)
+	if last != nil {
+		from = dayStart(*last)
+	}
+
+	var days []time.Time
+	for day := from; day.Before(today); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+// runFor accrues the given (closed) day and capitalizes its month if it was the last day
+// Run passes a context that is never cancelled: stopping between a TigerBeetle transfer and its
+// PostgreSQL record would leave the audit trail incomplete, so shutdown waits for the day to finish
+func (j *Job) runFor(ctx context.Context, day time.Time) {
+	if _, err := j.service.AccrueDay(ctx, day); err != nil {
+		logger.ErrorContext(ctx, "interest accrual failed", "date", day.Format("2006-01-02"), "error", err)
+		return
+	}
+
+	// Month-end: the day after is the first of a new month
+	if day.AddDate(0, 0, 1).Day() == 1 {
+		if _, err := j.service.CapitalizeMonth(ctx, day); err != nil {
+			logger.ErrorContext(ctx, "interest capitalization failed", "period", periodOf(day), "error", err)
+		}
+	}
+}
+
+// capitalizeOutstanding retries every closed month that still has unpaid accruals or pending
+// postings, e.g. when the month-end run failed and later days were accrued since
+func (j *Job) capitalizeOutstanding(ctx context.Context, today time.Time) {
+	periods, err := j.service.OutstandingPeriods(ctx, monthStart(today))
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to find outstanding capitalizations", "error", err)
+		return
+	}
+
+	for _, period := range periods {
+		if _, err := j.service.CapitalizeMonth(ctx, period); err != nil {
+			logger.ErrorContext(ctx, "interest capitalization failed", "period", periodOf(period), "error", err)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package interest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hlabs/banking-system/internal/models"
+)
+
+// Product describes the interest paid on one account type
+type Product struct {
+	AccountType   string `json:"account_type"`
+	Name          string `json:"name"`
+	AnnualRateBps int64  `json:"annual_rate_bps"` // Annual rate in basis points (2.50% = 250)
+}
+
+// Products maps account types to their interest product
+type Products map[string]Product
+
+// DefaultProducts returns the interest products used when no rates are configured
+func DefaultProducts() Products {
+	return Products{
+		models.AccountTypeChecking:   {AccountType: models.AccountTypeChecking, Name: "Checking", AnnualRateBps: 0},
+		models.AccountTypeSavings:    {AccountType: models.AccountTypeSavings, Name: "Savings", AnnualRateBps: 250},
+		models.AccountTypeInvestment: {AccountType: models.AccountTypeInvestment, Name: "Investment", AnnualRateBps: 400},
+	}
+}
+
+// ParseProducts overrides the default rates with a spec like "savings=250,investment=400"
+// An empty spec returns the default products
+func ParseProducts(spec string) (Products, error) {
+	products := DefaultProducts()
+	if strings.TrimSpace(spec) == "" {
+		return products, nil
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		accountType, rate, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid interest rate entry %q (expected account_type=bps)", entry)
+		}
+
+		bps, err := strconv.ParseInt(strings.TrimSpace(rate), 10, 64)
+		if err != nil || bps < 0 {
+			return nil, fmt.Errorf("invalid interest rate for %s: %q", accountType, rate)
+		}
+
+		accountType = strings.TrimSpace(accountType)
+		product, exists := products[accountType]
+		if !exists {
+			product = Product{AccountType: accountType, Name: accountType}
+		}
+		product.AnnualRateBps = bps
+		products[accountType] = product
+	}
+
+	return products, nil
+}
+
+// InterestBearingTypes returns the account types that earn a positive rate
+func (p Products) InterestBearingTypes() []string {
+	types := make([]string, 0, len(p))
+	for accountType, product := range p {
+		if product.AnnualRateBps > 0 {
+			types = append(types, accountType)
+		}
+	}
+	return types
+}
+
+// DailyInterestMicros computes one day of interest on a balance, in micro-cents
+// Uses an actual/365 day count; negative and zero balances earn nothing
+func DailyInterestMicros(balanceCents, annualRateBps int64) int64 {
+	if balanceCents <= 0 || annualRateBps <= 0 {
+		return 0
+	}
+	// balance * rate/10000 / 365 * MicrosPerCent
+	return balanceCents * annualRateBps * (models.MicrosPerCent / 10000) / 365
+}
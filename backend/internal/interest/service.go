@@ -0,0 +1,458 @@
+package interest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/logging"
+	"github.com/hlabs/banking-system/internal/metrics"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/tigerbeetle"
+	tb_types "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var logger = logging.For("interest")
+
+// accrualBatchSize is the number of users processed per batch by the accrual job
+const accrualBatchSize = 200
+
+// Service handles interest accrual and capitalization
+type Service struct {
+	db       *gorm.DB
+	tbClient *tigerbeetle.Client
+	products Products
+}
+
+// NewService creates a new interest service
+func NewService(db *gorm.DB, tbClient *tigerbeetle.Client, products Products) *Service {
+	return &Service{
+		db:       db,
+		tbClient: tbClient,
+		products: products,
+	}
+}
+
+// AccruedInterest summarizes the interest a user has earned in the current period
+type AccruedInterest struct {
+	Product            Product                        `json:"product"`
+	Period             string                         `json:"period"`
+	AccruedMicros      int64                          `json:"accrued_micros"`
+	AccruedCents       int64                          `json:"accrued_cents"`
+	AccruedUSD         float64                        `json:"accrued_usd"`
+	DaysAccrued        int64                          `json:"days_accrued"`
+	LastAccrualDate    *time.Time                     `json:"last_accrual_date,omitempty"`
+	LastCapitalization *models.InterestCapitalization `json:"last_capitalization,omitempty"`
+}
+
+// dayStart truncates a time to midnight UTC
+func dayStart(t time.Time) time.Time {
+	y, m, d := t.UTC().Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+}
+
+// monthStart returns midnight UTC on the first day of the time's month
+func monthStart(t time.Time) time.Time {
+	y, m, _ := t.UTC().Date()
+	return time.Date(y, m, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// periodOf formats the accrual period (YYYY-MM) a date belongs to
+func periodOf(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// AccrueDay computes one day of interest for every interest-bearing account
+// The balance used is the TigerBeetle end-of-day balance for that date. Safe to rerun:
+// accruals already recorded for the date are left untouched.
+func (s *Service) AccrueDay(ctx context.Context, date time.Time) (int, error) {
+	day := dayStart(date)
+	endOfDay := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+
+	accountTypes := s.products.InterestBearingTypes()
+	if len(accountTypes) == 0 {
+		return 0, nil
+	}
+
+	logger.InfoContext(ctx, "accruing interest", "date", day.Format("2006-01-02"), "account_types", accountTypes)
+
+	accrued := 0
+	var users []models.User
+	result := s.db.WithContext(ctx).
+		Where("account_type IN ? AND created_at <= ?", accountTypes, endOfDay).
+		FindInBatches(&users, accrualBatchSize, func(tx *gorm.DB, batch int) error {
+			for i := range users {
+				created, err := s.accrueUser(ctx, &users[i], day, endOfDay)
+				if err != nil {
+					// One bad account must not stop the whole run - it is picked up on the next rerun
+					logger.WarnContext(ctx, "failed to accrue interest", "user_id", users[i].ID, "error", err)
+					continue
+				}
+				if created {
+					accrued++
+				}
+			}
+			return nil
+		})
+	if result.Error != nil {
+		return accrued, fmt.Errorf("failed to load interest-bearing accounts: %w", result.Error)
+	}
+
+	logger.InfoContext(ctx, "interest accrued", "date", day.Format("2006-01-02"), "accounts", accrued)
+	return accrued, nil
+}
+
+// accrueUser records one day of interest for a user
+// Returns false if the day was already accrued or the balance earned nothing
+func (s *Service) accrueUser(ctx context.Context, user *models.User, day, endOfDay time.Time) (bool, error) {
+	product := s.products[user.AccountType]
+
+	balance, err := s.tbClient.BalanceAt(ctx, user.TigerBeetleAccountID, endOfDay)
+	if err != nil {
+		return false, fmt.Errorf("failed to get end-of-day balance: %w", err)
+	}
+
+	amount := DailyInterestMicros(balance, product.AnnualRateBps)
+	if amount == 0 {
+		return false, nil
+	}
+
+	return s.recordAccrual(ctx, &models.InterestAccrual{
+		UserID:       user.ID,
+		AccrualDate:  day,
+		AccountType:  user.AccountType,
+		Balance:      balance,
+		RateBps:      product.AnnualRateBps,
+		AmountMicros: amount,
+	})
+}
+
+// recordAccrual inserts an accrual, returning false if the day was already recorded
+// ON CONFLICT DO NOTHING on the (user_id, accrual_date) index makes reruns for the same day a no-op
+func (s *Service) recordAccrual(ctx context.Context, accrual *models.InterestAccrual) (bool, error) {
+	result := s.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(accrual)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to record accrual: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// LastAccrualDate returns the most recent day with a recorded accrual, or nil if there is none
+func (s *Service) LastAccrualDate(ctx context.Context) (*time.Time, error) {
+	var last *time.Time
+	if err := s.db.WithContext(ctx).Model(&models.InterestAccrual{}).
+		Select("MAX(accrual_date)").
+		Scan(&last).Error; err != nil {
+		return nil, fmt.Errorf("failed to find last accrual: %w", err)
+	}
+	return last, nil
+}
+
+// OutstandingPeriods returns the first day of every month before the given one that still has
+// accruals not linked to a capitalization or a capitalization not completed yet
+func (s *Service) OutstandingPeriods(ctx context.Context, before time.Time) ([]time.Time, error) {
+	var unpaid []time.Time
+	if err := s.db.WithContext(ctx).Model(&models.InterestAccrual{}).
+		Distinct("date_trunc('month', accrual_date)").
+		Where("accrual_date < ? AND capitalization_id IS NULL", before).
+		Pluck("date_trunc('month', accrual_date)", &unpaid).Error; err != nil {
+		return nil, fmt.Errorf("failed to find unpaid accruals: %w", err)
+	}
+
+	var pending []string
+	if err := s.db.WithContext(ctx).Model(&models.InterestCapitalization{}).
+		Distinct("period").
+		Where("period < ? AND status = ?", periodOf(before), models.InterestCapitalizationPending).
+		Pluck("period", &pending).Error; err != nil {
+		return nil, fmt.Errorf("failed to find pending capitalizations: %w", err)
+	}
+
+	seen := make(map[string]bool, len(unpaid)+len(pending))
+	periods := make([]time.Time, 0, len(unpaid)+len(pending))
+	add := func(month time.Time) {
+		if !seen[periodOf(month)] {
+			seen[periodOf(month)] = true
+			periods = append(periods, monthStart(month))
+		}
+	}
+	for _, month := range unpaid {
+		add(month)
+	}
+	for _, period := range pending {
+		month, err := time.Parse("2006-01", period)
+		if err != nil {
+			return nil, fmt.Errorf("invalid capitalization period %q: %w", period, err)
+		}
+		add(month)
+	}
+
+	sort.Slice(periods, func(i, k int) bool { return periods[i].Before(periods[k]) })
+	return periods, nil
+}
+
+// CapitalizeMonth posts the interest accrued during the month containing the given date
+// Interest is paid from the interest expense account. Safe to rerun: completed postings are
+// skipped and pending ones are retried with the same TigerBeetle transfer ID.
+func (s *Service) CapitalizeMonth(ctx context.Context, month time.Time) (int, error) {
+	start := monthStart(month)
+	end := start.AddDate(0, 1, 0)
+	period := periodOf(start)
+
+	logger.InfoContext(ctx, "capitalizing interest", "period", period)
+
+	// Users with unpaid accruals in the period, plus users whose posting is still pending
+	var userIDs []uuid.UUID
+	if err := s.db.Model(&models.InterestAccrual{}).
+		Distinct("user_id").
+		Where("accrual_date >= ? AND accrual_date < ? AND capitalization_id IS NULL", start, end).
+		Pluck("user_id", &userIDs).Error; err != nil {
+		return 0, fmt.Errorf("failed to find accounts to capitalize: %w", err)
+	}
+
+	var pendingIDs []uuid.UUID
+	if err := s.db.Model(&models.InterestCapitalization{}).
+		Where("period = ? AND status = ?", period, models.InterestCapitalizationPending).
+		Pluck("user_id", &pendingIDs).Error; err != nil {
+		return 0, fmt.Errorf("failed to find pending capitalizations: %w", err)
+	}
+	userIDs = append(userIDs, pendingIDs...)
+
+	capitalized := 0
+	seen := make(map[uuid.UUID]bool, len(userIDs))
+	for _, userID := range userIDs {
+		if seen[userID] {
+			continue
+		}
+		seen[userID] = true
+
+		posted, err := s.capitalizeUser(ctx, userID, period, start, end)
+		if err != nil {
+			logger.WarnContext(ctx, "failed to capitalize interest", "user_id", userID, "period", period, "error", err)
+			continue
+		}
+		if posted {
+			capitalized++
+		}
+	}
+
+	logger.InfoContext(ctx, "interest capitalized", "period", period, "accounts", capitalized)
+	return capitalized, nil
+}
+
+// capitalizeUser posts one user's interest for a period
+func (s *Service) capitalizeUser(ctx context.Context, userID uuid.UUID, period string, start, end time.Time) (bool, error) {
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		return false, fmt.Errorf("failed to load user: %w", err)
+	}
+
+	capitalization, err := s.prepareCapitalization(&user, period, start, end)
+	if err != nil {
+		return false, err
+	}
+	if capitalization.Status == models.InterestCapitalizationCompleted {
+		return false, nil
+	}
+
+	if capitalization.Amount > 0 {
+		if err := s.postCapitalization(ctx, &user, capitalization); err != nil {
+			return false, err
+		}
+	}
+
+	if err := s.db.Model(capitalization).
+		Update("status", models.InterestCapitalizationCompleted).Error; err != nil {
+		return false, fmt.Errorf("failed to complete capitalization: %w", err)
+	}
+
+	return capitalization.Amount > 0, nil
+}
+
+// prepareCapitalization creates (or loads) the capitalization row for a user and period
+// and links the period's accruals to it, all in one database transaction
+func (s *Service) prepareCapitalization(user *models.User, period string, start, end time.Time) (*models.InterestCapitalization, error) {
+	var capitalization models.InterestCapitalization
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND period = ?", user.ID, period).
+			First(&capitalization).Error
+		if err == nil {
+			return nil // Already prepared by a previous run
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to load capitalization: %w", err)
+		}
+
+		var accruedMicros int64
+		if err := tx.Model(&models.InterestAccrual{}).
+			Where("user_id = ? AND accrual_date >= ? AND accrual_date < ? AND capitalization_id IS NULL", user.ID, start, end).
+			Select("COALESCE(SUM(amount_micros), 0)").
+			Scan(&accruedMicros).Error; err != nil {
+			return fmt.Errorf("failed to sum accruals: %w", err)
+		}
+
+		// Carry the sub-cent remainder of the previous period forward
+		var previous models.InterestCapitalization
+		err = tx.Where("user_id = ? AND period < ?", user.ID, period).
+			Order("period DESC").
+			First(&previous).Error
+		if err == nil {
+			accruedMicros += previous.RemainderMicros
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("failed to load previous capitalization: %w", err)
+		}
+
+		capitalization = models.InterestCapitalization{
+			ID:              uuid.New(),
+			UserID:          user.ID,
+			Period:          period,
+			AccruedMicros:   accruedMicros,
+			Amount:          accruedMicros / models.MicrosPerCent,
+			RemainderMicros: accruedMicros % models.MicrosPerCent,
+			Status:          models.InterestCapitalizationPending,
+		}
+		// The TigerBeetle transfer ID is derived from the row ID so retries can't double-post
+		capitalization.TigerBeetleTransferID = models.Uint128ToHex(transferIDFor(capitalization.ID))
+
+		if err := tx.Create(&capitalization).Error; err != nil {
+			return fmt.Errorf("failed to create capitalization: %w", err)
+		}
+
+		if err := tx.Model(&models.InterestAccrual{}).
+			Where("user_id = ? AND accrual_date >= ? AND accrual_date < ? AND capitalization_id IS NULL", user.ID, start, end).
+			Update("capitalization_id", capitalization.ID).Error; err != nil {
+			return fmt.Errorf("failed to link accruals: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &capitalization, nil
+}
+
+// postCapitalization moves the interest from the interest expense account to the user
+func (s *Service) postCapitalization(ctx context.Context, user *models.User, capitalization *models.InterestCapitalization) error {
+	transferID := transferIDFor(capitalization.ID)
+
+	transfers := []tb_types.Transfer{
+		{
+			ID:              transferID,
+			DebitAccountID:  s.tbClient.InterestExpenseAccountID,           // Bank pays the interest
+			CreditAccountID: tb_types.ToUint128(user.TigerBeetleAccountID), // User account (destination)
+			Amount:          tb_types.ToUint128(uint64(capitalization.Amount)),
+			Ledger:          tigerbeetle.LedgerUSD,
+			Code:            tigerbeetle.TransferCodeInterest,
+		},
+	}
+
+	results, err := s.tbClient.CreateTransfers(ctx, transfers)
+	if err != nil {
+		return fmt.Errorf("failed to create interest transfer: %w", err)
+	}
+
+	// TransferExists means a previous run already posted it - that's the idempotent path
+	if len(results) > 0 && results[0].Result != tb_types.TransferExists {
+		return fmt.Errorf("interest transfer failed with result code: %d", results[0].Result)
+	}
+
+	// Audit record, skipped if a previous run already wrote it
+	interestAcctBI := s.tbClient.InterestExpenseAccountID.BigInt()
+	txRecord := &models.Transaction{
+		UserID:          user.ID,
+		Type:            models.TransactionTypeInterest,
+		Amount:          capitalization.Amount,
+		DebitAccountID:  interestAcctBI.Uint64(),
+		CreditAccountID: user.TigerBeetleAccountID,
+		Status:          models.TransactionStatusCompleted,
+		Description:     fmt.Sprintf("Interest for %s", capitalization.Period),
+	}
+	txRecord.SetTigerBeetleTransferID(transferID)
+
+	if err := s.db.Clauses(clause.OnConflict{DoNothing: true}).Create(txRecord).Error; err != nil {
+		metrics.AuditWriteFailed(string(txRecord.Type))
+		logger.ErrorContext(ctx, "interest transfer succeeded in TigerBeetle but failed to log in PostgreSQL",
+			"transfer_id", txRecord.TigerBeetleTransferID, "error", err)
+	}
+
+	if len(results) == 0 { // Not when a rerun found it already posted
+		metrics.ObserveTransfer(string(txRecord.Type), txRecord.Amount)
+	}
+	logger.InfoContext(ctx, "interest posted", "user_id", user.ID, "amount", capitalization.Amount, "period", capitalization.Period)
+	return nil
+}
+
+// transferIDFor derives a deterministic TigerBeetle transfer ID from a capitalization ID
+func transferIDFor(id uuid.UUID) tb_types.Uint128 {
+	return tb_types.BytesToUint128(id)
+}
+
+// GetAccrued returns the interest accrued to date in the current period for a user
+func (s *Service) GetAccrued(userID string) (*AccruedInterest, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user ID format: %w", err)
+	}
+
+	var user models.User
+	if err := s.db.First(&user, "id = ?", uid).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	product, exists := s.products[user.AccountType]
+	if !exists {
+		product = Product{AccountType: user.AccountType, Name: user.AccountType}
+	}
+
+	// Only the current period's accruals: a closed month that is still waiting for its
+	// capitalization belongs to that month, not to the period reported here
+	start := monthStart(time.Now())
+	var summary struct {
+		Total int64
+		Days  int64
+		Last  *time.Time
+	}
+	if err := s.db.Model(&models.InterestAccrual{}).
+		Select("COALESCE(SUM(amount_micros), 0) AS total, COUNT(*) AS days, MAX(accrual_date) AS last").
+		Where("user_id = ? AND accrual_date >= ? AND capitalization_id IS NULL", user.ID, start).
+		Scan(&summary).Error; err != nil {
+		return nil, fmt.Errorf("failed to sum accruals: %w", err)
+	}
+
+	accrued := &AccruedInterest{
+		Product:         product,
+		Period:          periodOf(start),
+		AccruedMicros:   summary.Total,
+		DaysAccrued:     summary.Days,
+		LastAccrualDate: summary.Last,
+	}
+
+	// Include the remainder carried from the last capitalization
+	var last models.InterestCapitalization
+	err = s.db.Where("user_id = ?", user.ID).Order("period DESC").First(&last).Error
+	if err == nil {
+		accrued.LastCapitalization = &last
+		if last.Status == models.InterestCapitalizationCompleted {
+			accrued.AccruedMicros += last.RemainderMicros
+		}
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to load last capitalization: %w", err)
+	}
+
+	accrued.AccruedCents = accrued.AccruedMicros / models.MicrosPerCent
+	accrued.AccruedUSD = float64(accrued.AccruedMicros) / models.MicrosPerCent / 100.0
+
+	return accrued, nil
+}
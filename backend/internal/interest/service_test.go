@@ -0,0 +1,156 @@
+package interest
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/models"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+)
+
+func TestDailyInterestMicros(t *testing.T) {
+	tests := []struct {
+		name    string
+		balance int64
+		rateBps int64
+		want    int64
+	}{
+		{"1000 USD at 2.50%", 100000, 250, 6849315},  // 6.849315 cents
+		{"1000 USD at 4.00%", 100000, 400, 10958904}, // 10.958904 cents
+		{"one cent at 2.50%", 1, 250, 68},            // Sub-cent amounts are kept in micros
+		{"full year rounds down", 36500, 10000, 1000000 * 100},
+		{"zero balance", 0, 250, 0},
+		{"negative balance", -100000, 250, 0},
+		{"zero rate", 100000, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DailyInterestMicros(tt.balance, tt.rateBps); got != tt.want {
+				t.Errorf("DailyInterestMicros(%d, %d) = %d, want %d", tt.balance, tt.rateBps, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDailyInterestMicrosAddsUpOverAYear(t *testing.T) {
+	// 365 daily accruals on 1000 USD at 2.50% pay 25 USD, less at most one micro-cent per day
+	var total int64
+	for range 365 {
+		total += DailyInterestMicros(100000, 250)
+	}
+	want := int64(2500) * models.MicrosPerCent
+	if total > want || want-total > 365 {
+		t.Errorf("yearly accrual = %d micros, want %d (minus truncation)", total, want)
+	}
+}
+
+func TestCatchUpDays(t *testing.T) {
+	date := func(month time.Month, day int) time.Time {
+		return time.Date(2026, month, day, 0, 0, 0, 0, time.UTC)
+	}
+	today := time.Date(2026, 3, 2, 0, 5, 0, 0, time.UTC) // Just after the daily run offset
+
+	tests := []struct {
+		name  string
+		last  *time.Time
+		first time.Time
+		days  int
+	}{
+		{"first start accrues the last week", nil, date(2, 23), initialCatchUpDays},
+		{"resumes at the last accrued day", ptr(date(2, 27)), date(2, 27), 3},
+		{"last accrued yesterday reruns yesterday", ptr(date(3, 1)), date(3, 1), 1},
+		{"last accrual time of day is ignored", ptr(time.Date(2026, 3, 1, 23, 59, 0, 0, time.UTC)), date(3, 1), 1},
+		{"nothing to do when today was accrued", ptr(date(3, 2)), time.Time{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			days := catchUpDays(today, tt.last)
+			if len(days) != tt.days {
+				t.Fatalf("catchUpDays returned %d days (%v), want %d", len(days), days, tt.days)
+			}
+			if tt.days == 0 {
+				return
+			}
+			if !days[0].Equal(tt.first) {
+				t.Errorf("first day = %s, want %s", days[0], tt.first)
+			}
+			for i, day := range days {
+				if !day.Equal(dayStart(day)) {
+					t.Errorf("day %d = %s, want midnight UTC", i, day)
+				}
+				if i > 0 && !day.Equal(days[i-1].AddDate(0, 0, 1)) {
+					t.Errorf("day %d = %s does not follow %s", i, day, days[i-1])
+				}
+			}
+			if last := days[len(days)-1]; !last.Equal(date(3, 1)) {
+				t.Errorf("last day = %s, want the day before today", last)
+			}
+		})
+	}
+}
+
+func TestAccrualUniqueIndex(t *testing.T) {
+	s, err := schema.Parse(&models.InterestAccrual{}, &sync.Map{}, schema.NamingStrategy{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	index := s.LookIndex("idx_interest_accruals_user_date")
+	if index == nil {
+		t.Fatal("idx_interest_accruals_user_date not declared")
+	}
+	if index.Class != "UNIQUE" {
+		t.Errorf("index class = %q, want UNIQUE", index.Class)
+	}
+
+	var columns []string
+	for _, field := range index.Fields {
+		columns = append(columns, field.DBName)
+	}
+	if strings.Join(columns, ",") != "user_id,accrual_date" {
+		t.Errorf("index columns = %v, want [user_id accrual_date]", columns)
+	}
+}
+
+func TestRecordAccrualIgnoresDuplicateDay(t *testing.T) {
+	db, err := gorm.Open(postgres.Open("host=localhost"), &gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var statement string
+	if err := db.Callback().Create().After("gorm:create").Register("test:capture", func(tx *gorm.DB) {
+		statement = tx.Statement.SQL.String()
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	service := NewService(db, nil, DefaultProducts())
+	_, err = service.recordAccrual(context.Background(), &models.InterestAccrual{
+		UserID:       uuid.New(),
+		AccrualDate:  time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC),
+		AccountType:  models.AccountTypeSavings,
+		Balance:      100000,
+		RateBps:      250,
+		AmountMicros: 6849315,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(statement, `INSERT INTO "interest_accruals"`) || !strings.Contains(statement, "ON CONFLICT DO NOTHING") {
+		t.Errorf("accrual insert = %q, want an INSERT ... ON CONFLICT DO NOTHING", statement)
+	}
+}
+
+func ptr(t time.Time) *time.Time {
+	return &t
+}
@@ -0,0 +1,103 @@
+package ledger
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/internal/logging"
+	"github.com/hlabs/banking-system/internal/tigerbeetle"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+var logger = logging.For("ledger")
+
+// Handler handles HTTP requests for accounting reports
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new ledger handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// GetTrialBalance returns the trial balance across system and customer accounts
+// GET /api/admin/ledger/trial-balance
+func (h *Handler) GetTrialBalance(c *gin.Context) {
+	report, err := h.service.TrialBalance(c.Request.Context())
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to build trial balance", "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to build trial balance")
+		return
+	}
+
+	if !report.Balanced {
+		logger.WarnContext(c.Request.Context(), "trial balance does not balance", "total_debits", report.TotalDebitBalance, "total_credits", report.TotalCreditBalance)
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, report, "Trial balance generated successfully")
+}
+
+// GetEntries returns the general ledger of one account
+// :account is "system:<name, code or ID>" for a chart account or a customer TigerBeetle account ID
+// GET /api/admin/ledger/accounts/:account/entries?limit=100&from=RFC3339&to=RFC3339&order=desc
+func (h *Handler) GetEntries(c *gin.Context) {
+	filter := EntriesFilter{Limit: DefaultEntriesLimit}
+
+	if limitStr := c.Query("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 || limit > MaxEntriesLimit {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid limit parameter")
+			return
+		}
+		filter.Limit = limit
+	}
+
+	var err error
+	if filter.From, err = parseTime(c.Query("from")); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid from parameter (expected RFC3339)")
+		return
+	}
+	if filter.To, err = parseTime(c.Query("to")); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid to parameter (expected RFC3339)")
+		return
+	}
+	filter.Reversed = c.Query("order") == "desc"
+
+	ledger, err := h.service.GeneralLedger(c.Request.Context(), c.Param("account"), filter)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "unknown account") || strings.HasPrefix(err.Error(), "account not found") {
+			utils.RespondWithError(c, http.StatusNotFound, err.Error())
+			return
+		}
+		logger.ErrorContext(c.Request.Context(), "failed to build general ledger", "account", c.Param("account"), "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve ledger entries")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, ledger, "Ledger entries retrieved successfully")
+}
+
+// GetChart returns the chart of accounts and the transfer code registry
+// GET /api/admin/ledger/chart
+func (h *Handler) GetChart(c *gin.Context) {
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"ledger":                tigerbeetle.LedgerUSD,
+		"customer_account_code": tigerbeetle.AccountCodeCustomer,
+		"accounts":              tigerbeetle.ChartOfAccounts,
+		"transfer_codes":        tigerbeetle.TransferCodes,
+	}, "Chart of accounts retrieved successfully")
+}
+
+// parseTime parses an optional RFC3339 query parameter
+func parseTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
@@ -0,0 +1,341 @@
+package ledger
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/tigerbeetle"
+	tb_types "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
+	"gorm.io/gorm"
+)
+
+const (
+	// customerBatchSize is how many customer accounts are looked up in TigerBeetle per call
+	customerBatchSize = 1000
+
+	// DefaultEntriesLimit and MaxEntriesLimit bound general ledger queries
+	DefaultEntriesLimit = 100
+	MaxEntriesLimit     = 8190
+)
+
+// Service builds accounting reports straight from TigerBeetle balances
+type Service struct {
+	db       *gorm.DB
+	tbClient *tigerbeetle.Client
+}
+
+// NewService creates a new ledger service
+func NewService(db *gorm.DB, tbClient *tigerbeetle.Client) *Service {
+	return &Service{
+		db:       db,
+		tbClient: tbClient,
+	}
+}
+
+// TrialBalanceLine is one row of the trial balance
+type TrialBalanceLine struct {
+	Account        string                  `json:"account"`
+	Code           uint16                  `json:"code"`
+	Kind           tigerbeetle.AccountKind `json:"kind"`
+	NormalBalance  string                  `json:"normal_balance"`
+	AccountCount   int                     `json:"account_count"`
+	DebitsPosted   int64                   `json:"debits_posted"`
+	CreditsPosted  int64                   `json:"credits_posted"`
+	DebitsPending  int64                   `json:"debits_pending"`
+	CreditsPending int64                   `json:"credits_pending"`
+	DebitBalance   int64                   `json:"debit_balance"`  // Net posted balance when on the debit side
+	CreditBalance  int64                   `json:"credit_balance"` // Net posted balance when on the credit side
+}
+
+// TrialBalance is the full report; it balances when total debits equal total credits
+type TrialBalance struct {
+	GeneratedAt        time.Time          `json:"generated_at"`
+	Lines              []TrialBalanceLine `json:"lines"`
+	TotalDebitBalance  int64              `json:"total_debit_balance"`
+	TotalCreditBalance int64              `json:"total_credit_balance"`
+	TotalDebitsPosted  int64              `json:"total_debits_posted"`
+	TotalCreditsPosted int64              `json:"total_credits_posted"`
+	Balanced           bool               `json:"balanced"`
+}
+
+// TrialBalance lists every system account and the aggregate of all customer accounts
+func (s *Service) TrialBalance(ctx context.Context) (*TrialBalance, error) {
+	report := &TrialBalance{GeneratedAt: time.Now().UTC()}
+
+	// System accounts
+	ids := make([]tb_types.Uint128, 0, len(tigerbeetle.ChartOfAccounts))
+	for _, account := range tigerbeetle.ChartOfAccounts {
+		ids = append(ids, tb_types.ToUint128(account.ID))
+	}
+	accounts, err := s.tbClient.LookupAccounts(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[tb_types.Uint128]tb_types.Account, len(accounts))
+	for _, account := range accounts {
+		byID[account.ID] = account
+	}
+
+	for _, system := range tigerbeetle.ChartOfAccounts {
+		line := TrialBalanceLine{
+			Account:       system.Name,
+			Code:          system.Code,
+			Kind:          system.Kind,
+			NormalBalance: system.Kind.NormalBalance(),
+		}
+		if account, ok := byID[tb_types.ToUint128(system.ID)]; ok {
+			line.AccountCount = 1
+			addAccount(&line, account)
+		}
+		report.Lines = append(report.Lines, line)
+	}
+
+	// Customer accounts are liabilities of the bank, aggregated into one line
+	customers, err := s.customerLine(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.Lines = append(report.Lines, customers)
+
+	for i := range report.Lines {
+		line := &report.Lines[i]
+		net := line.DebitsPosted - line.CreditsPosted
+		if net >= 0 {
+			line.DebitBalance = net
+		} else {
+			line.CreditBalance = -net
+		}
+		report.TotalDebitBalance += line.DebitBalance
+		report.TotalCreditBalance += line.CreditBalance
+		report.TotalDebitsPosted += line.DebitsPosted
+		report.TotalCreditsPosted += line.CreditsPosted
+	}
+	report.Balanced = report.TotalDebitBalance == report.TotalCreditBalance &&
+		report.TotalDebitsPosted == report.TotalCreditsPosted
+
+	return report, nil
+}
+
+// customerLine sums the balances of every customer account in TigerBeetle
+func (s *Service) customerLine(ctx context.Context) (TrialBalanceLine, error) {
+	line := TrialBalanceLine{
+		Account:       "customer_deposits",
+		Code:          tigerbeetle.AccountCodeCustomer,
+		Kind:          tigerbeetle.AccountKindLiability,
+		NormalBalance: tigerbeetle.AccountKindLiability.NormalBalance(),
+	}
+
+	var users []models.User
+	var lookupErr error
+	result := s.db.WithContext(ctx).Model(&models.User{}).
+		Select("id", "tigerbeetle_account_id").
+		FindInBatches(&users, customerBatchSize, func(tx *gorm.DB, batch int) error {
+			ids := make([]tb_types.Uint128, 0, len(users))
+			for _, user := range users {
+				ids = append(ids, tb_types.ToUint128(user.TigerBeetleAccountID))
+			}
+
+			accounts, err := s.tbClient.LookupAccounts(ctx, ids)
+			if err != nil {
+				lookupErr = err
+				return err
+			}
+			for _, account := range accounts {
+				line.AccountCount++
+				addAccount(&line, account)
+			}
+			return nil
+		})
+	if lookupErr != nil {
+		return line, lookupErr
+	}
+	if result.Error != nil {
+		return line, fmt.Errorf("failed to load customer accounts: %w", result.Error)
+	}
+
+	return line, nil
+}
+
+// addAccount adds the TigerBeetle balances of an account to a report line
+func addAccount(line *TrialBalanceLine, account tb_types.Account) {
+	line.DebitsPosted += toInt64(account.DebitsPosted)
+	line.CreditsPosted += toInt64(account.CreditsPosted)
+	line.DebitsPending += toInt64(account.DebitsPending)
+	line.CreditsPending += toInt64(account.CreditsPending)
+}
+
+// LedgerAccount identifies the account a general ledger was built for
+type LedgerAccount struct {
+	ID            uint64                  `json:"id"`
+	Name          string                  `json:"name"`
+	Code          uint16                  `json:"code"`
+	Kind          tigerbeetle.AccountKind `json:"kind"`
+	DebitsPosted  int64                   `json:"debits_posted"`
+	CreditsPosted int64                   `json:"credits_posted"`
+}
+
+// Entry is one transfer as seen from the account being reported on
+type Entry struct {
+	TransferID   string    `json:"transfer_id"`
+	Timestamp    time.Time `json:"timestamp"`
+	Code         uint16    `json:"code"`
+	CodeName     string    `json:"code_name"`
+	Side         string    `json:"side"` // "debit" or "credit"
+	Counterparty uint64    `json:"counterparty_account_id"`
+	Amount       int64     `json:"amount"`
+	Pending      bool      `json:"pending"`
+	Voided       bool      `json:"voided"`
+	Linked       bool      `json:"linked"`
+}
+
+// GeneralLedger lists the entries of one account
+type GeneralLedger struct {
+	Account LedgerAccount `json:"account"`
+	Entries []Entry       `json:"entries"`
+	Count   int           `json:"count"`
+}
+
+// EntriesFilter narrows a general ledger query
+type EntriesFilter struct {
+	From     time.Time // Zero means no lower bound
+	To       time.Time // Zero means no upper bound
+	Limit    int
+	Reversed bool // Newest first
+}
+
+// GeneralLedger returns the transfers touching an account, identified by SystemAccountPrefix
+// and a chart name/code/ID, or by a customer's TigerBeetle account ID
+func (s *Service) GeneralLedger(ctx context.Context, key string, filter EntriesFilter) (*GeneralLedger, error) {
+	account, err := s.resolveAccount(key)
+	if err != nil {
+		return nil, err
+	}
+
+	id := tb_types.ToUint128(account.ID)
+	accounts, err := s.tbClient.LookupAccounts(ctx, []tb_types.Uint128{id})
+	if err != nil {
+		return nil, err
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("account not found in ledger: %d", account.ID)
+	}
+	account.DebitsPosted = toInt64(accounts[0].DebitsPosted)
+	account.CreditsPosted = toInt64(accounts[0].CreditsPosted)
+
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultEntriesLimit
+	}
+	if filter.Limit > MaxEntriesLimit {
+		filter.Limit = MaxEntriesLimit
+	}
+
+	accountFilter := tb_types.AccountFilter{
+		AccountID: id,
+		Limit:     uint32(filter.Limit),
+		Flags: tb_types.AccountFilterFlags{
+			Debits:   true,
+			Credits:  true,
+			Reversed: filter.Reversed,
+		}.ToUint32(),
+	}
+	if !filter.From.IsZero() {
+		accountFilter.TimestampMin = uint64(filter.From.UnixNano())
+	}
+	if !filter.To.IsZero() {
+		accountFilter.TimestampMax = uint64(filter.To.UnixNano())
+	}
+
+	transfers, err := s.tbClient.GetAccountTransfers(ctx, accountFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(transfers))
+	for _, transfer := range transfers {
+		flags := transfer.TransferFlags()
+		entry := Entry{
+			TransferID: models.Uint128ToHex(transfer.ID),
+			Timestamp:  time.Unix(0, int64(transfer.Timestamp)).UTC(),
+			Code:       transfer.Code,
+			CodeName:   tigerbeetle.TransferCodeName(transfer.Code),
+			Amount:     toInt64(transfer.Amount),
+			Pending:    flags.Pending,
+			Voided:     flags.VoidPendingTransfer,
+			Linked:     flags.Linked,
+		}
+		if transfer.DebitAccountID == id {
+			entry.Side = "debit"
+			entry.Counterparty = toUint64(transfer.CreditAccountID)
+		} else {
+			entry.Side = "credit"
+			entry.Counterparty = toUint64(transfer.DebitAccountID)
+		}
+		entries = append(entries, entry)
+	}
+
+	return &GeneralLedger{
+		Account: *account,
+		Entries: entries,
+		Count:   len(entries),
+	}, nil
+}
+
+// SystemAccountPrefix marks a general ledger key as a chart account ("system:fee_income",
+// "system:999" or "system:2"); keys without it are customer TigerBeetle account IDs
+const SystemAccountPrefix = "system:"
+
+// resolveAccount maps a report key to a chart account or a customer account
+// Chart accounts must be named with SystemAccountPrefix, so a customer account whose ID
+// happens to match a chart ID or code is never shadowed by it
+func (s *Service) resolveAccount(key string) (*LedgerAccount, error) {
+	if name, ok := strings.CutPrefix(key, SystemAccountPrefix); ok {
+		system, err := tigerbeetle.LookupSystemAccount(name)
+		if err != nil {
+			return nil, fmt.Errorf("unknown account: %s", key)
+		}
+		return &LedgerAccount{
+			ID:   system.ID,
+			Name: system.Name,
+			Code: system.Code,
+			Kind: system.Kind,
+		}, nil
+	}
+
+	accountID, err := strconv.ParseUint(key, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("unknown account: %s", key)
+	}
+
+	var user models.User
+	if err := s.db.Select("id", "email", "tigerbeetle_account_id").
+		Where("tigerbeetle_account_id = ?", accountID).
+		First(&user).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("unknown account: %s", key)
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	return &LedgerAccount{
+		ID:   user.TigerBeetleAccountID,
+		Name: user.Email,
+		Code: tigerbeetle.AccountCodeCustomer,
+		Kind: tigerbeetle.AccountKindLiability,
+	}, nil
+}
+
+// toInt64 converts a TigerBeetle amount to cents
+func toInt64(value tb_types.Uint128) int64 {
+	bigInt := value.BigInt()
+	return bigInt.Int64()
+}
+
+// toUint64 converts a TigerBeetle account ID to the uint64 used in PostgreSQL
+func toUint64(value tb_types.Uint128) uint64 {
+	bigInt := value.BigInt()
+	return bigInt.Uint64()
+}
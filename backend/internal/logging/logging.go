@@ -0,0 +1,167 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Config configures the process-wide logger
+type Config struct {
+	Format string                // "json" (default) or "text"
+	Level  slog.Level            // Level of components without their own
+	Levels map[string]slog.Level // Per-component levels, e.g. {"tigerbeetle": DEBUG}
+	Output io.Writer             // Defaults to stdout
+}
+
+// state is the active configuration, swapped atomically by Setup
+type state struct {
+	handler slog.Handler
+	level   slog.Level
+	levels  map[string]slog.Level
+}
+
+var current atomic.Pointer[state]
+
+func init() {
+	current.Store(&state{
+		handler: newHandler("json", os.Stdout),
+		level:   slog.LevelInfo,
+	})
+}
+
+// Setup installs the configuration for every logger, including those created before it ran
+// Output of the standard library logger (used by some dependencies) is routed through the
+// "stdlog" component, so it is redacted and formatted like everything else
+func Setup(cfg Config) {
+	output := cfg.Output
+	if output == nil {
+		output = os.Stdout
+	}
+	current.Store(&state{
+		handler: newHandler(cfg.Format, output),
+		level:   cfg.Level,
+		levels:  cfg.Levels,
+	})
+
+	slog.SetDefault(For("app"))
+	log.SetFlags(0)
+	log.SetOutput(&stdlogWriter{logger: For("stdlog")})
+}
+
+// For returns the logger of a component (usually the package name)
+// Each record carries the component and, when logged with a context, the request ID
+func For(component string) *slog.Logger {
+	return slog.New(&componentHandler{component: component})
+}
+
+// ParseLevel parses a level name: debug, info, warn or error
+func ParseLevel(name string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(name))); err != nil {
+		return 0, fmt.Errorf("invalid log level %q (expected debug, info, warn or error)", name)
+	}
+	return level, nil
+}
+
+// ParseLevels parses per-component levels such as "tigerbeetle=debug,chat=warn"
+func ParseLevels(spec string) (map[string]slog.Level, error) {
+	levels := make(map[string]slog.Level)
+	for _, entry := range strings.Split(spec, ",") {
+		if strings.TrimSpace(entry) == "" {
+			continue
+		}
+		component, name, ok := strings.Cut(entry, "=")
+		component = strings.TrimSpace(component)
+		if !ok || component == "" {
+			return nil, fmt.Errorf("invalid log level entry %q (expected component=level)", entry)
+		}
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", component, err)
+		}
+		levels[component] = level
+	}
+	return levels, nil
+}
+
+// newHandler builds the output handler; every attribute passes through redaction
+func newHandler(format string, output io.Writer) slog.Handler {
+	options := &slog.HandlerOptions{
+		Level:       slog.LevelDebug, // Filtering happens per component
+		ReplaceAttr: redactAttr,
+	}
+	if format == "text" {
+		return slog.NewTextHandler(output, options)
+	}
+	return slog.NewJSONHandler(output, options)
+}
+
+// componentHandler applies the component's level and adds the component, request ID and trace IDs
+// It resolves the active configuration on every record, so package-level loggers created
+// at init time follow Setup
+type componentHandler struct {
+	component string
+	ops       []func(slog.Handler) slog.Handler // WithAttrs/WithGroup calls, replayed in order
+}
+
+// Enabled implements slog.Handler
+func (h *componentHandler) Enabled(_ context.Context, level slog.Level) bool {
+	st := current.Load()
+	if componentLevel, ok := st.levels[h.component]; ok {
+		return level >= componentLevel
+	}
+	return level >= st.level
+}
+
+// Handle implements slog.Handler
+func (h *componentHandler) Handle(ctx context.Context, record slog.Record) error {
+	attrs := []slog.Attr{slog.String("component", h.component)}
+	if requestID := RequestID(ctx); requestID != "" {
+		attrs = append(attrs, slog.String("request_id", requestID))
+	}
+	if span := trace.SpanContextFromContext(ctx); span.IsValid() {
+		attrs = append(attrs, slog.String("trace_id", span.TraceID().String()), slog.String("span_id", span.SpanID().String()))
+	}
+
+	handler := current.Load().handler.WithAttrs(attrs)
+	for _, op := range h.ops {
+		handler = op(handler)
+	}
+	return handler.Handle(ctx, record)
+}
+
+// WithAttrs implements slog.Handler
+func (h *componentHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithAttrs(attrs) })
+}
+
+// WithGroup implements slog.Handler
+func (h *componentHandler) WithGroup(name string) slog.Handler {
+	return h.with(func(next slog.Handler) slog.Handler { return next.WithGroup(name) })
+}
+
+// with returns a copy of the handler with one more operation
+func (h *componentHandler) with(op func(slog.Handler) slog.Handler) *componentHandler {
+	ops := make([]func(slog.Handler) slog.Handler, len(h.ops), len(h.ops)+1)
+	copy(ops, h.ops)
+	return &componentHandler{component: h.component, ops: append(ops, op)}
+}
+
+// stdlogWriter forwards standard library log output as info records
+type stdlogWriter struct {
+	logger *slog.Logger
+}
+
+// Write implements io.Writer
+func (w *stdlogWriter) Write(p []byte) (int, error) {
+	w.logger.Info(strings.TrimSpace(string(p)))
+	return len(p), nil
+}
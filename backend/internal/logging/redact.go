@@ -0,0 +1,83 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces the value of a sensitive attribute
+const redacted = "[REDACTED]"
+
+var (
+	emailPattern         = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+	accountNumberPattern = regexp.MustCompile(`\b\d{4}-\d{4}-\d{4}-(\d{4})\b`)
+	jwtPattern           = regexp.MustCompile(`eyJ[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]+\.[A-Za-z0-9_\-]*`)
+	bearerPattern        = regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/\-]+=*`)
+	secretPattern        = regexp.MustCompile(`\b(whsec|sk-or-v1|sk)[_\-][A-Za-z0-9_\-]{8,}`)
+)
+
+// sensitiveKeys are attribute keys whose values are never logged
+// A key matches when it equals one of these or ends with "_" + one of them (e.g. "refresh_token")
+var sensitiveKeys = []string{
+	"password", "secret", "token", "authorization", "api_key", "otp", "cookie",
+}
+
+// Redact masks emails, account numbers and tokens in s
+// Emails keep their first character and domain, account numbers their last four digits
+func Redact(s string) string {
+	if s == "" {
+		return s
+	}
+	s = jwtPattern.ReplaceAllString(s, "[REDACTED_JWT]")
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = secretPattern.ReplaceAllString(s, "${1}_"+redacted)
+	s = emailPattern.ReplaceAllString(s, "$1***@$2")
+	s = accountNumberPattern.ReplaceAllString(s, "****-****-****-$1")
+	return s
+}
+
+// isSensitiveKey reports whether the value of an attribute must be hidden entirely
+func isSensitiveKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if key == sensitive || strings.HasSuffix(key, "_"+sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// redactAttr is the handlers' ReplaceAttr: it hides sensitive keys and redacts every string value,
+// including the message, error texts and values logged as structs or maps
+func redactAttr(_ []string, attr slog.Attr) slog.Attr {
+	if attr.Key == slog.TimeKey || attr.Key == slog.LevelKey || attr.Key == slog.SourceKey {
+		return attr
+	}
+	if isSensitiveKey(attr.Key) {
+		return slog.String(attr.Key, redacted)
+	}
+
+	switch attr.Value.Kind() {
+	case slog.KindString:
+		return slog.String(attr.Key, Redact(attr.Value.String()))
+	case slog.KindAny:
+		switch v := attr.Value.Any().(type) {
+		case error:
+			return slog.String(attr.Key, Redact(v.Error()))
+		case fmt.Stringer:
+			return slog.String(attr.Key, Redact(v.String()))
+		case []byte:
+			return slog.String(attr.Key, Redact(string(v)))
+		default:
+			encoded, err := json.Marshal(v)
+			if err != nil {
+				return slog.String(attr.Key, Redact(fmt.Sprintf("%+v", v)))
+			}
+			return slog.Any(attr.Key, json.RawMessage(Redact(string(encoded))))
+		}
+	}
+	return attr
+}
@@ -0,0 +1,47 @@
+package mailer
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/hlabs/banking-system/internal/logging"
+)
+
+// FileMailer is a development sink: messages are appended to a file, or printed
+// to the server log when no path is set
+type FileMailer struct {
+	path string
+	from string
+	mu   sync.Mutex
+}
+
+// NewFileMailer creates a file/log sink mailer
+func NewFileMailer(path, from string) *FileMailer {
+	return &FileMailer{
+		path: path,
+		from: from,
+	}
+}
+
+// Send writes the message to the sink
+func (m *FileMailer) Send(msg Message) error {
+	if m.path == "" {
+		logging.For("mailer").Info("email sent", "to", msg.To, "subject", msg.Subject, "body", msg.Body)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	f, err := os.OpenFile(m.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail file: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(render(m.from, msg), "\r\n\r\n"...)); err != nil {
+		return fmt.Errorf("failed to write mail file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package mailer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Message is a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers transactional email (verification links, password resets, notices)
+type Mailer interface {
+	Send(msg Message) error
+}
+
+// Config selects and configures the mailer implementation
+type Config struct {
+	Driver string // "smtp", "file" or "log"
+	From   string
+
+	// SMTP settings
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+
+	// File sink path (driver "file")
+	FilePath string
+}
+
+// New creates the mailer selected by cfg.Driver
+// "log" (the default) prints messages to the server log, which is enough for local development
+func New(cfg Config) (Mailer, error) {
+	switch strings.ToLower(cfg.Driver) {
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required for the smtp mailer")
+		}
+		return NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From), nil
+	case "file":
+		if cfg.FilePath == "" {
+			return nil, fmt.Errorf("MAIL_FILE_PATH is required for the file mailer")
+		}
+		return NewFileMailer(cfg.FilePath, cfg.From), nil
+	case "", "log":
+		return NewFileMailer("", cfg.From), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer driver %q", cfg.Driver)
+	}
+}
@@ -0,0 +1,71 @@
+package mailer
+
+import (
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+// SMTPMailer sends email through an SMTP relay
+// STARTTLS is used automatically when the server offers it (net/smtp.SendMail)
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+	host string
+	from string
+}
+
+// NewSMTPMailer creates a mailer for the given SMTP server
+// Authentication is only used when a username is configured
+func NewSMTPMailer(host, port, username, password, from string) *SMTPMailer {
+	if port == "" {
+		port = "587"
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &SMTPMailer{
+		addr: net.JoinHostPort(host, port),
+		auth: auth,
+		host: host,
+		from: from,
+	}
+}
+
+// Send delivers a message
+func (m *SMTPMailer) Send(msg Message) error {
+	if strings.ContainsAny(msg.To+msg.Subject, "\r\n") {
+		return fmt.Errorf("invalid email header")
+	}
+
+	// The envelope sender is the bare address of the From header
+	envelope := m.from
+	if addr, err := mail.ParseAddress(m.from); err == nil {
+		envelope = addr.Address
+	}
+
+	if err := smtp.SendMail(m.addr, m.auth, envelope, []string{msg.To}, render(m.from, msg)); err != nil {
+		return fmt.Errorf("failed to send email via %s: %w", m.host, err)
+	}
+	return nil
+}
+
+// render builds the RFC 5322 message
+func render(from string, msg Message) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", from)
+	fmt.Fprintf(&b, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&b, "Subject: %s\r\n", msg.Subject)
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(msg.Body, "\n", "\r\n"))
+	return []byte(b.String())
+}
@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// namespace prefixes every metric of the service
+const namespace = "banking"
+
+// amountBuckets are histogram buckets for amounts in cents, from $1 to $100,000
+var amountBuckets = prometheus.ExponentialBuckets(100, 10, 6)
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "status"})
+
+	httpDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+
+	tigerBeetleDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "tigerbeetle",
+		Name:      "request_duration_seconds",
+		Help:      "TigerBeetle call latency by operation.",
+		Buckets:   []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+	}, []string{"operation"})
+
+	tigerBeetleResults = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "tigerbeetle",
+		Name:      "results_total",
+		Help:      "TigerBeetle event results by operation and result code (ok, error for failed calls, or the rejection code).",
+	}, []string{"operation", "result"})
+
+	transferAmount = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "ledger",
+		Name:      "transfer_amount_cents",
+		Help:      "Amounts of posted transactions by type, in cents.",
+		Buckets:   amountBuckets,
+	}, []string{"type"})
+
+	transferVolume = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ledger",
+		Name:      "transfer_volume_cents_total",
+		Help:      "Total amount of posted transactions by type, in cents.",
+	}, []string{"type"})
+
+	auditWriteFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "ledger",
+		Name:      "audit_write_failures_total",
+		Help:      "Transactions posted in TigerBeetle whose PostgreSQL audit record could not be written, by type.",
+	}, []string{"type"})
+
+	openRouterRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "openrouter",
+		Name:      "requests_total",
+		Help:      "OpenRouter completion requests by model and outcome (ok, error for transport failures, or the HTTP status code).",
+	}, []string{"model", "outcome"})
+
+	openRouterDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "openrouter",
+		Name:      "request_duration_seconds",
+		Help:      "OpenRouter completion latency by model.",
+		Buckets:   []float64{.25, .5, 1, 2, 4, 8, 15, 30, 60},
+	}, []string{"model"})
+
+	openRouterTokens = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "openrouter",
+		Name:      "tokens_total",
+		Help:      "OpenRouter tokens by model and kind (prompt or completion).",
+	}, []string{"model", "kind"})
+
+	openRouterCost = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "openrouter",
+		Name:      "cost_usd_total",
+		Help:      "OpenRouter cost reported with each completion, in USD (credits).",
+	}, []string{"model"})
+)
+
+// Handler serves the metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// RegisterDB exports the connection pool statistics of a database under the given name
+func RegisterDB(db *sql.DB, name string) error {
+	return prometheus.Register(collectors.NewDBStatsCollector(db, name))
+}
+
+// ObserveHTTP records a completed HTTP request
+// route is the route template (e.g. "/api/transactions/:id"), never the raw path
+func ObserveHTTP(method, route string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveTigerBeetle records the latency of a TigerBeetle call
+func ObserveTigerBeetle(operation string, duration time.Duration) {
+	tigerBeetleDuration.WithLabelValues(operation).Observe(duration.Seconds())
+}
+
+// CountTigerBeetleResults adds count events with the given result to an operation
+func CountTigerBeetleResults(operation, result string, count int) {
+	if count > 0 {
+		tigerBeetleResults.WithLabelValues(operation, result).Add(float64(count))
+	}
+}
+
+// ObserveTransfer records a transaction posted to the ledger
+func ObserveTransfer(txType string, amount int64) {
+	transferAmount.WithLabelValues(txType).Observe(float64(amount))
+	transferVolume.WithLabelValues(txType).Add(float64(amount))
+}
+
+// AuditWriteFailed records a transaction posted in TigerBeetle without its PostgreSQL audit record
+func AuditWriteFailed(txType string) {
+	auditWriteFailures.WithLabelValues(txType).Inc()
+}
+
+// ObserveOpenRouter records an OpenRouter completion request
+func ObserveOpenRouter(model, outcome string, duration time.Duration) {
+	openRouterRequests.WithLabelValues(model, outcome).Inc()
+	openRouterDuration.WithLabelValues(model).Observe(duration.Seconds())
+}
+
+// AddOpenRouterUsage records the tokens and cost reported by a completion
+func AddOpenRouterUsage(model string, promptTokens, completionTokens int, cost float64) {
+	openRouterTokens.WithLabelValues(model, "prompt").Add(float64(promptTokens))
+	openRouterTokens.WithLabelValues(model, "completion").Add(float64(completionTokens))
+	if cost > 0 {
+		openRouterCost.WithLabelValues(model).Add(cost)
+	}
+}
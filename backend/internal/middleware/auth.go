@@ -1,17 +1,55 @@
 package middleware
 
 import (
-	"log"
+	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/hlabs/banking-system/internal/auth"
+	"github.com/hlabs/banking-system/internal/models"
 	"github.com/hlabs/banking-system/pkg/utils"
 )
 
-// AuthMiddleware validates JWT tokens and sets user context
-func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
+// RevocationList reports whether an access token (by JWT ID) has been revoked
+type RevocationList interface {
+	IsRevoked(jti string) (bool, error)
+}
+
+// SessionTracker records which session (device) is making requests
+type SessionTracker interface {
+	Touch(sessionID, ip string) error
+}
+
+// MachineCredentials authenticates API keys and checks that keys holding
+// client-credentials tokens are still active
+type MachineCredentials interface {
+	AuthenticateKey(rawKey string) (*models.APIKey, error)
+	ActiveClient(clientID string) (*models.APIKey, error)
+	RecordUsage(keyID uuid.UUID, method, path string, status int, ip string)
+}
+
+// How the request was authenticated
+const (
+	AuthMethodSession           = "session"
+	AuthMethodAPIKey            = "api_key"
+	AuthMethodClientCredentials = "client_credentials"
+)
+
+// AuthMiddleware validates JWT tokens, rejects revoked ones and sets user context
+// keys resolves verification keys by kid; revocations may be nil when the service has
+// no access to the revocation list (tokens are then valid until they expire)
+// sessions refreshes the last seen time and IP of the calling session; it may be nil too
+// clients authenticates X-API-Key requests and client-credentials tokens; when nil,
+// API keys are refused and client tokens are only checked for expiry
+func AuthMiddleware(keys auth.KeyResolver, revocations RevocationList, sessions SessionTracker, clients MachineCredentials) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		// API keys authenticate without a token
+		if rawKey := c.GetHeader("X-API-Key"); rawKey != "" {
+			authenticateAPIKey(c, rawKey, clients)
+			return
+		}
+
 		// Get Authorization header
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
@@ -31,21 +69,105 @@ func AuthMiddleware(jwtSecret string) gin.HandlerFunc {
 		tokenString := parts[1]
 
 		// Validate token
-		claims, err := auth.ValidateToken(tokenString, jwtSecret)
+		claims, err := auth.ValidateToken(tokenString, keys)
 		if err != nil {
 			// Log authentication failure for security audit
-			log.Printf("⚠️  Authentication failed from IP %s: %v", c.ClientIP(), err)
+			logger.WarnContext(c.Request.Context(), "authentication failed", "ip", c.ClientIP(), "error", err)
 			utils.RespondWithError(c, 401, "Invalid or expired token")
 			c.Abort()
 			return
 		}
 
+		// Reject tokens revoked by logout, rotation or reuse detection
+		if revocations != nil {
+			revoked, err := revocations.IsRevoked(claims.ID)
+			if err != nil {
+				logger.ErrorContext(c.Request.Context(), "revocation check failed", "jti", claims.ID, "error", err)
+				utils.RespondWithError(c, http.StatusServiceUnavailable, "Unable to verify token")
+				c.Abort()
+				return
+			}
+			if revoked {
+				logger.WarnContext(c.Request.Context(), "revoked token used", "jti", claims.ID, "ip", c.ClientIP())
+				utils.RespondWithError(c, 401, "Token has been revoked")
+				c.Abort()
+				return
+			}
+		}
+
+		// Tokens of revoked or expired API keys stop working right away
+		var key *models.APIKey
+		if claims.ClientID != "" && clients != nil {
+			key, err = clients.ActiveClient(claims.ClientID)
+			if err != nil {
+				logger.WarnContext(c.Request.Context(), "token of inactive API key used", "client_id", claims.ClientID, "ip", c.ClientIP(), "error", err)
+				utils.RespondWithError(c, 401, "API key has been revoked")
+				c.Abort()
+				return
+			}
+		}
+
 		// Set user information in context
 		c.Set("user_id", claims.UserID)
 		c.Set("user_email", claims.Email)
+		c.Set("user_role", claims.Role)
+		c.Set("session_id", claims.SessionID)
+		c.Set("token_jti", claims.ID)
 
+		if claims.ClientID == "" {
+			// Keep the device list's last seen time current; a failed write never fails the request
+			if sessions != nil {
+				if err := sessions.Touch(claims.SessionID, c.ClientIP()); err != nil {
+					logger.WarnContext(c.Request.Context(), "failed to update session", "session_id", claims.SessionID, "error", err)
+				}
+			}
+
+			c.Set("auth_method", AuthMethodSession)
+			c.Next()
+			return
+		}
+
+		c.Set("auth_method", AuthMethodClientCredentials)
+		c.Set("client_id", claims.ClientID)
+		c.Set("scopes", strings.Fields(claims.Scope))
+		if key != nil {
+			c.Set("transfer_limit", key.TransferLimit)
+		}
 		c.Next()
+
+		if key != nil {
+			clients.RecordUsage(key.ID, c.Request.Method, c.Request.URL.Path, c.Writer.Status(), c.ClientIP())
+		}
+	}
+}
+
+// authenticateAPIKey authenticates a request made with the X-API-Key header
+// The request acts for the key's owner with the owner's role, restricted to the key's scopes
+func authenticateAPIKey(c *gin.Context, rawKey string, clients MachineCredentials) {
+	if clients == nil {
+		utils.RespondWithError(c, 401, "API keys are not accepted by this service")
+		c.Abort()
+		return
+	}
+
+	key, err := clients.AuthenticateKey(rawKey)
+	if err != nil {
+		logger.WarnContext(c.Request.Context(), "API key authentication failed", "ip", c.ClientIP(), "error", err)
+		utils.RespondWithError(c, 401, "Invalid or expired API key")
+		c.Abort()
+		return
 	}
+
+	c.Set("user_id", key.UserID.String())
+	c.Set("user_email", key.User.Email)
+	c.Set("user_role", key.User.Role)
+	c.Set("auth_method", AuthMethodAPIKey)
+	c.Set("client_id", key.ClientID)
+	c.Set("scopes", key.ScopeList())
+	c.Set("transfer_limit", key.TransferLimit)
+	c.Next()
+
+	clients.RecordUsage(key.ID, c.Request.Method, c.Request.URL.Path, c.Writer.Status(), c.ClientIP())
 }
 
 // GetUserID retrieves the user ID from the Gin context
@@ -58,3 +180,48 @@ func GetUserID(c *gin.Context) (string, bool) {
 	userIDStr, ok := userID.(string)
 	return userIDStr, ok
 }
+
+// RequireAdmin rejects requests from non-admin users
+// Machine clients are rejected even when their owner is an admin
+// Must be used after AuthMiddleware
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if role, _ := GetUserRole(c); role != models.RoleAdmin || IsMachineClient(c) {
+			userID, _ := GetUserID(c)
+			logger.WarnContext(c.Request.Context(), "admin access denied", "user_id", userID, "path", c.Request.URL.Path)
+			utils.RespondWithError(c, http.StatusForbidden, "Admin access required")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// GetTransferLimit returns the amount (cents) a machine client's key may move when
+// the step-up policy applies; zero for keys without a limit and for user sessions
+func GetTransferLimit(c *gin.Context) int64 {
+	return c.GetInt64("transfer_limit")
+}
+
+// GetSessionID retrieves the session ID of the current access token from the Gin context
+func GetSessionID(c *gin.Context) (string, bool) {
+	sessionID, exists := c.Get("session_id")
+	if !exists {
+		return "", false
+	}
+
+	sessionIDStr, ok := sessionID.(string)
+	return sessionIDStr, ok
+}
+
+// GetUserRole retrieves the user role from the Gin context
+func GetUserRole(c *gin.Context) (string, bool) {
+	role, exists := c.Get("user_role")
+	if !exists {
+		return "", false
+	}
+
+	roleStr, ok := role.(string)
+	return roleStr, ok
+}
@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/internal/metrics"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// Metrics counts requests and measures their latency per route
+// Requests that match no route are grouped as "unmatched", so scanners can't inflate the label set
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveHTTP(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// MetricsAuth protects the metrics endpoint with a static bearer token; an empty token leaves it open
+func MetricsAuth(token string) gin.HandlerFunc {
+	expected := []byte("Bearer " + token)
+	return func(c *gin.Context) {
+		if token == "" {
+			c.Next()
+			return
+		}
+		if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), expected) != 1 {
+			utils.RespondWithError(c, http.StatusUnauthorized, "Invalid metrics token")
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/internal/ratelimit"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// RateLimit applies a token-bucket policy to each client
+// Authenticated requests are limited per user (or per API key for machine clients), the rest per IP,
+// so place it after AuthMiddleware to limit users rather than addresses
+// Every response carries X-RateLimit-Limit/-Remaining/-Reset; a rejected request gets 429 with Retry-After
+// When the store fails the request is let through, so a database hiccup can't take the API down
+func RateLimit(store ratelimit.Store, policy ratelimit.Policy) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !policy.Enabled() {
+			c.Next()
+			return
+		}
+
+		key := "rl:" + policy.Name + ":" + clientKey(c)
+		result, err := store.Take(key, policy, time.Now())
+		if err != nil {
+			logger.ErrorContext(c.Request.Context(), "rate limit check failed", "key", key, "error", err)
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		c.Header("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			logger.WarnContext(c.Request.Context(), "rate limit exceeded", "policy", policy.Name, "limit", policy.String(), "client", clientKey(c), "path", c.Request.URL.Path)
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			utils.RespondWithError(c, http.StatusTooManyRequests, "Too many requests, please slow down")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// DailyQuota caps how many requests each user can make per calendar day (UTC)
+// Must be used after AuthMiddleware; responses carry X-Quota-Limit/-Remaining/-Reset
+// A zero limit disables the quota; store failures let the request through like RateLimit
+func DailyQuota(store ratelimit.Store, name string, limit int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if limit <= 0 {
+			c.Next()
+			return
+		}
+
+		now := time.Now().UTC()
+		day := now.Truncate(24 * time.Hour)
+		resetAt := day.Add(24 * time.Hour)
+		key := "quota:" + name + ":" + clientKey(c) + ":" + day.Format("2006-01-02")
+
+		count, err := store.Increment(key, resetAt, now)
+		if err != nil {
+			logger.ErrorContext(c.Request.Context(), "quota check failed", "key", key, "error", err)
+			c.Next()
+			return
+		}
+
+		remaining := int64(limit) - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		c.Header("X-Quota-Limit", strconv.Itoa(limit))
+		c.Header("X-Quota-Remaining", strconv.FormatInt(remaining, 10))
+		c.Header("X-Quota-Reset", strconv.Itoa(ceilSeconds(resetAt.Sub(now))))
+
+		if count > int64(limit) {
+			logger.WarnContext(c.Request.Context(), "daily quota exhausted", "quota", name, "limit", limit, "client", clientKey(c))
+			c.Header("Retry-After", strconv.Itoa(ceilSeconds(resetAt.Sub(now))))
+			utils.RespondWithError(c, http.StatusTooManyRequests, "Daily "+name+" limit reached, please try again tomorrow")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// clientKey identifies who a limit applies to: the API client, the user or the IP address
+func clientKey(c *gin.Context) string {
+	if clientID := c.GetString("client_id"); clientID != "" {
+		return "client:" + clientID
+	}
+	if userID, ok := GetUserID(c); ok && userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// ceilSeconds rounds a duration up to whole seconds for HTTP headers
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// RequireScope rejects machine credentials (API keys, client-credentials tokens)
+// that were not granted scope; interactive user sessions have every scope
+// Must be used after AuthMiddleware
+func RequireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !IsMachineClient(c) {
+			c.Next()
+			return
+		}
+
+		for _, granted := range GetScopes(c) {
+			if granted == scope {
+				c.Next()
+				return
+			}
+		}
+
+		logger.WarnContext(c.Request.Context(), "client lacks scope", "client_id", c.GetString("client_id"), "scope", scope, "path", c.Request.URL.Path)
+		c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_scope", scope=%q`, scope))
+		utils.RespondWithError(c, http.StatusForbidden, "Missing required scope: "+scope)
+		c.Abort()
+	}
+}
+
+// RequireUser rejects machine credentials: the route is only for a logged-in user
+// Must be used after AuthMiddleware
+func RequireUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if IsMachineClient(c) {
+			utils.RespondWithError(c, http.StatusForbidden, "This endpoint is not available to API clients")
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// IsMachineClient reports whether the request was authenticated with an API key or a client-credentials token
+func IsMachineClient(c *gin.Context) bool {
+	method := c.GetString("auth_method")
+	return method == AuthMethodAPIKey || method == AuthMethodClientCredentials
+}
+
+// GetScopes retrieves the scopes granted to a machine client from the Gin context
+func GetScopes(c *gin.Context) []string {
+	scopes, _ := c.Get("scopes")
+	list, _ := scopes.([]string)
+	return list
+}
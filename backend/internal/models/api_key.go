@@ -0,0 +1,100 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey is a machine credential owned by a user
+// The key is presented as "<client_id>.<secret>" (X-API-Key header) or exchanged for an
+// access token with the OAuth2 client-credentials grant; only a SHA-256 hash of the
+// high-entropy secret is stored
+type APIKey struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User   *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Name   string    `gorm:"type:varchar(100);not null" json:"name"`
+
+	ClientID   string `gorm:"type:varchar(32);not null;uniqueIndex" json:"client_id"`
+	SecretHash string `gorm:"type:varchar(64);not null" json:"-"`
+	Scopes     string `gorm:"type:text;not null" json:"-"` // Space separated, as in OAuth2
+
+	// TransferLimit is the largest withdrawal or transfer (cents) the key may make when the
+	// step-up policy applies; machine clients can't re-authenticate, so the owner approves
+	// this amount with step-up when creating the key. Zero refuses such operations
+	TransferLimit int64 `gorm:"not null;default:0" json:"transfer_limit"`
+
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the APIKey model
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// ScopeList returns the scopes granted to the key
+func (k *APIKey) ScopeList() []string {
+	return strings.Fields(k.Scopes)
+}
+
+// IsActive reports whether the key can still be used at the given time
+func (k *APIKey) IsActive(now time.Time) bool {
+	return k.RevokedAt == nil && (k.ExpiresAt == nil || now.Before(*k.ExpiresAt))
+}
+
+// APIKeyDTO is the API representation of a key (never includes the secret)
+type APIKeyDTO struct {
+	ID            uuid.UUID  `json:"id"`
+	UserID        uuid.UUID  `json:"user_id"`
+	Name          string     `json:"name"`
+	ClientID      string     `json:"client_id"`
+	Scopes        []string   `json:"scopes"`
+	TransferLimit int64      `json:"transfer_limit"`
+	LastUsedAt    *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt     *time.Time `json:"expires_at,omitempty"`
+	RevokedAt     *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// ToDTO converts the key to its API representation
+func (k *APIKey) ToDTO() APIKeyDTO {
+	return APIKeyDTO{
+		ID:            k.ID,
+		UserID:        k.UserID,
+		Name:          k.Name,
+		ClientID:      k.ClientID,
+		Scopes:        k.ScopeList(),
+		TransferLimit: k.TransferLimit,
+		LastUsedAt:    k.LastUsedAt,
+		ExpiresAt:     k.ExpiresAt,
+		RevokedAt:     k.RevokedAt,
+		CreatedAt:     k.CreatedAt,
+	}
+}
+
+// APIKeyUsage is one request made with an API key or a token issued to it
+type APIKeyUsage struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+
+	APIKeyID uuid.UUID `gorm:"type:uuid;not null;index:idx_api_key_usages_key_created,priority:1" json:"api_key_id"`
+	APIKey   *APIKey   `gorm:"foreignKey:APIKeyID;constraint:OnDelete:CASCADE" json:"-"`
+
+	Method     string `gorm:"type:varchar(10);not null" json:"method"`
+	Path       string `gorm:"type:varchar(255);not null" json:"path"`
+	StatusCode int    `gorm:"not null" json:"status_code"`
+	IPAddress  string `gorm:"type:varchar(45)" json:"ip_address"`
+
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_api_key_usages_key_created,priority:2" json:"created_at"`
+}
+
+// TableName specifies the table name for the APIKeyUsage model
+func (APIKeyUsage) TableName() string {
+	return "api_key_usages"
+}
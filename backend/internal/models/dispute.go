@@ -0,0 +1,120 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// DisputeStatus represents the state of a dispute case
+type DisputeStatus string
+
+const (
+	DisputeStatusOpening     DisputeStatus = "opening" // Recorded, reservation being posted in TigerBeetle
+	DisputeStatusOpened      DisputeStatus = "opened"
+	DisputeStatusUnderReview DisputeStatus = "under_review"
+	DisputeStatusResolving   DisputeStatus = "resolving" // Outcome decided, settlement being posted in TigerBeetle
+	DisputeStatusWon         DisputeStatus = "won"       // Resolved in the customer's favour - hold posted
+	DisputeStatusLost        DisputeStatus = "lost"      // Resolved against the customer - hold voided
+	DisputeStatusFailed      DisputeStatus = "failed"    // TigerBeetle rejected the reservation - nothing is held
+)
+
+// DisputeHoldingStatuses lists the states in which a case reserves part of its transaction
+// A case being opened or resolved may already have moved money in TigerBeetle, so it counts too
+var DisputeHoldingStatuses = []DisputeStatus{
+	DisputeStatusOpening,
+	DisputeStatusOpened,
+	DisputeStatusUnderReview,
+	DisputeStatusResolving,
+}
+
+// IsActive reports whether the case is open for review and resolution
+func (s DisputeStatus) IsActive() bool {
+	return s == DisputeStatusOpened || s == DisputeStatusUnderReview
+}
+
+// IsValid reports whether the status is one of the known dispute states
+func (s DisputeStatus) IsValid() bool {
+	switch s {
+	case DisputeStatusOpening, DisputeStatusOpened, DisputeStatusUnderReview, DisputeStatusResolving,
+		DisputeStatusWon, DisputeStatusLost, DisputeStatusFailed:
+		return true
+	}
+	return false
+}
+
+// DisputeReason is the reason code given by the customer
+type DisputeReason string
+
+const (
+	DisputeReasonUnauthorized    DisputeReason = "unauthorized"
+	DisputeReasonNotReceived     DisputeReason = "not_received"
+	DisputeReasonDuplicate       DisputeReason = "duplicate"
+	DisputeReasonIncorrectAmount DisputeReason = "incorrect_amount"
+	DisputeReasonFraud           DisputeReason = "fraud"
+	DisputeReasonOther           DisputeReason = "other"
+)
+
+// DisputeReasons lists every reason code with its description
+var DisputeReasons = map[DisputeReason]string{
+	DisputeReasonUnauthorized:    "I did not authorize this transaction",
+	DisputeReasonNotReceived:     "Goods, services or cash were not received",
+	DisputeReasonDuplicate:       "The transaction was processed more than once",
+	DisputeReasonIncorrectAmount: "The amount is different from what was agreed",
+	DisputeReasonFraud:           "The transaction is fraudulent",
+	DisputeReasonOther:           "Other reason (see description)",
+}
+
+// Dispute is a customer case contesting all or part of a transaction
+//
+// While the case is active the disputed amount is reserved for the customer: with a TigerBeetle
+// pending transfer debited from the original counterparty when it can cover it (a hold), otherwise
+// with a posted transfer from the suspense account (a provisional credit the customer can spend).
+// Winning posts the hold, or recovers the credit from the counterparty, and records a reversal;
+// losing voids the hold, or claws the credit back from the customer. A customer is only debited
+// what their balance covers when a credit is recovered: the rest is written off to dispute
+// losses, so suspense is always cleared and the loss shows in the trial balance.
+//
+// TigerBeetle is never called while the case is locked: the case is saved as opening (or resolving)
+// with the ID of the transfer about to be posted, the transfer is posted, then the case moves on.
+// A case left opening or resolving is the reconciliation entry for that transfer; resolving the
+// case again retries its settlement with the same transfer ID.
+type Dispute struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+
+	TransactionID uuid.UUID    `gorm:"type:uuid;not null;index" json:"transaction_id"`
+	Transaction   *Transaction `gorm:"foreignKey:TransactionID;constraint:OnDelete:RESTRICT" json:"transaction,omitempty"`
+	UserID        uuid.UUID    `gorm:"type:uuid;not null;index" json:"user_id"` // Customer who opened the case
+	User          *User        `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+
+	ReasonCode  DisputeReason `gorm:"type:varchar(20);not null" json:"reason_code"`
+	Description string        `gorm:"type:text" json:"description,omitempty"`
+	Amount      int64         `gorm:"not null;check:amount > 0" json:"amount"` // Disputed amount in cents
+
+	Status DisputeStatus `gorm:"type:varchar(15);not null;default:'opened';check:status IN ('opening','opened','under_review','resolving','won','lost','failed');index" json:"status"`
+
+	// Transfer reserving the disputed amount
+	HoldAccountID         uint64     `gorm:"not null" json:"hold_account_id"`                                  // Account debited by the hold or credit
+	CreditAccountID       uint64     `gorm:"not null" json:"credit_account_id"`                                // Customer account credited
+	PendingTransferID     string     `gorm:"type:varchar(32);not null;uniqueIndex" json:"pending_transfer_id"` // Pending hold, or the posted provisional credit
+	SettlementTransferID  string     `gorm:"type:varchar(32)" json:"settlement_transfer_id,omitempty"`         // Post/void of the hold, or recovery of the credit
+	WriteOffTransferID    string     `gorm:"type:varchar(32)" json:"write_off_transfer_id,omitempty"`          // Write-off of the part of a credit that wasn't recovered
+	WrittenOff            int64      `gorm:"not null;default:0" json:"written_off,omitempty"`                  // Amount written off to dispute losses
+	ProvisionalCredit     bool       `gorm:"not null;default:false" json:"provisional_credit"`                 // Held in suspense instead of at the counterparty
+	ReversalTransactionID *uuid.UUID `gorm:"type:uuid" json:"reversal_transaction_id,omitempty"`               // Reversal recorded on a win
+
+	// Case handling
+	AssignedTo     *uuid.UUID    `gorm:"type:uuid" json:"assigned_to,omitempty"`
+	Outcome        DisputeStatus `gorm:"type:varchar(15)" json:"outcome,omitempty"` // Won or lost, set when resolution starts
+	ResolvedBy     *uuid.UUID    `gorm:"type:uuid" json:"resolved_by,omitempty"`
+	ResolutionNote string        `gorm:"type:text" json:"resolution_note,omitempty"`
+	ResolvedAt     *time.Time    `json:"resolved_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the Dispute model
+func (Dispute) TableName() string {
+	return "disputes"
+}
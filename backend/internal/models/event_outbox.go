@@ -0,0 +1,27 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// OutboxEvent is a domain event in the PostgreSQL outbox, for consumers that read the database
+// Consumers page through rows in Position order and remember the last position they processed
+type OutboxEvent struct {
+	Position int64     `gorm:"primaryKey;autoIncrement" json:"position"`
+	ID       uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"id"`
+
+	Type       string         `gorm:"type:varchar(50);not null;index" json:"type"`
+	Key        string         `gorm:"type:varchar(100);not null" json:"key"`
+	Payload    datatypes.JSON `gorm:"type:jsonb;not null" json:"payload"`
+	OccurredAt time.Time      `gorm:"not null;index" json:"occurred_at"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the OutboxEvent model
+func (OutboxEvent) TableName() string {
+	return "event_outbox"
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MicrosPerCent is the number of accrual units in one cent
+// Daily interest on small balances is a fraction of a cent, so accruals are
+// tracked in micro-cents and only rounded down to cents when capitalized
+const MicrosPerCent = 1_000_000
+
+// InterestAccrual records the interest earned by one account for one day
+// The (user_id, accrual_date) unique index makes the daily accrual job idempotent
+type InterestAccrual struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+
+	UserID      uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_interest_accruals_user_date,priority:1" json:"user_id"`
+	User        *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	AccrualDate time.Time `gorm:"type:date;not null;uniqueIndex:idx_interest_accruals_user_date,priority:2;index" json:"accrual_date"`
+
+	// Inputs of the calculation, kept for auditability
+	AccountType string `gorm:"type:varchar(20);not null" json:"account_type"`
+	Balance     int64  `gorm:"not null" json:"balance"`  // End-of-day balance in cents (from TigerBeetle)
+	RateBps     int64  `gorm:"not null" json:"rate_bps"` // Annual rate in basis points
+
+	// Interest earned for the day in micro-cents
+	AmountMicros int64 `gorm:"not null" json:"amount_micros"`
+
+	// Set once the accrual has been paid out by a month-end capitalization
+	CapitalizationID *uuid.UUID `gorm:"type:uuid;index" json:"capitalization_id,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the InterestAccrual model
+func (InterestAccrual) TableName() string {
+	return "interest_accruals"
+}
+
+// InterestCapitalizationStatus represents the state of a month-end interest posting
+type InterestCapitalizationStatus string
+
+const (
+	InterestCapitalizationPending   InterestCapitalizationStatus = "pending"
+	InterestCapitalizationCompleted InterestCapitalizationStatus = "completed"
+)
+
+// InterestCapitalization records the month-end posting of accrued interest to an account
+// The (user_id, period) unique index and the deterministic TigerBeetle transfer ID make
+// reruns idempotent: a pending row is retried with the same transfer ID, a completed row is skipped
+type InterestCapitalization struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_interest_capitalizations_user_period,priority:1" json:"user_id"`
+	User   *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Period string    `gorm:"type:varchar(7);not null;uniqueIndex:idx_interest_capitalizations_user_period,priority:2" json:"period"` // YYYY-MM
+
+	// AccruedMicros = accruals of the period + remainder carried from the previous period
+	AccruedMicros   int64 `gorm:"not null" json:"accrued_micros"`
+	Amount          int64 `gorm:"not null" json:"amount"`           // Posted amount in cents
+	RemainderMicros int64 `gorm:"not null" json:"remainder_micros"` // Sub-cent remainder carried forward
+
+	TigerBeetleTransferID string                       `gorm:"type:varchar(32);not null;uniqueIndex" json:"tigerbeetle_transfer_id"`
+	Status                InterestCapitalizationStatus `gorm:"type:varchar(10);not null;default:'pending'" json:"status"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the InterestCapitalization model
+func (InterestCapitalization) TableName() string {
+	return "interest_capitalizations"
+}
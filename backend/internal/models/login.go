@@ -0,0 +1,63 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LoginAttempt is one entry of the login history
+// Failed attempts against unknown emails are kept too (UserID is nil) to trace credential stuffing
+type LoginAttempt struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+
+	UserID *uuid.UUID `gorm:"type:uuid;index:idx_login_attempts_user_created,priority:1" json:"user_id,omitempty"`
+	User   *User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Email  string     `gorm:"type:varchar(255);not null" json:"email"`
+
+	IPAddress string `gorm:"type:varchar(45);index:idx_login_attempts_ip_created,priority:1" json:"ip_address"`
+	UserAgent string `gorm:"type:text" json:"user_agent"`
+
+	Success       bool   `gorm:"not null" json:"success"`
+	FailureReason string `gorm:"type:varchar(50)" json:"failure_reason,omitempty"`
+
+	CreatedAt time.Time `gorm:"not null;default:CURRENT_TIMESTAMP;index:idx_login_attempts_user_created,priority:2;index:idx_login_attempts_ip_created,priority:2" json:"created_at"`
+}
+
+// TableName specifies the table name for the LoginAttempt model
+func (LoginAttempt) TableName() string {
+	return "login_attempts"
+}
+
+// Login failure reasons recorded in the history
+const (
+	LoginFailureInvalidCredentials = "invalid_credentials"
+	LoginFailureInvalidMFACode     = "invalid_mfa_code"
+	LoginFailureInvalidPasskey     = "invalid_passkey"
+	LoginFailureThrottled          = "throttled"
+)
+
+// Login throttle scopes: failures are counted per account (email) and per client IP
+const (
+	ThrottleScopeAccount = "account"
+	ThrottleScopeIP      = "ip"
+)
+
+// LoginThrottle counts consecutive failed logins for an account or an IP
+// Once the count reaches the scope's threshold each further failure locks the key
+// for an exponentially growing period
+type LoginThrottle struct {
+	Scope string `gorm:"type:varchar(10);primaryKey" json:"scope"`
+	Key   string `gorm:"column:throttle_key;type:varchar(255);primaryKey" json:"key"`
+
+	Failures      int        `gorm:"not null;default:0" json:"failures"`
+	LastFailureAt time.Time  `gorm:"not null" json:"last_failure_at"`
+	LockedUntil   *time.Time `json:"locked_until,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the LoginThrottle model
+func (LoginThrottle) TableName() string {
+	return "login_throttles"
+}
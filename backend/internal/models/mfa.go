@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RecoveryCode is a one-time MFA recovery code
+// Codes are random and high-entropy, so a SHA-256 hash is enough to store them
+type RecoveryCode struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+
+	UserID   uuid.UUID  `gorm:"type:uuid;not null;index" json:"user_id"`
+	User     *User      `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	CodeHash string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	UsedAt   *time.Time `json:"used_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the RecoveryCode model
+func (RecoveryCode) TableName() string {
+	return "mfa_recovery_codes"
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Notification types
+const (
+	NotificationIncomingTransfer       = "incoming_transfer"         // Money received from another user
+	NotificationLargeWithdrawal        = "large_withdrawal"          // Withdrawal at or above the user's threshold
+	NotificationLowBalance             = "low_balance"               // Balance dropped below the user's threshold
+	NotificationAPIClientPaymentFailed = "api_client_payment_failed" // A payment an API client made on the user's behalf was rejected
+	NotificationReversal               = "reversal"                  // A transaction was refunded or reversed
+	NotificationDispute                = "dispute"                   // A dispute was opened or resolved
+	NotificationSecurity               = "security"                  // New sign-in, password or MFA change
+)
+
+// NotificationTypes lists every notification type a user can configure
+var NotificationTypes = []string{
+	NotificationIncomingTransfer,
+	NotificationLargeWithdrawal,
+	NotificationLowBalance,
+	NotificationAPIClientPaymentFailed,
+	NotificationReversal,
+	NotificationDispute,
+	NotificationSecurity,
+}
+
+// Notification channels
+const (
+	NotificationChannelInApp = "in_app"
+	NotificationChannelEmail = "email"
+	NotificationChannelSMS   = "sms"
+)
+
+// Notification is an in-app notification shown in the user's notification center
+type Notification struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index:idx_notifications_user_created,priority:1" json:"-"`
+	User   *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+
+	Type  string         `gorm:"type:varchar(40);not null" json:"type"`
+	Title string         `gorm:"type:varchar(255);not null" json:"title"`
+	Body  string         `gorm:"type:text;not null" json:"body"`
+	Data  datatypes.JSON `gorm:"type:jsonb" json:"data,omitempty"`
+
+	ReadAt    *time.Time `json:"read_at"`
+	CreatedAt time.Time  `gorm:"not null;index:idx_notifications_user_created,priority:2" json:"created_at"`
+}
+
+// TableName specifies the table name for the Notification model
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// NotificationPreference is a user's choice of channels for one notification type
+// Types without a row use the defaults; Threshold (cents) only applies to large_withdrawal
+// and low_balance, where nil means the configured default
+type NotificationPreference struct {
+	UserID uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	User   *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Type   string    `gorm:"type:varchar(40);primaryKey" json:"type"`
+
+	InApp     bool   `gorm:"not null" json:"in_app"`
+	Email     bool   `gorm:"not null" json:"email"`
+	SMS       bool   `gorm:"not null" json:"sms"`
+	Threshold *int64 `json:"threshold,omitempty"`
+	Mandatory bool   `gorm:"-" json:"mandatory,omitempty"` // In-app and email can't be turned off
+
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the NotificationPreference model
+func (NotificationPreference) TableName() string {
+	return "notification_preferences"
+}
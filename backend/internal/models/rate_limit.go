@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// RateLimitBucket is the state of one token bucket when rate limits are kept in PostgreSQL
+// Key combines the policy name with the client (user, API key or IP)
+type RateLimitBucket struct {
+	Key       string    `gorm:"type:varchar(255);primary_key" json:"key"`
+	Tokens    float64   `gorm:"not null" json:"tokens"`
+	UpdatedAt time.Time `gorm:"not null;index" json:"updated_at"`
+}
+
+// TableName specifies the table name for the RateLimitBucket model
+func (RateLimitBucket) TableName() string {
+	return "rate_limit_buckets"
+}
+
+// UsageQuota counts requests in a fixed window (e.g. chat messages per user and day)
+type UsageQuota struct {
+	Key       string    `gorm:"type:varchar(255);primary_key" json:"key"`
+	Count     int64     `gorm:"not null;default:0" json:"count"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+// TableName specifies the table name for the UsageQuota model
+func (UsageQuota) TableName() string {
+	return "usage_quotas"
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// SchemaVersion records each schema version applied by the migrations
+// The highest version is the one the database is at
+type SchemaVersion struct {
+	Version   int       `gorm:"primaryKey;autoIncrement:false" json:"version"`
+	AppliedAt time.Time `gorm:"not null" json:"applied_at"`
+}
+
+// TableName specifies the table name for the SchemaVersion model
+func (SchemaVersion) TableName() string {
+	return "schema_versions"
+}
@@ -0,0 +1,94 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session is a server-side login session
+// Each session holds one refresh token family; access tokens carry the session ID (sid claim)
+type Session struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User   *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+
+	// Client information captured at login; the IP address follows the client while the session is used
+	DeviceName string `gorm:"type:varchar(100)" json:"device_name"`
+	IPAddress  string `gorm:"type:varchar(45)" json:"ip_address"`
+	UserAgent  string `gorm:"type:text" json:"user_agent"`
+
+	// SHA-256 of the device identifier the client sent (X-Device-ID), empty if it sent none
+	DeviceIDHash string `gorm:"type:varchar(64);index" json:"-"`
+
+	// JTI of the most recently issued access token (revoked on rotation and logout)
+	CurrentJTI string `gorm:"type:varchar(64)" json:"-"`
+
+	ExpiresAt     time.Time  `gorm:"not null" json:"expires_at"`
+	LastUsedAt    time.Time  `gorm:"not null" json:"last_used_at"` // Last seen, refreshed by the auth middleware
+	RevokedAt     *time.Time `gorm:"index" json:"revoked_at,omitempty"`
+	RevokedReason string     `gorm:"type:varchar(50)" json:"revoked_reason,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Current marks the session of the request that listed it (not stored)
+	Current bool `gorm:"-" json:"current"`
+}
+
+// TableName specifies the table name for the Session model
+func (Session) TableName() string {
+	return "sessions"
+}
+
+// IsActive reports whether the session can still be used at the given time
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// Session revocation reasons
+const (
+	SessionRevokedLogout         = "logout"
+	SessionRevokedLogoutAll      = "logout_all"
+	SessionRevokedReuse          = "refresh_token_reuse"
+	SessionRevokedAdmin          = "admin"
+	SessionRevokedPasswordChange = "password_change"
+	SessionRevokedByUser         = "revoked_by_user"
+)
+
+// RefreshToken is one link of a session's rotating refresh token chain
+// Only the SHA-256 hash is stored; presenting a token that was already rotated
+// (UsedAt set) is treated as theft and revokes the whole session
+type RefreshToken struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+
+	SessionID uuid.UUID `gorm:"type:uuid;not null;index" json:"session_id"`
+	Session   *Session  `gorm:"foreignKey:SessionID;constraint:OnDelete:CASCADE" json:"-"`
+	TokenHash string    `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+
+	ExpiresAt time.Time  `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the RefreshToken model
+func (RefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// RevokedToken is an entry of the access token revocation list, keyed by JWT ID
+// Entries can be purged once the token would have expired anyway
+type RevokedToken struct {
+	JTI       string    `gorm:"type:varchar(64);primary_key" json:"jti"`
+	UserID    uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	ExpiresAt time.Time `gorm:"not null;index" json:"expires_at"`
+	Reason    string    `gorm:"type:varchar(50)" json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the RevokedToken model
+func (RevokedToken) TableName() string {
+	return "revoked_tokens"
+}
@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// SigningKey is an asymmetric key pair used to sign access tokens
+// Exactly one key is active (RetiredAt is nil) and signs new tokens; retired keys keep
+// verifying tokens until ExpiresAt so tokens signed just before a rotation stay valid
+type SigningKey struct {
+	KID       string `gorm:"column:kid;type:varchar(64);primaryKey" json:"kid"`
+	Algorithm string `gorm:"type:varchar(10);not null" json:"algorithm"`
+
+	PublicKey  string `gorm:"type:text;not null" json:"public_key"` // PEM encoded PKIX
+	PrivateKey string `gorm:"type:text;not null" json:"-"`          // PKCS#8, encrypted at rest
+
+	RetiredAt *time.Time `gorm:"index" json:"retired_at,omitempty"`
+	ExpiresAt *time.Time `gorm:"index" json:"expires_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName specifies the table name for the SigningKey model
+func (SigningKey) TableName() string {
+	return "signing_keys"
+}
+
+// IsActive reports whether the key signs new tokens
+func (k *SigningKey) IsActive() bool {
+	return k.RetiredAt == nil
+}
@@ -18,6 +18,8 @@ const (
 	TransactionTypeDeposit  TransactionType = "deposit"
 	TransactionTypeWithdraw TransactionType = "withdraw"
 	TransactionTypeTransfer TransactionType = "transfer"
+	TransactionTypeInterest TransactionType = "interest"
+	TransactionTypeReversal TransactionType = "reversal"
 )
 
 // TransactionStatus represents the status of a transaction
@@ -41,7 +43,7 @@ type Transaction struct {
 	RecipientUser   *User      `gorm:"foreignKey:RecipientUserID;constraint:OnDelete:SET NULL" json:"recipient_user,omitempty"`
 
 	// Transaction details
-	Type   TransactionType   `gorm:"type:varchar(10);not null;check:type IN ('deposit','withdraw','transfer');index:idx_transactions_type" json:"type"`
+	Type   TransactionType   `gorm:"type:varchar(10);not null;check:type IN ('deposit','withdraw','transfer','interest','reversal');index:idx_transactions_type" json:"type"`
 	Amount int64             `gorm:"not null;check:amount > 0" json:"amount"` // Amount in cents
 	Status TransactionStatus `gorm:"type:varchar(10);not null;default:'pending';check:status IN ('pending','completed','failed');index:idx_transactions_status" json:"status"`
 
@@ -52,6 +54,15 @@ type Transaction struct {
 	// TigerBeetle transfer tracking (uint128 stored as hex string)
 	TigerBeetleTransferID string `gorm:"type:varchar(32);not null;uniqueIndex" json:"tigerbeetle_transfer_id"`
 
+	// Fee charged on top of Amount (in cents), posted as a linked transfer to the fee income account
+	Fee                      int64  `gorm:"not null;default:0;check:fee >= 0" json:"fee"`
+	FeeTigerBeetleTransferID string `gorm:"type:varchar(32)" json:"fee_tigerbeetle_transfer_id,omitempty"`
+
+	// Reversals: a reversal points at the transaction it undoes, and the original
+	// tracks how much of its amount has been reversed so far (never more than Amount)
+	ReversalOfID   *uuid.UUID `gorm:"type:uuid;index:idx_transactions_reversal_of_id" json:"reversal_of_id,omitempty"`
+	ReversedAmount int64      `gorm:"not null;default:0;check:reversed_amount >= 0" json:"reversed_amount"`
+
 	// Optional fields
 	Description string         `gorm:"type:text" json:"description,omitempty"`
 	Metadata    datatypes.JSON `gorm:"type:jsonb;default:'{}'" json:"metadata,omitempty"`
@@ -80,11 +91,7 @@ func (t *Transaction) BeforeCreate(tx *gorm.DB) error {
 
 // SetTigerBeetleTransferID converts TigerBeetle Uint128 to hex string and stores it
 func (t *Transaction) SetTigerBeetleTransferID(id tb_types.Uint128) {
-	// Convert Uint128 to bytes and then to hex string
-	bytes := make([]byte, 16)
-	bigInt := id.BigInt()
-	bigInt.FillBytes(bytes)
-	t.TigerBeetleTransferID = hex.EncodeToString(bytes)
+	t.TigerBeetleTransferID = Uint128ToHex(id)
 }
 
 // GetTigerBeetleTransferID converts hex string back to TigerBeetle Uint128
@@ -99,10 +106,7 @@ func (t *Transaction) GetTigerBeetleTransferID() (tb_types.Uint128, error) {
 		return tb_types.Uint128{}, fmt.Errorf("invalid transfer ID length: expected 16 bytes, got %d", len(bytes))
 	}
 
-	var fixedBytes [16]byte
-	copy(fixedBytes[:], bytes)
-
-	return tb_types.BytesToUint128(fixedBytes), nil
+	return bigEndianToUint128(bytes), nil
 }
 
 // TransactionDTO is the data transfer object for transaction information
@@ -118,15 +122,39 @@ type TransactionDTO struct {
 	DebitAccountID        uint64            `json:"debit_account_id"`
 	CreditAccountID       uint64            `json:"credit_account_id"`
 	TigerBeetleTransferID string            `json:"tigerbeetle_transfer_id"`
+	Fee                   int64             `json:"fee"` // Fee in cents
+	FeeFormatted          string            `json:"fee_formatted"`
 	Description           string            `json:"description,omitempty"`
+	Metadata              datatypes.JSON    `json:"metadata,omitempty"`
 	CreatedAt             time.Time         `json:"created_at"`
 	UpdatedAt             time.Time         `json:"updated_at"`
 
+	// Reversal relationship
+	ReversalOfID            *uuid.UUID  `json:"reversal_of_id,omitempty"`
+	ReversedAmount          int64       `json:"reversed_amount,omitempty"`
+	ReversedAmountFormatted string      `json:"reversed_amount_formatted,omitempty"`
+	ReversalStatus          string      `json:"reversal_status,omitempty"` // "partially_reversed" or "reversed"
+	ReversalIDs             []uuid.UUID `json:"reversal_ids,omitempty"`
+
 	// Enriched fields (from JOINs)
 	RecipientEmail string `json:"recipient_email,omitempty"`
 	RecipientName  string `json:"recipient_name,omitempty"`
 }
 
+// Reversal statuses reported on original transactions
+const (
+	ReversalStatusPartial = "partially_reversed"
+	ReversalStatusFull    = "reversed"
+)
+
+// RefundableAmount returns how much of the transaction can still be reversed
+func (t *Transaction) RefundableAmount() int64 {
+	if t.Type == TransactionTypeReversal {
+		return 0
+	}
+	return t.Amount - t.ReversedAmount
+}
+
 // ToDTO converts a Transaction to TransactionDTO with enriched information
 func (t *Transaction) ToDTO() TransactionDTO {
 	dto := TransactionDTO{
@@ -140,9 +168,22 @@ func (t *Transaction) ToDTO() TransactionDTO {
 		DebitAccountID:        t.DebitAccountID,
 		CreditAccountID:       t.CreditAccountID,
 		TigerBeetleTransferID: t.TigerBeetleTransferID,
+		Fee:                   t.Fee,
+		FeeFormatted:          formatAmount(t.Fee),
 		Description:           t.Description,
+		Metadata:              t.Metadata,
 		CreatedAt:             t.CreatedAt,
 		UpdatedAt:             t.UpdatedAt,
+		ReversalOfID:          t.ReversalOfID,
+	}
+
+	if t.ReversedAmount > 0 {
+		dto.ReversedAmount = t.ReversedAmount
+		dto.ReversedAmountFormatted = formatAmount(t.ReversedAmount)
+		dto.ReversalStatus = ReversalStatusPartial
+		if t.ReversedAmount >= t.Amount {
+			dto.ReversalStatus = ReversalStatusFull
+		}
 	}
 
 	// Enrich with recipient information if available
@@ -180,8 +221,16 @@ func HexToUint128(s string) (tb_types.Uint128, error) {
 		return tb_types.Uint128{}, fmt.Errorf("invalid hex length: expected 32 characters (16 bytes), got %d bytes", len(bytes))
 	}
 
+	return bigEndianToUint128(bytes), nil
+}
+
+// bigEndianToUint128 reverses the hex encoding above: the stored bytes are big-endian
+// (from big.Int.FillBytes) while Uint128 holds its bytes little-endian
+func bigEndianToUint128(bytes []byte) tb_types.Uint128 {
 	var fixedBytes [16]byte
-	copy(fixedBytes[:], bytes)
+	for i := range fixedBytes {
+		fixedBytes[i] = bytes[len(bytes)-1-i]
+	}
 
-	return tb_types.BytesToUint128(fixedBytes), nil
+	return tb_types.BytesToUint128(fixedBytes)
 }
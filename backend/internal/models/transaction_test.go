@@ -0,0 +1,63 @@
+package models
+
+import (
+	"math/big"
+	"testing"
+
+	tb_types "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
+)
+
+func TestTransferIDRoundTrip(t *testing.T) {
+	high := new(big.Int).Lsh(big.NewInt(1), 127)
+	ids := []tb_types.Uint128{
+		tb_types.ToUint128(0),
+		tb_types.ToUint128(1),
+		tb_types.ToUint128(0xdeadbeef),
+		tb_types.ToUint128(^uint64(0)),
+		tb_types.BigIntToUint128(*high),
+	}
+
+	for _, id := range ids {
+		encoded := Uint128ToHex(id)
+		if len(encoded) != 32 {
+			t.Fatalf("Uint128ToHex(%s) = %q, want 32 hex characters", id.String(), encoded)
+		}
+
+		decoded, err := HexToUint128(encoded)
+		if err != nil {
+			t.Fatalf("HexToUint128(%q): %v", encoded, err)
+		}
+		if decoded != id {
+			t.Errorf("HexToUint128(Uint128ToHex(%s)) = %s", id.String(), decoded.String())
+		}
+
+		tx := Transaction{}
+		tx.SetTigerBeetleTransferID(id)
+		fromRecord, err := tx.GetTigerBeetleTransferID()
+		if err != nil {
+			t.Fatalf("GetTigerBeetleTransferID(%q): %v", tx.TigerBeetleTransferID, err)
+		}
+		if fromRecord != id {
+			t.Errorf("GetTigerBeetleTransferID after SetTigerBeetleTransferID(%s) = %s", id.String(), fromRecord.String())
+		}
+	}
+}
+
+func TestHexToUint128IsBigEndian(t *testing.T) {
+	// The stored form is the big-endian hex of the number, so 1 ends in "01"
+	decoded, err := HexToUint128("00000000000000000000000000000001")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded != tb_types.ToUint128(1) {
+		t.Errorf("HexToUint128(...01) = %s, want 1", decoded.String())
+	}
+}
+
+func TestHexToUint128RejectsBadInput(t *testing.T) {
+	for _, input := range []string{"", "zz", "0001"} {
+		if _, err := HexToUint128(input); err == nil {
+			t.Errorf("HexToUint128(%q) succeeded, want error", input)
+		}
+	}
+}
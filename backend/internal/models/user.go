@@ -21,6 +21,26 @@ type User struct {
 	// This is used to link transactions from the JSON test data
 	AccountNumber string `gorm:"index" json:"account_number,omitempty"`
 
+	// Account product type from test data ("checking", "savings", "investment")
+	// Drives the fee schedule and other per-product rules
+	AccountType string `gorm:"type:varchar(20);not null;default:'checking'" json:"account_type"`
+
+	// Role controls access to back-office endpoints ("customer" or "admin")
+	Role string `gorm:"type:varchar(20);not null;default:'customer'" json:"role"`
+
+	// TOTP multi-factor authentication
+	// The secret is stored encrypted and only becomes active once a code has been verified
+	MFAEnabled       bool       `gorm:"not null;default:false" json:"mfa_enabled"`
+	TOTPSecret       string     `gorm:"type:text" json:"-"`
+	TOTPLastUsedStep int64      `gorm:"not null;default:0" json:"-"` // Rejects replay of an already used code
+	MFAEnabledAt     *time.Time `json:"mfa_enabled_at,omitempty"`
+
+	// Set once the user proves ownership of the email address; unverified users get restricted limits
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+
+	// Destination of SMS notifications in international format (e.g. +14155550123)
+	PhoneNumber string `gorm:"type:varchar(20)" json:"phone_number,omitempty"`
+
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -31,6 +51,29 @@ func (User) TableName() string {
 	return "users"
 }
 
+// Account types from the test data
+const (
+	AccountTypeChecking   = "checking"
+	AccountTypeSavings    = "savings"
+	AccountTypeInvestment = "investment"
+)
+
+// User roles
+const (
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+)
+
+// IsAdmin reports whether the user has back-office access
+func (u *User) IsAdmin() bool {
+	return u.Role == RoleAdmin
+}
+
+// IsEmailVerified reports whether the user confirmed their email address
+func (u *User) IsEmailVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
 // UserDTO is the data transfer object for user information (safe for API responses)
 type UserDTO struct {
 	ID                   uuid.UUID `json:"id"`
@@ -38,6 +81,10 @@ type UserDTO struct {
 	FullName             string    `json:"full_name"`
 	TigerBeetleAccountID uint64    `json:"tigerbeetle_account_id"`
 	AccountNumber        string    `json:"account_number,omitempty"`
+	AccountType          string    `json:"account_type"`
+	Role                 string    `json:"role"`
+	MFAEnabled           bool      `json:"mfa_enabled"`
+	EmailVerified        bool      `json:"email_verified"`
 	CreatedAt            time.Time `json:"created_at"`
 }
 
@@ -49,6 +96,10 @@ func (u *User) ToDTO() UserDTO {
 		FullName:             u.FullName,
 		TigerBeetleAccountID: u.TigerBeetleAccountID,
 		AccountNumber:        u.AccountNumber,
+		AccountType:          u.AccountType,
+		Role:                 u.Role,
+		MFAEnabled:           u.MFAEnabled,
+		EmailVerified:        u.IsEmailVerified(),
 		CreatedAt:            u.CreatedAt,
 	}
 }
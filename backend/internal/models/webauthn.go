@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential is a passkey (WebAuthn public key credential) registered by a user
+// A user can register several authenticators; each one is identified by its credential ID
+type WebAuthnCredential struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User   *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	Name   string    `gorm:"type:varchar(100);not null" json:"name"`
+
+	CredentialID    []byte `gorm:"type:bytea;not null;uniqueIndex" json:"-"`
+	PublicKey       []byte `gorm:"type:bytea;not null" json:"-"` // COSE encoded
+	AttestationType string `gorm:"type:varchar(32)" json:"attestation_type"`
+	Transports      string `gorm:"type:varchar(100)" json:"transports"` // Comma separated
+	AAGUID          []byte `gorm:"type:bytea" json:"-"`
+
+	// Authenticator data flags (raw byte) and signature counter, checked on every assertion
+	Flags        uint8 `gorm:"not null;default:0" json:"-"`
+	SignCount    int64 `gorm:"not null;default:0" json:"sign_count"`
+	CloneWarning bool  `gorm:"not null;default:false" json:"clone_warning"`
+
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// TableName specifies the table name for the WebAuthnCredential model
+func (WebAuthnCredential) TableName() string {
+	return "webauthn_credentials"
+}
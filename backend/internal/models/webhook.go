@@ -0,0 +1,134 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Webhook event types
+const (
+	WebhookEventDepositCompleted    = "deposit.completed"
+	WebhookEventWithdrawalCompleted = "withdrawal.completed"
+	WebhookEventTransferCompleted   = "transfer.completed"
+	WebhookEventTransactionFailed   = "transaction.failed"
+
+	// Reserved for account status changes; nothing raises them yet
+	WebhookEventAccountFrozen   = "account.frozen"
+	WebhookEventAccountUnfrozen = "account.unfrozen"
+)
+
+// WebhookEventTypes lists every event a subscription can receive
+var WebhookEventTypes = []string{
+	WebhookEventDepositCompleted,
+	WebhookEventWithdrawalCompleted,
+	WebhookEventTransferCompleted,
+	WebhookEventTransactionFailed,
+	WebhookEventAccountFrozen,
+	WebhookEventAccountUnfrozen,
+}
+
+// WebhookSubscription is an endpoint that receives a user's events
+// Subscriptions created with an API key belong to that key (ClientID) and stop
+// receiving events when the key is revoked
+type WebhookSubscription struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+
+	UserID   uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	User     *User     `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	ClientID string    `gorm:"type:varchar(32);index" json:"client_id,omitempty"`
+
+	URL         string `gorm:"type:text;not null" json:"url"`
+	EventTypes  string `gorm:"type:text;not null" json:"-"` // Space separated
+	Description string `gorm:"type:varchar(255)" json:"description,omitempty"`
+	Secret      string `gorm:"type:text;not null" json:"-"` // HMAC key, encrypted at rest
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the WebhookSubscription model
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscriptions"
+}
+
+// EventTypeList returns the subscribed event types
+func (s *WebhookSubscription) EventTypeList() []string {
+	return strings.Fields(s.EventTypes)
+}
+
+// WebhookSubscriptionDTO is the API representation of a subscription
+type WebhookSubscriptionDTO struct {
+	ID          uuid.UUID `json:"id"`
+	ClientID    string    `json:"client_id,omitempty"`
+	URL         string    `json:"url"`
+	EventTypes  []string  `json:"event_types"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// ToDTO converts a subscription to its API representation
+func (s *WebhookSubscription) ToDTO() WebhookSubscriptionDTO {
+	return WebhookSubscriptionDTO{
+		ID:          s.ID,
+		ClientID:    s.ClientID,
+		URL:         s.URL,
+		EventTypes:  s.EventTypeList(),
+		Description: s.Description,
+		CreatedAt:   s.CreatedAt,
+	}
+}
+
+// WebhookEvent is a durable record of something that happened to a user's account
+// Deliveries are created for every matching subscription in the same transaction,
+// so an event is never lost between being recorded and being delivered
+type WebhookEvent struct {
+	ID        uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Type      string         `gorm:"type:varchar(50);not null;index" json:"type"`
+	UserID    uuid.UUID      `gorm:"type:uuid;not null;index" json:"user_id"`
+	Data      datatypes.JSON `gorm:"type:jsonb;not null" json:"data"`
+	CreatedAt time.Time      `gorm:"not null;index" json:"created_at"`
+}
+
+// TableName specifies the table name for the WebhookEvent model
+func (WebhookEvent) TableName() string {
+	return "webhook_events"
+}
+
+// WebhookDeliveryStatus is the state of one event delivery to one subscription
+type WebhookDeliveryStatus string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryStatus = "pending"   // Waiting for its next attempt
+	WebhookDeliverySucceeded WebhookDeliveryStatus = "succeeded" // The endpoint answered 2xx
+	WebhookDeliveryDead      WebhookDeliveryStatus = "dead"      // Out of attempts; kept for manual replay
+)
+
+// WebhookDelivery tracks the attempts to deliver an event to a subscription
+// Pending deliveries are claimed by the dispatcher with a lease (NextAttemptAt), so a
+// delivery interrupted by a crash or restart is simply attempted again
+type WebhookDelivery struct {
+	ID uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+
+	EventID        uuid.UUID            `gorm:"type:uuid;not null;index" json:"event_id"`
+	Event          *WebhookEvent        `gorm:"foreignKey:EventID;constraint:OnDelete:CASCADE" json:"event,omitempty"`
+	SubscriptionID uuid.UUID            `gorm:"type:uuid;not null;index" json:"subscription_id"`
+	Subscription   *WebhookSubscription `gorm:"foreignKey:SubscriptionID;constraint:OnDelete:CASCADE" json:"-"`
+
+	Status         WebhookDeliveryStatus `gorm:"type:varchar(10);not null;default:'pending';check:status IN ('pending','succeeded','dead');index:idx_webhook_deliveries_due,priority:1" json:"status"`
+	Attempts       int                   `gorm:"not null;default:0" json:"attempts"`
+	NextAttemptAt  time.Time             `gorm:"not null;index:idx_webhook_deliveries_due,priority:2" json:"next_attempt_at"`
+	LastStatusCode int                   `json:"last_status_code,omitempty"`
+	LastError      string                `gorm:"type:text" json:"last_error,omitempty"`
+	DeliveredAt    *time.Time            `json:"delivered_at,omitempty"`
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName specifies the table name for the WebhookDelivery model
+func (WebhookDelivery) TableName() string {
+	return "webhook_deliveries"
+}
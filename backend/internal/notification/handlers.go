@@ -0,0 +1,169 @@
+package notification
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// Handler handles HTTP requests for the notification center
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new notification handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// UpdatePreferencesRequest represents a request to change notification preferences
+// Types that are not listed keep their current channels
+type UpdatePreferencesRequest struct {
+	PhoneNumber *string            `json:"phone_number"`
+	Preferences []PreferenceUpdate `json:"preferences" binding:"dive"`
+}
+
+// List returns the current user's notifications, newest first
+// GET /api/notifications?unread=true&limit=50
+func (h *Handler) List(c *gin.Context) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+	limit = min(limit, 200)
+
+	notifications, unread, err := h.service.List(userID, c.Query("unread") == "true", limit)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to list notifications", "user_id", userID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve notifications")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"notifications": notifications,
+		"count":         len(notifications),
+		"unread_count":  unread,
+	}, "Notifications retrieved successfully")
+}
+
+// MarkRead marks a notification as read
+// POST /api/notifications/:id/read
+func (h *Handler) MarkRead(c *gin.Context) {
+	h.markRead(c, true)
+}
+
+// MarkUnread marks a notification as unread again
+// POST /api/notifications/:id/unread
+func (h *Handler) MarkUnread(c *gin.Context) {
+	h.markRead(c, false)
+}
+
+// MarkAllRead marks every notification of the current user as read
+// POST /api/notifications/read-all
+func (h *Handler) MarkAllRead(c *gin.Context) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	updated, err := h.service.MarkAllRead(userID)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to mark notifications read", "user_id", userID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to update notifications")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{"updated": updated}, "All notifications marked as read")
+}
+
+// GetPreferences returns the channels chosen for every notification type
+// GET /api/notifications/preferences
+func (h *Handler) GetPreferences(c *gin.Context) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	settings, err := h.service.Settings(userID)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to retrieve notification preferences", "user_id", userID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve notification preferences")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, settings, "Notification preferences retrieved successfully")
+}
+
+// UpdatePreferences changes the phone number and the channels of the listed notification types
+// PUT /api/notifications/preferences
+func (h *Handler) UpdatePreferences(c *gin.Context) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	var req UpdatePreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	settings, err := h.service.UpdateSettings(userID, req.PhoneNumber, req.Preferences)
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case strings.HasPrefix(errMsg, "invalid "), strings.HasSuffix(errMsg, "are not available"),
+			strings.HasPrefix(errMsg, "a phone number is required"):
+			utils.RespondWithError(c, http.StatusBadRequest, errMsg)
+		default:
+			logger.ErrorContext(c.Request.Context(), "failed to update notification preferences", "user_id", userID, "error", err)
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to update notification preferences")
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, settings, "Notification preferences updated")
+}
+
+// markRead sets the read state of one of the current user's notifications
+func (h *Handler) markRead(c *gin.Context, read bool) {
+	userID, ok := currentUser(c)
+	if !ok {
+		return
+	}
+
+	notification, err := h.service.MarkRead(userID, c.Param("id"), read)
+	if err != nil {
+		if err.Error() == "notification not found" {
+			utils.RespondWithError(c, http.StatusNotFound, "Notification not found")
+			return
+		}
+		logger.ErrorContext(c.Request.Context(), "failed to update notification", "notification_id", c.Param("id"), "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to update notification")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, notification, "Notification updated")
+}
+
+// currentUser returns the authenticated user's ID, answering the request if there is none
+// The auth middleware's helpers can't be used here: auth raises notifications, so this
+// package must not depend on it
+func currentUser(c *gin.Context) (string, bool) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User not authenticated")
+		return "", false
+	}
+	return userID, true
+}
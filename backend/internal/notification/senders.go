@@ -0,0 +1,63 @@
+package notification
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hlabs/banking-system/internal/mailer"
+	"github.com/hlabs/banking-system/internal/models"
+)
+
+// Sender delivers a notification outside the app on one channel (email, SMS)
+type Sender interface {
+	Send(user *models.User, n *models.Notification) error
+}
+
+// EmailSender sends notifications through the mailer
+type EmailSender struct {
+	mailer  mailer.Mailer
+	baseURL string
+}
+
+// NewEmailSender creates a sender that emails notifications; baseURL links back to the app
+func NewEmailSender(m mailer.Mailer, baseURL string) *EmailSender {
+	return &EmailSender{
+		mailer:  m,
+		baseURL: baseURL,
+	}
+}
+
+// Send emails the notification to the user's address
+func (s *EmailSender) Send(user *models.User, n *models.Notification) error {
+	return s.mailer.Send(mailer.Message{
+		To:      user.Email,
+		Subject: n.Title,
+		Body: fmt.Sprintf("Hi %s,\n\n%s\n\nYou can choose which notifications you receive by email at %s.\n",
+			user.FullName, n.Body, s.baseURL),
+	})
+}
+
+// LogSMSSender is a development stub that prints text messages to the server log
+type LogSMSSender struct{}
+
+// Send logs the text message that would be sent to the user's phone
+func (LogSMSSender) Send(user *models.User, n *models.Notification) error {
+	if user.PhoneNumber == "" {
+		return fmt.Errorf("no phone number on file")
+	}
+	logger.Info("SMS sent", "phone", user.PhoneNumber, "title", n.Title, "body", n.Body)
+	return nil
+}
+
+// NewSMSSender creates the SMS sender selected by driver
+// "log" prints messages to the server log; "none" (the default) disables the SMS channel
+func NewSMSSender(driver string) (Sender, error) {
+	switch strings.ToLower(driver) {
+	case "log":
+		return LogSMSSender{}, nil
+	case "", "none":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown SMS driver %q", driver)
+	}
+}
@@ -0,0 +1,544 @@
+package notification
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/logging"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/realtime"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var logger = logging.For("notification")
+
+// phoneNumberPattern matches international (E.164) phone numbers
+var phoneNumberPattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+// Thresholds are the default amounts (cents) of the threshold-based notifications
+type Thresholds struct {
+	LargeWithdrawal int64 // Withdrawals of at least this amount are notified
+	LowBalance      int64 // A balance falling below this amount is notified
+}
+
+// PreferenceUpdate changes the channels (and threshold) of one notification type; nil fields are kept
+type PreferenceUpdate struct {
+	Type      string `json:"type" binding:"required"`
+	InApp     *bool  `json:"in_app"`
+	Email     *bool  `json:"email"`
+	SMS       *bool  `json:"sms"`
+	Threshold *int64 `json:"threshold"` // Cents; large_withdrawal and low_balance only
+}
+
+// Settings are a user's effective notification preferences
+type Settings struct {
+	PhoneNumber string                          `json:"phone_number"`
+	Preferences []models.NotificationPreference `json:"preferences"` // One per type, defaults filled in
+	Channels    []string                        `json:"channels"`    // Channels available on this server
+}
+
+// Service records in-app notifications and sends them by email and SMS according to each
+// user's preferences
+type Service struct {
+	db         *gorm.DB
+	senders    map[string]Sender
+	thresholds Thresholds
+	events     realtime.Broker
+}
+
+// NewService creates a new notification service
+// senders maps the email and sms channels to their sender; a channel without a sender is
+// unavailable. New in-app notifications are pushed to the user's open event streams (events may be nil)
+func NewService(db *gorm.DB, senders map[string]Sender, thresholds Thresholds, events realtime.Broker) *Service {
+	return &Service{
+		db:         db,
+		senders:    senders,
+		thresholds: thresholds,
+		events:     events,
+	}
+}
+
+// IncomingTransfer notifies a user of money received from another user
+func (s *Service) IncomingTransfer(userID uuid.UUID, amount int64, from string, transactionID uuid.UUID) {
+	if s == nil {
+		return
+	}
+	pref, ok := s.preference(userID, models.NotificationIncomingTransfer)
+	if !ok {
+		return
+	}
+	s.deliver(pref,
+		fmt.Sprintf("You received %s", formatAmount(amount)),
+		fmt.Sprintf("%s sent you %s.", from, formatAmount(amount)),
+		map[string]interface{}{"transaction_id": transactionID, "amount": amount})
+}
+
+// Withdrawal notifies a user of a withdrawal at or above their large withdrawal threshold
+func (s *Service) Withdrawal(userID uuid.UUID, amount int64, transactionID uuid.UUID) {
+	if s == nil {
+		return
+	}
+	pref, ok := s.preference(userID, models.NotificationLargeWithdrawal)
+	if !ok || amount < *pref.Threshold {
+		return
+	}
+	s.deliver(pref,
+		fmt.Sprintf("Large withdrawal of %s", formatAmount(amount)),
+		fmt.Sprintf("A withdrawal of %s was made from your account. If you didn't make it, contact support right away.", formatAmount(amount)),
+		map[string]interface{}{"transaction_id": transactionID, "amount": amount})
+}
+
+// BalanceChanged notifies a user whose balance fell below their low balance threshold
+// Only crossing the threshold is notified, not every payment made while below it
+func (s *Service) BalanceChanged(userID uuid.UUID, before, after int64) {
+	if s == nil || after >= before {
+		return
+	}
+	pref, ok := s.preference(userID, models.NotificationLowBalance)
+	if !ok || before < *pref.Threshold || after >= *pref.Threshold {
+		return
+	}
+	s.deliver(pref,
+		"Low balance",
+		fmt.Sprintf("Your balance is %s, below your alert threshold of %s.", formatAmount(after), formatAmount(*pref.Threshold)),
+		map[string]interface{}{"balance": after, "threshold": *pref.Threshold})
+}
+
+// APIClientPaymentFailed notifies a user that a payment an API client made on their behalf was rejected
+// The user isn't there when an integration makes the payment, so the failure would otherwise go unnoticed
+func (s *Service) APIClientPaymentFailed(userID uuid.UUID, amount int64, reason string) {
+	if s == nil {
+		return
+	}
+	pref, ok := s.preference(userID, models.NotificationAPIClientPaymentFailed)
+	if !ok {
+		return
+	}
+	s.deliver(pref,
+		"Payment by a connected app failed",
+		fmt.Sprintf("A payment of %s made on your behalf by a connected app could not be made: %s.", formatAmount(amount), reason),
+		map[string]interface{}{"amount": amount, "reason": reason})
+}
+
+// Reversal notifies one party of a posted reversal
+// credited is true for the party the money went back to, false for the one it was taken from
+func (s *Service) Reversal(userID uuid.UUID, amount int64, credited bool, reversalID, originalID uuid.UUID) {
+	if s == nil {
+		return
+	}
+	pref, ok := s.preference(userID, models.NotificationReversal)
+	if !ok {
+		return
+	}
+	title := fmt.Sprintf("Refund of %s received", formatAmount(amount))
+	body := fmt.Sprintf("%s was returned to your account for an earlier transaction.", formatAmount(amount))
+	if !credited {
+		title = fmt.Sprintf("Payment of %s reversed", formatAmount(amount))
+		body = fmt.Sprintf("%s was taken back from your account to reverse an earlier transaction.", formatAmount(amount))
+	}
+	s.deliver(pref, title, body,
+		map[string]interface{}{"transaction_id": reversalID, "reversal_of": originalID, "amount": amount})
+}
+
+// Dispute notifies a customer that their dispute was opened or resolved
+func (s *Service) Dispute(dispute *models.Dispute) {
+	if s == nil {
+		return
+	}
+
+	amount := formatAmount(dispute.Amount)
+	var title, body string
+	switch dispute.Status {
+	case models.DisputeStatusOpened:
+		title = "Dispute opened"
+		body = fmt.Sprintf("We're looking into your dispute of %s. The amount is on hold until the case is resolved.", amount)
+		if dispute.ProvisionalCredit {
+			body = fmt.Sprintf("We're looking into your dispute of %s and have credited it to your account while we review it.", amount)
+		}
+	case models.DisputeStatusWon:
+		title = "Dispute resolved in your favor"
+		body = fmt.Sprintf("%s was refunded to your account.", amount)
+		if dispute.ProvisionalCredit {
+			body = fmt.Sprintf("The provisional credit of %s is now final.", amount)
+		}
+	case models.DisputeStatusLost:
+		title = "Dispute closed"
+		body = fmt.Sprintf("We could not uphold your dispute of %s: %s", amount, dispute.ResolutionNote)
+		if dispute.ProvisionalCredit {
+			body += " The provisional credit was taken back."
+		}
+	default:
+		return
+	}
+
+	pref, ok := s.preference(dispute.UserID, models.NotificationDispute)
+	if !ok {
+		return
+	}
+	s.deliver(pref, title, body, map[string]interface{}{
+		"dispute_id":     dispute.ID,
+		"transaction_id": dispute.TransactionID,
+		"amount":         dispute.Amount,
+		"status":         dispute.Status,
+	})
+}
+
+// Security notifies a user of a security-relevant change to their account
+// Security notifications can't be turned off: they are always shown in the app and emailed,
+// even when the user's preferences can't be loaded
+func (s *Service) Security(userID uuid.UUID, title, body string) {
+	if s == nil {
+		return
+	}
+	pref, ok := s.preference(userID, models.NotificationSecurity)
+	if !ok {
+		pref = s.effective(defaultPreference(userID, models.NotificationSecurity))
+	}
+	s.deliver(pref, title, body, nil)
+}
+
+// List returns a user's most recent notifications (only unread ones if unreadOnly) and the unread count
+func (s *Service) List(userID string, unreadOnly bool, limit int) ([]models.Notification, int64, error) {
+	query := s.db.Where("user_id = ?", userID)
+	if unreadOnly {
+		query = query.Where("read_at IS NULL")
+	}
+
+	var notifications []models.Notification
+	if err := query.Order("created_at DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	var unread int64
+	if err := s.db.Model(&models.Notification{}).Where("user_id = ? AND read_at IS NULL", userID).Count(&unread).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return notifications, unread, nil
+}
+
+// MarkRead marks one of a user's notifications as read, or as unread again
+func (s *Service) MarkRead(userID, notificationID string, read bool) (*models.Notification, error) {
+	if _, err := uuid.Parse(notificationID); err != nil {
+		return nil, fmt.Errorf("notification not found")
+	}
+
+	var notification models.Notification
+	if err := s.db.Where("id = ? AND user_id = ?", notificationID, userID).First(&notification).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("notification not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	switch {
+	case read && notification.ReadAt == nil:
+		now := time.Now()
+		notification.ReadAt = &now
+	case !read && notification.ReadAt != nil:
+		notification.ReadAt = nil
+	default:
+		return &notification, nil
+	}
+
+	if err := s.db.Model(&notification).Update("read_at", notification.ReadAt).Error; err != nil {
+		return nil, fmt.Errorf("failed to update notification: %w", err)
+	}
+	return &notification, nil
+}
+
+// MarkAllRead marks every unread notification of a user as read and returns how many there were
+func (s *Service) MarkAllRead(userID string) (int64, error) {
+	result := s.db.Model(&models.Notification{}).
+		Where("user_id = ? AND read_at IS NULL", userID).
+		Update("read_at", time.Now())
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to update notifications: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// Settings returns a user's phone number and the effective preferences of every notification type
+func (s *Service) Settings(userID string) (*Settings, error) {
+	var user models.User
+	if err := s.db.First(&user, "id = ?", userID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("user not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+
+	stored, err := s.storedPreferences(s.db, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	preferences := make([]models.NotificationPreference, 0, len(models.NotificationTypes))
+	for _, notificationType := range models.NotificationTypes {
+		pref, ok := stored[notificationType]
+		if !ok {
+			pref = defaultPreference(user.ID, notificationType)
+		}
+		preferences = append(preferences, s.effective(pref))
+	}
+
+	return &Settings{
+		PhoneNumber: user.PhoneNumber,
+		Preferences: preferences,
+		Channels:    s.Channels(),
+	}, nil
+}
+
+// UpdateSettings changes a user's phone number (when phoneNumber is not nil; "" removes it)
+// and the preferences of the listed notification types
+func (s *Service) UpdateSettings(userID string, phoneNumber *string, updates []PreferenceUpdate) (*Settings, error) {
+	if phoneNumber != nil {
+		*phoneNumber = strings.ReplaceAll(strings.TrimSpace(*phoneNumber), " ", "")
+		if *phoneNumber != "" && !phoneNumberPattern.MatchString(*phoneNumber) {
+			return nil, fmt.Errorf("invalid phone number: use the international format, e.g. +14155550123")
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var user models.User
+		if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("user not found")
+			}
+			return fmt.Errorf("database error: %w", err)
+		}
+
+		if phoneNumber != nil && *phoneNumber != user.PhoneNumber {
+			if err := tx.Model(&user).Update("phone_number", *phoneNumber).Error; err != nil {
+				return fmt.Errorf("failed to update phone number: %w", err)
+			}
+			user.PhoneNumber = *phoneNumber
+		}
+
+		stored, err := s.storedPreferences(tx, user.ID)
+		if err != nil {
+			return err
+		}
+
+		for _, update := range updates {
+			pref, err := s.apply(stored, user, update)
+			if err != nil {
+				return err
+			}
+			pref.UpdatedAt = time.Now()
+			if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&pref).Error; err != nil {
+				return fmt.Errorf("failed to save notification preferences: %w", err)
+			}
+			stored[pref.Type] = pref
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Settings(userID)
+}
+
+// Channels returns the channels available on this server
+func (s *Service) Channels() []string {
+	channels := []string{models.NotificationChannelInApp}
+	for _, channel := range []string{models.NotificationChannelEmail, models.NotificationChannelSMS} {
+		if s.senders[channel] != nil {
+			channels = append(channels, channel)
+		}
+	}
+	return channels
+}
+
+// apply validates an update against the user's current preference for that type
+func (s *Service) apply(stored map[string]models.NotificationPreference, user models.User, update PreferenceUpdate) (models.NotificationPreference, error) {
+	if !slices.Contains(models.NotificationTypes, update.Type) {
+		return models.NotificationPreference{}, fmt.Errorf("invalid notification type: %s", update.Type)
+	}
+
+	pref, ok := stored[update.Type]
+	if !ok {
+		pref = defaultPreference(user.ID, update.Type)
+	}
+
+	if isMandatory(update.Type) && ((update.InApp != nil && !*update.InApp) || (update.Email != nil && !*update.Email)) {
+		return models.NotificationPreference{}, fmt.Errorf("invalid preference: %s notifications are always shown in the app and emailed", update.Type)
+	}
+
+	if update.InApp != nil {
+		pref.InApp = *update.InApp
+	}
+	if update.Email != nil {
+		pref.Email = *update.Email
+	}
+	if update.SMS != nil {
+		pref.SMS = *update.SMS
+	}
+	if update.Threshold != nil {
+		if !hasThreshold(update.Type) {
+			return models.NotificationPreference{}, fmt.Errorf("invalid threshold: %s notifications have no threshold", update.Type)
+		}
+		if *update.Threshold < 0 {
+			return models.NotificationPreference{}, fmt.Errorf("invalid threshold: must not be negative")
+		}
+		pref.Threshold = update.Threshold
+	}
+
+	// Only checked when a channel is switched on, so a channel disabled on the server later
+	// doesn't block unrelated changes
+	if update.Email != nil && *update.Email && s.senders[models.NotificationChannelEmail] == nil {
+		return models.NotificationPreference{}, fmt.Errorf("email notifications are not available")
+	}
+	if update.SMS != nil && *update.SMS {
+		if s.senders[models.NotificationChannelSMS] == nil {
+			return models.NotificationPreference{}, fmt.Errorf("sms notifications are not available")
+		}
+		if user.PhoneNumber == "" {
+			return models.NotificationPreference{}, fmt.Errorf("a phone number is required for sms notifications")
+		}
+	}
+	return pref, nil
+}
+
+// storedPreferences loads the preferences a user has saved, by type
+func (s *Service) storedPreferences(db *gorm.DB, userID uuid.UUID) (map[string]models.NotificationPreference, error) {
+	var rows []models.NotificationPreference
+	if err := db.Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to load notification preferences: %w", err)
+	}
+
+	stored := make(map[string]models.NotificationPreference, len(rows))
+	for _, row := range rows {
+		stored[row.Type] = row
+	}
+	return stored, nil
+}
+
+// preference returns a user's effective preference for one notification type
+// Failures are logged; the notification is then skipped
+func (s *Service) preference(userID uuid.UUID, notificationType string) (models.NotificationPreference, bool) {
+	var pref models.NotificationPreference
+	err := s.db.Where("user_id = ? AND type = ?", userID, notificationType).First(&pref).Error
+	switch {
+	case err == gorm.ErrRecordNotFound:
+		pref = defaultPreference(userID, notificationType)
+	case err != nil:
+		logger.Warn("failed to load notification preference", "type", notificationType, "user_id", userID, "error", err)
+		return models.NotificationPreference{}, false
+	}
+	return s.effective(pref), true
+}
+
+// effective fills in the default threshold of a preference that has none and switches on the
+// channels of mandatory types
+func (s *Service) effective(pref models.NotificationPreference) models.NotificationPreference {
+	if isMandatory(pref.Type) {
+		pref.InApp = true
+		pref.Email = true
+		pref.Mandatory = true
+	}
+	if pref.Threshold != nil {
+		return pref
+	}
+	var threshold int64
+	switch pref.Type {
+	case models.NotificationLargeWithdrawal:
+		threshold = s.thresholds.LargeWithdrawal
+	case models.NotificationLowBalance:
+		threshold = s.thresholds.LowBalance
+	default:
+		return pref
+	}
+	pref.Threshold = &threshold
+	return pref
+}
+
+// deliver records the notification in the app and sends it on the user's other channels
+// Email and SMS are sent in the background so they never hold up the operation that
+// triggered them; failures are logged
+func (s *Service) deliver(pref models.NotificationPreference, title, body string, data interface{}) {
+	notification := &models.Notification{
+		UserID: pref.UserID,
+		Type:   pref.Type,
+		Title:  title,
+		Body:   body,
+	}
+	if data != nil {
+		encoded, err := json.Marshal(data)
+		if err != nil {
+			logger.Warn("failed to encode notification", "type", pref.Type, "user_id", pref.UserID, "error", err)
+			return
+		}
+		notification.Data = encoded
+	}
+
+	if pref.InApp {
+		if err := s.db.Create(notification).Error; err != nil {
+			logger.Error("failed to record notification", "type", pref.Type, "user_id", pref.UserID, "error", err)
+		} else if s.events != nil {
+			s.events.Publish(pref.UserID, realtime.EventNotification, notification)
+		}
+	}
+
+	channels := make([]string, 0, 2)
+	if pref.Email {
+		channels = append(channels, models.NotificationChannelEmail)
+	}
+	if pref.SMS {
+		channels = append(channels, models.NotificationChannelSMS)
+	}
+	if len(channels) == 0 {
+		return
+	}
+
+	var user models.User
+	if err := s.db.First(&user, "id = ?", pref.UserID).Error; err != nil {
+		logger.Warn("failed to load user for notification", "type", pref.Type, "user_id", pref.UserID, "error", err)
+		return
+	}
+
+	go func() {
+		for _, channel := range channels {
+			sender := s.senders[channel]
+			if sender == nil {
+				continue
+			}
+			if err := sender.Send(&user, notification); err != nil {
+				logger.Warn("failed to send notification", "type", pref.Type, "user_id", user.ID, "channel", channel, "error", err)
+			}
+		}
+	}()
+}
+
+// defaultPreference is the preference of a type the user hasn't configured
+// Everything shows up in the app; everything but incoming transfers is also emailed
+func defaultPreference(userID uuid.UUID, notificationType string) models.NotificationPreference {
+	return models.NotificationPreference{
+		UserID: userID,
+		Type:   notificationType,
+		InApp:  true,
+		Email:  notificationType != models.NotificationIncomingTransfer,
+	}
+}
+
+// isMandatory reports whether a notification type can't be turned off in the app or by email
+// SMS stays optional since it needs a phone number
+func isMandatory(notificationType string) bool {
+	return notificationType == models.NotificationSecurity
+}
+
+// hasThreshold reports whether a notification type is triggered by an amount threshold
+func hasThreshold(notificationType string) bool {
+	return notificationType == models.NotificationLargeWithdrawal || notificationType == models.NotificationLowBalance
+}
+
+// formatAmount formats cents as dollars
+func formatAmount(cents int64) string {
+	return fmt.Sprintf("$%.2f", float64(cents)/100.0)
+}
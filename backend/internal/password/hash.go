@@ -0,0 +1,116 @@
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Params are the argon2id cost parameters encoded into every hash
+type Params struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams follow the OWASP recommendation for argon2id (19 MiB, 2 iterations, 1 lane)
+var DefaultParams = Params{
+	Memory:      19 * 1024,
+	Iterations:  2,
+	Parallelism: 1,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// argon2idPrefix starts every argon2id hash in PHC string format:
+// $argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+const argon2idPrefix = "$argon2id$"
+
+// Hash hashes a password with argon2id using DefaultParams
+func Hash(plain string) (string, error) {
+	return HashWithParams(plain, DefaultParams)
+}
+
+// HashWithParams hashes a password with argon2id and the given parameters
+// The algorithm version and parameters are stored with the hash, so they can change later
+func HashWithParams(plain string, p Params) (string, error) {
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key := argon2.IDKey([]byte(plain), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2idPrefix, argon2.Version, p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	), nil
+}
+
+// Verify checks a password against a stored hash (argon2id or legacy bcrypt)
+// needsRehash is true when the password matched but the hash uses an older
+// algorithm or parameters, so the caller should store a fresh Hash
+func Verify(hash, plain string) (ok bool, needsRehash bool, err error) {
+	switch {
+	case strings.HasPrefix(hash, argon2idPrefix):
+		return verifyArgon2id(hash, plain)
+	case strings.HasPrefix(hash, "$2a$"), strings.HasPrefix(hash, "$2b$"), strings.HasPrefix(hash, "$2y$"):
+		if err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(plain)); err != nil {
+			if err == bcrypt.ErrMismatchedHashAndPassword {
+				return false, false, nil
+			}
+			return false, false, fmt.Errorf("invalid bcrypt hash: %w", err)
+		}
+		return true, true, nil
+	default:
+		return false, false, fmt.Errorf("unknown password hash format")
+	}
+}
+
+// verifyArgon2id checks a password against a PHC-formatted argon2id hash
+func verifyArgon2id(hash, plain string) (bool, bool, error) {
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return false, false, fmt.Errorf("invalid argon2id hash")
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, false, fmt.Errorf("invalid argon2id hash version: %w", err)
+	}
+	if version != argon2.Version {
+		return false, false, fmt.Errorf("unsupported argon2id version %d", version)
+	}
+
+	var p Params
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return false, false, fmt.Errorf("invalid argon2id parameters: %w", err)
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, false, fmt.Errorf("invalid argon2id salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, false, fmt.Errorf("invalid argon2id key: %w", err)
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+
+	candidate := argon2.IDKey([]byte(plain), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	if subtle.ConstantTimeCompare(candidate, key) != 1 {
+		return false, false, nil
+	}
+
+	return true, p != DefaultParams, nil
+}
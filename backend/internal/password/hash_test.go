@@ -0,0 +1,88 @@
+package password
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testParams keep the tests fast; real hashes use DefaultParams
+var testParams = Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 16, KeyLength: 32}
+
+func TestHashVerify(t *testing.T) {
+	hash, err := Hash("Correct-Horse-42")
+	if err != nil {
+		t.Fatalf("Hash: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$v=19$m=19456,t=2,p=1$") {
+		t.Errorf("Hash() = %q, want a PHC string with the default parameters", hash)
+	}
+
+	ok, needsRehash, err := Verify(hash, "Correct-Horse-42")
+	if err != nil || !ok || needsRehash {
+		t.Errorf("Verify(right password) = %v, %v, %v; want true, false, nil", ok, needsRehash, err)
+	}
+
+	ok, needsRehash, err = Verify(hash, "correct-horse-42")
+	if err != nil || ok || needsRehash {
+		t.Errorf("Verify(wrong password) = %v, %v, %v; want false, false, nil", ok, needsRehash, err)
+	}
+
+	// Salts are random, so the same password never hashes the same
+	if again, _ := Hash("Correct-Horse-42"); again == hash {
+		t.Error("two hashes of the same password are equal")
+	}
+}
+
+func TestVerifyRehash(t *testing.T) {
+	legacy, err := bcrypt.GenerateFromPassword([]byte("Correct-Horse-42"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	weaker, err := HashWithParams("Correct-Horse-42", testParams)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		hash        string
+		plain       string
+		ok          bool
+		needsRehash bool
+	}{
+		{"bcrypt match is rehashed to argon2id", string(legacy), "Correct-Horse-42", true, true},
+		{"bcrypt mismatch", string(legacy), "wrong", false, false},
+		{"argon2id with old parameters is rehashed", weaker, "Correct-Horse-42", true, true},
+		{"argon2id mismatch with old parameters", weaker, "wrong", false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, needsRehash, err := Verify(tt.hash, tt.plain)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if ok != tt.ok || needsRehash != tt.needsRehash {
+				t.Errorf("Verify = %v, %v; want %v, %v", ok, needsRehash, tt.ok, tt.needsRehash)
+			}
+		})
+	}
+}
+
+func TestVerifyInvalidHash(t *testing.T) {
+	for _, hash := range []string{
+		"",
+		"plaintext",
+		"$argon2id$v=19$m=1024,t=1,p=1$c2FsdA", // Missing key
+		"$argon2id$v=16$m=1024,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5", // Unsupported version
+		"$argon2id$v=19$m=x,t=1,p=1$c2FsdHNhbHQ$a2V5a2V5",    // Bad parameters
+		"$argon2id$v=19$m=1024,t=1,p=1$!!!$a2V5a2V5",         // Bad salt
+		"$2a$04$short", // Truncated bcrypt
+	} {
+		if ok, _, err := Verify(hash, "anything"); err == nil || ok {
+			t.Errorf("Verify(%q) = %v, %v; want an error", hash, ok, err)
+		}
+	}
+}
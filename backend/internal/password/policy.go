@@ -0,0 +1,158 @@
+package password
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+//go:embed common_passwords.txt
+var builtinCommonPasswords string
+
+// Policy describes what a new password must satisfy
+type Policy struct {
+	MinLength  int // Minimum number of characters
+	MaxLength  int // Upper bound, keeps hashing cost predictable
+	MinClasses int // Minimum number of character classes (lower, upper, digit, symbol)
+
+	common map[string]struct{} // Breached/common passwords, lowercased
+}
+
+// PolicyError lists every rule a password broke
+type PolicyError struct {
+	Violations []string
+}
+
+// Error implements the error interface
+func (e *PolicyError) Error() string {
+	return "password does not meet the policy: " + strings.Join(e.Violations, "; ")
+}
+
+// NewPolicy creates a password policy with the built-in common password list,
+// extended with the entries of blocklistPath when set (one password per line, # comments)
+func NewPolicy(minLength, minClasses int, blocklistPath string) (*Policy, error) {
+	p := &Policy{
+		MinLength:  minLength,
+		MaxLength:  128,
+		MinClasses: minClasses,
+		common:     make(map[string]struct{}),
+	}
+
+	if err := p.loadCommon(strings.NewReader(builtinCommonPasswords)); err != nil {
+		return nil, err
+	}
+
+	if blocklistPath != "" {
+		f, err := os.Open(blocklistPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open password blocklist: %w", err)
+		}
+		defer f.Close()
+		if err := p.loadCommon(f); err != nil {
+			return nil, err
+		}
+	}
+
+	return p, nil
+}
+
+// loadCommon adds the passwords read from r to the blocklist
+func (p *Policy) loadCommon(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.common[strings.ToLower(line)] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read password blocklist: %w", err)
+	}
+	return nil
+}
+
+// CommonPasswords returns the size of the blocklist
+func (p *Policy) CommonPasswords() int {
+	return len(p.common)
+}
+
+// Validate checks a new password for the given user
+// It may not contain the email (or its local part) or any part of the user's name
+func (p *Policy) Validate(plain, email, fullName string) error {
+	var violations []string
+
+	length := utf8.RuneCountInString(plain)
+	if length < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters", p.MinLength))
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d characters", p.MaxLength))
+	}
+
+	if classes := characterClasses(plain); classes < p.MinClasses {
+		violations = append(violations, fmt.Sprintf("must mix at least %d of: lowercase, uppercase, digits, symbols", p.MinClasses))
+	}
+
+	lower := strings.ToLower(plain)
+	if _, ok := p.common[lower]; ok {
+		violations = append(violations, "is too common")
+	}
+
+	if containsPersonalInfo(lower, email, fullName) {
+		violations = append(violations, "must not contain your email or name")
+	}
+
+	if len(violations) > 0 {
+		return &PolicyError{Violations: violations}
+	}
+	return nil
+}
+
+// characterClasses counts the character classes used in a password
+func characterClasses(plain string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range plain {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+// containsPersonalInfo reports whether the lowercased password contains the email,
+// its local part or a part of the name (parts shorter than 3 characters are ignored)
+func containsPersonalInfo(lower, email, fullName string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	candidates := []string{email}
+	if local, _, found := strings.Cut(email, "@"); found {
+		candidates = append(candidates, local)
+	}
+	candidates = append(candidates, strings.Fields(strings.ToLower(fullName))...)
+
+	for _, candidate := range candidates {
+		if utf8.RuneCountInString(candidate) >= 3 && strings.Contains(lower, candidate) {
+			return true
+		}
+	}
+	return false
+}
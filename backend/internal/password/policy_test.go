@@ -0,0 +1,108 @@
+package password
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCharacterClasses(t *testing.T) {
+	tests := []struct {
+		plain   string
+		classes int
+	}{
+		{"", 0},
+		{"lowercase", 1},
+		{"UPPERCASE", 1},
+		{"12345678", 1},
+		{"lower UPPER", 3}, // The space counts as a symbol
+		{"Lower123", 3},
+		{"Lower123!", 4},
+		{"ÉtéÀ", 2}, // Unicode letters count as lower and upper case
+	}
+
+	for _, tt := range tests {
+		if got := characterClasses(tt.plain); got != tt.classes {
+			t.Errorf("characterClasses(%q) = %d, want %d", tt.plain, got, tt.classes)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	policy, err := NewPolicy(12, 3, "")
+	if err != nil {
+		t.Fatalf("NewPolicy: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		plain      string
+		violations int
+	}{
+		{"strong password", "Correct-Horse-42", 0},
+		{"too short", "Ab1!", 1},
+		{"too long", "Aa1!" + strings.Repeat("x", 125), 1},
+		{"too few classes", "correcthorsebattery", 1},
+		{"common password ignoring case", "SecurePassword123!", 1},
+		{"contains the email local part", "Jdoe-Secure-42", 1},
+		{"contains a name part", "Smithson-Vault-42", 1},
+		{"short name parts are ignored", "Al-Vault-Secure-42", 0},
+		{"short, one class and common", "password", 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := policy.Validate(tt.plain, "jdoe@example.com", "Al Smith")
+			if tt.violations == 0 {
+				if err != nil {
+					t.Fatalf("Validate(%q) = %v, want nil", tt.plain, err)
+				}
+				return
+			}
+
+			var policyErr *PolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("Validate(%q) = %v, want a PolicyError", tt.plain, err)
+			}
+			if len(policyErr.Violations) != tt.violations {
+				t.Errorf("Validate(%q) violations = %q, want %d", tt.plain, policyErr.Violations, tt.violations)
+			}
+		})
+	}
+}
+
+func TestBlocklistFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	if err := os.WriteFile(path, []byte("# Company terms\n\nHLabs-Banking-2024\n  acme-Secure-1  \n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	builtin, err := NewPolicy(8, 1, "")
+	if err != nil {
+		t.Fatalf("NewPolicy: %v", err)
+	}
+	policy, err := NewPolicy(8, 1, path)
+	if err != nil {
+		t.Fatalf("NewPolicy with blocklist: %v", err)
+	}
+
+	// Comments and blank lines are skipped, entries are trimmed
+	if got, want := policy.CommonPasswords(), builtin.CommonPasswords()+2; got != want {
+		t.Errorf("CommonPasswords() = %d, want %d", got, want)
+	}
+
+	for _, plain := range []string{"hlabs-banking-2024", "ACME-SECURE-1"} {
+		if err := policy.Validate(plain, "", ""); err == nil {
+			t.Errorf("Validate(%q) = nil, want the blocklist to reject it", plain)
+		}
+	}
+	if err := builtin.Validate("hlabs-banking-2024", "", ""); err != nil {
+		t.Errorf("built-in policy rejected a password only on the extra list: %v", err)
+	}
+
+	if _, err := NewPolicy(8, 1, filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("NewPolicy with a missing blocklist file = nil error")
+	}
+}
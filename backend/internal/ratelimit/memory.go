@@ -0,0 +1,92 @@
+package ratelimit
+
+import (
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets and expired counters are dropped from memory
+const sweepInterval = time.Minute
+
+// MemoryStore keeps rate limit state in process memory
+// Limits apply per API instance and are lost on restart
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	counters  map[string]*memoryCounter
+	lastSweep time.Time
+}
+
+type memoryBucket struct {
+	tokens    float64
+	updatedAt time.Time
+	fullAt    time.Time // When the bucket will have refilled completely
+}
+
+type memoryCounter struct {
+	count     int64
+	expiresAt time.Time
+}
+
+// NewMemoryStore creates an empty in-memory store
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets:   make(map[string]*memoryBucket),
+		counters:  make(map[string]*memoryCounter),
+		lastSweep: time.Now(),
+	}
+}
+
+// Take implements Store
+func (s *MemoryStore) Take(key string, policy Policy, now time.Time) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	bucket, ok := s.buckets[key]
+	if !ok {
+		bucket = &memoryBucket{tokens: float64(policy.Limit), updatedAt: now}
+		s.buckets[key] = bucket
+	}
+
+	tokens, result := take(bucket.tokens, bucket.updatedAt, policy, now)
+	bucket.tokens = tokens
+	bucket.updatedAt = now
+	bucket.fullAt = now.Add(result.Reset)
+	return result, nil
+}
+
+// Increment implements Store
+func (s *MemoryStore) Increment(key string, expiresAt, now time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(now)
+
+	counter, ok := s.counters[key]
+	if !ok || !now.Before(counter.expiresAt) {
+		counter = &memoryCounter{expiresAt: expiresAt}
+		s.counters[key] = counter
+	}
+	counter.count++
+	return counter.count, nil
+}
+
+// sweep drops full buckets and expired counters; they behave exactly like missing ones
+// Must be called with the lock held
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < sweepInterval {
+		return
+	}
+	s.lastSweep = now
+
+	for key, bucket := range s.buckets {
+		if !now.Before(bucket.fullAt) {
+			delete(s.buckets, key)
+		}
+	}
+	for key, counter := range s.counters {
+		if !now.Before(counter.expiresAt) {
+			delete(s.counters, key)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hlabs/banking-system/internal/logging"
+	"github.com/hlabs/banking-system/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// purgeInterval is how often stale buckets and expired counters are deleted
+	purgeInterval = 10 * time.Minute
+
+	// bucketRetention is how long an untouched bucket is kept; every policy refills well within it
+	bucketRetention = 24 * time.Hour
+)
+
+// PostgresStore keeps rate limit state in PostgreSQL, so limits hold across API instances
+// Each bucket is updated under a row lock
+type PostgresStore struct {
+	db *gorm.DB
+}
+
+// NewPostgresStore creates a store backed by the rate_limit_buckets and usage_quotas tables
+func NewPostgresStore(db *gorm.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take implements Store
+func (s *PostgresStore) Take(key string, policy Policy, now time.Time) (Result, error) {
+	var result Result
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.RateLimitBucket{
+			Key:       key,
+			Tokens:    float64(policy.Limit),
+			UpdatedAt: now,
+		}).Error; err != nil {
+			return err
+		}
+
+		var bucket models.RateLimitBucket
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&bucket, "key = ?", key).Error; err != nil {
+			return err
+		}
+
+		var tokens float64
+		tokens, result = take(bucket.Tokens, bucket.UpdatedAt, policy, now)
+		return tx.Model(&bucket).Updates(map[string]interface{}{
+			"tokens":     tokens,
+			"updated_at": now,
+		}).Error
+	})
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to update rate limit: %w", err)
+	}
+	return result, nil
+}
+
+// Increment implements Store
+func (s *PostgresStore) Increment(key string, expiresAt, now time.Time) (int64, error) {
+	quota := models.UsageQuota{Key: key, Count: 1, ExpiresAt: expiresAt}
+	err := s.db.Clauses(
+		clause.OnConflict{
+			Columns: []clause.Column{{Name: "key"}},
+			DoUpdates: clause.Set{
+				{Column: clause.Column{Name: "count"}, Value: gorm.Expr("CASE WHEN usage_quotas.expires_at <= ? THEN 1 ELSE usage_quotas.count + 1 END", now)},
+				{Column: clause.Column{Name: "expires_at"}, Value: gorm.Expr("CASE WHEN usage_quotas.expires_at <= ? THEN excluded.expires_at ELSE usage_quotas.expires_at END", now)},
+			},
+		},
+		clause.Returning{Columns: []clause.Column{{Name: "count"}}},
+	).Create(&quota).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to update quota: %w", err)
+	}
+	return quota.Count, nil
+}
+
+// PurgeExpired deletes buckets untouched for a day and counters whose window has ended
+func (s *PostgresStore) PurgeExpired() error {
+	now := time.Now()
+	if err := s.db.Where("updated_at < ?", now.Add(-bucketRetention)).Delete(&models.RateLimitBucket{}).Error; err != nil {
+		return fmt.Errorf("failed to purge rate limit buckets: %w", err)
+	}
+	if err := s.db.Where("expires_at < ?", now).Delete(&models.UsageQuota{}).Error; err != nil {
+		return fmt.Errorf("failed to purge quotas: %w", err)
+	}
+	return nil
+}
+
+// RunCleanup purges stale state periodically until the context is cancelled
+func (s *PostgresStore) RunCleanup(ctx context.Context) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := s.PurgeExpired(); err != nil {
+				logging.For("ratelimit").Warn("rate limit cleanup failed", "error", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is a token bucket: it holds up to Limit tokens and refills Limit tokens every Period
+// Each request takes one token, so Limit requests can burst and the sustained rate is Limit per Period
+// A zero Limit disables the policy
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+// ParsePolicy parses a policy written as "<limit>/<period>", e.g. "20/1m" or "300/1h"
+// The period may omit the count ("20/m", "5/s"); "off" or "0" disable the policy
+func ParsePolicy(name, spec string) (Policy, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" || spec == "0" || strings.EqualFold(spec, "off") {
+		return Policy{Name: name}, nil
+	}
+
+	limitPart, periodPart, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("invalid rate limit %q for %s: expected <limit>/<period>", spec, name)
+	}
+
+	limit, err := strconv.Atoi(strings.TrimSpace(limitPart))
+	if err != nil || limit < 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit %q for %s: bad limit", spec, name)
+	}
+
+	periodPart = strings.TrimSpace(periodPart)
+	if periodPart != "" && (periodPart[0] < '0' || periodPart[0] > '9') {
+		periodPart = "1" + periodPart
+	}
+	period, err := time.ParseDuration(periodPart)
+	if err != nil || period <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate limit %q for %s: bad period", spec, name)
+	}
+
+	return Policy{Name: name, Limit: limit, Period: period}, nil
+}
+
+// Enabled reports whether the policy limits anything
+func (p Policy) Enabled() bool {
+	return p.Limit > 0 && p.Period > 0
+}
+
+// String formats the policy like ParsePolicy expects it
+func (p Policy) String() string {
+	if !p.Enabled() {
+		return "off"
+	}
+	return fmt.Sprintf("%d/%s", p.Limit, p.Period)
+}
+
+// rate returns the refill rate in tokens per second
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// Result is the outcome of taking a token
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int           // Whole tokens left after this request
+	RetryAfter time.Duration // Until the next token is available (zero when allowed)
+	Reset      time.Duration // Until the bucket is full again
+}
+
+// Store keeps rate limit state
+// Implementations must be safe for concurrent use; a shared store (PostgreSQL or Redis)
+// enforces limits across all API instances, the in-memory store only per process
+type Store interface {
+	// Take removes one token from the bucket at key
+	Take(key string, policy Policy, now time.Time) (Result, error)
+
+	// Increment adds one to the counter at key and returns the new count
+	// The counter starts over once expiresAt has passed
+	Increment(key string, expiresAt, now time.Time) (int64, error)
+}
+
+// take refills a bucket holding tokens (last updated at updatedAt) and tries to take one token
+// It returns the new token count and the result
+func take(tokens float64, updatedAt time.Time, policy Policy, now time.Time) (float64, Result) {
+	capacity := float64(policy.Limit)
+	rate := policy.rate()
+
+	if elapsed := now.Sub(updatedAt).Seconds(); elapsed > 0 {
+		tokens = math.Min(capacity, tokens+elapsed*rate)
+	}
+
+	result := Result{Limit: policy.Limit}
+	if tokens >= 1 {
+		tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - tokens) / rate)
+	}
+	result.Remaining = int(math.Floor(tokens))
+	result.Reset = seconds((capacity - tokens) / rate)
+	return tokens, result
+}
+
+// seconds converts a number of seconds to a duration
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		spec   string
+		limit  int
+		period time.Duration
+	}{
+		{"20/1m", 20, time.Minute},
+		{"300/1h", 300, time.Hour},
+		{" 5 / s ", 5, time.Second},
+		{"20/m", 20, time.Minute},
+		{"10/30s", 10, 30 * time.Second},
+		{"off", 0, 0},
+		{"OFF", 0, 0},
+		{"0", 0, 0},
+		{"", 0, 0},
+	}
+
+	for _, tt := range tests {
+		policy, err := ParsePolicy("test", tt.spec)
+		if err != nil {
+			t.Errorf("ParsePolicy(%q): %v", tt.spec, err)
+			continue
+		}
+		if policy.Limit != tt.limit || policy.Period != tt.period {
+			t.Errorf("ParsePolicy(%q) = %d/%s, want %d/%s", tt.spec, policy.Limit, policy.Period, tt.limit, tt.period)
+		}
+		if policy.Enabled() != (tt.limit > 0) {
+			t.Errorf("ParsePolicy(%q).Enabled() = %v", tt.spec, policy.Enabled())
+		}
+	}
+
+	for _, spec := range []string{"20", "x/1m", "-1/1m", "20/", "20/0s", "20/-1m", "20/fortnight"} {
+		if _, err := ParsePolicy("test", spec); err == nil {
+			t.Errorf("ParsePolicy(%q) = nil error, want an error", spec)
+		}
+	}
+}
+
+func TestTake(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 2, Period: time.Second} // 2 tokens, 1 every 500ms
+	start := time.Unix(1700000000, 0)
+
+	tests := []struct {
+		name       string
+		tokens     float64
+		elapsed    time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		reset      time.Duration
+		left       float64
+	}{
+		{"full bucket", 2, 0, true, 1, 0, 500 * time.Millisecond, 1},
+		{"last token", 1, 0, true, 0, 0, time.Second, 0},
+		{"empty bucket waits for a whole token", 0, 0, false, 0, 500 * time.Millisecond, time.Second, 0},
+		{"partly refilled bucket waits for the rest", 0, 250 * time.Millisecond, false, 0, 250 * time.Millisecond, 750 * time.Millisecond, 0.5},
+		{"refill makes a token available", 0, 500 * time.Millisecond, true, 0, 0, time.Second, 0},
+		{"refill is capped at the limit", 0, time.Hour, true, 1, 0, 500 * time.Millisecond, 1},
+		{"clock going backwards doesn't refill", 0, -time.Second, false, 0, 500 * time.Millisecond, time.Second, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, result := take(tt.tokens, start, policy, start.Add(tt.elapsed))
+			if result.Allowed != tt.allowed || result.Remaining != tt.remaining || result.Limit != policy.Limit {
+				t.Errorf("take = %+v, want allowed %v, remaining %d, limit %d", result, tt.allowed, tt.remaining, policy.Limit)
+			}
+			if result.RetryAfter != tt.retryAfter {
+				t.Errorf("RetryAfter = %s, want %s", result.RetryAfter, tt.retryAfter)
+			}
+			if result.Reset != tt.reset {
+				t.Errorf("Reset = %s, want %s", result.Reset, tt.reset)
+			}
+			if left != tt.left {
+				t.Errorf("tokens left = %v, want %v", left, tt.left)
+			}
+		})
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	policy := Policy{Name: "test", Limit: 3, Period: 3 * time.Second}
+	now := time.Now()
+
+	// A new key starts with a full bucket and allows a burst of Limit requests
+	for i := 0; i < policy.Limit; i++ {
+		if result, _ := store.Take("user:1", policy, now); !result.Allowed {
+			t.Fatalf("request %d denied within the burst", i+1)
+		}
+	}
+	result, _ := store.Take("user:1", policy, now)
+	if result.Allowed || result.RetryAfter != time.Second {
+		t.Errorf("request over the burst = %+v, want denied with RetryAfter 1s", result)
+	}
+
+	// Buckets are per key
+	if result, _ := store.Take("user:2", policy, now); !result.Allowed {
+		t.Error("another key was limited")
+	}
+
+	// One token refills after Period/Limit
+	if result, _ := store.Take("user:1", policy, now.Add(time.Second)); !result.Allowed {
+		t.Error("request after refill denied")
+	}
+
+	// Counters count until they expire, then start over
+	expiresAt := now.Add(time.Hour)
+	for want := int64(1); want <= 2; want++ {
+		if count, _ := store.Increment("quota", expiresAt, now); count != want {
+			t.Errorf("Increment = %d, want %d", count, want)
+		}
+	}
+	if count, _ := store.Increment("quota", expiresAt.Add(time.Hour), expiresAt); count != 1 {
+		t.Errorf("Increment after expiry = %d, want 1", count)
+	}
+}
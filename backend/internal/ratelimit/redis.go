@@ -0,0 +1,112 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// redisTimeout bounds each round trip, so a slow Redis can't hold requests up
+	redisTimeout = time.Second
+
+	// redisMaxRetries bounds how often Take retries when another instance updated the bucket first
+	redisMaxRetries = 5
+)
+
+// RedisStore keeps rate limit state in Redis (or a compatible server such as Valkey),
+// so limits hold across API instances
+// Buckets are updated with optimistic transactions (WATCH/MULTI); idle keys expire on their own
+type RedisStore struct {
+	client *redis.Client
+}
+
+// NewRedisStore connects to the server at url ("redis://[:password@]host:port/db", or rediss:// for TLS)
+func NewRedisStore(url string) (*RedisStore, error) {
+	options, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Redis URL: %w", err)
+	}
+	client := redis.NewClient(options)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
+	return &RedisStore{client: client}, nil
+}
+
+// Take implements Store
+func (s *RedisStore) Take(key string, policy Policy, now time.Time) (Result, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	var result Result
+	update := func(tx *redis.Tx) error {
+		state, err := tx.HMGet(ctx, key, "tokens", "updated_at").Result()
+		if err != nil {
+			return err
+		}
+
+		tokens, updatedAt := float64(policy.Limit), now
+		if stored, ok := state[0].(string); ok {
+			if tokens, err = strconv.ParseFloat(stored, 64); err != nil {
+				return fmt.Errorf("corrupt bucket %s: %w", key, err)
+			}
+			micros, err := strconv.ParseInt(state[1].(string), 10, 64)
+			if err != nil {
+				return fmt.Errorf("corrupt bucket %s: %w", key, err)
+			}
+			updatedAt = time.UnixMicro(micros)
+		}
+
+		tokens, result = take(tokens, updatedAt, policy, now)
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.HSet(ctx, key, "tokens", strconv.FormatFloat(tokens, 'f', -1, 64), "updated_at", now.UnixMicro())
+			// A bucket untouched until it is full again is the same as no bucket
+			pipe.PExpire(ctx, key, result.Reset+time.Second)
+			return nil
+		})
+		return err
+	}
+
+	for attempt := 0; attempt < redisMaxRetries; attempt++ {
+		err := s.client.Watch(ctx, update, key)
+		if errors.Is(err, redis.TxFailedErr) {
+			continue
+		}
+		if err != nil {
+			return Result{}, fmt.Errorf("failed to update rate limit: %w", err)
+		}
+		return result, nil
+	}
+	return Result{}, fmt.Errorf("failed to update rate limit: bucket %s is contended", key)
+}
+
+// Increment implements Store
+func (s *RedisStore) Increment(key string, expiresAt, now time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+
+	var count *redis.IntCmd
+	_, err := s.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.Incr(ctx, key)
+		pipe.ExpireAt(ctx, key, expiresAt)
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to update quota: %w", err)
+	}
+	return count.Val(), nil
+}
+
+// Close closes the connection pool
+func (s *RedisStore) Close() error {
+	return s.client.Close()
+}
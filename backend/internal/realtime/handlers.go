@@ -0,0 +1,192 @@
+package realtime
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/pkg/utils"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	// heartbeatInterval keeps idle connections open through proxies
+	heartbeatInterval = 25 * time.Second
+
+	// reconnectDelay is the SSE retry hint sent to EventSource clients (milliseconds)
+	reconnectDelay = 3000
+)
+
+// BalanceReader returns a user's current balance, sent when a stream opens
+type BalanceReader interface {
+	GetBalance(ctx context.Context, userID string) (int64, error)
+}
+
+// BalanceEvent is the data of a balance event
+type BalanceEvent struct {
+	Balance  int64  `json:"balance"` // Cents
+	Currency string `json:"currency"`
+}
+
+// Handler serves the event streams
+type Handler struct {
+	broker      Broker
+	balances    BalanceReader
+	maxDuration time.Duration
+}
+
+// NewHandler creates a new event stream handler
+// Streams are closed after maxDuration (the access token lifetime), so a client keeps
+// receiving events only while it holds a valid token; it reconnects with a fresh one
+func NewHandler(broker Broker, balances BalanceReader, maxDuration time.Duration) *Handler {
+	return &Handler{
+		broker:      broker,
+		balances:    balances,
+		maxDuration: maxDuration,
+	}
+}
+
+// Stream pushes balance changes and new transactions as Server-Sent Events
+// The current balance is sent first, then an event per posted transaction
+// GET /api/events/stream
+func (h *Handler) Stream(c *gin.Context) {
+	userID, subscription, ok := h.subscribe(c)
+	if !ok {
+		return
+	}
+	defer subscription.Close()
+
+	ctx, cancel := context.WithTimeout(c.Request.Context(), h.maxDuration)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no") // Disable proxy buffering (nginx)
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", reconnectDelay)
+	if snapshot, ok := h.snapshot(c.Request.Context(), userID); ok {
+		writeSSE(c, snapshot)
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(heartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, open := <-subscription.C:
+			if !open {
+				return
+			}
+			writeSSE(c, event)
+			c.Writer.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			c.Writer.Flush()
+		}
+	}
+}
+
+// Socket pushes the same events as Stream over a WebSocket, one JSON message per event
+// Heartbeats are sent as {"type":"ping"} messages; anything the client sends is ignored
+// GET /api/events/ws
+func (h *Handler) Socket(c *gin.Context) {
+	userID, subscription, ok := h.subscribe(c)
+	if !ok {
+		return
+	}
+	defer subscription.Close()
+
+	server := websocket.Server{
+		// The connection is authenticated by the Authorization header like any other request,
+		// so the browser Origin check that protects cookie-based sockets is not needed
+		Handshake: func(*websocket.Config, *http.Request) error { return nil },
+		Handler: func(ws *websocket.Conn) {
+			ctx, cancel := context.WithTimeout(c.Request.Context(), h.maxDuration)
+			defer cancel()
+
+			// Reading detects the client going away
+			go func() {
+				defer cancel()
+				var discard string
+				for websocket.Message.Receive(ws, &discard) == nil {
+				}
+			}()
+
+			if snapshot, ok := h.snapshot(c.Request.Context(), userID); ok {
+				if websocket.JSON.Send(ws, snapshot) != nil {
+					return
+				}
+			}
+
+			heartbeat := time.NewTicker(heartbeatInterval)
+			defer heartbeat.Stop()
+
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case event, open := <-subscription.C:
+					if !open || websocket.JSON.Send(ws, event) != nil {
+						return
+					}
+				case <-heartbeat.C:
+					if websocket.JSON.Send(ws, gin.H{"type": "ping"}) != nil {
+						return
+					}
+				}
+			}
+		},
+	}
+	server.ServeHTTP(c.Writer, c.Request)
+}
+
+// subscribe opens a subscription for the authenticated user, answering the request on failure
+// The user ID is read from the context directly: auth publishes through this package (via
+// notifications), so it must not depend on the auth middleware
+func (h *Handler) subscribe(c *gin.Context) (string, *Subscription, bool) {
+	userID := c.GetString("user_id")
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User not authenticated")
+		return "", nil, false
+	}
+
+	subscription, err := h.broker.Subscribe(uid)
+	if err != nil {
+		logger.WarnContext(c.Request.Context(), "event stream refused", "user_id", userID, "error", err)
+		if err.Error() == "server is shutting down" {
+			utils.RespondWithError(c, http.StatusServiceUnavailable, "Server is shutting down, reconnect shortly")
+			return "", nil, false
+		}
+		utils.RespondWithError(c, http.StatusTooManyRequests, "Too many open event streams")
+		return "", nil, false
+	}
+	return userID, subscription, true
+}
+
+// snapshot builds the balance event sent when a stream opens
+func (h *Handler) snapshot(ctx context.Context, userID string) (Event, bool) {
+	balance, err := h.balances.GetBalance(ctx, userID)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to load balance for event stream", "user_id", userID, "error", err)
+		return Event{}, false
+	}
+	event, err := newEvent(EventBalance, BalanceEvent{Balance: balance, Currency: "USD"})
+	if err != nil {
+		return Event{}, false
+	}
+	return event, true
+}
+
+// writeSSE writes one event in the text/event-stream format
+func writeSSE(c *gin.Context, event Event) {
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event.Type, event.Data)
+}
@@ -0,0 +1,129 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+const (
+	// notifyChannel is the PostgreSQL channel events travel on between API instances
+	notifyChannel = "realtime_events"
+
+	// maxReconnectDelay caps the wait between attempts to re-establish the LISTEN connection
+	maxReconnectDelay = 30 * time.Second
+)
+
+// notification is the NOTIFY payload (PostgreSQL limits it to 8000 bytes)
+type notification struct {
+	UserID uuid.UUID `json:"user_id"`
+	Event
+}
+
+// PostgresBroker shares events between API instances with PostgreSQL LISTEN/NOTIFY
+// Every instance publishes with NOTIFY and delivers what it hears on its LISTEN connection to
+// its own streams, so a client receives events wherever the transaction was posted.
+// Events published while the LISTEN connection is down are missed; clients get a fresh
+// balance when they reconnect.
+type PostgresBroker struct {
+	db  *gorm.DB
+	dsn string
+	hub *Hub
+}
+
+// NewPostgresBroker creates a broker that notifies through db and listens on a dedicated
+// connection opened with dsn; call Run to start listening
+func NewPostgresBroker(db *gorm.DB, dsn string) *PostgresBroker {
+	return &PostgresBroker{
+		db:  db,
+		dsn: dsn,
+		hub: NewHub(),
+	}
+}
+
+// Publish implements Broker
+func (b *PostgresBroker) Publish(userID uuid.UUID, eventType string, data interface{}) {
+	event, err := newEvent(eventType, data)
+	if err != nil {
+		logger.Warn("failed to encode event", "type", eventType, "user_id", userID, "error", err)
+		return
+	}
+
+	payload, err := json.Marshal(notification{UserID: userID, Event: event})
+	if err != nil {
+		logger.Warn("failed to encode event", "type", eventType, "user_id", userID, "error", err)
+		return
+	}
+
+	if err := b.db.Exec("SELECT pg_notify(?, ?)", notifyChannel, string(payload)).Error; err != nil {
+		// Other instances miss it, but the streams open here still get the event
+		logger.Warn("failed to broadcast event", "type", eventType, "user_id", userID, "error", err)
+		b.hub.dispatch(userID, event)
+	}
+}
+
+// Subscribe implements Broker
+func (b *PostgresBroker) Subscribe(userID uuid.UUID) (*Subscription, error) {
+	return b.hub.Subscribe(userID)
+}
+
+// Close implements Broker
+func (b *PostgresBroker) Close() {
+	b.hub.Close()
+}
+
+// Connections returns the number of open streams on this instance
+func (b *PostgresBroker) Connections() int {
+	return b.hub.Connections()
+}
+
+// Run listens for events until the context is cancelled, reconnecting with backoff
+func (b *PostgresBroker) Run(ctx context.Context) {
+	delay := time.Second
+	for {
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		logger.Warn("event stream LISTEN connection lost", "retry_in", delay, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+// listen opens the LISTEN connection and dispatches notifications until it fails
+func (b *PostgresBroker) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, b.dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+	logger.Info("listening for events", "channel", notifyChannel)
+
+	for {
+		received, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var n notification
+		if err := json.Unmarshal([]byte(received.Payload), &n); err != nil {
+			logger.Warn("ignoring malformed event notification", "error", err)
+			continue
+		}
+		b.hub.dispatch(n.UserID, n.Event)
+	}
+}
@@ -0,0 +1,181 @@
+package realtime
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/logging"
+)
+
+var logger = logging.For("realtime")
+
+// Event types pushed to clients
+const (
+	EventBalance      = "balance"      // The user's new balance after a posted transaction
+	EventTransaction  = "transaction"  // A transaction involving the user was posted
+	EventNotification = "notification" // A new in-app notification
+)
+
+const (
+	// subscriptionBuffer is how many events a slow connection can lag behind before it is dropped
+	subscriptionBuffer = 32
+
+	// maxSubscriptionsPerUser bounds the open streams per user (tabs, devices)
+	maxSubscriptionsPerUser = 10
+)
+
+// Event is a message pushed to a user's open streams
+type Event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// Broker fans events out to the open streams of a user
+// Implementations must be safe for concurrent use
+type Broker interface {
+	// Publish pushes an event to every stream the user has open; failures are logged
+	Publish(userID uuid.UUID, eventType string, data interface{})
+
+	// Subscribe opens a stream for a user; the caller must Close it
+	Subscribe(userID uuid.UUID) (*Subscription, error)
+
+	// Close ends every open stream and refuses new ones, so shutdown doesn't wait on long-lived connections
+	Close()
+}
+
+// Subscription receives the events of one user
+// C is closed when the subscription is closed, or when the client falls too far behind;
+// a dropped client reconnects and starts again from a fresh balance
+type Subscription struct {
+	C <-chan Event
+
+	c      chan Event
+	userID uuid.UUID
+	hub    *Hub
+	once   sync.Once
+}
+
+// Close stops the subscription
+func (s *Subscription) Close() {
+	s.hub.remove(s)
+}
+
+// Hub is the in-process broker: it delivers events to the streams open on this API instance
+type Hub struct {
+	mu          sync.Mutex
+	subscribers map[uuid.UUID]map[*Subscription]struct{}
+	closed      bool
+}
+
+// NewHub creates an empty hub
+func NewHub() *Hub {
+	return &Hub{
+		subscribers: make(map[uuid.UUID]map[*Subscription]struct{}),
+	}
+}
+
+// Publish implements Broker
+func (h *Hub) Publish(userID uuid.UUID, eventType string, data interface{}) {
+	event, err := newEvent(eventType, data)
+	if err != nil {
+		logger.Warn("failed to encode event", "type", eventType, "user_id", userID, "error", err)
+		return
+	}
+	h.dispatch(userID, event)
+}
+
+// Subscribe implements Broker
+func (h *Hub) Subscribe(userID uuid.UUID) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.closed {
+		return nil, fmt.Errorf("server is shutting down")
+	}
+	subscriptions := h.subscribers[userID]
+	if len(subscriptions) >= maxSubscriptionsPerUser {
+		return nil, fmt.Errorf("too many open streams")
+	}
+	if subscriptions == nil {
+		subscriptions = make(map[*Subscription]struct{})
+		h.subscribers[userID] = subscriptions
+	}
+
+	c := make(chan Event, subscriptionBuffer)
+	subscription := &Subscription{C: c, c: c, userID: userID, hub: h}
+	subscriptions[subscription] = struct{}{}
+	return subscription, nil
+}
+
+// Close implements Broker
+// Clients see their stream end and reconnect, reaching another instance
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.closed = true
+	for _, subscriptions := range h.subscribers {
+		for subscription := range subscriptions {
+			h.removeLocked(subscription)
+		}
+	}
+}
+
+// Connections returns the number of open streams on this instance
+func (h *Hub) Connections() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	count := 0
+	for _, subscriptions := range h.subscribers {
+		count += len(subscriptions)
+	}
+	return count
+}
+
+// dispatch hands an event to the user's local subscriptions without blocking
+func (h *Hub) dispatch(userID uuid.UUID, event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for subscription := range h.subscribers[userID] {
+		select {
+		case subscription.c <- event:
+		default:
+			logger.Warn("dropping slow event stream", "user_id", userID)
+			h.removeLocked(subscription)
+		}
+	}
+}
+
+// remove closes a subscription and forgets it
+func (h *Hub) remove(subscription *Subscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(subscription)
+}
+
+// removeLocked closes a subscription and forgets it
+// Must be called with the lock held
+func (h *Hub) removeLocked(subscription *Subscription) {
+	subscription.once.Do(func() {
+		close(subscription.c)
+	})
+
+	subscriptions := h.subscribers[subscription.userID]
+	delete(subscriptions, subscription)
+	if len(subscriptions) == 0 {
+		delete(h.subscribers, subscription.userID)
+	}
+}
+
+// newEvent encodes an event's data
+func newEvent(eventType string, data interface{}) (Event, error) {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Type: eventType, Data: encoded}, nil
+}
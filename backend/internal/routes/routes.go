@@ -6,10 +6,29 @@ import (
 	"github.com/hlabs/banking-system/internal/account"
 	"github.com/hlabs/banking-system/internal/auth"
 	"github.com/hlabs/banking-system/internal/chat"
+	"github.com/hlabs/banking-system/internal/dispute"
+	"github.com/hlabs/banking-system/internal/health"
+	"github.com/hlabs/banking-system/internal/interest"
+	"github.com/hlabs/banking-system/internal/ledger"
+	"github.com/hlabs/banking-system/internal/metrics"
 	"github.com/hlabs/banking-system/internal/middleware"
+	"github.com/hlabs/banking-system/internal/notification"
+	"github.com/hlabs/banking-system/internal/ratelimit"
+	"github.com/hlabs/banking-system/internal/realtime"
 	"github.com/hlabs/banking-system/internal/transaction"
+	"github.com/hlabs/banking-system/internal/webhook"
 )
 
+// RateLimits are the rate limit policies applied to the route groups
+type RateLimits struct {
+	Store          ratelimit.Store
+	Default        ratelimit.Policy // Every /api request, per IP
+	Auth           ratelimit.Policy // Login, registration, password reset and token endpoints, per IP
+	Money          ratelimit.Policy // Deposits, withdrawals, transfers and reversals, per user
+	Chat           ratelimit.Policy // AI chat, per user
+	ChatDailyQuota int              // Chat messages per user and day (UTC)
+}
+
 // SetupRoutes configures all API routes for the application
 func SetupRoutes(
 	router *gin.Engine,
@@ -17,66 +36,216 @@ func SetupRoutes(
 	accountHandler *account.Handler,
 	transactionHandler *transaction.Handler,
 	chatHandler *chat.Handler,
-	jwtSecret string,
+	interestHandler *interest.Handler,
+	ledgerHandler *ledger.Handler,
+	disputeHandler *dispute.Handler,
+	webhookHandler *webhook.Handler,
+	eventsHandler *realtime.Handler,
+	notificationHandler *notification.Handler,
+	healthHandler *health.Handler,
+	keys auth.KeyResolver,
+	sessions *auth.SessionService,
+	clients middleware.MachineCredentials,
+	limits RateLimits,
+	metricsToken string,
 ) {
 	// CORS middleware
 	corsConfig := cors.DefaultConfig()
 	corsConfig.AllowOrigins = []string{"http://localhost:5173", "http://localhost:3000"}
 	corsConfig.AllowCredentials = true
-	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization"}
+	corsConfig.AllowHeaders = []string{"Origin", "Content-Type", "Accept", "Authorization", "X-Step-Up-Token", "X-API-Key", "X-Request-ID", "X-Device-ID"}
+	corsConfig.ExposeHeaders = []string{"Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset",
+		"X-Quota-Limit", "X-Quota-Remaining", "X-Quota-Reset", "X-Request-ID"}
 	router.Use(cors.New(corsConfig))
 
-	// Health check endpoint
-	router.GET("/health", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"status":  "healthy",
-			"service": "hlabs-banking-api",
-		})
-	})
+	// Liveness and readiness probes; /health is kept for existing checks and behaves like /readyz
+	router.GET("/livez", healthHandler.Live)
+	router.GET("/readyz", healthHandler.Ready)
+	router.GET("/health", healthHandler.Ready)
+
+	// Prometheus metrics (bearer token required when METRICS_TOKEN is set)
+	router.GET("/metrics", middleware.MetricsAuth(metricsToken), gin.WrapH(metrics.Handler()))
+
+	// Public keys that verify access tokens (for services that validate tokens without a shared secret)
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+	// Every protected route validates the JWT (or API key) and checks the revocation list;
+	// the session service keeps both the revocation list and the sessions' last seen times
+	// Machine clients are limited to the groups that check a scope; the rest require a user
+	requireAuth := middleware.AuthMiddleware(keys, sessions, sessions, clients)
+	requireUser := middleware.RequireUser()
+
+	// Dependency status for operators
+	router.GET("/health/details", requireAuth, requireUser, middleware.RequireAdmin(), healthHandler.Details)
 
 	// API routes group
 	api := router.Group("/api")
+	api.Use(middleware.RateLimit(limits.Store, limits.Default))
 	{
 		// ========================================
 		// Public routes - Authentication
 		// ========================================
+		limitAuth := middleware.RateLimit(limits.Store, limits.Auth)
 		authRoutes := api.Group("/auth")
+		authRoutes.Use(limitAuth)
 		{
 			authRoutes.POST("/register", authHandler.Register)
 			authRoutes.POST("/login", authHandler.Login)
-			authRoutes.POST("/logout", authHandler.Logout)
+			authRoutes.POST("/login/mfa", authHandler.LoginMFA)
+			authRoutes.POST("/refresh", authHandler.Refresh)
+			authRoutes.POST("/verify-email/confirm", authHandler.ConfirmEmailVerification)
+			authRoutes.POST("/password-reset/request", authHandler.RequestPasswordReset)
+			authRoutes.POST("/password-reset/confirm", authHandler.ConfirmPasswordReset)
+			authRoutes.POST("/passkeys/login/begin", authHandler.BeginPasskeyLogin)
+			authRoutes.POST("/passkeys/login/finish", authHandler.FinishPasskeyLogin)
+		}
+
+		// OAuth2 client-credentials grant for API keys
+		api.POST("/oauth/token", limitAuth, authHandler.ClientToken)
+
+		// ========================================
+		// Protected routes - Sessions
+		// ========================================
+		sessionRoutes := api.Group("/auth")
+		sessionRoutes.Use(requireAuth, requireUser)
+		{
+			sessionRoutes.POST("/logout", authHandler.Logout)
+			sessionRoutes.POST("/logout-all", authHandler.LogoutAll)
+			sessionRoutes.POST("/password", limitAuth, authHandler.ChangePassword)
+
+			sessionRoutes.GET("/sessions", authHandler.ListSessions)
+			sessionRoutes.DELETE("/sessions", authHandler.RevokeOtherSessions)
+			sessionRoutes.DELETE("/sessions/:id", authHandler.RevokeSession)
+
+			sessionRoutes.POST("/mfa/enroll", authHandler.EnrollMFA)
+			sessionRoutes.POST("/mfa/activate", authHandler.ActivateMFA)
+			sessionRoutes.POST("/mfa/disable", limitAuth, authHandler.DisableMFA)
+			sessionRoutes.POST("/mfa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+
+			sessionRoutes.POST("/verify-email/request", authHandler.RequestEmailVerification)
+			sessionRoutes.POST("/step-up", limitAuth, authHandler.StepUp)
+			sessionRoutes.GET("/login-history", authHandler.GetLoginHistory)
+
+			sessionRoutes.POST("/api-keys", authHandler.CreateAPIKey)
+			sessionRoutes.GET("/api-keys", authHandler.ListAPIKeys)
+			sessionRoutes.DELETE("/api-keys/:id", authHandler.RevokeAPIKey)
+			sessionRoutes.GET("/api-keys/:id/usage", authHandler.GetAPIKeyUsage)
+
+			sessionRoutes.POST("/passkeys/register/begin", limitAuth, authHandler.BeginPasskeyRegistration)
+			sessionRoutes.POST("/passkeys/register/finish", authHandler.FinishPasskeyRegistration)
+			sessionRoutes.GET("/passkeys", authHandler.ListPasskeys)
+			sessionRoutes.PATCH("/passkeys/:id", authHandler.RenamePasskey)
+			sessionRoutes.DELETE("/passkeys/:id", authHandler.DeletePasskey)
 		}
 
 		// ========================================
 		// Protected routes - Accounts
 		// ========================================
 		accountRoutes := api.Group("/accounts")
-		accountRoutes.Use(middleware.AuthMiddleware(jwtSecret))
+		accountRoutes.Use(requireAuth, middleware.RequireScope(auth.ScopeReadBalance))
 		{
 			accountRoutes.GET("/me", accountHandler.GetAccountInfo)
 			accountRoutes.GET("/balance", accountHandler.GetBalance)
+			accountRoutes.GET("/interest", interestHandler.GetAccrued)
 		}
 
 		// ========================================
 		// Protected routes - Transactions
 		// ========================================
 		transactionRoutes := api.Group("/transactions")
-		transactionRoutes.Use(middleware.AuthMiddleware(jwtSecret))
+		transactionRoutes.Use(requireAuth)
+		{
+			canTransfer := middleware.RequireScope(auth.ScopeWriteTransfer)
+			limitMoney := middleware.RateLimit(limits.Store, limits.Money)
+			transactionRoutes.POST("/deposit", canTransfer, limitMoney, transactionHandler.Deposit)
+			transactionRoutes.POST("/withdraw", canTransfer, limitMoney, transactionHandler.Withdraw)
+			transactionRoutes.POST("/transfer", canTransfer, limitMoney, transactionHandler.Transfer)
+			transactionRoutes.POST("/preview", canTransfer, transactionHandler.Preview)
+			transactionRoutes.GET("/history", middleware.RequireScope(auth.ScopeReadTransactions), transactionHandler.GetHistory)
+			transactionRoutes.POST("/:id/reverse", canTransfer, limitMoney, transactionHandler.Reverse)
+		}
+
+		// ========================================
+		// Protected routes - Realtime events
+		// ========================================
+		eventRoutes := api.Group("/events")
+		eventRoutes.Use(requireAuth, requireUser)
 		{
-			transactionRoutes.POST("/deposit", transactionHandler.Deposit)
-			transactionRoutes.POST("/withdraw", transactionHandler.Withdraw)
-			transactionRoutes.POST("/transfer", transactionHandler.Transfer)
-			transactionRoutes.GET("/history", transactionHandler.GetHistory)
+			eventRoutes.GET("/stream", eventsHandler.Stream)
+			eventRoutes.GET("/ws", eventsHandler.Socket)
+		}
+
+		// ========================================
+		// Protected routes - Notifications
+		// ========================================
+		notificationRoutes := api.Group("/notifications")
+		notificationRoutes.Use(requireAuth, requireUser)
+		{
+			notificationRoutes.GET("", notificationHandler.List)
+			notificationRoutes.POST("/read-all", notificationHandler.MarkAllRead)
+			notificationRoutes.POST("/:id/read", notificationHandler.MarkRead)
+			notificationRoutes.POST("/:id/unread", notificationHandler.MarkUnread)
+			notificationRoutes.GET("/preferences", notificationHandler.GetPreferences)
+			notificationRoutes.PUT("/preferences", notificationHandler.UpdatePreferences)
 		}
 
 		// ========================================
 		// Protected routes - AI Chat
 		// ========================================
 		chatRoutes := api.Group("/chat")
-		chatRoutes.Use(middleware.AuthMiddleware(jwtSecret))
+		chatRoutes.Use(requireAuth, requireUser, middleware.RateLimit(limits.Store, limits.Chat))
+		{
+			// Every message is a paid model call, so messages also count against a daily quota
+			chatRoutes.POST("", middleware.DailyQuota(limits.Store, "chat", limits.ChatDailyQuota), chatHandler.ProcessMessage)
+			// Confirmations move money
+			chatRoutes.POST("/confirm", middleware.RateLimit(limits.Store, limits.Money), chatHandler.ProcessConfirmation)
+		}
+
+		// ========================================
+		// Protected routes - Disputes
+		// ========================================
+		disputeRoutes := api.Group("/disputes")
+		disputeRoutes.Use(requireAuth, requireUser)
+		{
+			disputeRoutes.GET("/reasons", disputeHandler.GetReasons)
+			disputeRoutes.POST("", disputeHandler.Open)
+			disputeRoutes.GET("", disputeHandler.List)
+			disputeRoutes.GET("/:id", disputeHandler.Get)
+		}
+
+		// ========================================
+		// Protected routes - Webhooks
+		// ========================================
+		webhookRoutes := api.Group("/webhooks")
+		webhookRoutes.Use(requireAuth, middleware.RequireScope(auth.ScopeReadTransactions))
+		requireWebhookWrite := middleware.RequireScope(auth.ScopeWriteWebhooks)
 		{
-			chatRoutes.POST("", chatHandler.ProcessMessage)
-			chatRoutes.POST("/confirm", chatHandler.ProcessConfirmation)
+			webhookRoutes.POST("", requireWebhookWrite, webhookHandler.Create)
+			webhookRoutes.GET("", webhookHandler.List)
+			webhookRoutes.DELETE("/:id", requireWebhookWrite, webhookHandler.Delete)
+			webhookRoutes.GET("/:id/deliveries", webhookHandler.GetDeliveries)
+			webhookRoutes.GET("/dead-letters", webhookHandler.GetDeadLetters)
+			webhookRoutes.POST("/deliveries/:id/replay", requireWebhookWrite, webhookHandler.Replay)
+		}
+
+		// ========================================
+		// Admin routes - Back office
+		// ========================================
+		adminRoutes := api.Group("/admin")
+		adminRoutes.Use(requireAuth, requireUser, middleware.RequireAdmin())
+		{
+			adminRoutes.GET("/ledger/chart", ledgerHandler.GetChart)
+			adminRoutes.GET("/ledger/trial-balance", ledgerHandler.GetTrialBalance)
+			adminRoutes.GET("/ledger/accounts/:account/entries", ledgerHandler.GetEntries)
+
+			adminRoutes.POST("/users/:id/unlock", authHandler.UnlockUser)
+			adminRoutes.POST("/users/:id/api-keys", authHandler.CreateUserAPIKey)
+			adminRoutes.GET("/signing-keys", authHandler.ListSigningKeys)
+			adminRoutes.POST("/signing-keys/rotate", authHandler.RotateSigningKey)
+
+			adminRoutes.GET("/disputes", disputeHandler.Queue)
+			adminRoutes.POST("/disputes/:id/review", disputeHandler.StartReview)
+			adminRoutes.POST("/disputes/:id/resolve", disputeHandler.Resolve)
 		}
 	}
 }
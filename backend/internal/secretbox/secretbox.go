@@ -0,0 +1,54 @@
+package secretbox
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+)
+
+// Box encrypts secrets stored in the database (TOTP secrets, signing keys, webhook secrets)
+// with AES-256-GCM using a key derived from a configured passphrase
+type Box struct {
+	aead cipher.AEAD
+}
+
+// New derives the AES-256 key from passphrase
+func New(passphrase string) (*Box, error) {
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return &Box{aead: aead}, nil
+}
+
+// Seal encrypts plaintext into a base64 string (nonce followed by ciphertext)
+func (b *Box) Seal(plaintext []byte) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := b.aead.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Open decrypts a string produced by Seal
+func (b *Box) Open(stored string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(stored)
+	if err != nil || len(sealed) < b.aead.NonceSize() {
+		return nil, fmt.Errorf("invalid sealed value")
+	}
+	nonce, ciphertext := sealed[:b.aead.NonceSize()], sealed[b.aead.NonceSize():]
+	plaintext, err := b.aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
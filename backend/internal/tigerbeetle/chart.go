@@ -0,0 +1,172 @@
+package tigerbeetle
+
+import "fmt"
+
+// LedgerUSD is the TigerBeetle ledger for all USD accounts (customer and system)
+const LedgerUSD uint32 = 1
+
+// AccountCodeCustomer is the TigerBeetle account code for customer accounts
+const AccountCodeCustomer uint16 = 1
+
+// AccountKind classifies a ledger account for reporting
+type AccountKind string
+
+const (
+	AccountKindAsset     AccountKind = "asset"
+	AccountKindLiability AccountKind = "liability"
+	AccountKindEquity    AccountKind = "equity"
+	AccountKindIncome    AccountKind = "income"
+	AccountKindExpense   AccountKind = "expense"
+)
+
+// NormalBalance returns which side increases accounts of this kind
+// Assets and expenses are debit-normal; liabilities, equity and income are credit-normal
+func (k AccountKind) NormalBalance() string {
+	if k == AccountKindAsset || k == AccountKindExpense {
+		return "debit"
+	}
+	return "credit"
+}
+
+// SystemAccount describes one of the bank's own accounts in the chart of accounts
+type SystemAccount struct {
+	ID          uint64      `json:"id"`
+	Code        uint16      `json:"code"`
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Kind        AccountKind `json:"kind"`
+}
+
+// System account IDs. IDs and codes are fixed: changing them on an existing
+// cluster would fail with AccountExistsWithDifferentCode.
+const (
+	CashVaultAccountID            uint64 = 1
+	FeeIncomeAccountID            uint64 = 2
+	InterestExpenseAccountID      uint64 = 3
+	SuspenseAccountID             uint64 = 4
+	FXAccountID                   uint64 = 5
+	OpeningBalanceEquityAccountID uint64 = 6
+	DisputeLossAccountID          uint64 = 7
+)
+
+// ChartOfAccounts lists every system account provisioned at startup
+var ChartOfAccounts = []SystemAccount{
+	{
+		ID:          CashVaultAccountID,
+		Code:        999,
+		Name:        "cash_vault",
+		Description: "Cash held by the bank - counterparty of deposits and withdrawals",
+		Kind:        AccountKindAsset,
+	},
+	{
+		ID:          FeeIncomeAccountID,
+		Code:        998,
+		Name:        "fee_income",
+		Description: "Revenue from customer fees",
+		Kind:        AccountKindIncome,
+	},
+	{
+		ID:          InterestExpenseAccountID,
+		Code:        997,
+		Name:        "interest_expense",
+		Description: "Interest paid to customers",
+		Kind:        AccountKindExpense,
+	},
+	{
+		ID:          SuspenseAccountID,
+		Code:        996,
+		Name:        "suspense",
+		Description: "Temporary holding account for unresolved items",
+		Kind:        AccountKindLiability,
+	},
+	{
+		ID:          FXAccountID,
+		Code:        995,
+		Name:        "fx",
+		Description: "Foreign exchange position",
+		Kind:        AccountKindAsset,
+	},
+	{
+		ID:          OpeningBalanceEquityAccountID,
+		Code:        994,
+		Name:        "opening_balance_equity",
+		Description: "Counterparty of opening balances loaded at seed time",
+		Kind:        AccountKindEquity,
+	},
+	{
+		ID:          DisputeLossAccountID,
+		Code:        993,
+		Name:        "dispute_losses",
+		Description: "Provisional dispute credits that could not be recovered",
+		Kind:        AccountKindExpense,
+	},
+}
+
+// LookupSystemAccount finds a chart account by ID, code or name
+func LookupSystemAccount(key string) (SystemAccount, error) {
+	for _, account := range ChartOfAccounts {
+		if account.Name == key || fmt.Sprint(account.Code) == key || fmt.Sprint(account.ID) == key {
+			return account, nil
+		}
+	}
+	return SystemAccount{}, fmt.Errorf("unknown system account: %s", key)
+}
+
+// IsSystemAccount reports whether an account ID belongs to the chart of accounts
+func IsSystemAccount(accountID uint64) bool {
+	for _, account := range ChartOfAccounts {
+		if account.ID == accountID {
+			return true
+		}
+	}
+	return false
+}
+
+// Transfer codes identify the business meaning of each TigerBeetle transfer
+const (
+	TransferCodeDeposit        uint16 = 1
+	TransferCodeWithdrawal     uint16 = 2
+	TransferCodeTransfer       uint16 = 3
+	TransferCodeHistorical     uint16 = 4
+	TransferCodeFee            uint16 = 5
+	TransferCodeInterest       uint16 = 6
+	TransferCodeReversal       uint16 = 7
+	TransferCodeDisputeHold    uint16 = 8
+	TransferCodeDisputeCredit  uint16 = 9
+	TransferCodeDisputeRecover uint16 = 10
+	TransferCodeDisputeLoss    uint16 = 11
+	TransferCodeOpeningBalance uint16 = 100
+)
+
+// TransferCodeInfo documents a transfer code
+type TransferCodeInfo struct {
+	Code        uint16 `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// TransferCodes is the registry of every transfer code used by the system
+var TransferCodes = []TransferCodeInfo{
+	{Code: TransferCodeDeposit, Name: "deposit", Description: "Cash deposited into a customer account"},
+	{Code: TransferCodeWithdrawal, Name: "withdrawal", Description: "Cash withdrawn from a customer account"},
+	{Code: TransferCodeTransfer, Name: "transfer", Description: "Transfer between customer accounts"},
+	{Code: TransferCodeHistorical, Name: "historical", Description: "Historical transaction loaded from seed data"},
+	{Code: TransferCodeFee, Name: "fee", Description: "Fee charged to a customer, credited to fee income"},
+	{Code: TransferCodeInterest, Name: "interest", Description: "Interest paid to a customer from interest expense"},
+	{Code: TransferCodeReversal, Name: "reversal", Description: "Full or partial reversal of an earlier transfer (user_data_128 holds the original transfer ID)"},
+	{Code: TransferCodeDisputeHold, Name: "dispute_hold", Description: "Pending hold on the counterparty for a disputed transaction, posted when won and voided when lost"},
+	{Code: TransferCodeDisputeCredit, Name: "dispute_credit", Description: "Provisional credit from suspense for a disputed transaction, recovered from the counterparty when won and clawed back when lost"},
+	{Code: TransferCodeDisputeRecover, Name: "dispute_recovery", Description: "Recovery of a provisional dispute credit into suspense, from the counterparty (won) or the customer (lost) (user_data_128 holds the credit's transfer ID)"},
+	{Code: TransferCodeDisputeLoss, Name: "dispute_loss", Description: "Part of a provisional dispute credit that could not be recovered, written off from dispute losses to suspense"},
+	{Code: TransferCodeOpeningBalance, Name: "opening_balance", Description: "Opening balance loaded at seed time"},
+}
+
+// TransferCodeName returns the registered name of a transfer code
+func TransferCodeName(code uint16) string {
+	for _, info := range TransferCodes {
+		if info.Code == code {
+			return info.Name
+		}
+	}
+	return fmt.Sprintf("unknown_%d", code)
+}
@@ -1,186 +1,406 @@
 package tigerbeetle
 
 import (
+	"context"
 	"fmt"
-	"log"
 	"math/big"
 	"net"
+	"time"
 
+	"github.com/hlabs/banking-system/internal/logging"
+	"github.com/hlabs/banking-system/internal/metrics"
+	"github.com/hlabs/banking-system/internal/tracing"
 	tb "github.com/tigerbeetle/tigerbeetle-go"
 	tb_types "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Client wraps the TigerBeetle client
+// System account IDs are exposed as Uint128 for building transfers (see ChartOfAccounts)
 type Client struct {
-	client          tb.Client
-	clusterID       uint128
-	SystemAccountID uint128 // Bank system account for deposits/withdrawals
+	client                        tb.Client
+	clusterID                     uint128
+	SystemAccountID               uint128 // Cash vault - counterparty of deposits/withdrawals
+	FeeIncomeAccountID            uint128 // Bank revenue account credited with customer fees
+	InterestExpenseAccountID      uint128 // Bank expense account debited when interest is paid
+	SuspenseAccountID             uint128 // Holding account for unresolved items
+	FXAccountID                   uint128 // Foreign exchange position
+	OpeningBalanceEquityAccountID uint128 // Counterparty of seeded opening balances
+	DisputeLossAccountID          uint128 // Expense account debited for unrecovered dispute credits
 }
 
 // uint128 represents a 128-bit unsigned integer
 type uint128 = tb_types.Uint128
 
+var logger = logging.For("tigerbeetle")
+
+var tracer = tracing.Tracer("tigerbeetle")
+
+// startSpan starts a client span for a TigerBeetle operation with the number of events sent
+func startSpan(ctx context.Context, operation string, events int) (context.Context, trace.Span) {
+	return tracer.Start(ctx, "tigerbeetle."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "tigerbeetle"),
+			attribute.String("db.operation.name", operation),
+			attribute.Int("tigerbeetle.events", events),
+		),
+	)
+}
+
 // NewClient creates a new TigerBeetle client
 func NewClient(address string) (*Client, error) {
-	log.Printf("🔗 Attempting to connect to TigerBeetle at: %s", address)
-
 	// Resolve hostname to IP address (required for TigerBeetle Go client)
 	resolvedAddr, err := resolveAddress(address)
 	if err != nil {
-		log.Printf("⚠️  Failed to resolve TigerBeetle address '%s': %v", address, err)
-		log.Printf("⚠️  This might cause connection issues. Ensure TigerBeetle is running and accessible.")
+		logger.Warn("failed to resolve TigerBeetle address, ensure TigerBeetle is running and accessible", "address", address, "error", err)
 		// Don't fail immediately, let the TigerBeetle client try with the original address
 		resolvedAddr = address
 	}
 
-	log.Printf("🔍 TigerBeetle connection details:")
-	log.Printf("   Original address: %s", address)
-	log.Printf("   Resolved address: %s", resolvedAddr)
+	logger.Info("connecting to TigerBeetle", "address", address, "resolved_address", resolvedAddr)
 
 	// Cluster ID (must match the initialized cluster)
 	clusterID := tb_types.ToUint128(0)
 
 	// Create TigerBeetle client
-	log.Printf("📡 Creating TigerBeetle client connection...")
 	tbClient, err := tb.NewClient(clusterID, []string{resolvedAddr})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create TigerBeetle client to '%s' (resolved: '%s'): %w", address, resolvedAddr, err)
 	}
 
-	log.Printf("✅ TigerBeetle client connected successfully!")
-
 	client := &Client{
-		client:          tbClient,
-		clusterID:       clusterID,
-		SystemAccountID: tb_types.ToUint128(1), // System account ID = 1
+		client:                        tbClient,
+		clusterID:                     clusterID,
+		SystemAccountID:               tb_types.ToUint128(CashVaultAccountID),
+		FeeIncomeAccountID:            tb_types.ToUint128(FeeIncomeAccountID),
+		InterestExpenseAccountID:      tb_types.ToUint128(InterestExpenseAccountID),
+		SuspenseAccountID:             tb_types.ToUint128(SuspenseAccountID),
+		FXAccountID:                   tb_types.ToUint128(FXAccountID),
+		OpeningBalanceEquityAccountID: tb_types.ToUint128(OpeningBalanceEquityAccountID),
+		DisputeLossAccountID:          tb_types.ToUint128(DisputeLossAccountID),
 	}
 
-	// Initialize system account if needed
-	log.Printf("🔧 Initializing system account...")
-	if err := client.ensureSystemAccount(); err != nil {
-		return nil, fmt.Errorf("failed to initialize system account: %w", err)
+	// Provision the chart of accounts if needed
+	logger.Info("initializing chart of accounts", "system_accounts", len(ChartOfAccounts), "transfer_codes", len(TransferCodes))
+	if err := client.ensureSystemAccounts(); err != nil {
+		return nil, fmt.Errorf("failed to initialize system accounts: %w", err)
 	}
 
-	log.Printf("✅ TigerBeetle client fully initialized!")
+	logger.Info("TigerBeetle client initialized")
 	return client, nil
 }
 
-// ensureSystemAccount creates the system bank account if it doesn't exist
-func (c *Client) ensureSystemAccount() error {
-	// Try to create system account (ID = 1)
-	accounts := []tb_types.Account{
-		{
-			ID:     c.SystemAccountID,
-			Ledger: 1,   // User ledger
-			Code:   999, // System account code
-			Flags:  0,   // No restrictions on system account
-		},
+// ensureSystemAccounts creates every account in the chart of accounts if it doesn't exist
+func (c *Client) ensureSystemAccounts() error {
+	// System accounts have no balance restrictions - they represent the bank's side of the ledger
+	accounts := make([]tb_types.Account, 0, len(ChartOfAccounts))
+	for _, account := range ChartOfAccounts {
+		accounts = append(accounts, tb_types.Account{
+			ID:     tb_types.ToUint128(account.ID),
+			Ledger: LedgerUSD,
+			Code:   account.Code,
+			Flags:  0,
+		})
 	}
 
 	results, err := c.client.CreateAccounts(accounts)
 	if err != nil {
-		return fmt.Errorf("failed to create system account: %w", err)
+		return fmt.Errorf("failed to create system accounts: %w", err)
 	}
 
-	// Check results - ignore "exists" error
+	// Results only contain failed events - anything other than "exists" is a real error
+	failed := make(map[uint32]tb_types.CreateAccountResult, len(results))
 	for _, result := range results {
 		if result.Result != tb_types.AccountExists {
-			log.Println("✅ System account initialized (ID: 1)")
-		} else {
-			log.Println("ℹ️  System account already exists")
+			return fmt.Errorf("failed to create system account %s: %s", ChartOfAccounts[result.Index].Name, result.Result)
 		}
+		failed[result.Index] = result.Result
+	}
+
+	for i, account := range ChartOfAccounts {
+		_, exists := failed[uint32(i)]
+		logger.Debug("system account ready", "name", account.Name, "id", account.ID, "code", account.Code, "created", !exists)
 	}
 
 	return nil
 }
 
 // CreateAccount creates a new user account in TigerBeetle
-func (c *Client) CreateAccount(accountID uint64) error {
+func (c *Client) CreateAccount(ctx context.Context, accountID uint64) error {
 	id := tb_types.ToUint128(accountID)
 
 	accounts := []tb_types.Account{
 		{
 			ID:     id,
-			Ledger: 1, // User ledger
-			Code:   1, // User account code
-			Flags:  tb_types.AccountFlags{DebitsMustNotExceedCredits: true}.ToUint16(),
+			Ledger: LedgerUSD,
+			Code:   AccountCodeCustomer,
+			// History keeps a balance snapshot per transfer so BalanceAt is a single lookup
+			Flags: tb_types.AccountFlags{DebitsMustNotExceedCredits: true, History: true}.ToUint16(),
 		},
 	}
 
+	_, span := startSpan(ctx, "create_accounts", len(accounts))
+	start := time.Now()
 	results, err := c.client.CreateAccounts(accounts)
+	metrics.ObserveTigerBeetle("create_accounts", time.Since(start))
 	if err != nil {
+		tracing.End(span, err)
+		metrics.CountTigerBeetleResults("create_accounts", "error", len(accounts))
+		logger.ErrorContext(ctx, "create_accounts failed", "account_id", accountID, "duration", time.Since(start), "error", err)
 		return fmt.Errorf("failed to create account: %w", err)
 	}
 
 	// Check for errors in results
+	metrics.CountTigerBeetleResults("create_accounts", "ok", len(accounts)-len(results))
+	span.SetAttributes(attribute.Int("tigerbeetle.rejected", len(results)))
 	if len(results) > 0 {
+		span.SetAttributes(attribute.String("tigerbeetle.result", results[0].Result.String()))
+		span.End()
+		metrics.CountTigerBeetleResults("create_accounts", results[0].Result.String(), 1)
+		logger.WarnContext(ctx, "account rejected", "account_id", accountID, "result", results[0].Result.String())
 		return fmt.Errorf("failed to create account: result code %d", results[0].Result)
 	}
 
-	log.Printf("✅ Created TigerBeetle account ID: %d", accountID)
+	span.End()
+	logger.DebugContext(ctx, "account created", "account_id", accountID, "duration", time.Since(start))
 
 	return nil
 }
 
-// GetBalance retrieves the balance of an account
-func (c *Client) GetBalance(accountID uint64) (int64, error) {
-	log.Printf("🟢 [TigerBeetle] GetBalance called for accountID: %d", accountID)
-
+// GetBalance retrieves the available balance of an account: what it can still spend
+// Pending debits (dispute holds) are already reserved, so they are subtracted along with the
+// posted debits; pending credits are not counted until they are posted
+func (c *Client) GetBalance(ctx context.Context, accountID uint64) (int64, error) {
 	id := tb_types.ToUint128(accountID)
-	log.Printf("🟢 [TigerBeetle] Converted to Uint128: %v", id)
 
-	log.Printf("🟢 [TigerBeetle] Calling LookupAccounts...")
-	accounts, err := c.client.LookupAccounts([]tb_types.Uint128{id})
+	accounts, err := c.LookupAccounts(ctx, []tb_types.Uint128{id})
 	if err != nil {
-		log.Printf("❌ [TigerBeetle] Failed to lookup account %d: %v", accountID, err)
 		return 0, fmt.Errorf("failed to lookup account: %w", err)
 	}
-	log.Printf("🟢 [TigerBeetle] LookupAccounts returned %d account(s)", len(accounts))
 
 	if len(accounts) == 0 {
-		log.Printf("❌ [TigerBeetle] Account %d not found in TigerBeetle", accountID)
+		logger.WarnContext(ctx, "account not found", "account_id", accountID)
 		return 0, fmt.Errorf("account not found")
 	}
 
-	account := accounts[0]
-	log.Printf("🟢 [TigerBeetle] Account details: ID=%v, Ledger=%d, Code=%d", account.ID, account.Ledger, account.Code)
-	log.Printf("🟢 [TigerBeetle] Account raw values: CreditsPosted=%v, DebitsPosted=%v", account.CreditsPosted, account.DebitsPosted)
+	return AvailableBalance(accounts[0]), nil
+}
 
-	// Balance = Credits - Debits (for accounts with DebitsMustNotExceedCredits flag)
+// AvailableBalance computes credits_posted - debits_posted - debits_pending of an account
+// This is the bound TigerBeetle enforces on accounts with DebitsMustNotExceedCredits
+func AvailableBalance(account tb_types.Account) int64 {
 	// Convert Uint128 to big.Int for arithmetic
 	creditsBI := account.CreditsPosted.BigInt()
 	debitsBI := account.DebitsPosted.BigInt()
-	log.Printf("🟢 [TigerBeetle] Credits as BigInt: %s", creditsBI.String())
-	log.Printf("🟢 [TigerBeetle] Debits as BigInt: %s", debitsBI.String())
-
+	pendingBI := account.DebitsPending.BigInt()
 	balanceBI := new(big.Int).Sub(&creditsBI, &debitsBI)
-	log.Printf("🟢 [TigerBeetle] Balance as BigInt: %s", balanceBI.String())
+	balanceBI.Sub(balanceBI, &pendingBI)
 
 	// Convert to int64 (safe for reasonable banking amounts)
-	balance := balanceBI.Int64()
-	log.Printf("✅ [TigerBeetle] Final balance: %d cents (accountID: %d)", balance, accountID)
-
-	return balance, nil
+	return balanceBI.Int64()
 }
 
 // CreateTransfers creates one or more transfers in TigerBeetle
-func (c *Client) CreateTransfers(transfers []tb_types.Transfer) ([]tb_types.TransferEventResult, error) {
+func (c *Client) CreateTransfers(ctx context.Context, transfers []tb_types.Transfer) ([]tb_types.TransferEventResult, error) {
+	_, span := startSpan(ctx, "create_transfers", len(transfers))
+	start := time.Now()
 	results, err := c.client.CreateTransfers(transfers)
+	metrics.ObserveTigerBeetle("create_transfers", time.Since(start))
 	if err != nil {
+		tracing.End(span, err)
+		metrics.CountTigerBeetleResults("create_transfers", "error", len(transfers))
+		logger.ErrorContext(ctx, "create_transfers failed", "transfers", len(transfers), "duration", time.Since(start), "error", err)
 		return nil, fmt.Errorf("failed to create transfers: %w", err)
 	}
+
+	metrics.CountTigerBeetleResults("create_transfers", "ok", len(transfers)-len(results))
+	span.SetAttributes(attribute.Int("tigerbeetle.rejected", len(results)))
+	if len(results) > 0 {
+		codes := make([]string, len(results))
+		for i, result := range results {
+			codes[i] = result.Result.String()
+			metrics.CountTigerBeetleResults("create_transfers", codes[i], 1)
+		}
+		span.SetAttributes(attribute.StringSlice("tigerbeetle.results", codes))
+		logger.DebugContext(ctx, "transfers rejected", "transfers", len(transfers), "rejected", len(results), "results", codes, "duration", time.Since(start))
+	} else {
+		logger.DebugContext(ctx, "transfers created", "transfers", len(transfers), "duration", time.Since(start))
+	}
+	span.End()
 	return results, nil
 }
 
+// LookupTransfers retrieves transfers by ID; IDs that don't exist are left out of the result
+func (c *Client) LookupTransfers(ctx context.Context, transferIDs []tb_types.Uint128) ([]tb_types.Transfer, error) {
+	_, span := startSpan(ctx, "lookup_transfers", len(transferIDs))
+	start := time.Now()
+	transfers, err := c.client.LookupTransfers(transferIDs)
+	metrics.ObserveTigerBeetle("lookup_transfers", time.Since(start))
+	if err != nil {
+		tracing.End(span, err)
+		metrics.CountTigerBeetleResults("lookup_transfers", "error", 1)
+		logger.ErrorContext(ctx, "lookup_transfers failed", "transfers", len(transferIDs), "duration", time.Since(start), "error", err)
+		return nil, fmt.Errorf("failed to lookup transfers: %w", err)
+	}
+	metrics.CountTigerBeetleResults("lookup_transfers", "ok", 1)
+	span.SetAttributes(attribute.Int("tigerbeetle.found", len(transfers)))
+	span.End()
+	logger.DebugContext(ctx, "transfers looked up", "transfers", len(transferIDs), "found", len(transfers), "duration", time.Since(start))
+	return transfers, nil
+}
+
 // LookupAccounts retrieves account information from TigerBeetle
-func (c *Client) LookupAccounts(accountIDs []tb_types.Uint128) ([]tb_types.Account, error) {
+func (c *Client) LookupAccounts(ctx context.Context, accountIDs []tb_types.Uint128) ([]tb_types.Account, error) {
+	_, span := startSpan(ctx, "lookup_accounts", len(accountIDs))
+	start := time.Now()
 	accounts, err := c.client.LookupAccounts(accountIDs)
+	metrics.ObserveTigerBeetle("lookup_accounts", time.Since(start))
 	if err != nil {
+		tracing.End(span, err)
+		metrics.CountTigerBeetleResults("lookup_accounts", "error", 1)
+		logger.ErrorContext(ctx, "lookup_accounts failed", "accounts", len(accountIDs), "duration", time.Since(start), "error", err)
 		return nil, fmt.Errorf("failed to lookup accounts: %w", err)
 	}
+	metrics.CountTigerBeetleResults("lookup_accounts", "ok", 1)
+	span.SetAttributes(attribute.Int("tigerbeetle.found", len(accounts)))
+	span.End()
+	logger.DebugContext(ctx, "accounts looked up", "accounts", len(accountIDs), "found", len(accounts), "duration", time.Since(start))
 	return accounts, nil
 }
 
+// GetAccountTransfers retrieves transfers involving an account, filtered by the given filter
+func (c *Client) GetAccountTransfers(ctx context.Context, filter tb_types.AccountFilter) ([]tb_types.Transfer, error) {
+	_, span := startSpan(ctx, "get_account_transfers", 1)
+	start := time.Now()
+	transfers, err := c.client.GetAccountTransfers(filter)
+	metrics.ObserveTigerBeetle("get_account_transfers", time.Since(start))
+	if err != nil {
+		tracing.End(span, err)
+		metrics.CountTigerBeetleResults("get_account_transfers", "error", 1)
+		logger.ErrorContext(ctx, "get_account_transfers failed", "duration", time.Since(start), "error", err)
+		return nil, fmt.Errorf("failed to get account transfers: %w", err)
+	}
+	metrics.CountTigerBeetleResults("get_account_transfers", "ok", 1)
+	span.SetAttributes(attribute.Int("tigerbeetle.transfers", len(transfers)))
+	span.End()
+	logger.DebugContext(ctx, "account transfers fetched", "transfers", len(transfers), "duration", time.Since(start))
+	return transfers, nil
+}
+
+// GetAccountBalances retrieves historical balances of an account created with the History flag
+func (c *Client) GetAccountBalances(ctx context.Context, filter tb_types.AccountFilter) ([]tb_types.AccountBalance, error) {
+	_, span := startSpan(ctx, "get_account_balances", 1)
+	start := time.Now()
+	balances, err := c.client.GetAccountBalances(filter)
+	metrics.ObserveTigerBeetle("get_account_balances", time.Since(start))
+	if err != nil {
+		tracing.End(span, err)
+		metrics.CountTigerBeetleResults("get_account_balances", "error", 1)
+		logger.ErrorContext(ctx, "get_account_balances failed", "duration", time.Since(start), "error", err)
+		return nil, fmt.Errorf("failed to get account balances: %w", err)
+	}
+	metrics.CountTigerBeetleResults("get_account_balances", "ok", 1)
+	span.SetAttributes(attribute.Int("tigerbeetle.balances", len(balances)))
+	span.End()
+	logger.DebugContext(ctx, "account balances fetched", "balances", len(balances), "duration", time.Since(start))
+	return balances, nil
+}
+
+// accountTransfersPageSize is the maximum number of transfers returned per GetAccountTransfers call
+const accountTransfersPageSize = 8190
+
+// BalanceAt computes the posted balance (credits - debits) of an account as of the given instant
+//
+// TigerBeetle timestamps are nanoseconds since the UNIX epoch. Accounts created with the
+// History flag answer from the last balance snapshot at or before the instant; older accounts
+// (created before the flag was set) fall back to rebuilding the balance from their transfers.
+func (c *Client) BalanceAt(ctx context.Context, accountID uint64, at time.Time) (int64, error) {
+	id := tb_types.ToUint128(accountID)
+
+	accounts, err := c.LookupAccounts(ctx, []tb_types.Uint128{id})
+	if err != nil {
+		return 0, err
+	}
+	if len(accounts) == 0 {
+		return 0, fmt.Errorf("account not found")
+	}
+	if !accounts[0].AccountFlags().History {
+		return c.balanceFromTransfers(ctx, id, at)
+	}
+
+	balances, err := c.GetAccountBalances(ctx, tb_types.AccountFilter{
+		AccountID:    id,
+		TimestampMax: uint64(at.UnixNano()),
+		Limit:        1,
+		Flags: tb_types.AccountFilterFlags{
+			Debits:   true,
+			Credits:  true,
+			Reversed: true, // Newest snapshot first
+		}.ToUint32(),
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(balances) == 0 {
+		return 0, nil // No transfers yet at that instant
+	}
+
+	credits := balances[0].CreditsPosted.BigInt()
+	debits := balances[0].DebitsPosted.BigInt()
+	return new(big.Int).Sub(&credits, &debits).Int64(), nil
+}
+
+// balanceFromTransfers rebuilds the posted balance of an account without history from every
+// posted transfer with a timestamp at or before the instant. Pending and voided transfers don't
+// affect posted balances and are skipped; posts of pending transfers count.
+func (c *Client) balanceFromTransfers(ctx context.Context, id tb_types.Uint128, at time.Time) (int64, error) {
+	timestampMax := uint64(at.UnixNano())
+
+	credits := new(big.Int)
+	debits := new(big.Int)
+	timestampMin := uint64(0)
+
+	for {
+		transfers, err := c.GetAccountTransfers(ctx, tb_types.AccountFilter{
+			AccountID:    id,
+			TimestampMin: timestampMin,
+			TimestampMax: timestampMax,
+			Limit:        accountTransfersPageSize,
+			Flags: tb_types.AccountFilterFlags{
+				Debits:  true,
+				Credits: true,
+			}.ToUint32(),
+		})
+		if err != nil {
+			return 0, err
+		}
+
+		for _, transfer := range transfers {
+			flags := transfer.TransferFlags()
+			if flags.Pending || flags.VoidPendingTransfer {
+				continue
+			}
+
+			amount := transfer.Amount.BigInt()
+			if transfer.CreditAccountID == id {
+				credits.Add(credits, &amount)
+			}
+			if transfer.DebitAccountID == id {
+				debits.Add(debits, &amount)
+			}
+		}
+
+		if len(transfers) < accountTransfersPageSize {
+			break
+		}
+		timestampMin = transfers[len(transfers)-1].Timestamp + 1
+	}
+
+	return new(big.Int).Sub(credits, debits).Int64(), nil
+}
+
 // resolveAddress resolves a hostname:port to IP:port for TigerBeetle client
 func resolveAddress(address string) (string, error) {
 	// Split address into host and port
@@ -194,13 +414,10 @@ func resolveAddress(address string) (string, error) {
 	// Check if host is already an IP address
 	if net.ParseIP(host) != nil {
 		// Already an IP, return as-is
-		result := net.JoinHostPort(host, port)
-		log.Printf("✅ Address is already an IP: %s", result)
-		return result, nil
+		return net.JoinHostPort(host, port), nil
 	}
 
 	// Try to resolve hostname to IP address with retries
-	log.Printf("🔍 Resolving hostname '%s' to IP address...", host)
 	
 	var ips []string
 	var lastErr error
@@ -213,7 +430,7 @@ func resolveAddress(address string) (string, error) {
 		}
 		
 		if attempt < 3 {
-			log.Printf("⚠️  DNS resolution attempt %d/3 failed for '%s': %v (retrying...)", attempt, host, lastErr)
+			logger.Warn("DNS resolution failed, retrying", "attempt", attempt, "host", host, "error", lastErr)
 			// Simple sleep could be added here if needed
 		}
 	}
@@ -230,7 +447,7 @@ func resolveAddress(address string) (string, error) {
 	resolvedIP := ips[0]
 	resolvedAddr := net.JoinHostPort(resolvedIP, port)
 
-	log.Printf("✅ DNS resolved '%s' → '%s' (from %d available IPs)", address, resolvedAddr, len(ips))
+	logger.Debug("DNS resolved", "address", address, "resolved_address", resolvedAddr, "candidates", len(ips))
 
 	return resolvedAddr, nil
 }
@@ -238,5 +455,5 @@ func resolveAddress(address string) (string, error) {
 // Close closes the TigerBeetle client connection
 func (c *Client) Close() {
 	c.client.Close()
-	log.Println("TigerBeetle client closed")
+	logger.Info("TigerBeetle client closed")
 }
@@ -0,0 +1,34 @@
+package tigerbeetle
+
+import (
+	"testing"
+
+	tb_types "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
+)
+
+func TestAvailableBalance(t *testing.T) {
+	tests := []struct {
+		name                                                       string
+		creditsPosted, debitsPosted, debitsPending, creditsPending uint64
+		want                                                       int64
+	}{
+		{"posted only", 10000, 2500, 0, 0, 7500},
+		{"dispute hold is not available", 10000, 2500, 3000, 0, 4500},
+		{"pending credit is not available yet", 10000, 2500, 0, 5000, 7500},
+		{"fully held", 10000, 0, 10000, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := tb_types.Account{
+				CreditsPosted:  tb_types.ToUint128(tt.creditsPosted),
+				DebitsPosted:   tb_types.ToUint128(tt.debitsPosted),
+				DebitsPending:  tb_types.ToUint128(tt.debitsPending),
+				CreditsPending: tb_types.ToUint128(tt.creditsPending),
+			}
+			if got := AvailableBalance(account); got != tt.want {
+				t.Errorf("AvailableBalance() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
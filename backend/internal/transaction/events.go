@@ -0,0 +1,36 @@
+package transaction
+
+import (
+	"time"
+
+	"github.com/hlabs/banking-system/internal/eventbus"
+	"github.com/hlabs/banking-system/internal/models"
+)
+
+// publishDepositPosted publishes a posted deposit on the domain event bus
+func (s *Service) publishDepositPosted(user *models.User, tx *models.Transaction) {
+	s.bus.Publish(eventbus.DepositPosted{
+		TransactionID: tx.ID,
+		TransferID:    tx.TigerBeetleTransferID,
+		UserID:        user.ID,
+		AccountID:     user.TigerBeetleAccountID,
+		Amount:        tx.Amount,
+		Fee:           tx.Fee,
+		PostedAt:      time.Now().UTC(),
+	})
+}
+
+// publishTransferPosted publishes a posted transfer on the domain event bus
+func (s *Service) publishTransferPosted(fromUser *models.User, tx *models.Transaction) {
+	s.bus.Publish(eventbus.TransferPosted{
+		TransactionID: tx.ID,
+		TransferID:    tx.TigerBeetleTransferID,
+		FromUserID:    fromUser.ID,
+		ToUserID:      tx.RecipientUserID,
+		FromAccountID: tx.DebitAccountID,
+		ToAccountID:   tx.CreditAccountID,
+		Amount:        tx.Amount,
+		Fee:           tx.Fee,
+		PostedAt:      time.Now().UTC(),
+	})
+}
@@ -1,12 +1,16 @@
 package transaction
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/internal/fee"
 	"github.com/hlabs/banking-system/internal/middleware"
+	"github.com/hlabs/banking-system/internal/models"
 	"github.com/hlabs/banking-system/pkg/utils"
 )
 
@@ -28,14 +32,32 @@ type DepositRequest struct {
 }
 
 // WithdrawRequest represents a withdrawal request payload
+// StepUpToken (or the X-Step-Up-Token header) is required when the step-up policy applies
 type WithdrawRequest struct {
-	Amount int64 `json:"amount" binding:"required,gt=0"`
+	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	StepUpToken string `json:"step_up_token"`
 }
 
 // TransferRequest represents a transfer request payload
+// StepUpToken (or the X-Step-Up-Token header) is required when the step-up policy applies
 type TransferRequest struct {
 	ToAccountID uint64 `json:"to_account_id" binding:"required"`
 	Amount      int64  `json:"amount" binding:"required,gt=0"`
+	StepUpToken string `json:"step_up_token"`
+}
+
+// PreviewRequest represents a fee preview request payload
+type PreviewRequest struct {
+	Type        fee.Operation `json:"type" binding:"required,oneof=deposit withdraw transfer"`
+	Amount      int64         `json:"amount" binding:"required,gt=0"`
+	ToAccountID uint64        `json:"to_account_id"`
+}
+
+// ReverseRequest represents a refund/reversal request payload
+// Amount is optional: when omitted the whole refundable amount is reversed
+type ReverseRequest struct {
+	Amount int64  `json:"amount" binding:"omitempty,gt=0"`
+	Reason string `json:"reason" binding:"max=500"`
 }
 
 // Deposit handles deposit requests
@@ -62,15 +84,18 @@ func (h *Handler) Deposit(c *gin.Context) {
 	}
 
 	// Execute deposit
-	if err := h.service.Deposit(userID, req.Amount); err != nil {
-		log.Printf("Deposit failed for user %s: %v", userID, err)
+	tx, err := h.service.Deposit(c.Request.Context(), userID, req.Amount)
+	if err != nil {
+		logger.WarnContext(c.Request.Context(), "deposit failed", "user_id", userID, "error", err)
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to process deposit")
 		return
 	}
 
 	response := gin.H{
-		"amount":  req.Amount,
-		"message": "Deposit successful",
+		"amount":      req.Amount,
+		"fee":         tx.Fee,
+		"transaction": tx.ToDTO(),
+		"message":     "Deposit successful",
 	}
 
 	utils.RespondWithSuccess(c, http.StatusOK, response, "Deposit completed successfully")
@@ -94,8 +119,13 @@ func (h *Handler) Withdraw(c *gin.Context) {
 	}
 
 	// Execute withdrawal
-	if err := h.service.Withdraw(userID, req.Amount); err != nil {
-		log.Printf("Withdrawal failed for user %s: %v", userID, err)
+	tx, err := h.service.Withdraw(requestContext(c), userID, req.Amount, stepUpToken(c, req.StepUpToken))
+	if err != nil {
+		logger.WarnContext(c.Request.Context(), "withdrawal failed", "user_id", userID, "error", err)
+
+		if respondWithPolicyError(c, err) {
+			return
+		}
 
 		// Check if it's an insufficient funds error
 		if err.Error() == "insufficient funds" || len(err.Error()) > 18 && err.Error()[:18] == "insufficient funds" {
@@ -108,8 +138,10 @@ func (h *Handler) Withdraw(c *gin.Context) {
 	}
 
 	response := gin.H{
-		"amount":  req.Amount,
-		"message": "Withdrawal successful",
+		"amount":      req.Amount,
+		"fee":         tx.Fee,
+		"transaction": tx.ToDTO(),
+		"message":     "Withdrawal successful",
 	}
 
 	utils.RespondWithSuccess(c, http.StatusOK, response, "Withdrawal completed successfully")
@@ -133,8 +165,13 @@ func (h *Handler) Transfer(c *gin.Context) {
 	}
 
 	// Execute transfer
-	if err := h.service.Transfer(userID, req.ToAccountID, req.Amount); err != nil {
-		log.Printf("Transfer failed from user %s to account %d: %v", userID, req.ToAccountID, err)
+	tx, err := h.service.Transfer(requestContext(c), userID, req.ToAccountID, req.Amount, stepUpToken(c, req.StepUpToken))
+	if err != nil {
+		logger.WarnContext(c.Request.Context(), "transfer failed", "user_id", userID, "to_account_id", req.ToAccountID, "error", err)
+
+		if respondWithPolicyError(c, err) {
+			return
+		}
 
 		// Check for specific errors
 		errMsg := err.Error()
@@ -146,6 +183,10 @@ func (h *Handler) Transfer(c *gin.Context) {
 			utils.RespondWithError(c, http.StatusNotFound, "Destination account does not exist")
 			return
 		}
+		if errMsg == "cannot transfer to a system account" {
+			utils.RespondWithError(c, http.StatusBadRequest, "Cannot transfer to a system account")
+			return
+		}
 
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to process transfer")
 		return
@@ -154,12 +195,143 @@ func (h *Handler) Transfer(c *gin.Context) {
 	response := gin.H{
 		"to_account_id": req.ToAccountID,
 		"amount":        req.Amount,
+		"fee":           tx.Fee,
+		"transaction":   tx.ToDTO(),
 		"message":       "Transfer successful",
 	}
 
 	utils.RespondWithSuccess(c, http.StatusOK, response, "Transfer completed successfully")
 }
 
+// Preview handles fee preview requests for the confirmation step
+// POST /api/transactions/preview
+func (h *Handler) Preview(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+
+	// Parse request body
+	var req PreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	if req.Type == fee.OperationTransfer && req.ToAccountID == 0 {
+		utils.RespondWithError(c, http.StatusBadRequest, "to_account_id is required for transfers")
+		return
+	}
+	if req.Type == fee.OperationTransfer {
+		if err := h.service.CheckDestination(c.Request.Context(), req.ToAccountID); err != nil {
+			if err.Error() == "cannot transfer to a system account" {
+				utils.RespondWithError(c, http.StatusBadRequest, "Cannot transfer to a system account")
+				return
+			}
+			utils.RespondWithError(c, http.StatusNotFound, "Destination account does not exist")
+			return
+		}
+	}
+
+	// Price the operation without moving any money
+	quote, err := h.service.QuoteFee(c.Request.Context(), userID, req.Type, req.Amount)
+	if err != nil {
+		logger.WarnContext(c.Request.Context(), "fee preview failed", "user_id", userID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to calculate fee")
+		return
+	}
+
+	response := gin.H{
+		"type":            quote.Operation,
+		"amount":          quote.Amount,
+		"fee":             quote.Fee,
+		"total":           quote.Total,
+		"fee_formatted":   fmt.Sprintf("$%.2f", float64(quote.Fee)/100.0),
+		"total_formatted": fmt.Sprintf("$%.2f", float64(quote.Total)/100.0),
+	}
+	if req.Type == fee.OperationTransfer {
+		response["to_account_id"] = req.ToAccountID
+	}
+
+	// Tell the client up front whether the operation will need step-up authentication
+	challenge, err := h.service.StepUpChallengeFor(requestContext(c), userID, req.Type, req.Amount, req.ToAccountID)
+	if err != nil {
+		logger.WarnContext(c.Request.Context(), "step-up evaluation failed", "user_id", userID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to calculate fee")
+		return
+	}
+	response["step_up_required"] = challenge != nil
+	if challenge != nil {
+		response["step_up"] = challenge
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, response, "Fee preview calculated successfully")
+}
+
+// Reverse handles full or partial reversal of a transaction
+// POST /api/transactions/:id/reverse
+func (h *Handler) Reverse(c *gin.Context) {
+	// Get user ID from context
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User not authenticated")
+		return
+	}
+	role, _ := middleware.GetUserRole(c)
+	if middleware.IsMachineClient(c) {
+		// Admin reversals need an interactive session, even with a key owned by an admin
+		role = models.RoleCustomer
+	}
+
+	// Parse request body (optional - an empty body reverses everything)
+	var req ReverseRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+			return
+		}
+	}
+
+	reversal, original, err := h.service.Reverse(c.Request.Context(), ReversalRequest{
+		TransactionID: c.Param("id"),
+		InitiatorID:   userID,
+		InitiatorRole: role,
+		Amount:        req.Amount,
+		Reason:        req.Reason,
+	})
+	if err != nil {
+		logger.WarnContext(c.Request.Context(), "reversal failed", "transaction_id", c.Param("id"), "user_id", userID, "error", err)
+
+		errMsg := err.Error()
+		switch {
+		case errMsg == "transaction not found":
+			utils.RespondWithError(c, http.StatusNotFound, "Transaction not found")
+		case errMsg == "not authorized to reverse this transaction":
+			utils.RespondWithError(c, http.StatusForbidden, "Only the recipient or an admin can reverse this transaction")
+		case strings.HasPrefix(errMsg, "reversal amount exceeds"),
+			strings.HasPrefix(errMsg, "transaction cannot be reversed"):
+			utils.RespondWithError(c, http.StatusConflict, errMsg)
+		case strings.HasPrefix(errMsg, "insufficient funds"),
+			strings.HasPrefix(errMsg, "reason is required"),
+			strings.HasPrefix(errMsg, "reversal amount must be"):
+			utils.RespondWithError(c, http.StatusBadRequest, errMsg)
+		default:
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to process reversal")
+		}
+		return
+	}
+
+	response := gin.H{
+		"reversal":             reversal.ToDTO(),
+		"original":             original.ToDTO(),
+		"refundable_remaining": original.RefundableAmount(),
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, response, "Reversal completed successfully")
+}
+
 // GetHistory handles transaction history requests
 // GET /api/transactions/history?page=1&limit=10
 func (h *Handler) GetHistory(c *gin.Context) {
@@ -187,17 +359,17 @@ func (h *Handler) GetHistory(c *gin.Context) {
 	}
 
 	// Get transaction history
-	history, err := h.service.GetHistory(userID, page, limit)
+	history, err := h.service.GetHistory(c.Request.Context(), userID, page, limit)
 	if err != nil {
-		log.Printf("Failed to get history for user %s: %v", userID, err)
+		logger.ErrorContext(c.Request.Context(), "failed to get history", "user_id", userID, "error", err)
 		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve transaction history")
 		return
 	}
 
 	// Get total count for pagination metadata
-	totalCount, err := h.service.GetHistoryCount(userID)
+	totalCount, err := h.service.GetHistoryCount(c.Request.Context(), userID)
 	if err != nil {
-		log.Printf("Failed to get history count for user %s: %v", userID, err)
+		logger.ErrorContext(c.Request.Context(), "failed to get history count", "user_id", userID, "error", err)
 		// Continue with empty count rather than failing the request
 		totalCount = 0
 	}
@@ -222,3 +394,50 @@ func (h *Handler) GetHistory(c *gin.Context) {
 
 	utils.RespondWithSuccess(c, http.StatusOK, response, "Transaction history retrieved successfully")
 }
+
+// requestContext returns the request's context, marked with the key's transfer limit for API clients
+func requestContext(c *gin.Context) context.Context {
+	if middleware.IsMachineClient(c) {
+		return WithAPIClient(c.Request.Context(), middleware.GetTransferLimit(c))
+	}
+	return c.Request.Context()
+}
+
+// stepUpToken returns the step-up token from the request body or the X-Step-Up-Token header
+func stepUpToken(c *gin.Context, bodyToken string) string {
+	if bodyToken != "" {
+		return bodyToken
+	}
+	return c.GetHeader("X-Step-Up-Token")
+}
+
+// respondWithPolicyError answers step-up and verification failures with 403
+// (including the challenge to satisfy for step-up); returns false for any other error
+func respondWithPolicyError(c *gin.Context, err error) bool {
+	var required *StepUpRequiredError
+	if errors.As(err, &required) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error":   http.StatusText(http.StatusForbidden),
+			"message": "Step-up authentication required: confirm with your password or authenticator code",
+			"step_up": required.Challenge,
+		})
+		return true
+	}
+
+	if strings.HasPrefix(err.Error(), "api key transfer limit exceeded") {
+		utils.RespondWithError(c, http.StatusForbidden, err.Error())
+		return true
+	}
+
+	if err.Error() == "invalid step-up token" {
+		utils.RespondWithError(c, http.StatusForbidden, "Step-up token is invalid, expired or was issued for a different operation")
+		return true
+	}
+
+	if strings.HasPrefix(err.Error(), "email verification required") {
+		utils.RespondWithError(c, http.StatusForbidden, err.Error())
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,46 @@
+package transaction
+
+import (
+	"fmt"
+	"sync"
+)
+
+// inFlight counts the money movements running, so shutdown can wait for them to write their
+// records before TigerBeetle and PostgreSQL are closed
+type inFlight struct {
+	mu       sync.Mutex
+	draining bool
+	wg       sync.WaitGroup
+}
+
+// start registers a money movement; it fails once the service is draining
+// Every successful start must be followed by done
+func (f *inFlight) start() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.draining {
+		return fmt.Errorf("service is shutting down")
+	}
+	f.wg.Add(1)
+	return nil
+}
+
+// done marks a money movement as finished
+func (f *inFlight) done() {
+	f.wg.Done()
+}
+
+// drain refuses new money movements and waits for the running ones
+func (f *inFlight) drain() {
+	f.mu.Lock()
+	f.draining = true
+	f.mu.Unlock()
+	f.wg.Wait()
+}
+
+// Drain stops new deposits, withdrawals, transfers and reversals and waits for the running ones
+// Shutdown calls it before closing TigerBeetle and PostgreSQL: a handler whose connection was
+// closed may still be between posting a transfer and writing its record
+func (s *Service) Drain() {
+	s.inFlight.drain()
+}
@@ -0,0 +1,46 @@
+package transaction
+
+import (
+	"testing"
+	"time"
+)
+
+func TestInFlightDrain(t *testing.T) {
+	var f inFlight
+	if err := f.start(); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	drained := make(chan struct{})
+	go func() {
+		f.drain()
+		close(drained)
+	}()
+
+	// Drain waits for the running movement and refuses new ones meanwhile
+	deadline := time.Now().Add(time.Second)
+	for f.start() == nil {
+		f.done()
+		if time.Now().After(deadline) {
+			t.Fatal("start still accepted after drain began")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	select {
+	case <-drained:
+		t.Fatal("drain returned while a movement was running")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	f.done()
+	select {
+	case <-drained:
+	case <-time.After(time.Second):
+		t.Fatal("drain did not return after the movement finished")
+	}
+
+	if err := f.start(); err == nil {
+		t.Error("start accepted after drain returned")
+	}
+	f.drain() // Draining again returns at once
+}
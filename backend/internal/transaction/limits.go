@@ -0,0 +1,32 @@
+package transaction
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hlabs/banking-system/internal/models"
+)
+
+// Limits caps money movements for accounts that are not fully trusted yet
+type Limits struct {
+	UnverifiedDailyLimit int64 // Max withdrawals + transfers (cents) per 24h before the email is verified; 0 disables
+}
+
+// checkUnverifiedLimit rejects withdrawals and transfers that would take an unverified user
+// over the rolling 24h limit
+func (s *Service) checkUnverifiedLimit(user *models.User, amount int64) error {
+	if user.IsEmailVerified() || s.limits.UnverifiedDailyLimit <= 0 {
+		return nil
+	}
+
+	sent, err := s.repo.SumOutgoingSince(user.ID, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		return err
+	}
+
+	if sent+amount > s.limits.UnverifiedDailyLimit {
+		return fmt.Errorf("email verification required: unverified accounts can send at most $%.2f per day ($%.2f remaining)",
+			float64(s.limits.UnverifiedDailyLimit)/100.0, float64(max(s.limits.UnverifiedDailyLimit-sent, 0))/100.0)
+	}
+	return nil
+}
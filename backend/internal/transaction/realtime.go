@@ -0,0 +1,48 @@
+package transaction
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/realtime"
+	"github.com/hlabs/banking-system/internal/tigerbeetle"
+)
+
+// transactionEvent is the data of a realtime transaction event
+type transactionEvent struct {
+	Transaction models.TransactionDTO `json:"transaction"`
+	Direction   string                `json:"direction"` // "incoming" or "outgoing" for the receiving user
+}
+
+// pushUpdate sends a posted transaction and the resulting balance of accountID to the user's open streams
+func (s *Service) pushUpdate(ctx context.Context, userID uuid.UUID, accountID uint64, tx *models.Transaction, direction string) {
+	if s.events == nil {
+		return
+	}
+
+	s.events.Publish(userID, realtime.EventTransaction, transactionEvent{
+		Transaction: tx.ToDTO(),
+		Direction:   direction,
+	})
+
+	balance, err := s.tbClient.GetBalance(ctx, accountID)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to load balance for realtime update", "account_id", accountID, "error", err)
+		return
+	}
+	s.events.Publish(userID, realtime.EventBalance, realtime.BalanceEvent{Balance: balance, Currency: "USD"})
+}
+
+// pushReversal sends a reversal to both parties
+// The initiating side is whoever's account is debited, unless that is a system account (reversed withdrawal)
+func (s *Service) pushReversal(ctx context.Context, reversal *models.Transaction) {
+	if !tigerbeetle.IsSystemAccount(reversal.DebitAccountID) {
+		s.pushUpdate(ctx, reversal.UserID, reversal.DebitAccountID, reversal, directionOutgoing)
+	} else {
+		s.pushUpdate(ctx, reversal.UserID, reversal.CreditAccountID, reversal, directionIncoming)
+	}
+	if reversal.RecipientUserID != nil {
+		s.pushUpdate(ctx, *reversal.RecipientUserID, reversal.CreditAccountID, reversal, directionIncoming)
+	}
+}
@@ -1,8 +1,9 @@
 package transaction
 
 import (
+	"context"
 	"fmt"
-	"log"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/hlabs/banking-system/internal/models"
@@ -19,6 +20,11 @@ func NewRepository(db *gorm.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// WithContext returns a repository whose queries run with ctx, so they join its trace and cancellation
+func (r *Repository) WithContext(ctx context.Context) *Repository {
+	return &Repository{db: r.db.WithContext(ctx)}
+}
+
 // Create saves a new transaction record to the database
 func (r *Repository) Create(tx *models.Transaction) error {
 	if err := r.db.Create(tx).Error; err != nil {
@@ -84,9 +90,6 @@ func (r *Repository) GetAllByUserID(userID uuid.UUID, page, limit int) ([]models
 		offset = 0
 	}
 
-	log.Printf("🔍 [Repository] GetAllByUserID called with userID=%s, page=%d, limit=%d, offset=%d", userID, page, limit, offset)
-	log.Printf("🔍 [Repository] Query: WHERE user_id = '%s' OR recipient_user_id = '%s'", userID, userID)
-
 	// Query for transactions where user is either sender or recipient
 	err := r.db.
 		Preload("User").
@@ -98,11 +101,9 @@ func (r *Repository) GetAllByUserID(userID uuid.UUID, page, limit int) ([]models
 		Find(&transactions).Error
 
 	if err != nil {
-		log.Printf("❌ [Repository] Query failed: %v", err)
 		return nil, fmt.Errorf("failed to retrieve transactions: %w", err)
 	}
 
-	log.Printf("✅ [Repository] Query returned %d transactions", len(transactions))
 	return transactions, nil
 }
 
@@ -160,6 +161,65 @@ func (r *Repository) CountAllByUserID(userID uuid.UUID) (int64, error) {
 	return count, nil
 }
 
+// HasPaidAccount reports whether the user already completed a transfer to the given account
+// Used by the step-up policy to recognise new recipients
+func (r *Repository) HasPaidAccount(userID uuid.UUID, accountID uint64) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.Transaction{}).
+		Where("user_id = ? AND type = ? AND credit_account_id = ? AND status = ?",
+			userID, models.TransactionTypeTransfer, accountID, models.TransactionStatusCompleted).
+		Limit(1).
+		Count(&count).Error
+
+	if err != nil {
+		return false, fmt.Errorf("failed to check transfer history: %w", err)
+	}
+
+	return count > 0, nil
+}
+
+// SumOutgoingSince totals the withdrawals and transfers sent by a user since the given time
+func (r *Repository) SumOutgoingSince(userID uuid.UUID, since time.Time) (int64, error) {
+	var total int64
+	err := r.db.Model(&models.Transaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("user_id = ? AND type IN ? AND status = ? AND created_at >= ?",
+			userID, []models.TransactionType{models.TransactionTypeWithdraw, models.TransactionTypeTransfer},
+			models.TransactionStatusCompleted, since).
+		Scan(&total).Error
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to sum outgoing transactions: %w", err)
+	}
+
+	return total, nil
+}
+
+// GetReversalIDs maps each of the given transactions to the reversals posted against it
+func (r *Repository) GetReversalIDs(originalIDs []uuid.UUID) (map[uuid.UUID][]uuid.UUID, error) {
+	reversals := make(map[uuid.UUID][]uuid.UUID)
+	if len(originalIDs) == 0 {
+		return reversals, nil
+	}
+
+	var rows []models.Transaction
+	err := r.db.
+		Select("id", "reversal_of_id").
+		Where("reversal_of_id IN ? AND status <> ?", originalIDs, models.TransactionStatusFailed).
+		Order("created_at ASC").
+		Find(&rows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve reversals: %w", err)
+	}
+
+	for _, row := range rows {
+		reversals[*row.ReversalOfID] = append(reversals[*row.ReversalOfID], row.ID)
+	}
+
+	return reversals, nil
+}
+
 // UpdateStatus updates the status of a transaction
 // Used when TigerBeetle operations complete or fail
 func (r *Repository) UpdateStatus(id uuid.UUID, status models.TransactionStatus) error {
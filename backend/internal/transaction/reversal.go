@@ -0,0 +1,380 @@
+package transaction
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/metrics"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/tigerbeetle"
+	tb_types "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ReversalRequest describes a full or partial reversal of an earlier transaction
+type ReversalRequest struct {
+	TransactionID string
+	InitiatorID   string
+	InitiatorRole string
+	Amount        int64 // Zero reverses everything still refundable
+	Reason        string
+}
+
+// reversalMetadata is stored on the reversal record to document the relationship
+type reversalMetadata struct {
+	ReversalOf                    uuid.UUID `json:"reversal_of"`
+	OriginalTigerBeetleTransferID string    `json:"original_tigerbeetle_transfer_id"`
+	OriginalType                  string    `json:"original_type"`
+	Partial                       bool      `json:"partial"`
+	Reason                        string    `json:"reason,omitempty"`
+	InitiatedBy                   string    `json:"initiated_by"`
+	InitiatedByRole               string    `json:"initiated_by_role"`
+}
+
+// Reverse posts the opposite TigerBeetle transfer for all or part of a transaction
+//
+// The recipient of a transfer can refund it; admins can reverse any transaction but must give
+// a reason. The reversal runs in three steps so no row lock is held while TigerBeetle is called:
+//  1. reserve: with the original row locked, check the refundable amount, add the reversal to
+//     ReversedAmount and insert the reversal record as pending, then commit
+//  2. post the opposite transfer in TigerBeetle
+//  3. mark the reversal completed, or release the reservation if TigerBeetle rejected it
+//
+// A reversal posted in TigerBeetle whose completion can't be recorded stays pending with its
+// amount still reserved, so a retry can never refund it twice; the pending record is the
+// reconciliation entry. The same applies when TigerBeetle can't be reached and the transfer
+// isn't found afterwards: it may still have been posted. Fees are not refunded.
+// Returns the reversal record and the updated original.
+func (s *Service) Reverse(ctx context.Context, req ReversalRequest) (*models.Transaction, *models.Transaction, error) {
+	if err := s.inFlight.start(); err != nil {
+		return nil, nil, err
+	}
+	defer s.inFlight.done()
+
+	txID, err := uuid.Parse(req.TransactionID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("transaction not found")
+	}
+	initiator, err := s.getUserByID(ctx, req.InitiatorID)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+
+	reversal, original, err := s.reserveReversal(ctx, txID, initiator, req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.postReversal(ctx, reversal, original); err != nil {
+		if releasable(err) {
+			s.releaseReversal(ctx, reversal, original)
+		} else {
+			logger.ErrorContext(ctx, "reversal outcome unknown, left pending for reconciliation",
+				"transaction_id", txID, "reversal_id", reversal.ID, "amount", reversal.Amount,
+				"transfer_id", reversal.TigerBeetleTransferID, "error", err)
+		}
+		return nil, nil, err
+	}
+
+	// Posted in TigerBeetle: completing the record must not be cancelled with the request
+	if err := s.repo.WithContext(context.WithoutCancel(ctx)).UpdateStatus(reversal.ID, models.TransactionStatusCompleted); err != nil {
+		// Money already moved in TigerBeetle (source of truth) and the amount stays reserved -
+		// the pending reversal record flags it for reconciliation
+		metrics.AuditWriteFailed(string(reversal.Type))
+		logger.ErrorContext(ctx, "reversal posted in TigerBeetle but failed to complete in PostgreSQL",
+			"transaction_id", txID, "reversal_id", reversal.ID, "initiator_id", req.InitiatorID, "amount", reversal.Amount,
+			"transfer_id", reversal.TigerBeetleTransferID, "error", err)
+	} else {
+		reversal.Status = models.TransactionStatusCompleted
+	}
+
+	observePosted(reversal)
+	logger.InfoContext(ctx, "reversal posted", "transaction_id", original.ID, "amount", reversal.Amount,
+		"initiator_id", req.InitiatorID, "initiator_role", req.InitiatorRole,
+		"reversed_amount", original.ReversedAmount, "original_amount", original.Amount)
+	s.pushReversal(ctx, reversal)
+	s.notifyReversal(reversal)
+	return reversal, original, nil
+}
+
+// reserveReversal validates the reversal against the locked original and records it as pending,
+// adding its amount to the original's ReversedAmount before any money moves
+func (s *Service) reserveReversal(ctx context.Context, txID uuid.UUID, initiator *models.User, req ReversalRequest) (*models.Transaction, *models.Transaction, error) {
+	var original models.Transaction
+	var reversal *models.Transaction
+
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Lock the original so concurrent reversals see each other's ReversedAmount
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			First(&original, "id = ?", txID).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return fmt.Errorf("transaction not found")
+			}
+			return fmt.Errorf("database error: %w", err)
+		}
+
+		if err := authorizeReversal(&original, initiator, req); err != nil {
+			return err
+		}
+
+		if original.Status != models.TransactionStatusCompleted {
+			return fmt.Errorf("transaction cannot be reversed: status is %s", original.Status)
+		}
+
+		// Amounts held by disputes (including cases being opened or resolved) are settled by the dispute workflow
+		var held int64
+		if err := tx.Model(&models.Dispute{}).
+			Where("transaction_id = ? AND status IN ?", original.ID, models.DisputeHoldingStatuses).
+			Select("COALESCE(SUM(amount), 0)").
+			Scan(&held).Error; err != nil {
+			return fmt.Errorf("failed to sum active disputes: %w", err)
+		}
+
+		amount, err := reversalAmount(&original, held, req.Amount)
+		if err != nil {
+			return err
+		}
+
+		metadata, err := json.Marshal(reversalMetadata{
+			ReversalOf:                    original.ID,
+			OriginalTigerBeetleTransferID: original.TigerBeetleTransferID,
+			OriginalType:                  string(original.Type),
+			Partial:                       amount < original.Amount,
+			Reason:                        req.Reason,
+			InitiatedBy:                   initiator.ID.String(),
+			InitiatedByRole:               req.InitiatorRole,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to encode reversal metadata: %w", err)
+		}
+
+		reversal = &models.Transaction{
+			UserID:          original.UserID,
+			Type:            models.TransactionTypeReversal,
+			Amount:          amount,
+			DebitAccountID:  original.CreditAccountID,
+			CreditAccountID: original.DebitAccountID,
+			Status:          models.TransactionStatusPending,
+			Description:     reversalDescription(&original, amount, req.Reason),
+			Metadata:        datatypes.JSON(metadata),
+			ReversalOfID:    &original.ID,
+		}
+		reversal.SetTigerBeetleTransferID(tb_types.ID())
+
+		// For transfers the original recipient pays the refund back to the sender,
+		// so both parties see the reversal in their history
+		if original.Type == models.TransactionTypeTransfer && original.RecipientUserID != nil {
+			sender := original.UserID
+			reversal.UserID = *original.RecipientUserID
+			reversal.RecipientUserID = &sender
+		}
+
+		if err := tx.Create(reversal).Error; err != nil {
+			return fmt.Errorf("failed to create reversal record: %w", err)
+		}
+
+		original.ReversedAmount += amount
+		if err := tx.Model(&original).Update("reversed_amount", original.ReversedAmount).Error; err != nil {
+			return fmt.Errorf("failed to update original transaction: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return reversal, &original, nil
+}
+
+// reversalAmount returns how much a reversal moves and checks it against what is still refundable:
+// the original amount, less what earlier reversals already reserved (ReversedAmount) and what
+// active disputes hold. A requested amount of zero reverses everything still refundable
+func reversalAmount(original *models.Transaction, held, requested int64) (int64, error) {
+	remaining := original.RefundableAmount() - held
+	if remaining <= 0 {
+		if held > 0 {
+			return 0, fmt.Errorf("transaction cannot be reversed: remaining amount is under dispute")
+		}
+		return 0, fmt.Errorf("transaction cannot be reversed: already fully reversed")
+	}
+
+	amount := requested
+	if amount == 0 {
+		amount = remaining
+	}
+	if amount < 0 {
+		return 0, fmt.Errorf("reversal amount must be positive")
+	}
+	if amount > remaining {
+		return 0, fmt.Errorf("reversal amount exceeds refundable amount: requested %d, refundable %d", amount, remaining)
+	}
+	return amount, nil
+}
+
+// reversalRejectedError is returned by postReversal when the reversal was certainly not posted,
+// so its reservation can be released
+type reversalRejectedError struct {
+	err error
+}
+
+// Error implements the error interface
+func (e *reversalRejectedError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *reversalRejectedError) Unwrap() error {
+	return e.err
+}
+
+// rejected marks err as a reversal TigerBeetle did not post
+func rejected(err error) error {
+	return &reversalRejectedError{err: err}
+}
+
+// releasable reports whether a postReversal error proves nothing was posted
+func releasable(err error) bool {
+	var rejected *reversalRejectedError
+	return errors.As(err, &rejected)
+}
+
+// postReversal moves the reserved amount back in TigerBeetle, referencing the original
+// transfer through user_data_128
+// Returns a reversalRejectedError when nothing was posted: the balance pre-check failed, the
+// transfer was never sent or TigerBeetle rejected it. Any other error means the outcome is unknown
+func (s *Service) postReversal(ctx context.Context, reversal, original *models.Transaction) error {
+	// The account that was credited pays the reversal back
+	if !tigerbeetle.IsSystemAccount(original.CreditAccountID) {
+		balance, err := s.tbClient.GetBalance(ctx, original.CreditAccountID)
+		if err != nil {
+			return rejected(fmt.Errorf("failed to check balance: %w", err))
+		}
+		if balance < reversal.Amount {
+			return rejected(fmt.Errorf("insufficient funds: balance is %d, requested %d", balance, reversal.Amount))
+		}
+	}
+
+	originalTransferID, err := original.GetTigerBeetleTransferID()
+	if err != nil {
+		return rejected(fmt.Errorf("invalid original transfer ID: %w", err))
+	}
+	transferID, err := reversal.GetTigerBeetleTransferID()
+	if err != nil {
+		return rejected(fmt.Errorf("invalid reversal transfer ID: %w", err))
+	}
+
+	results, err := s.tbClient.CreateTransfers(ctx, []tb_types.Transfer{
+		{
+			ID:              transferID,
+			DebitAccountID:  tb_types.ToUint128(original.CreditAccountID),
+			CreditAccountID: tb_types.ToUint128(original.DebitAccountID),
+			Amount:          tb_types.ToUint128(uint64(reversal.Amount)),
+			UserData128:     originalTransferID,
+			Ledger:          tigerbeetle.LedgerUSD,
+			Code:            tigerbeetle.TransferCodeReversal,
+		},
+	})
+	if err != nil {
+		// The request may have reached TigerBeetle - only a lookup can tell
+		return s.checkReversalPosted(ctx, transferID, fmt.Errorf("failed to create transfer: %w", err))
+	}
+	if len(results) > 0 {
+		if results[0].Result == tb_types.TransferExceedsCredits {
+			return rejected(fmt.Errorf("insufficient funds: balance does not cover reversal of %d", reversal.Amount))
+		}
+		return rejected(fmt.Errorf("transfer failed with result code: %d", results[0].Result))
+	}
+
+	return nil
+}
+
+// checkReversalPosted looks up a reversal transfer whose creation returned an error
+// Returns nil if TigerBeetle posted it after all, and createErr otherwise: a transfer that
+// isn't found may still be in flight, so it is never reported as rejected
+func (s *Service) checkReversalPosted(ctx context.Context, transferID tb_types.Uint128, createErr error) error {
+	transfers, err := s.tbClient.LookupTransfers(ctx, []tb_types.Uint128{transferID})
+	if err != nil || len(transfers) == 0 {
+		return createErr
+	}
+	return nil
+}
+
+// releaseReversal undoes a reservation whose TigerBeetle transfer was not posted
+// If the release itself fails the amount stays reserved: the original can be refunded less,
+// never more, and the pending reversal record is left for reconciliation
+func (s *Service) releaseReversal(ctx context.Context, reversal, original *models.Transaction) {
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Transaction{}).
+			Where("id = ?", original.ID).
+			Update("reversed_amount", gorm.Expr("reversed_amount - ?", reversal.Amount)).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.Transaction{}).
+			Where("id = ?", reversal.ID).
+			Update("status", models.TransactionStatusFailed).Error
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to release reversal reservation",
+			"transaction_id", original.ID, "reversal_id", reversal.ID, "amount", reversal.Amount, "error", err)
+		return
+	}
+	original.ReversedAmount -= reversal.Amount
+}
+
+// notifyReversal notifies both parties of a posted reversal
+// reversal.UserID is the party debited, unless that is a system account (reversed withdrawal)
+func (s *Service) notifyReversal(reversal *models.Transaction) {
+	original := uuid.Nil
+	if reversal.ReversalOfID != nil {
+		original = *reversal.ReversalOfID
+	}
+	credited := tigerbeetle.IsSystemAccount(reversal.DebitAccountID)
+	s.notifications.Reversal(reversal.UserID, reversal.Amount, credited, reversal.ID, original)
+	if reversal.RecipientUserID != nil {
+		s.notifications.Reversal(*reversal.RecipientUserID, reversal.Amount, true, reversal.ID, original)
+	}
+}
+
+// authorizeReversal checks that the initiator may reverse the transaction
+func authorizeReversal(original *models.Transaction, initiator *models.User, req ReversalRequest) error {
+	if original.Type == models.TransactionTypeReversal {
+		return fmt.Errorf("transaction cannot be reversed: reversals are final")
+	}
+
+	// The recipient of a transfer can always refund it
+	if original.Type == models.TransactionTypeTransfer &&
+		original.RecipientUserID != nil && *original.RecipientUserID == initiator.ID {
+		return nil
+	}
+
+	if req.InitiatorRole == models.RoleAdmin && initiator.IsAdmin() {
+		if req.Reason == "" {
+			return fmt.Errorf("reason is required for admin reversals")
+		}
+		return nil
+	}
+
+	return fmt.Errorf("not authorized to reverse this transaction")
+}
+
+// reversalDescription builds the history description of a reversal
+func reversalDescription(original *models.Transaction, amount int64, reason string) string {
+	kind := "Refund"
+	if amount < original.Amount {
+		kind = "Partial refund"
+	}
+
+	description := fmt.Sprintf("%s of %d cents for %s %s", kind, amount, original.Type, original.ID)
+	if reason != "" {
+		description += ": " + reason
+	}
+	return description
+}
@@ -0,0 +1,114 @@
+package transaction
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/hlabs/banking-system/internal/models"
+)
+
+func TestReversalAmount(t *testing.T) {
+	tests := []struct {
+		name      string
+		amount    int64
+		reversed  int64
+		held      int64
+		requested int64
+		want      int64
+		err       string
+	}{
+		{"zero reverses everything", 10000, 0, 0, 0, 10000, ""},
+		{"partial", 10000, 0, 0, 2500, 2500, ""},
+		{"exactly the remainder", 10000, 7500, 0, 2500, 2500, ""},
+		{"zero reverses what is left", 10000, 7500, 0, 0, 2500, ""},
+		{"more than the remainder", 10000, 7500, 0, 2501, 0, "reversal amount exceeds refundable amount"},
+		{"negative", 10000, 0, 0, -1, 0, "reversal amount must be positive"},
+		{"already fully reversed", 10000, 10000, 0, 0, 0, "transaction cannot be reversed: already fully reversed"},
+		{"dispute hold is not refundable", 10000, 0, 4000, 0, 6000, ""},
+		{"dispute hold and earlier reversal", 10000, 5000, 4000, 1001, 0, "reversal amount exceeds refundable amount"},
+		{"rest is under dispute", 10000, 6000, 4000, 0, 0, "transaction cannot be reversed: remaining amount is under dispute"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := &models.Transaction{Type: models.TransactionTypeTransfer, Amount: tt.amount, ReversedAmount: tt.reversed}
+			got, err := reversalAmount(original, tt.held, tt.requested)
+			if tt.err != "" {
+				if err == nil || !strings.HasPrefix(err.Error(), tt.err) {
+					t.Fatalf("reversalAmount() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("reversalAmount() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("reversalAmount() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReversalAmountRejectsReversals(t *testing.T) {
+	original := &models.Transaction{Type: models.TransactionTypeReversal, Amount: 10000}
+	if _, err := reversalAmount(original, 0, 0); err == nil {
+		t.Error("reversalAmount() of a reversal succeeded, want error")
+	}
+}
+
+func TestPartialReversalsAccumulate(t *testing.T) {
+	// Each reservation adds to ReversedAmount, so later reversals only see what is left
+	original := &models.Transaction{Type: models.TransactionTypeDeposit, Amount: 10000}
+
+	for _, requested := range []int64{3000, 3000, 3000} {
+		amount, err := reversalAmount(original, 0, requested)
+		if err != nil {
+			t.Fatalf("reversal of %d after %d reversed: %v", requested, original.ReversedAmount, err)
+		}
+		original.ReversedAmount += amount
+	}
+
+	if _, err := reversalAmount(original, 0, 1001); err == nil {
+		t.Fatal("reversal beyond the original amount succeeded")
+	}
+
+	amount, err := reversalAmount(original, 0, 0)
+	if err != nil || amount != 1000 {
+		t.Fatalf("final reversal = %d, %v; want 1000", amount, err)
+	}
+	original.ReversedAmount += amount
+
+	if _, err := reversalAmount(original, 0, 0); err == nil {
+		t.Fatal("reversal of a fully reversed transaction succeeded")
+	}
+
+	// Releasing a failed reservation makes its amount refundable again
+	original.ReversedAmount -= 3000
+	if amount, err := reversalAmount(original, 0, 0); err != nil || amount != 3000 {
+		t.Fatalf("reversal after release = %d, %v; want 3000", amount, err)
+	}
+}
+
+func TestReleasable(t *testing.T) {
+	insufficient := rejected(fmt.Errorf("insufficient funds: balance is 0, requested 100"))
+	if !releasable(insufficient) {
+		t.Error("rejected reversal is not releasable")
+	}
+	if !releasable(fmt.Errorf("reversal: %w", insufficient)) {
+		t.Error("wrapped rejected reversal is not releasable")
+	}
+	// Handlers map errors by message, so the rejection must not change it
+	if !strings.HasPrefix(insufficient.Error(), "insufficient funds") {
+		t.Errorf("rejected error message = %q", insufficient.Error())
+	}
+
+	// A transport error leaves the outcome unknown: the reservation must be kept
+	if releasable(fmt.Errorf("failed to create transfer: %w", errors.New("client closed"))) {
+		t.Error("transport error is releasable")
+	}
+	if releasable(nil) {
+		t.Error("nil error is releasable")
+	}
+}
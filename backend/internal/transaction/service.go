@@ -1,34 +1,66 @@
 package transaction
 
 import (
+	"context"
 	"fmt"
-	"log"
 
 	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/auth"
+	"github.com/hlabs/banking-system/internal/eventbus"
+	"github.com/hlabs/banking-system/internal/fee"
+	"github.com/hlabs/banking-system/internal/logging"
+	"github.com/hlabs/banking-system/internal/metrics"
 	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/notification"
+	"github.com/hlabs/banking-system/internal/realtime"
 	"github.com/hlabs/banking-system/internal/tigerbeetle"
+	"github.com/hlabs/banking-system/internal/webhook"
 	tb_types "github.com/tigerbeetle/tigerbeetle-go/pkg/types"
 	"gorm.io/gorm"
 )
 
+var logger = logging.For("transaction")
+
 // Service handles transaction-related business logic
 type Service struct {
-	db       *gorm.DB
-	tbClient *tigerbeetle.Client
-	repo     *Repository
+	db            *gorm.DB
+	tbClient      *tigerbeetle.Client
+	repo          *Repository
+	fees          *fee.Schedule
+	stepUp        *auth.StepUpService
+	stepUpPolicy  StepUpPolicy
+	limits        Limits
+	webhooks      *webhook.Service
+	events        realtime.Broker
+	notifications *notification.Service
+	bus           *eventbus.Bus
+	inFlight      inFlight
 }
 
 // NewService creates a new transaction service
-func NewService(db *gorm.DB, tbClient *tigerbeetle.Client) *Service {
+// Withdrawals and transfers matching stepUpPolicy must present a step-up token issued by stepUp
+// Completed and failed money movements are published to the users' webhooks; posted transactions
+// and new balances are pushed to their open event streams (events may be nil); incoming transfers,
+// large withdrawals, low balances and reversals raise notifications. Posted deposits and transfers are
+// published on the domain event bus
+func NewService(db *gorm.DB, tbClient *tigerbeetle.Client, fees *fee.Schedule, stepUp *auth.StepUpService, stepUpPolicy StepUpPolicy, limits Limits, webhooks *webhook.Service, events realtime.Broker, notifications *notification.Service, bus *eventbus.Bus) *Service {
 	return &Service{
-		db:       db,
-		tbClient: tbClient,
-		repo:     NewRepository(db),
+		db:            db,
+		tbClient:      tbClient,
+		repo:          NewRepository(db),
+		fees:          fees,
+		stepUp:        stepUp,
+		stepUpPolicy:  stepUpPolicy,
+		limits:        limits,
+		webhooks:      webhooks,
+		events:        events,
+		notifications: notifications,
+		bus:           bus,
 	}
 }
 
 // getUserByID retrieves a user by their ID (helper method)
-func (s *Service) getUserByID(userID string) (*models.User, error) {
+func (s *Service) getUserByID(ctx context.Context, userID string) (*models.User, error) {
 	var user models.User
 
 	uid, err := uuid.Parse(userID)
@@ -36,7 +68,7 @@ func (s *Service) getUserByID(userID string) (*models.User, error) {
 		return nil, fmt.Errorf("invalid user ID format: %w", err)
 	}
 
-	if err := s.db.First(&user, "id = ?", uid).Error; err != nil {
+	if err := s.db.WithContext(ctx).First(&user, "id = ?", uid).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return nil, fmt.Errorf("user not found")
 		}
@@ -46,42 +78,120 @@ func (s *Service) getUserByID(userID string) (*models.User, error) {
 	return &user, nil
 }
 
+// QuoteFee prices an operation for a user according to the fee schedule
+// Used by the confirmation step (REST preview and chat) before any money moves
+func (s *Service) QuoteFee(ctx context.Context, userID string, op fee.Operation, amount int64) (fee.Quote, error) {
+	if amount <= 0 {
+		return fee.Quote{}, fmt.Errorf("amount must be positive")
+	}
+
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return fee.Quote{}, err
+	}
+
+	return s.fees.Calculate(op, user.AccountType, amount), nil
+}
+
+// CheckDestination verifies that a transfer destination is an existing customer account
+// Used by transfers and by the fee preview, so the confirmation step rejects the same
+// destinations the transfer itself would. System accounts (the whole chart of accounts,
+// not just the cash vault) only move money through their own flows.
+func (s *Service) CheckDestination(ctx context.Context, toAccountID uint64) error {
+	if tigerbeetle.IsSystemAccount(toAccountID) {
+		logger.DebugContext(ctx, "transfer to system account rejected", "to_account_id", toAccountID)
+		return fmt.Errorf("cannot transfer to a system account")
+	}
+
+	destAccounts, err := s.tbClient.LookupAccounts(ctx, []tb_types.Uint128{tb_types.ToUint128(toAccountID)})
+	if err != nil || len(destAccounts) == 0 {
+		logger.DebugContext(ctx, "transfer destination not found", "to_account_id", toAccountID)
+		return fmt.Errorf("destination account not found")
+	}
+	return nil
+}
+
+// feeTransfer builds the transfer that moves a fee from the customer to the fee income account
+// It is appended to the principal transfer, which must carry the Linked flag so both post atomically
+func (s *Service) feeTransfer(user *models.User, amount int64) tb_types.Transfer {
+	return tb_types.Transfer{
+		ID:              tb_types.ID(),
+		DebitAccountID:  tb_types.ToUint128(user.TigerBeetleAccountID), // Customer pays the fee
+		CreditAccountID: s.tbClient.FeeIncomeAccountID,                 // Bank revenue
+		Amount:          tb_types.ToUint128(uint64(amount)),
+		Ledger:          tigerbeetle.LedgerUSD,
+		Code:            tigerbeetle.TransferCodeFee,
+	}
+}
+
+// withFee appends the fee transfer (if any) to the principal, linking them into one atomic chain
+func (s *Service) withFee(principal tb_types.Transfer, user *models.User, quote fee.Quote) ([]tb_types.Transfer, *tb_types.Transfer) {
+	if quote.Fee <= 0 {
+		return []tb_types.Transfer{principal}, nil
+	}
+
+	principal.Flags = tb_types.TransferFlags{Linked: true}.ToUint16()
+	feeTx := s.feeTransfer(user, quote.Fee)
+
+	return []tb_types.Transfer{principal, feeTx}, &feeTx
+}
+
+// observePosted records a posted transaction, and the fee charged with it, in the ledger metrics
+func observePosted(tx *models.Transaction) {
+	metrics.ObserveTransfer(string(tx.Type), tx.Amount)
+	if tx.Fee > 0 {
+		metrics.ObserveTransfer("fee", tx.Fee)
+	}
+}
+
 // Deposit adds funds to a user's account (from system account)
-func (s *Service) Deposit(userID string, amount int64) error {
+// Returns the audit record, including any fee charged on the deposit
+func (s *Service) Deposit(ctx context.Context, userID string, amount int64) (*models.Transaction, error) {
+	if err := s.inFlight.start(); err != nil {
+		return nil, err
+	}
+	defer s.inFlight.done()
+
 	if amount <= 0 {
-		return fmt.Errorf("deposit amount must be positive")
+		return nil, fmt.Errorf("deposit amount must be positive")
 	}
 
 	// Get user to retrieve TigerBeetle account ID
-	user, err := s.getUserByID(userID)
+	user, err := s.getUserByID(ctx, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
+	// Price the deposit (the fee is taken out of the deposited funds)
+	quote := s.fees.Calculate(fee.OperationDeposit, user.AccountType, amount)
+
 	// Generate transfer ID
-	transferID := tb_types.ToUint128(uint64(uuid.New().ID()))
+	transferID := tb_types.ID()
 
 	// Create transfer from system account to user account
-	transfers := []tb_types.Transfer{
-		{
-			ID:              transferID,
-			DebitAccountID:  s.tbClient.SystemAccountID,        // System account (source)
-			CreditAccountID: tb_types.ToUint128(user.TigerBeetleAccountID), // User account (destination)
-			Amount:          tb_types.ToUint128(uint64(amount)),
-			Ledger:          1,
-			Code:            1, // Deposit code
-		},
+	principal := tb_types.Transfer{
+		ID:              transferID,
+		DebitAccountID:  s.tbClient.SystemAccountID,                    // System account (source)
+		CreditAccountID: tb_types.ToUint128(user.TigerBeetleAccountID), // User account (destination)
+		Amount:          tb_types.ToUint128(uint64(amount)),
+		Ledger:          tigerbeetle.LedgerUSD,
+		Code:            tigerbeetle.TransferCodeDeposit,
 	}
+	transfers, feeTx := s.withFee(principal, user, quote)
 
 	// Execute transfer in TigerBeetle
-	results, err := s.tbClient.CreateTransfers(transfers)
+	results, err := s.tbClient.CreateTransfers(ctx, transfers)
 	if err != nil {
-		return fmt.Errorf("failed to create transfer: %w", err)
+		err = fmt.Errorf("failed to create transfer: %w", err)
+		s.publishFailure(ctx, user, fee.OperationDeposit, amount, 0, err)
+		return nil, err
 	}
 
 	// Check for errors
 	if len(results) > 0 {
-		return fmt.Errorf("transfer failed with result code: %d", results[0].Result)
+		err := fmt.Errorf("transfer failed with result code: %d", results[0].Result)
+		s.publishFailure(ctx, user, fee.OperationDeposit, amount, 0, err)
+		return nil, err
 	}
 
 	// Create transaction record in PostgreSQL (audit log)
@@ -92,72 +202,109 @@ func (s *Service) Deposit(userID string, amount int64) error {
 		UserID:          user.ID,
 		Type:            models.TransactionTypeDeposit,
 		Amount:          amount,
+		Fee:             quote.Fee,
 		DebitAccountID:  systemAcctBI.Uint64(),
 		CreditAccountID: user.TigerBeetleAccountID,
 		Status:          models.TransactionStatusCompleted,
 		Description:     fmt.Sprintf("Deposit of %d cents", amount),
 	}
 	txRecord.SetTigerBeetleTransferID(transferID)
+	if feeTx != nil {
+		txRecord.FeeTigerBeetleTransferID = models.Uint128ToHex(feeTx.ID)
+	}
 
 	// Save to PostgreSQL - non-blocking (TigerBeetle is source of truth)
-	if err := s.repo.Create(txRecord); err != nil {
+	if err := s.saveCompleted(ctx, txRecord, completedWebhook{models.WebhookEventDepositCompleted, user.ID, ""}); err != nil {
 		// Log error but don't fail the request (money already transferred in TigerBeetle)
-		log.Printf("🚨 CRITICAL: Deposit transfer %s succeeded in TigerBeetle but failed to log in PostgreSQL: %v",
-			txRecord.TigerBeetleTransferID, err)
-		log.Printf("   UserID: %s, Amount: %d, TigerBeetle Account: %d", userID, amount, user.TigerBeetleAccountID)
+		metrics.AuditWriteFailed(string(txRecord.Type))
+		logger.ErrorContext(ctx, "deposit posted in TigerBeetle but failed to log in PostgreSQL",
+			"transfer_id", txRecord.TigerBeetleTransferID, "user_id", userID, "amount", amount, "fee", quote.Fee,
+			"account_id", user.TigerBeetleAccountID, "error", err)
 		// Continue execution - the deposit succeeded in TigerBeetle
 	}
 
-	log.Printf("✅ Deposit successful: %d cents to user %s (TB Account: %d)", amount, userID, user.TigerBeetleAccountID)
-	return nil
+	s.pushUpdate(ctx, user.ID, user.TigerBeetleAccountID, txRecord, directionIncoming)
+	s.publishDepositPosted(user, txRecord)
+
+	observePosted(txRecord)
+	logger.InfoContext(ctx, "deposit posted", "transaction_id", txRecord.ID, "user_id", userID, "amount", amount, "fee", quote.Fee)
+	return txRecord, nil
 }
 
 // Withdraw removes funds from a user's account (to system account)
-func (s *Service) Withdraw(userID string, amount int64) error {
+// The withdrawal fee is posted atomically with the principal as a linked transfer
+// Withdrawals above the step-up threshold require a step-up token for this exact operation
+func (s *Service) Withdraw(ctx context.Context, userID string, amount int64, stepUpToken string) (*models.Transaction, error) {
+	if err := s.inFlight.start(); err != nil {
+		return nil, err
+	}
+	defer s.inFlight.done()
+
 	if amount <= 0 {
-		return fmt.Errorf("withdrawal amount must be positive")
+		return nil, fmt.Errorf("withdrawal amount must be positive")
 	}
 
 	// Get user
-	user, err := s.getUserByID(userID)
+	user, err := s.getUserByID(ctx, userID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Check balance first
-	balance, err := s.tbClient.GetBalance(user.TigerBeetleAccountID)
+	// Price the withdrawal
+	quote := s.fees.Calculate(fee.OperationWithdraw, user.AccountType, amount)
+
+	// Check balance first (principal + fee)
+	balance, err := s.tbClient.GetBalance(ctx, user.TigerBeetleAccountID)
 	if err != nil {
-		return fmt.Errorf("failed to check balance: %w", err)
+		return nil, fmt.Errorf("failed to check balance: %w", err)
 	}
 
-	if balance < amount {
-		return fmt.Errorf("insufficient funds: balance is %d, requested %d", balance, amount)
+	if balance < quote.Total {
+		err := fmt.Errorf("insufficient funds: balance is %d, requested %d (including fee of %d)", balance, quote.Total, quote.Fee)
+		s.publishFailure(ctx, user, fee.OperationWithdraw, amount, 0, err)
+		return nil, err
+	}
+
+	// Unverified users can only move a limited amount out per day
+	if err := s.checkUnverifiedLimit(user, amount); err != nil {
+		return nil, err
+	}
+
+	// Re-authentication for high-value withdrawals
+	stepUp, err := s.requireStepUp(ctx, user, fee.OperationWithdraw, amount, 0, stepUpToken)
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate transfer ID
-	transferID := tb_types.ToUint128(uint64(uuid.New().ID()))
+	transferID := tb_types.ID()
 
 	// Create transfer from user account to system account
-	transfers := []tb_types.Transfer{
-		{
-			ID:              transferID,
-			DebitAccountID:  tb_types.ToUint128(user.TigerBeetleAccountID), // User account (source)
-			CreditAccountID: s.tbClient.SystemAccountID,        // System account (destination)
-			Amount:          tb_types.ToUint128(uint64(amount)),
-			Ledger:          1,
-			Code:            2, // Withdrawal code
-		},
+	principal := tb_types.Transfer{
+		ID:              transferID,
+		DebitAccountID:  tb_types.ToUint128(user.TigerBeetleAccountID), // User account (source)
+		CreditAccountID: s.tbClient.SystemAccountID,                    // System account (destination)
+		Amount:          tb_types.ToUint128(uint64(amount)),
+		Ledger:          tigerbeetle.LedgerUSD,
+		Code:            tigerbeetle.TransferCodeWithdrawal,
 	}
+	transfers, feeTx := s.withFee(principal, user, quote)
 
 	// Execute transfer in TigerBeetle
-	results, err := s.tbClient.CreateTransfers(transfers)
+	results, err := s.tbClient.CreateTransfers(ctx, transfers)
 	if err != nil {
-		return fmt.Errorf("failed to create transfer: %w", err)
+		stepUp.Release()
+		err = fmt.Errorf("failed to create transfer: %w", err)
+		s.publishFailure(ctx, user, fee.OperationWithdraw, amount, 0, err)
+		return nil, err
 	}
 
 	// Check for errors
 	if len(results) > 0 {
-		return fmt.Errorf("transfer failed with result code: %d", results[0].Result)
+		stepUp.Release()
+		err := fmt.Errorf("transfer failed with result code: %d", results[0].Result)
+		s.publishFailure(ctx, user, fee.OperationWithdraw, amount, 0, err)
+		return nil, err
 	}
 
 	// Create transaction record in PostgreSQL (audit log)
@@ -168,138 +315,172 @@ func (s *Service) Withdraw(userID string, amount int64) error {
 		UserID:          user.ID,
 		Type:            models.TransactionTypeWithdraw,
 		Amount:          amount,
+		Fee:             quote.Fee,
 		DebitAccountID:  user.TigerBeetleAccountID,
 		CreditAccountID: systemAcctBI.Uint64(),
 		Status:          models.TransactionStatusCompleted,
 		Description:     fmt.Sprintf("Withdrawal of %d cents", amount),
 	}
 	txRecord.SetTigerBeetleTransferID(transferID)
+	if feeTx != nil {
+		txRecord.FeeTigerBeetleTransferID = models.Uint128ToHex(feeTx.ID)
+	}
 
 	// Save to PostgreSQL - non-blocking
-	if err := s.repo.Create(txRecord); err != nil {
-		log.Printf("🚨 CRITICAL: Withdrawal transfer %s succeeded in TigerBeetle but failed to log in PostgreSQL: %v",
-			txRecord.TigerBeetleTransferID, err)
-		log.Printf("   UserID: %s, Amount: %d, TigerBeetle Account: %d", userID, amount, user.TigerBeetleAccountID)
+	if err := s.saveCompleted(ctx, txRecord, completedWebhook{models.WebhookEventWithdrawalCompleted, user.ID, ""}); err != nil {
+		metrics.AuditWriteFailed(string(txRecord.Type))
+		logger.ErrorContext(ctx, "withdrawal posted in TigerBeetle but failed to log in PostgreSQL",
+			"transfer_id", txRecord.TigerBeetleTransferID, "user_id", userID, "amount", amount, "fee", quote.Fee,
+			"account_id", user.TigerBeetleAccountID, "error", err)
 	}
 
-	log.Printf("✅ Withdrawal successful: %d cents from user %s (TB Account: %d)", amount, userID, user.TigerBeetleAccountID)
-	return nil
+	s.pushUpdate(ctx, user.ID, user.TigerBeetleAccountID, txRecord, directionOutgoing)
+	s.notifications.Withdrawal(user.ID, amount, txRecord.ID)
+	s.notifications.BalanceChanged(user.ID, balance, balance-quote.Total)
+
+	observePosted(txRecord)
+	logger.InfoContext(ctx, "withdrawal posted", "transaction_id", txRecord.ID, "user_id", userID, "amount", amount, "fee", quote.Fee)
+	return txRecord, nil
 }
 
 // Transfer sends funds from one user to another
-func (s *Service) Transfer(fromUserID string, toAccountID uint64, amount int64) error {
+// The sender pays the transfer fee, posted atomically with the principal as a linked transfer
+// High-value transfers and transfers to new recipients require a step-up token for this exact operation
+func (s *Service) Transfer(ctx context.Context, fromUserID string, toAccountID uint64, amount int64, stepUpToken string) (*models.Transaction, error) {
+	if err := s.inFlight.start(); err != nil {
+		return nil, err
+	}
+	defer s.inFlight.done()
+
 	if amount <= 0 {
-		return fmt.Errorf("transfer amount must be positive")
+		return nil, fmt.Errorf("transfer amount must be positive")
 	}
 
 	// Get sender
-	fromUser, err := s.getUserByID(fromUserID)
+	fromUser, err := s.getUserByID(ctx, fromUserID)
 	if err != nil {
-		return fmt.Errorf("sender not found: %w", err)
+		return nil, fmt.Errorf("sender not found: %w", err)
 	}
 
-	// Check sender's balance
-	balance, err := s.tbClient.GetBalance(fromUser.TigerBeetleAccountID)
+	// Price the transfer
+	quote := s.fees.Calculate(fee.OperationTransfer, fromUser.AccountType, amount)
+
+	// Check sender's balance (principal + fee)
+	balance, err := s.tbClient.GetBalance(ctx, fromUser.TigerBeetleAccountID)
 	if err != nil {
-		return fmt.Errorf("failed to check balance: %w", err)
+		return nil, fmt.Errorf("failed to check balance: %w", err)
 	}
 
-	if balance < amount {
-		return fmt.Errorf("insufficient funds: balance is %d, requested %d", balance, amount)
+	if balance < quote.Total {
+		err := fmt.Errorf("insufficient funds: balance is %d, requested %d (including fee of %d)", balance, quote.Total, quote.Fee)
+		s.publishFailure(ctx, fromUser, fee.OperationTransfer, amount, toAccountID, err)
+		return nil, err
 	}
 
 	// Verify destination account exists (lookup in TigerBeetle)
-	log.Printf("🔍 [Transfer] Verifying destination account exists in TigerBeetle (AccountID: %d)...", toAccountID)
-	destAccounts, err := s.tbClient.LookupAccounts([]tb_types.Uint128{tb_types.ToUint128(toAccountID)})
-	if err != nil || len(destAccounts) == 0 {
-		log.Printf("❌ [Transfer] Destination account %d not found in TigerBeetle", toAccountID)
-		return fmt.Errorf("destination account not found")
+	if err := s.CheckDestination(ctx, toAccountID); err != nil {
+		return nil, err
 	}
-	log.Printf("✅ [Transfer] Destination account %d exists in TigerBeetle", toAccountID)
 
 	// Find recipient user by TigerBeetle account ID
-	log.Printf("🔍 [Transfer] Searching for recipient user in PostgreSQL by tigerbeetle_account_id = %d...", toAccountID)
 	var toUser models.User
-	if err := s.db.Where("tigerbeetle_account_id = ?", toAccountID).First(&toUser).Error; err != nil {
+	if err := s.db.WithContext(ctx).Where("tigerbeetle_account_id = ?", toAccountID).First(&toUser).Error; err != nil {
 		// Recipient not found in PostgreSQL (might be system account or deleted user)
-		log.Printf("❌ [Transfer] Recipient with TigerBeetle account %d NOT FOUND in PostgreSQL: %v", toAccountID, err)
-		log.Printf("❌ [Transfer] toUser.ID will be uuid.Nil, RecipientUserID will NOT be set")
-	} else {
-		log.Printf("✅ [Transfer] Recipient user FOUND: ID=%s, Email=%s, TigerBeetleAccountID=%d", toUser.ID, toUser.Email, toUser.TigerBeetleAccountID)
+		logger.WarnContext(ctx, "transfer recipient has no user, the recipient will not be recorded", "to_account_id", toAccountID, "error", err)
+	}
+
+	// Unverified users can only move a limited amount out per day
+	if err := s.checkUnverifiedLimit(fromUser, amount); err != nil {
+		return nil, err
+	}
+
+	// Re-authentication for high-value transfers and new recipients
+	stepUp, err := s.requireStepUp(ctx, fromUser, fee.OperationTransfer, amount, toAccountID, stepUpToken)
+	if err != nil {
+		return nil, err
 	}
 
 	// Generate transfer ID
-	transferID := tb_types.ToUint128(uint64(uuid.New().ID()))
+	transferID := tb_types.ID()
 
 	// Create transfer between user accounts
-	transfers := []tb_types.Transfer{
-		{
-			ID:              transferID,
-			DebitAccountID:  tb_types.ToUint128(fromUser.TigerBeetleAccountID), // Sender
-			CreditAccountID: tb_types.ToUint128(toAccountID),                   // Recipient
-			Amount:          tb_types.ToUint128(uint64(amount)),
-			Ledger:          1,
-			Code:            3, // Transfer code
-		},
+	principal := tb_types.Transfer{
+		ID:              transferID,
+		DebitAccountID:  tb_types.ToUint128(fromUser.TigerBeetleAccountID), // Sender
+		CreditAccountID: tb_types.ToUint128(toAccountID),                   // Recipient
+		Amount:          tb_types.ToUint128(uint64(amount)),
+		Ledger:          tigerbeetle.LedgerUSD,
+		Code:            tigerbeetle.TransferCodeTransfer,
 	}
+	transfers, feeTx := s.withFee(principal, fromUser, quote)
 
 	// Execute transfer in TigerBeetle
-	results, err := s.tbClient.CreateTransfers(transfers)
+	results, err := s.tbClient.CreateTransfers(ctx, transfers)
 	if err != nil {
-		return fmt.Errorf("failed to create transfer: %w", err)
+		stepUp.Release()
+		err = fmt.Errorf("failed to create transfer: %w", err)
+		s.publishFailure(ctx, fromUser, fee.OperationTransfer, amount, toAccountID, err)
+		return nil, err
 	}
 
 	// Check for errors
 	if len(results) > 0 {
-		return fmt.Errorf("transfer failed with result code: %d", results[0].Result)
+		stepUp.Release()
+		err := fmt.Errorf("transfer failed with result code: %d", results[0].Result)
+		s.publishFailure(ctx, fromUser, fee.OperationTransfer, amount, toAccountID, err)
+		return nil, err
 	}
 
 	// Create transaction record in PostgreSQL (audit log)
-	log.Printf("🔍 [Transfer] Creating transaction record for PostgreSQL...")
 	txRecord := &models.Transaction{
 		UserID:          fromUser.ID,
 		RecipientUserID: nil, // Will set if recipient found
 		Type:            models.TransactionTypeTransfer,
 		Amount:          amount,
+		Fee:             quote.Fee,
 		DebitAccountID:  fromUser.TigerBeetleAccountID,
 		CreditAccountID: toAccountID,
 		Status:          models.TransactionStatusCompleted,
 		Description:     fmt.Sprintf("Transfer of %d cents to account %d", amount, toAccountID),
 	}
 	txRecord.SetTigerBeetleTransferID(transferID)
+	if feeTx != nil {
+		txRecord.FeeTigerBeetleTransferID = models.Uint128ToHex(feeTx.ID)
+	}
 
 	// Set recipient user ID if found
-	log.Printf("🔍 [Transfer] Checking if toUser.ID != uuid.Nil (toUser.ID = %s)...", toUser.ID)
 	if toUser.ID != uuid.Nil {
 		txRecord.RecipientUserID = &toUser.ID
-		log.Printf("✅ [Transfer] RecipientUserID SET to: %s", toUser.ID)
-	} else {
-		log.Printf("❌ [Transfer] RecipientUserID NOT SET (toUser.ID is uuid.Nil)")
 	}
 
-	log.Printf("🔍 [Transfer] Transaction record to save:")
-	log.Printf("   UserID: %s", txRecord.UserID)
-	log.Printf("   RecipientUserID: %v", txRecord.RecipientUserID)
-	log.Printf("   Type: %s", txRecord.Type)
-	log.Printf("   Amount: %d cents", txRecord.Amount)
-	log.Printf("   DebitAccountID: %d", txRecord.DebitAccountID)
-	log.Printf("   CreditAccountID: %d", txRecord.CreditAccountID)
-	log.Printf("   Status: %s", txRecord.Status)
+	// Save to PostgreSQL with the webhook events of both parties - non-blocking
+	webhooks := []completedWebhook{{models.WebhookEventTransferCompleted, fromUser.ID, directionOutgoing}}
+	if toUser.ID != uuid.Nil {
+		webhooks = append(webhooks, completedWebhook{models.WebhookEventTransferCompleted, toUser.ID, directionIncoming})
+	}
+	if err := s.saveCompleted(ctx, txRecord, webhooks...); err != nil {
+		metrics.AuditWriteFailed(string(txRecord.Type))
+		logger.ErrorContext(ctx, "transfer posted in TigerBeetle but failed to log in PostgreSQL",
+			"transfer_id", txRecord.TigerBeetleTransferID, "user_id", fromUserID, "to_account_id", toAccountID,
+			"amount", amount, "fee", quote.Fee, "error", err)
+	}
 
-	// Save to PostgreSQL - non-blocking
-	if err := s.repo.Create(txRecord); err != nil {
-		log.Printf("🚨 CRITICAL: Transfer %s succeeded in TigerBeetle but failed to log in PostgreSQL: %v",
-			txRecord.TigerBeetleTransferID, err)
-		log.Printf("   From UserID: %s, To Account: %d, Amount: %d", fromUserID, toAccountID, amount)
+	s.pushUpdate(ctx, fromUser.ID, fromUser.TigerBeetleAccountID, txRecord, directionOutgoing)
+	if toUser.ID != uuid.Nil {
+		s.pushUpdate(ctx, toUser.ID, toAccountID, txRecord, directionIncoming)
+		s.notifications.IncomingTransfer(toUser.ID, amount, fromUser.FullName, txRecord.ID)
 	}
+	s.notifications.BalanceChanged(fromUser.ID, balance, balance-quote.Total)
+	s.publishTransferPosted(fromUser, txRecord)
 
-	log.Printf("✅ Transfer successful: %d cents from user %s to account %d", amount, fromUserID, toAccountID)
-	return nil
+	observePosted(txRecord)
+	logger.InfoContext(ctx, "transfer posted", "transaction_id", txRecord.ID, "user_id", fromUserID, "to_account_id", toAccountID, "amount", amount, "fee", quote.Fee)
+	return txRecord, nil
 }
 
 // GetHistory retrieves transaction history for a user from PostgreSQL
 // Returns paginated transaction records with enriched user information
-func (s *Service) GetHistory(userID string, page, limit int) ([]models.TransactionDTO, error) {
+func (s *Service) GetHistory(ctx context.Context, userID string, page, limit int) ([]models.TransactionDTO, error) {
 	// Validate pagination parameters
 	if page < 1 {
 		page = 1
@@ -309,44 +490,48 @@ func (s *Service) GetHistory(userID string, page, limit int) ([]models.Transacti
 	}
 
 	// Get user to validate existence
-	user, err := s.getUserByID(userID)
+	user, err := s.getUserByID(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	log.Printf("📋 [GetHistory] Retrieving transaction history for user %s (UUID: %s, page %d, limit %d)", userID, user.ID, page, limit)
-
 	// Get transactions from repository (includes all transactions where user is sender or recipient)
-	log.Printf("📋 [GetHistory] Calling repository.GetAllByUserID...")
-	transactions, err := s.repo.GetAllByUserID(user.ID, page, limit)
+	transactions, err := s.repo.WithContext(ctx).GetAllByUserID(user.ID, page, limit)
 	if err != nil {
-		log.Printf("❌ [GetHistory] Failed to retrieve transactions: %v", err)
 		return nil, fmt.Errorf("failed to retrieve transaction history: %w", err)
 	}
-	log.Printf("📋 [GetHistory] Repository returned %d raw transactions", len(transactions))
 
-	// Log each transaction for debugging
-	for i, tx := range transactions {
-		log.Printf("📋 [GetHistory] Transaction %d: ID=%s, UserID=%s, RecipientUserID=%v, Type=%s, Amount=%d",
-			i+1, tx.ID, tx.UserID, tx.RecipientUserID, tx.Type, tx.Amount)
+	// Link originals to the reversals posted against them
+	reversedIDs := make([]uuid.UUID, 0)
+	for _, tx := range transactions {
+		if tx.ReversedAmount > 0 {
+			reversedIDs = append(reversedIDs, tx.ID)
+		}
+	}
+	reversals, err := s.repo.WithContext(ctx).GetReversalIDs(reversedIDs)
+	if err != nil {
+		logger.WarnContext(ctx, "failed to load reversals", "user_id", userID, "error", err)
+		reversals = nil
 	}
 
 	// Convert to DTOs with enriched information
 	dtos := make([]models.TransactionDTO, 0, len(transactions))
 	for _, tx := range transactions {
-		dtos = append(dtos, tx.ToDTO())
+		dto := tx.ToDTO()
+		dto.ReversalIDs = reversals[tx.ID]
+		dtos = append(dtos, dto)
 	}
 
-	log.Printf("✅ [GetHistory] Retrieved %d transactions for user %s", len(dtos), userID)
+	logger.DebugContext(ctx, "history retrieved", "user_id", userID, "page", page, "limit", limit, "transactions", len(dtos))
 	return dtos, nil
 }
 
 // GetHistoryCount returns the total count of transactions for pagination
-func (s *Service) GetHistoryCount(userID string) (int64, error) {
-	user, err := s.getUserByID(userID)
+func (s *Service) GetHistoryCount(ctx context.Context, userID string) (int64, error) {
+	user, err := s.getUserByID(ctx, userID)
 	if err != nil {
 		return 0, err
 	}
 
-	return s.repo.CountAllByUserID(user.ID)
+	return s.repo.WithContext(ctx).CountAllByUserID(user.ID)
 }
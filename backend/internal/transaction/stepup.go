@@ -0,0 +1,133 @@
+package transaction
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/hlabs/banking-system/internal/auth"
+	"github.com/hlabs/banking-system/internal/fee"
+	"github.com/hlabs/banking-system/internal/models"
+)
+
+// Reasons a money movement needs step-up authentication
+const (
+	StepUpReasonAmount       = "amount_above_threshold"
+	StepUpReasonNewRecipient = "new_recipient"
+)
+
+// StepUpPolicy decides which withdrawals and transfers need the user to re-authenticate
+type StepUpPolicy struct {
+	Threshold    int64 // Amounts (cents) strictly above this require step-up; 0 disables the check
+	NewRecipient bool  // Transfers to an account the user never paid before require step-up
+}
+
+// StepUpChallenge tells the client how to authorize a sensitive operation
+// The client calls POST /api/auth/step-up with Operation and one of Methods,
+// then retries the original request with the returned step_up_token
+type StepUpChallenge struct {
+	Required  bool                 `json:"step_up_required"`
+	Reasons   []string             `json:"reasons"`
+	Methods   []string             `json:"methods"`
+	Operation auth.StepUpOperation `json:"operation"`
+}
+
+// StepUpRequiredError is returned by Withdraw and Transfer when the policy applies
+// and no valid step-up token was presented
+type StepUpRequiredError struct {
+	Challenge *StepUpChallenge
+}
+
+// Error implements the error interface
+func (e *StepUpRequiredError) Error() string {
+	return "step-up authentication required"
+}
+
+// apiClientKey is the context key of the transfer limit of an API client's key
+type apiClientKey struct{}
+
+// WithAPIClient marks ctx as a request made by an API client
+// Machine clients can't re-authenticate, so operations that need step-up are allowed up to the
+// key's transferLimit (approved by the owner when the key was created) and refused above it
+func WithAPIClient(ctx context.Context, transferLimit int64) context.Context {
+	return context.WithValue(ctx, apiClientKey{}, transferLimit)
+}
+
+// apiClientLimit returns the transfer limit of the API client making the request, if any
+func apiClientLimit(ctx context.Context) (int64, bool) {
+	limit, ok := ctx.Value(apiClientKey{}).(int64)
+	return limit, ok
+}
+
+// StepUpChallengeFor evaluates the step-up policy without moving money
+// Returns nil when the operation can proceed without re-authentication
+func (s *Service) StepUpChallengeFor(ctx context.Context, userID string, op fee.Operation, amount int64, toAccountID uint64) (*StepUpChallenge, error) {
+	user, err := s.getUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.stepUpChallenge(ctx, user, op, amount, toAccountID)
+}
+
+// stepUpChallenge applies the policy to an operation by the given user
+// API clients get no challenge for amounts within their key's transfer limit, and a
+// challenge without methods above it
+func (s *Service) stepUpChallenge(ctx context.Context, user *models.User, op fee.Operation, amount int64, toAccountID uint64) (*StepUpChallenge, error) {
+	if op != fee.OperationWithdraw && op != fee.OperationTransfer {
+		return nil, nil
+	}
+
+	var reasons []string
+	if s.stepUpPolicy.Threshold > 0 && amount > s.stepUpPolicy.Threshold {
+		reasons = append(reasons, StepUpReasonAmount)
+	}
+	if op == fee.OperationTransfer && s.stepUpPolicy.NewRecipient {
+		known, err := s.repo.HasPaidAccount(user.ID, toAccountID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check recipient history: %w", err)
+		}
+		if !known {
+			reasons = append(reasons, StepUpReasonNewRecipient)
+		}
+	}
+	if len(reasons) == 0 {
+		return nil, nil
+	}
+
+	methods := auth.StepUpMethods(user)
+	if limit, ok := apiClientLimit(ctx); ok {
+		if amount <= limit {
+			return nil, nil
+		}
+		methods = []string{}
+	}
+
+	return &StepUpChallenge{
+		Required: true,
+		Reasons:  reasons,
+		Methods:  methods,
+		Operation: auth.StepUpOperation{
+			Operation:   string(op),
+			Amount:      amount,
+			ToAccountID: toAccountID,
+		},
+	}, nil
+}
+
+// requireStepUp enforces the policy before money moves
+// A presented token must have been issued for exactly this operation. It is claimed here and
+// must be released by the caller if the money movement then fails (Release is nil-safe)
+// API clients above their key's transfer limit are refused outright
+func (s *Service) requireStepUp(ctx context.Context, user *models.User, op fee.Operation, amount int64, toAccountID uint64, stepUpToken string) (*auth.StepUpClaim, error) {
+	challenge, err := s.stepUpChallenge(ctx, user, op, amount, toAccountID)
+	if err != nil || challenge == nil {
+		return nil, err
+	}
+
+	if limit, ok := apiClientLimit(ctx); ok {
+		return nil, fmt.Errorf("api key transfer limit exceeded: operations over %d cents that need step-up must be made by the account owner", limit)
+	}
+	if stepUpToken == "" {
+		return nil, &StepUpRequiredError{Challenge: challenge}
+	}
+	return s.stepUp.Redeem(stepUpToken, user.ID.String(), challenge.Operation)
+}
@@ -0,0 +1,84 @@
+package transaction
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/fee"
+	"github.com/hlabs/banking-system/internal/models"
+	"gorm.io/gorm"
+)
+
+// Direction of a transfer from the point of view of the webhook's owner
+const (
+	directionOutgoing = "outgoing"
+	directionIncoming = "incoming"
+)
+
+// completedEvent is the data of deposit.completed, withdrawal.completed and transfer.completed
+type completedEvent struct {
+	Transaction models.TransactionDTO `json:"transaction"`
+	Direction   string                `json:"direction,omitempty"` // Transfers only
+}
+
+// failedEvent is the data of transaction.failed
+type failedEvent struct {
+	Operation   fee.Operation `json:"operation"`
+	Amount      int64         `json:"amount"`
+	ToAccountID uint64        `json:"to_account_id,omitempty"`
+	Reason      string        `json:"reason"`
+	FailedAt    time.Time     `json:"failed_at"`
+}
+
+// completedWebhook is a completed event for one party of a posted transaction
+type completedWebhook struct {
+	eventType string
+	userID    uuid.UUID
+	direction string
+}
+
+// saveCompleted saves a posted transaction's record together with its completed webhook events
+// Both are written in one database transaction, so an event is only delivered for a recorded
+// transaction and can't be lost once the record is saved. The events are recorded in a savepoint:
+// the money has already moved, so an event that can't be recorded is logged and dropped and never
+// takes the record down with it
+// The write ignores cancellation of ctx (keeping its trace and request ID): a client that disconnects
+// after the transfer was posted must not leave it without its PostgreSQL record
+func (s *Service) saveCompleted(ctx context.Context, txRecord *models.Transaction, webhooks ...completedWebhook) error {
+	return s.db.WithContext(context.WithoutCancel(ctx)).Transaction(func(tx *gorm.DB) error {
+		if err := NewRepository(tx).Create(txRecord); err != nil {
+			return err
+		}
+		for _, webhook := range webhooks {
+			if err := tx.Transaction(func(tx *gorm.DB) error {
+				return s.webhooks.Record(tx, webhook.eventType, webhook.userID, completedEvent{
+					Transaction: txRecord.ToDTO(),
+					Direction:   webhook.direction,
+				})
+			}); err != nil {
+				logger.ErrorContext(ctx, "failed to record webhook event, the event is lost",
+					"type", webhook.eventType, "user_id", webhook.userID, "transaction_id", txRecord.ID, "error", err)
+			}
+		}
+		return nil
+	})
+}
+
+// publishFailure notifies a user's webhooks that a money movement was rejected
+// Only failures of the movement itself (insufficient funds, ledger rejection)
+// are published, not invalid requests. Withdrawals and transfers an API client makes on the
+// user's behalf also notify the user, who isn't there to see the error
+func (s *Service) publishFailure(ctx context.Context, user *models.User, op fee.Operation, amount int64, toAccountID uint64, err error) {
+	s.webhooks.Publish(ctx, models.WebhookEventTransactionFailed, user.ID, failedEvent{
+		Operation:   op,
+		Amount:      amount,
+		ToAccountID: toAccountID,
+		Reason:      err.Error(),
+		FailedAt:    time.Now(),
+	})
+
+	if _, unattended := apiClientLimit(ctx); unattended && op != fee.OperationDeposit {
+		s.notifications.APIClientPaymentFailed(user.ID, amount, err.Error())
+	}
+}
@@ -0,0 +1,359 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/models"
+	"gorm.io/gorm"
+)
+
+const (
+	// pollInterval is how often the dispatcher looks for due deliveries
+	pollInterval = 5 * time.Second
+
+	// batchSize is how many deliveries are claimed and sent concurrently
+	batchSize = 20
+
+	// requestTimeout bounds a single delivery attempt
+	requestTimeout = 10 * time.Second
+
+	// leaseDuration is how long a claimed delivery is hidden from other dispatchers;
+	// if this one dies mid-attempt the delivery becomes due again afterwards
+	leaseDuration = requestTimeout + time.Minute
+
+	// baseBackoff is the delay before the first retry, doubled after every further failure
+	baseBackoff = 30 * time.Second
+
+	// maxBackoff caps the delay between two attempts
+	maxBackoff = 6 * time.Hour
+
+	// eventRetention is how long fully delivered events are kept
+	eventRetention = 30 * 24 * time.Hour
+
+	// purgeInterval is how often old delivered events are deleted
+	purgeInterval = time.Hour
+
+	// maxErrorLength bounds the error stored on a failed delivery
+	maxErrorLength = 500
+)
+
+// Dispatcher sends queued webhook deliveries
+// Deliveries are claimed from the database with FOR UPDATE SKIP LOCKED, so several API
+// instances can run a dispatcher side by side without sending an event twice
+type Dispatcher struct {
+	db          *gorm.DB
+	service     *Service
+	client      *http.Client
+	maxAttempts int
+}
+
+// payload is the JSON body POSTed to an endpoint
+// ID is the event ID and stays the same across retries, so receivers can deduplicate on it
+type payload struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// NewDispatcher creates a dispatcher for the deliveries queued by service
+// A delivery is moved to the dead letters after maxAttempts failed attempts
+func NewDispatcher(service *Service, maxAttempts int) *Dispatcher {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	if !service.allowPrivate {
+		dialer.Control = refusePrivateAddresses
+	}
+
+	return &Dispatcher{
+		db:      service.db,
+		service: service,
+		client: &http.Client{
+			Timeout: requestTimeout,
+			Transport: &http.Transport{
+				Proxy:               nil, // A proxy would hide the real destination from the address check
+				DialContext:         dialer.DialContext,
+				TLSHandshakeTimeout: 5 * time.Second,
+				MaxIdleConnsPerHost: 2,
+				IdleConnTimeout:     90 * time.Second,
+			},
+			// Redirects are not followed: they could point anywhere, including internal hosts
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+		maxAttempts: maxAttempts,
+	}
+}
+
+// Run sends due deliveries until the context is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	logger.Info("webhook dispatcher started", "max_attempts", d.maxAttempts)
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	lastPurge := time.Now()
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Info("webhook dispatcher stopped")
+			return
+		case <-ticker.C:
+			d.DispatchDue(ctx)
+
+			if time.Since(lastPurge) >= purgeInterval {
+				lastPurge = time.Now()
+				if err := d.PurgeDelivered(); err != nil {
+					logger.Warn("webhook cleanup failed", "error", err)
+				}
+			}
+		}
+	}
+}
+
+// DispatchDue sends every delivery that is due, a batch at a time
+func (d *Dispatcher) DispatchDue(ctx context.Context) {
+	for ctx.Err() == nil {
+		deliveries, err := d.claim(time.Now())
+		if err != nil {
+			logger.Warn("failed to claim webhook deliveries", "error", err)
+			return
+		}
+
+		var wg sync.WaitGroup
+		for i := range deliveries {
+			wg.Add(1)
+			go func(delivery *models.WebhookDelivery) {
+				defer wg.Done()
+				d.deliver(ctx, delivery)
+			}(&deliveries[i])
+		}
+		wg.Wait()
+
+		if len(deliveries) < batchSize {
+			return
+		}
+	}
+}
+
+// PurgeDelivered deletes old events whose deliveries all succeeded
+// Events with pending deliveries or dead letters are kept until they are delivered or deleted
+func (d *Dispatcher) PurgeDelivered() error {
+	result := d.db.
+		Where("created_at < ?", time.Now().Add(-eventRetention)).
+		Where("NOT EXISTS (SELECT 1 FROM webhook_deliveries WHERE webhook_deliveries.event_id = webhook_events.id AND webhook_deliveries.status <> ?)", models.WebhookDeliverySucceeded).
+		Delete(&models.WebhookEvent{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to purge webhook events: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		logger.Info("purged delivered webhook events", "events", result.RowsAffected)
+	}
+	return nil
+}
+
+// claim leases a batch of due deliveries by pushing their next attempt past the lease
+// The event and subscription are preloaded
+func (d *Dispatcher) claim(now time.Time) ([]models.WebhookDelivery, error) {
+	var ids []uuid.UUID
+	if err := d.db.Raw(`
+		UPDATE webhook_deliveries SET next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id`,
+		now.Add(leaseDuration), now, models.WebhookDeliveryPending, now, batchSize,
+	).Scan(&ids).Error; err != nil {
+		return nil, err
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := d.db.Preload("Event").Preload("Subscription").Where("id IN ?", ids).Find(&deliveries).Error; err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// deliver makes one attempt and records its outcome
+func (d *Dispatcher) deliver(ctx context.Context, delivery *models.WebhookDelivery) {
+	if delivery.Event == nil || delivery.Subscription == nil {
+		return // Deleted while claimed; the cascade removes the delivery too
+	}
+
+	// The API key owning the endpoint may have been revoked after the event was queued
+	revoked, err := d.clientRevoked(ctx, delivery.Subscription.ClientID)
+	if err != nil {
+		logger.Warn("failed to check webhook client", "delivery_id", delivery.ID, "error", err)
+		return // The lease expires and the delivery is tried again later
+	}
+	if revoked {
+		if err := d.db.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(map[string]interface{}{
+			"status":     models.WebhookDeliveryDead,
+			"last_error": "api key revoked",
+			"updated_at": time.Now(),
+		}).Error; err != nil {
+			logger.Error("failed to record webhook delivery", "delivery_id", delivery.ID, "error", err)
+		}
+		logger.Info("webhook delivery dropped: api key revoked", "delivery_id", delivery.ID, "client_id", delivery.Subscription.ClientID)
+		return
+	}
+
+	statusCode, err := d.send(ctx, delivery.Subscription, delivery.Event)
+	if ctx.Err() != nil {
+		return // Shutting down: the lease expires and the attempt is repeated later
+	}
+
+	now := time.Now()
+	attempts := delivery.Attempts + 1
+	updates := map[string]interface{}{
+		"attempts":         attempts,
+		"last_status_code": statusCode,
+		"updated_at":       now,
+	}
+
+	switch {
+	case err == nil:
+		updates["status"] = models.WebhookDeliverySucceeded
+		updates["delivered_at"] = now
+		updates["last_error"] = ""
+	case attempts >= d.maxAttempts:
+		updates["status"] = models.WebhookDeliveryDead
+		updates["last_error"] = truncate(err.Error(), maxErrorLength)
+		logger.Warn("webhook delivery moved to dead letters", "delivery_id", delivery.ID, "type", delivery.Event.Type,
+			"url", delivery.Subscription.URL, "attempts", attempts, "error", err)
+	default:
+		updates["next_attempt_at"] = now.Add(backoff(attempts))
+		updates["last_error"] = truncate(err.Error(), maxErrorLength)
+		logger.Info("webhook delivery failed, will retry", "delivery_id", delivery.ID, "type", delivery.Event.Type,
+			"url", delivery.Subscription.URL, "attempt", attempts, "max_attempts", d.maxAttempts, "error", err)
+	}
+
+	if err := d.db.Model(&models.WebhookDelivery{}).Where("id = ?", delivery.ID).Updates(updates).Error; err != nil {
+		logger.Error("failed to record webhook delivery", "delivery_id", delivery.ID, "error", err)
+	}
+}
+
+// clientRevoked reports whether the API key that registered an endpoint has been revoked or has
+// expired; endpoints registered in an interactive session (no client ID) are never revoked
+func (d *Dispatcher) clientRevoked(ctx context.Context, clientID string) (bool, error) {
+	if clientID == "" {
+		return false, nil
+	}
+	var active int64
+	if err := d.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("client_id = ? AND revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", clientID, time.Now()).
+		Count(&active).Error; err != nil {
+		return false, err
+	}
+	return active == 0, nil
+}
+
+// send POSTs the signed event to the subscription's endpoint
+// Returns the response status (0 if there was none) and an error unless the endpoint answered 2xx
+func (d *Dispatcher) send(ctx context.Context, subscription *models.WebhookSubscription, event *models.WebhookEvent) (int, error) {
+	secret, err := d.service.box.Open(subscription.Secret)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrypt webhook secret: %w", err)
+	}
+
+	body, err := json.Marshal(payload{
+		ID:        event.ID,
+		Type:      event.Type,
+		CreatedAt: event.CreatedAt,
+		Data:      json.RawMessage(event.Data),
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("invalid url: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "HLABS-Webhooks/1.0")
+	req.Header.Set("X-Webhook-ID", event.ID.String())
+	req.Header.Set("X-Webhook-Event", event.Type)
+	req.Header.Set("X-Webhook-Timestamp", timestamp)
+	req.Header.Set("X-Webhook-Signature", Sign(secret, timestamp, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("endpoint responded with HTTP %d", resp.StatusCode)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign computes the X-Webhook-Signature header: "v1=" followed by the hex HMAC-SHA256 of
+// "<timestamp>.<body>" keyed with the subscription secret
+// Receivers recompute it over the raw body and reject stale timestamps to prevent replays
+func Sign(secret []byte, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "v1=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// backoff returns the delay before the attempt following the given number of failures
+func backoff(failures int) time.Duration {
+	delay := baseBackoff
+	for i := 1; i < failures && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, maxBackoff)
+}
+
+// refusePrivateAddresses is a dialer hook that blocks connections to loopback, private and
+// link-local addresses, so webhooks can't be used to reach internal services
+// It runs after DNS resolution, so a public name pointing at a private address is refused too
+func refusePrivateAddresses(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return fmt.Errorf("refusing to connect to %s", address)
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return fmt.Errorf("refusing to connect to non-public address %s", ip)
+	}
+	return nil
+}
+
+// truncate shortens s to at most n bytes
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n]
+}
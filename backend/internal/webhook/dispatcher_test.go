@@ -0,0 +1,59 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSign(t *testing.T) {
+	secret := []byte("whsec_test")
+
+	// Expected values computed independently with
+	// printf '%s' '<timestamp>.<body>' | openssl dgst -sha256 -hmac whsec_test
+	tests := []struct {
+		name      string
+		timestamp string
+		body      string
+		signature string
+	}{
+		{"event body", "1700000000", `{"id":"evt_1","type":"deposit.completed"}`, "v1=4730453ba1131bd81862a16d3f1cd5a06770cf2b28fe53fcc54c7449c6946dc0"},
+		{"empty body", "1700000000", "", "v1=5967f3c560522fa40cf2876ebc3c3a08551dd6959aaade3b413460591895bdcc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Sign(secret, tt.timestamp, []byte(tt.body)); got != tt.signature {
+				t.Errorf("Sign = %s, want %s", got, tt.signature)
+			}
+		})
+	}
+
+	body := []byte(`{"id":"evt_1"}`)
+	signature := Sign(secret, "1700000000", body)
+	if Sign([]byte("other"), "1700000000", body) == signature {
+		t.Error("signature does not depend on the secret")
+	}
+	if Sign(secret, "1700000001", body) == signature {
+		t.Error("signature does not depend on the timestamp")
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	tests := []struct {
+		failures int
+		delay    time.Duration
+	}{
+		{1, 30 * time.Second},
+		{2, time.Minute},
+		{3, 2 * time.Minute},
+		{10, 256 * time.Minute},
+		{11, maxBackoff},
+		{100, maxBackoff},
+	}
+
+	for _, tt := range tests {
+		if got := backoff(tt.failures); got != tt.delay {
+			t.Errorf("backoff(%d) = %s, want %s", tt.failures, got, tt.delay)
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package webhook
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/hlabs/banking-system/internal/middleware"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/pkg/utils"
+)
+
+// Handler handles HTTP requests for webhook subscriptions
+type Handler struct {
+	service *Service
+}
+
+// NewHandler creates a new webhook handler
+func NewHandler(service *Service) *Handler {
+	return &Handler{
+		service: service,
+	}
+}
+
+// CreateWebhookRequest represents a request to register an endpoint
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	EventTypes  []string `json:"event_types" binding:"required"`
+	Description string   `json:"description"`
+}
+
+// Create registers an endpoint for the current user (or API client)
+// The signing secret is only returned in this response
+// POST /api/webhooks
+func (h *Handler) Create(c *gin.Context) {
+	userID, clientID, ok := caller(c)
+	if !ok {
+		return
+	}
+
+	var req CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.RespondWithError(c, http.StatusBadRequest, "Invalid request payload")
+		return
+	}
+
+	created, err := h.service.Create(userID, clientID, req.URL, req.EventTypes, req.Description)
+	if err != nil {
+		errMsg := err.Error()
+		switch {
+		case strings.HasPrefix(errMsg, "invalid url"), strings.HasPrefix(errMsg, "invalid event type"),
+			errMsg == "at least one event type is required", errMsg == "description is too long":
+			utils.RespondWithError(c, http.StatusBadRequest, errMsg)
+		case strings.HasPrefix(errMsg, "webhook limit reached"):
+			utils.RespondWithError(c, http.StatusConflict, errMsg)
+		default:
+			logger.ErrorContext(c.Request.Context(), "failed to create webhook", "user_id", userID, "error", err)
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to create webhook")
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusCreated, created, "Webhook created - store the secret now, it won't be shown again")
+}
+
+// List lists the current user's endpoints
+// GET /api/webhooks
+func (h *Handler) List(c *gin.Context) {
+	userID, clientID, ok := caller(c)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := h.service.List(userID, clientID)
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to list webhooks", "user_id", userID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve webhooks")
+		return
+	}
+
+	dtos := make([]models.WebhookSubscriptionDTO, len(subscriptions))
+	for i := range subscriptions {
+		dtos[i] = subscriptions[i].ToDTO()
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"webhooks":    dtos,
+		"count":       len(dtos),
+		"event_types": models.WebhookEventTypes,
+	}, "Webhooks retrieved successfully")
+}
+
+// Delete removes one of the current user's endpoints
+// DELETE /api/webhooks/:id
+func (h *Handler) Delete(c *gin.Context) {
+	userID, clientID, ok := caller(c)
+	if !ok {
+		return
+	}
+
+	if err := h.service.Delete(userID, clientID, c.Param("id")); err != nil {
+		if err.Error() == "webhook not found" {
+			utils.RespondWithError(c, http.StatusNotFound, "Webhook not found")
+			return
+		}
+		logger.ErrorContext(c.Request.Context(), "failed to delete webhook", "webhook_id", c.Param("id"), "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to delete webhook")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, nil, "Webhook deleted")
+}
+
+// GetDeliveries returns the recent deliveries to one of the current user's endpoints
+// GET /api/webhooks/:id/deliveries?limit=50
+func (h *Handler) GetDeliveries(c *gin.Context) {
+	userID, clientID, ok := caller(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.service.Deliveries(userID, clientID, c.Param("id"), queryLimit(c))
+	if err != nil {
+		if err.Error() == "webhook not found" {
+			utils.RespondWithError(c, http.StatusNotFound, "Webhook not found")
+			return
+		}
+		logger.ErrorContext(c.Request.Context(), "failed to retrieve webhook deliveries", "webhook_id", c.Param("id"), "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve deliveries")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	}, "Deliveries retrieved successfully")
+}
+
+// GetDeadLetters returns the deliveries that ran out of attempts
+// GET /api/webhooks/dead-letters?limit=50
+func (h *Handler) GetDeadLetters(c *gin.Context) {
+	userID, clientID, ok := caller(c)
+	if !ok {
+		return
+	}
+
+	deliveries, err := h.service.DeadLetters(userID, clientID, queryLimit(c))
+	if err != nil {
+		logger.ErrorContext(c.Request.Context(), "failed to retrieve webhook dead letters", "user_id", userID, "error", err)
+		utils.RespondWithError(c, http.StatusInternalServerError, "Failed to retrieve dead letters")
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusOK, gin.H{
+		"deliveries": deliveries,
+		"count":      len(deliveries),
+	}, "Dead letters retrieved successfully")
+}
+
+// Replay queues a dead letter (or a delivered event) for delivery again
+// POST /api/webhooks/deliveries/:id/replay
+func (h *Handler) Replay(c *gin.Context) {
+	userID, clientID, ok := caller(c)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.Replay(userID, clientID, c.Param("id"))
+	if err != nil {
+		switch err.Error() {
+		case "delivery not found":
+			utils.RespondWithError(c, http.StatusNotFound, "Delivery not found")
+		case "delivery is already pending":
+			utils.RespondWithError(c, http.StatusConflict, "Delivery is already pending")
+		default:
+			logger.ErrorContext(c.Request.Context(), "failed to replay webhook delivery", "delivery_id", c.Param("id"), "error", err)
+			utils.RespondWithError(c, http.StatusInternalServerError, "Failed to replay delivery")
+		}
+		return
+	}
+
+	utils.RespondWithSuccess(c, http.StatusAccepted, gin.H{"delivery": delivery}, "Delivery queued for replay")
+}
+
+// caller returns the authenticated user and, for machine clients, the API client ID
+func caller(c *gin.Context) (string, string, bool) {
+	userID, exists := middleware.GetUserID(c)
+	if !exists {
+		utils.RespondWithError(c, http.StatusUnauthorized, "User not authenticated")
+		return "", "", false
+	}
+	return userID, c.GetString("client_id"), true
+}
+
+// queryLimit parses the limit query parameter (default 50, at most 500)
+func queryLimit(c *gin.Context) int {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 {
+		limit = 50
+	}
+	return min(limit, 500)
+}
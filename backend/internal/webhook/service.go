@@ -0,0 +1,361 @@
+package webhook
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/hlabs/banking-system/internal/logging"
+	"github.com/hlabs/banking-system/internal/models"
+	"github.com/hlabs/banking-system/internal/secretbox"
+	"gorm.io/gorm"
+)
+
+var logger = logging.For("webhook")
+
+const (
+	// secretPrefix makes signing secrets recognizable (e.g. by secret scanners)
+	secretPrefix = "whsec_"
+
+	// secretBytes is the amount of randomness in a signing secret
+	secretBytes = 32
+
+	// maxSubscriptionsPerUser bounds how many endpoints a user can register
+	maxSubscriptionsPerUser = 10
+)
+
+// NewSubscription is returned once when a subscription is created; the secret can't be retrieved later
+type NewSubscription struct {
+	Subscription models.WebhookSubscriptionDTO `json:"subscription"`
+	Secret       string                        `json:"secret"` // Verifies X-Webhook-Signature
+}
+
+// Service manages webhook subscriptions and records the events delivered to them
+type Service struct {
+	db           *gorm.DB
+	box          *secretbox.Box
+	allowPrivate bool
+}
+
+// NewService creates a new webhook service
+// encryptionKey protects the signing secrets at rest; allowPrivate permits plain HTTP endpoints
+// and endpoints on private networks (local development only)
+func NewService(db *gorm.DB, encryptionKey string, allowPrivate bool) (*Service, error) {
+	box, err := secretbox.New(encryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	return &Service{
+		db:           db,
+		box:          box,
+		allowPrivate: allowPrivate,
+	}, nil
+}
+
+// Publish records an event for a user and queues a delivery to every matching subscription
+// The event and its deliveries are written in one transaction, so they survive restarts and
+// are picked up by the dispatcher. Failures are logged and never fail the operation that
+// triggered the event. A nil service publishes nothing.
+func (s *Service) Publish(ctx context.Context, eventType string, userID uuid.UUID, data interface{}) {
+	if s == nil {
+		return
+	}
+	err := s.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return s.Record(tx, eventType, userID, data)
+	})
+	if err != nil {
+		logger.ErrorContext(ctx, "failed to record webhook event", "type", eventType, "user_id", userID, "error", err)
+	}
+}
+
+// Record writes an event and its pending deliveries with tx
+// Callers pass the transaction that saves the record the event describes, so the event exists
+// exactly when the record does. A nil service records nothing.
+func (s *Service) Record(tx *gorm.DB, eventType string, userID uuid.UUID, data interface{}) error {
+	if s == nil {
+		return nil
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	now := time.Now()
+	var subscriptions []models.WebhookSubscription
+	if err := tx.
+		Where("user_id = ? AND (' ' || event_types || ' ') LIKE ?", userID, "% "+eventType+" %").
+		Where("client_id = '' OR client_id IN (?)", tx.Model(&models.APIKey{}).
+			Select("client_id").
+			Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", now)).
+		Find(&subscriptions).Error; err != nil {
+		return err
+	}
+	if len(subscriptions) == 0 {
+		return nil
+	}
+
+	event := models.WebhookEvent{
+		Type:      eventType,
+		UserID:    userID,
+		Data:      payload,
+		CreatedAt: now,
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		return err
+	}
+
+	deliveries := make([]models.WebhookDelivery, len(subscriptions))
+	for i, subscription := range subscriptions {
+		deliveries[i] = models.WebhookDelivery{
+			EventID:        event.ID,
+			SubscriptionID: subscription.ID,
+			Status:         models.WebhookDeliveryPending,
+			NextAttemptAt:  now,
+		}
+	}
+	if err := tx.Create(&deliveries).Error; err != nil {
+		return err
+	}
+	logger.DebugContext(tx.Statement.Context, "webhook event queued", "type", eventType, "user_id", userID, "deliveries", len(deliveries))
+	return nil
+}
+
+// Create registers an endpoint for a user
+// clientID is set when the request was made with an API key; the subscription then belongs to
+// that key and stops receiving events once it is revoked
+func (s *Service) Create(userID, clientID, endpoint string, eventTypes []string, description string) (*NewSubscription, error) {
+	uid, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, fmt.Errorf("user not found")
+	}
+	endpoint, err = s.validateURL(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	eventTypes, err = normalizeEventTypes(eventTypes)
+	if err != nil {
+		return nil, err
+	}
+	description = strings.TrimSpace(description)
+	if len(description) > 255 {
+		return nil, fmt.Errorf("description is too long")
+	}
+
+	var count int64
+	if err := s.db.Model(&models.WebhookSubscription{}).Where("user_id = ?", uid).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if count >= maxSubscriptionsPerUser {
+		return nil, fmt.Errorf("webhook limit reached: at most %d endpoints per user", maxSubscriptionsPerUser)
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.box.Seal([]byte(secret))
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt webhook secret: %w", err)
+	}
+
+	subscription := models.WebhookSubscription{
+		UserID:      uid,
+		ClientID:    clientID,
+		URL:         endpoint,
+		EventTypes:  strings.Join(eventTypes, " "),
+		Description: description,
+		Secret:      sealed,
+	}
+	if err := s.db.Create(&subscription).Error; err != nil {
+		return nil, fmt.Errorf("failed to create webhook: %w", err)
+	}
+
+	logger.Info("webhook registered", "webhook_id", subscription.ID, "user_id", userID, "event_types", subscription.EventTypes)
+	return &NewSubscription{
+		Subscription: subscription.ToDTO(),
+		Secret:       secret,
+	}, nil
+}
+
+// List returns a user's subscriptions, newest first
+// API clients only see the subscriptions they created
+func (s *Service) List(userID, clientID string) ([]models.WebhookSubscription, error) {
+	var subscriptions []models.WebhookSubscription
+	if err := s.owner(s.db, userID, clientID).Order("created_at DESC").Find(&subscriptions).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve webhooks: %w", err)
+	}
+	return subscriptions, nil
+}
+
+// Delete removes a subscription together with its pending deliveries and dead letters
+func (s *Service) Delete(userID, clientID, subscriptionID string) error {
+	subscription, err := s.owned(userID, clientID, subscriptionID)
+	if err != nil {
+		return err
+	}
+	if err := s.db.Delete(subscription).Error; err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	logger.Info("webhook deleted", "webhook_id", subscription.ID)
+	return nil
+}
+
+// Deliveries returns the most recent deliveries to one of a user's subscriptions, newest first
+func (s *Service) Deliveries(userID, clientID, subscriptionID string, limit int) ([]models.WebhookDelivery, error) {
+	subscription, err := s.owned(userID, clientID, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var deliveries []models.WebhookDelivery
+	if err := s.db.
+		Preload("Event").
+		Where("subscription_id = ?", subscription.ID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve deliveries: %w", err)
+	}
+	return deliveries, nil
+}
+
+// DeadLetters returns the deliveries that ran out of attempts across a user's subscriptions
+func (s *Service) DeadLetters(userID, clientID string, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	if err := s.db.
+		Preload("Event").
+		Where("status = ?", models.WebhookDeliveryDead).
+		Where("subscription_id IN (?)", s.owner(s.db.Model(&models.WebhookSubscription{}), userID, clientID).Select("id")).
+		Order("updated_at DESC").
+		Limit(limit).
+		Find(&deliveries).Error; err != nil {
+		return nil, fmt.Errorf("failed to retrieve dead letters: %w", err)
+	}
+	return deliveries, nil
+}
+
+// Replay queues a dead (or already delivered) delivery again with a fresh set of attempts
+func (s *Service) Replay(userID, clientID, deliveryID string) (*models.WebhookDelivery, error) {
+	id, err := uuid.Parse(deliveryID)
+	if err != nil {
+		return nil, fmt.Errorf("delivery not found")
+	}
+
+	var delivery models.WebhookDelivery
+	if err := s.db.
+		Where("id = ?", id).
+		Where("subscription_id IN (?)", s.owner(s.db.Model(&models.WebhookSubscription{}), userID, clientID).Select("id")).
+		First(&delivery).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("delivery not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	if delivery.Status == models.WebhookDeliveryPending {
+		return nil, fmt.Errorf("delivery is already pending")
+	}
+
+	now := time.Now()
+	if err := s.db.Model(&delivery).Updates(map[string]interface{}{
+		"status":          models.WebhookDeliveryPending,
+		"attempts":        0,
+		"next_attempt_at": now,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to replay delivery: %w", err)
+	}
+	delivery.Status = models.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = now
+
+	logger.Info("webhook delivery queued for replay", "delivery_id", delivery.ID)
+	return &delivery, nil
+}
+
+// owner restricts a subscription query to a user, and to the API client if the request used one
+func (s *Service) owner(query *gorm.DB, userID, clientID string) *gorm.DB {
+	query = query.Where("user_id = ?", userID)
+	if clientID != "" {
+		query = query.Where("client_id = ?", clientID)
+	}
+	return query
+}
+
+// owned loads one of a user's subscriptions
+func (s *Service) owned(userID, clientID, subscriptionID string) (*models.WebhookSubscription, error) {
+	id, err := uuid.Parse(subscriptionID)
+	if err != nil {
+		return nil, fmt.Errorf("webhook not found")
+	}
+
+	var subscription models.WebhookSubscription
+	if err := s.owner(s.db, userID, clientID).Where("id = ?", id).First(&subscription).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("webhook not found")
+		}
+		return nil, fmt.Errorf("database error: %w", err)
+	}
+	return &subscription, nil
+}
+
+// validateURL checks that an endpoint is an absolute HTTPS URL
+// Whether its host is reachable from here is only checked when delivering, since DNS can change
+func (s *Service) validateURL(endpoint string) (string, error) {
+	endpoint = strings.TrimSpace(endpoint)
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Host == "" || parsed.Hostname() == "" {
+		return "", fmt.Errorf("invalid url: must be an absolute URL")
+	}
+	if parsed.User != nil {
+		return "", fmt.Errorf("invalid url: credentials are not allowed")
+	}
+	if parsed.Scheme != "https" && !(s.allowPrivate && parsed.Scheme == "http") {
+		return "", fmt.Errorf("invalid url: must use https")
+	}
+	if len(endpoint) > 2048 {
+		return "", fmt.Errorf("invalid url: too long")
+	}
+	parsed.Fragment = ""
+	return parsed.String(), nil
+}
+
+// normalizeEventTypes validates and deduplicates requested event types
+func normalizeEventTypes(eventTypes []string) ([]string, error) {
+	known := make(map[string]bool, len(models.WebhookEventTypes))
+	for _, eventType := range models.WebhookEventTypes {
+		known[eventType] = true
+	}
+
+	seen := make(map[string]bool, len(eventTypes))
+	normalized := make([]string, 0, len(eventTypes))
+	for _, eventType := range eventTypes {
+		eventType = strings.TrimSpace(eventType)
+		if !known[eventType] {
+			return nil, fmt.Errorf("invalid event type: %s", eventType)
+		}
+		if !seen[eventType] {
+			seen[eventType] = true
+			normalized = append(normalized, eventType)
+		}
+	}
+	if len(normalized) == 0 {
+		return nil, fmt.Errorf("at least one event type is required")
+	}
+	return normalized, nil
+}
+
+// newSecret generates a random signing secret
+func newSecret() (string, error) {
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return "", fmt.Errorf("failed to generate webhook secret: %w", err)
+	}
+	return secretPrefix + base64.RawURLEncoding.EncodeToString(secret), nil
+}
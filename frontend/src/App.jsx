@@ -9,6 +9,8 @@ import Dashboard from './pages/Dashboard'
 import Transactions from './pages/Transactions'
 import History from './pages/History'
 import Chat from './pages/Chat'
+import VerifyEmail from './pages/VerifyEmail'
+import ResetPassword from './pages/ResetPassword'
 
 function App() {
   return (
@@ -17,6 +19,8 @@ function App() {
         <Routes>
           {/* Public Route */}
           <Route path="/" element={<Login />} />
+          <Route path="/verify-email" element={<VerifyEmail />} />
+          <Route path="/reset-password" element={<ResetPassword />} />
 
           {/* Private Routes */}
           <Route
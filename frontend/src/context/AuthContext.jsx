@@ -61,9 +61,10 @@ export const AuthProvider = ({ children }) => {
 
   const login = async (email, password) => {
     const response = await authAPI.login({ email, password })
-    const { token, user: userData } = response.data.data
+    const { token, refresh_token, user: userData } = response.data.data
 
     localStorage.setItem('token', token)
+    localStorage.setItem('refresh_token', refresh_token)
     localStorage.setItem('user', JSON.stringify(userData))
 
     setUser(userData)
@@ -78,9 +79,10 @@ export const AuthProvider = ({ children }) => {
       password,
       full_name: fullName
     })
-    const { token, user: userData } = response.data.data
+    const { token, refresh_token, user: userData } = response.data.data
 
     localStorage.setItem('token', token)
+    localStorage.setItem('refresh_token', refresh_token)
     localStorage.setItem('user', JSON.stringify(userData))
 
     setUser(userData)
@@ -89,8 +91,15 @@ export const AuthProvider = ({ children }) => {
     return response
   }
 
-  const logout = () => {
+  const logout = async () => {
+    // Revoke the session server-side; clear local state even if the call fails
+    try {
+      await authAPI.logout()
+    } catch (error) {
+      console.log('🟡 [AuthContext] Logout request failed:', error?.response?.status)
+    }
     localStorage.removeItem('token')
+    localStorage.removeItem('refresh_token')
     localStorage.removeItem('user')
     setUser(null)
     setBalance(null)
@@ -1,5 +1,6 @@
 import { useEffect } from 'react'
 import { useAuth } from '../context/AuthContext'
+import { eventsAPI } from '../services/api'
 import { HiCreditCard } from 'react-icons/hi'
 import { RiShieldStarFill } from 'react-icons/ri'
 
@@ -27,6 +28,16 @@ const Dashboard = () => {
     }
   }, [fetchBalance])
 
+  // Refresh the balance as soon as the server pushes a change
+  useEffect(() => {
+    const unsubscribe = eventsAPI.subscribe((type) => {
+      if (type === 'balance') {
+        fetchBalance()
+      }
+    })
+    return unsubscribe
+  }, [fetchBalance])
+
   const formatBalance = (value) => {
     if (value === null || value === undefined) return '0.00'
     return value.toLocaleString('en-US', {
@@ -1,6 +1,6 @@
 import { useState, useEffect } from 'react';
 import { HiRefresh, HiDocumentText } from 'react-icons/hi';
-import { transactionAPI } from '../services/api';
+import { transactionAPI, eventsAPI } from '../services/api';
 import TransactionList from '../components/TransactionList';
 import Pagination from '../components/Pagination';
 import Alert from '../components/Alert';
@@ -47,6 +47,16 @@ const History = () => {
     fetchTransactions(currentPage, itemsPerPage);
   }, [currentPage, itemsPerPage]);
 
+  // New transactions appear on the first page as soon as the server pushes them
+  useEffect(() => {
+    if (currentPage !== 1) return undefined;
+    return eventsAPI.subscribe((type) => {
+      if (type === 'transaction') {
+        fetchTransactions(1, itemsPerPage);
+      }
+    });
+  }, [currentPage, itemsPerPage]);
+
   const handlePageChange = (newPage) => {
     setCurrentPage(newPage);
     window.scrollTo({ top: 0, behavior: 'smooth' });
@@ -1,5 +1,5 @@
 import { useState } from 'react'
-import { useNavigate } from 'react-router-dom'
+import { Link, useNavigate } from 'react-router-dom'
 import { useAuth } from '../context/AuthContext'
 import { HiSparkles } from 'react-icons/hi'
 
@@ -90,8 +90,8 @@ const Login = () => {
               value={password}
               onChange={(e) => setPassword(e.target.value)}
               required
-              minLength={6}
-              placeholder="At least 6 characters"
+              minLength={isLogin ? undefined : 10}
+              placeholder={isLogin ? 'Your password' : 'At least 10 characters, mix letters, digits and symbols'}
             />
           </div>
 
@@ -100,6 +100,12 @@ const Login = () => {
           </button>
         </form>
 
+        {isLogin && (
+          <div className="toggle-link">
+            <Link to="/reset-password">Forgot your password?</Link>
+          </div>
+        )}
+
         <div className="toggle-link">
           {isLogin ? (
             <>
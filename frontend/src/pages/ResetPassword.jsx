@@ -0,0 +1,87 @@
+import { useState } from 'react'
+import { Link, useSearchParams } from 'react-router-dom'
+import { authAPI } from '../services/api'
+
+// Without a token the page requests a reset link; with one (from the email) it sets the new password
+const ResetPassword = () => {
+  const [searchParams] = useSearchParams()
+  const token = searchParams.get('token')
+
+  const [email, setEmail] = useState('')
+  const [password, setPassword] = useState('')
+  const [error, setError] = useState('')
+  const [success, setSuccess] = useState('')
+  const [loading, setLoading] = useState(false)
+
+  const handleSubmit = async (e) => {
+    e.preventDefault()
+    setError('')
+    setSuccess('')
+    setLoading(true)
+
+    try {
+      if (token) {
+        await authAPI.confirmPasswordReset(token, password)
+        setSuccess('Password updated. You can now sign in with your new password.')
+      } else {
+        await authAPI.requestPasswordReset(email)
+        setSuccess('If the email belongs to an account, a reset link is on its way.')
+      }
+    } catch (err) {
+      setError(err.response?.data?.message || 'An error occurred')
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  return (
+    <div className="container">
+      <div className="card">
+        <h1>HLABS Banking</h1>
+        <h2>Reset Password</h2>
+
+        {error && <div className="error">{error}</div>}
+        {success && <div className="success">{success}</div>}
+
+        <form onSubmit={handleSubmit}>
+          {token ? (
+            <div className="form-group">
+              <label htmlFor="password">New Password</label>
+              <input
+                type="password"
+                id="password"
+                value={password}
+                onChange={(e) => setPassword(e.target.value)}
+                required
+                minLength={10}
+                placeholder="At least 10 characters, mix letters, digits and symbols"
+              />
+            </div>
+          ) : (
+            <div className="form-group">
+              <label htmlFor="email">Email</label>
+              <input
+                type="email"
+                id="email"
+                value={email}
+                onChange={(e) => setEmail(e.target.value)}
+                required
+                placeholder="you@example.com"
+              />
+            </div>
+          )}
+
+          <button type="submit" disabled={loading}>
+            {loading ? 'Please wait...' : token ? 'Set New Password' : 'Send Reset Link'}
+          </button>
+        </form>
+
+        <div className="toggle-link">
+          <Link to="/">Back to sign in</Link>
+        </div>
+      </div>
+    </div>
+  )
+}
+
+export default ResetPassword
@@ -0,0 +1,40 @@
+import { useEffect, useState } from 'react'
+import { Link, useSearchParams } from 'react-router-dom'
+import { authAPI } from '../services/api'
+
+const VerifyEmail = () => {
+  const [searchParams] = useSearchParams()
+  const [error, setError] = useState('')
+  const [success, setSuccess] = useState('')
+
+  useEffect(() => {
+    const token = searchParams.get('token')
+    if (!token) {
+      setError('Verification link is missing its token')
+      return
+    }
+
+    authAPI.confirmEmail(token)
+      .then(() => setSuccess('Your email address is verified.'))
+      .catch((err) => setError(err.response?.data?.message || 'Verification failed'))
+  }, [searchParams])
+
+  return (
+    <div className="container">
+      <div className="card">
+        <h1>HLABS Banking</h1>
+        <h2>Email Verification</h2>
+
+        {!error && !success && <p>Verifying...</p>}
+        {error && <div className="error">{error}</div>}
+        {success && <div className="success">{success}</div>}
+
+        <div className="toggle-link">
+          <Link to="/">Back to sign in</Link>
+        </div>
+      </div>
+    </div>
+  )
+}
+
+export default VerifyEmail
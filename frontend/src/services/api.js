@@ -9,23 +9,76 @@ const api = axios.create({
   },
 });
 
+// Random identifier of this browser, so logins from it are recognized as the same device
+const deviceId = () => {
+  let id = localStorage.getItem('device_id');
+  if (!id) {
+    id = crypto.randomUUID();
+    localStorage.setItem('device_id', id);
+  }
+  return id;
+};
+
 // Add token to requests if available
 api.interceptors.request.use((config) => {
   const token = localStorage.getItem('token');
   if (token) {
     config.headers.Authorization = `Bearer ${token}`;
   }
+  config.headers['X-Device-ID'] = deviceId();
   return config;
 });
 
-// Handle 401 errors (logout)
+// Clear the stored session and go back to the login page
+const clearSession = () => {
+  localStorage.removeItem('token');
+  localStorage.removeItem('refresh_token');
+  localStorage.removeItem('user');
+  window.location.href = '/';
+};
+
+// Single in-flight refresh shared by concurrent 401s (refresh tokens rotate on every use)
+let refreshPromise = null;
+
+const refreshSession = () => {
+  if (!refreshPromise) {
+    const refreshToken = localStorage.getItem('refresh_token');
+    refreshPromise = axios
+      .post(`${API_BASE_URL}/auth/refresh`, { refresh_token: refreshToken })
+      .then((response) => {
+        const { token, refresh_token } = response.data.data;
+        localStorage.setItem('token', token);
+        localStorage.setItem('refresh_token', refresh_token);
+        return token;
+      })
+      .finally(() => {
+        refreshPromise = null;
+      });
+  }
+  return refreshPromise;
+};
+
+// Handle 401 errors: refresh the access token once, then logout
 api.interceptors.response.use(
   (response) => response,
-  (error) => {
-    if (error.response?.status === 401) {
-      localStorage.removeItem('token');
-      localStorage.removeItem('user');
-      window.location.href = '/';
+  async (error) => {
+    const original = error.config;
+    const isAuthCall = original?.url?.startsWith('/auth/');
+
+    if (error.response?.status === 401 && !original._retry && !isAuthCall && localStorage.getItem('refresh_token')) {
+      original._retry = true;
+      try {
+        const token = await refreshSession();
+        original.headers.Authorization = `Bearer ${token}`;
+        return api(original);
+      } catch (refreshError) {
+        clearSession();
+        return Promise.reject(refreshError);
+      }
+    }
+
+    if (error.response?.status === 401 && !isAuthCall) {
+      clearSession();
     }
     return Promise.reject(error);
   }
@@ -36,6 +89,38 @@ export const authAPI = {
   register: (data) => api.post('/auth/register', data),
   login: (data) => api.post('/auth/login', data),
   logout: () => api.post('/auth/logout'),
+  logoutAll: () => api.post('/auth/logout-all'),
+  // Devices the user is logged in on; revoke one, or all but the current one
+  listSessions: () => api.get('/auth/sessions'),
+  revokeSession: (id) => api.delete(`/auth/sessions/${id}`),
+  revokeOtherSessions: () => api.delete('/auth/sessions'),
+  // Change the password; other sessions are signed out
+  changePassword: (currentPassword, newPassword) =>
+    api.post('/auth/password', { current_password: currentPassword, new_password: newPassword }),
+  // Re-authenticate (password or TOTP code) for one withdrawal/transfer; returns a step_up_token
+  stepUp: (data) => api.post('/auth/step-up', data),
+  requestEmailVerification: () => api.post('/auth/verify-email/request'),
+  confirmEmail: (token) => api.post('/auth/verify-email/confirm', { token }),
+  requestPasswordReset: (email) => api.post('/auth/password-reset/request', { email }),
+  confirmPasswordReset: (token, newPassword) =>
+    api.post('/auth/password-reset/confirm', { token, new_password: newPassword }),
+  // API keys for third-party integrations (the secret is only returned on creation)
+  listApiKeys: () => api.get('/auth/api-keys'),
+  createApiKey: (name, scopes, expiresInDays) =>
+    api.post('/auth/api-keys', { name, scopes, expires_in_days: expiresInDays }),
+  revokeApiKey: (id) => api.delete(`/auth/api-keys/${id}`),
+  getApiKeyUsage: (id, limit = 50) => api.get(`/auth/api-keys/${id}/usage`, { params: { limit } }),
+  // Passkeys: pass `options` to navigator.credentials.create()/get() and send the
+  // resulting credential back with the ceremony_token
+  beginPasskeyRegistration: (data) => api.post('/auth/passkeys/register/begin', data),
+  finishPasskeyRegistration: (ceremonyToken, credential, name) =>
+    api.post('/auth/passkeys/register/finish', { ceremony_token: ceremonyToken, credential, name }),
+  listPasskeys: () => api.get('/auth/passkeys'),
+  renamePasskey: (id, name) => api.patch(`/auth/passkeys/${id}`, { name }),
+  deletePasskey: (id) => api.delete(`/auth/passkeys/${id}`),
+  beginPasskeyLogin: (email) => api.post('/auth/passkeys/login/begin', { email }),
+  finishPasskeyLogin: (ceremonyToken, credential) =>
+    api.post('/auth/passkeys/login/finish', { ceremony_token: ceremonyToken, credential }),
 };
 
 // Account endpoints
@@ -49,22 +134,116 @@ export const transactionAPI = {
   // Convert dollar amounts to cents before sending to backend
   // Backend expects all amounts in cents (integer)
   deposit: (amount) => api.post('/transactions/deposit', { amount: Math.round(amount * 100) }),
-  withdraw: (amount) => api.post('/transactions/withdraw', { amount: Math.round(amount * 100) }),
-  transfer: (toAccountId, amount) => api.post('/transactions/transfer', {
+  // A 403 with `step_up` means the request must be retried with a step-up token
+  withdraw: (amount, stepUpToken) => api.post('/transactions/withdraw', {
+    amount: Math.round(amount * 100),
+    step_up_token: stepUpToken
+  }),
+  transfer: (toAccountId, amount, stepUpToken) => api.post('/transactions/transfer', {
     to_account_id: parseInt(toAccountId, 10), // Convert string to number
-    amount: Math.round(amount * 100)
+    amount: Math.round(amount * 100),
+    step_up_token: stepUpToken
   }),
   getHistory: (page = 1, limit = 10) => api.get(`/transactions/history?page=${page}&limit=${limit}`),
 };
 
+// Webhook endpoints
+export const webhookAPI = {
+  // The signing secret is only returned by create
+  create: (url, eventTypes, description) =>
+    api.post('/webhooks', { url, event_types: eventTypes, description }),
+  list: () => api.get('/webhooks'),
+  remove: (id) => api.delete(`/webhooks/${id}`),
+  getDeliveries: (id, limit = 50) => api.get(`/webhooks/${id}/deliveries?limit=${limit}`),
+  getDeadLetters: (limit = 50) => api.get(`/webhooks/dead-letters?limit=${limit}`),
+  replay: (deliveryId) => api.post(`/webhooks/deliveries/${deliveryId}/replay`),
+};
+
+// Notification center API
+export const notificationAPI = {
+  list: (unreadOnly = false, limit = 50) =>
+    api.get(`/notifications?limit=${limit}${unreadOnly ? '&unread=true' : ''}`),
+  markRead: (id) => api.post(`/notifications/${id}/read`),
+  markUnread: (id) => api.post(`/notifications/${id}/unread`),
+  markAllRead: () => api.post('/notifications/read-all'),
+  getPreferences: () => api.get('/notifications/preferences'),
+  // preferences: [{ type, in_app, email, sms, threshold }]; omitted fields are left unchanged
+  updatePreferences: (preferences, phoneNumber) =>
+    api.put('/notifications/preferences', { preferences, phone_number: phoneNumber }),
+};
+
+// Realtime events: balance changes and new transactions pushed by the server
+// Uses fetch instead of EventSource so the Authorization header can be sent.
+// Calls onEvent(type, data) for every event and reconnects (refreshing the token
+// when needed) until the returned function is called.
+export const eventsAPI = {
+  subscribe: (onEvent) => {
+    let stopped = false;
+    let controller = null;
+
+    const readStream = async (response) => {
+      const reader = response.body.pipeThrough(new TextDecoderStream()).getReader();
+      let buffer = '';
+      for (;;) {
+        const { value, done } = await reader.read();
+        if (done) return;
+        buffer += value;
+
+        let end;
+        while ((end = buffer.indexOf('\n\n')) >= 0) {
+          const block = buffer.slice(0, end);
+          buffer = buffer.slice(end + 2);
+
+          let type = 'message';
+          let data = '';
+          for (const line of block.split('\n')) {
+            if (line.startsWith('event: ')) type = line.slice(7);
+            else if (line.startsWith('data: ')) data += line.slice(6);
+          }
+          if (data) onEvent(type, JSON.parse(data));
+        }
+      }
+    };
+
+    const connect = async () => {
+      while (!stopped) {
+        controller = new AbortController();
+        try {
+          const response = await fetch(`${API_BASE_URL}/events/stream`, {
+            headers: { Authorization: `Bearer ${localStorage.getItem('token')}` },
+            signal: controller.signal,
+          });
+          if (response.status === 401 && localStorage.getItem('refresh_token')) {
+            await refreshSession();
+            continue;
+          }
+          if (response.ok) {
+            await readStream(response);
+          }
+        } catch {
+          // Network error or aborted: retry below unless stopped
+        }
+        if (!stopped) await new Promise((resolve) => setTimeout(resolve, 3000));
+      }
+    };
+
+    connect();
+    return () => {
+      stopped = true;
+      controller?.abort();
+    };
+  },
+};
+
 // Chat endpoints
 export const chatAPI = {
   sendMessage: (message) => api.post('/chat', { message }),
-  confirmOperation: (toolName, args, confirmed) =>
+  confirmOperation: (toolName, args, confirmed, stepUpToken) =>
     api.post('/chat/confirm', {
       tool_name: toolName,
       arguments: args,
-      confirmed: confirmed
+      confirmed: confirmed,
+      step_up_token: stepUpToken
     }),
 };
 